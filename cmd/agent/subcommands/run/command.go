@@ -15,6 +15,7 @@ import (
 	"os/signal"
 	"runtime"
 	"syscall"
+	"time"
 
 	_ "expvar" // Blank import used because this isn't directly used in this file
 
@@ -51,6 +52,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/netflow"
 	"github.com/DataDog/datadog-agent/pkg/otlp"
 	"github.com/DataDog/datadog-agent/pkg/pidfile"
+	"github.com/DataDog/datadog-agent/pkg/secrets"
 	"github.com/DataDog/datadog-agent/pkg/snmp/traps"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
@@ -434,7 +436,7 @@ func startAgent(cliParams *cliParams) error {
 		if pkgconfig.Datadog.GetBool("log_enabled") {
 			pkglog.Warn(`"log_enabled" is deprecated, use "logs_enabled" instead`)
 		}
-		if _, err := logs.Start(common.AC); err != nil {
+		if _, err := logs.Start(common.AC, demux); err != nil {
 			pkglog.Error("Could not start logs-agent: ", err)
 		}
 	} else {
@@ -459,6 +461,11 @@ func startAgent(cliParams *cliParams) error {
 	// load and run all configs in AD
 	common.AC.LoadAndRun(common.MainCtx)
 
+	// refresh the secrets used by the check configurations, to reschedule the ones using rotated secrets
+	if interval := pkgconfig.Datadog.GetInt("secret_refresh_interval"); interval > 0 {
+		secrets.StartRefreshRoutine(common.MainCtx, time.Duration(interval)*time.Second, common.AC.RefreshSecrets)
+	}
+
 	// check for common misconfigurations and report them to log
 	misconfig.ToLog(misconfig.CoreAgent)
 
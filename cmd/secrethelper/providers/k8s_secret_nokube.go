@@ -0,0 +1,20 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build secrets && !kubeapiserver
+// +build secrets,!kubeapiserver
+
+package providers
+
+import (
+	"k8s.io/client-go/kubernetes"
+
+	s "github.com/DataDog/datadog-agent/pkg/secrets"
+)
+
+// ReadKubernetesSecret is not available without the Kubernetes client
+func ReadKubernetesSecret(kubeClient kubernetes.Interface, path string) s.Secret {
+	return s.Secret{ErrorMsg: "the k8s_secret provider is not supported by this build"}
+}
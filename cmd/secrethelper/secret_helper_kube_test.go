@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build secrets && kubeapiserver
+// +build secrets,kubeapiserver
+
+package secrethelper
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReadSecretsWithKubernetesProvider(t *testing.T) {
+	newKubeClientFunc := func(timeout time.Duration) (kubernetes.Interface, error) {
+		return fake.NewSimpleClientset(&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "some_name",
+				Namespace: "some_namespace",
+			},
+			Data: map[string][]byte{"some_key": []byte("some_value")},
+		}), nil
+	}
+
+	in := fmt.Sprintf(`
+	{
+		"version": "1.0",
+		"secrets": [
+			"file@%s",
+			"k8s_secret@some_namespace/some_name/some_key",
+			"file@%s",
+			"k8s_secret@another_namespace/another_name/another_key"
+		]
+	}`, secretAbsPath("secret1"), secretAbsPath("secret2"))
+	out := fmt.Sprintf(`
+	{
+		"file@%s": {
+			"value": "secret1-value"
+		},
+		"k8s_secret@some_namespace/some_name/some_key": {
+			"value": "some_value"
+		},
+		"file@%s": {
+			"error": "secret does not exist"
+		},
+		"k8s_secret@another_namespace/another_name/another_key": {
+			"error": "secrets \"another_name\" not found"
+		}
+	}`, secretAbsPath("secret1"), secretAbsPath("secret2"))
+
+	var w bytes.Buffer
+	err := readSecrets(strings.NewReader(in), &w, filepath.Join("testdata", "read-secrets"), true, newKubeClientFunc)
+	assert.NoError(t, err)
+	assert.JSONEq(t, out, w.String())
+}
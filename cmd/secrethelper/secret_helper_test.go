@@ -10,7 +10,6 @@ package secrethelper
 
 import (
 	"bytes"
-	"fmt"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -88,35 +87,6 @@ func TestReadSecrets(t *testing.T) {
 				}
 			}`,
 		},
-		{
-			name: "valid input, reading from file and k8s providers",
-			in: fmt.Sprintf(`
-			{
-				"version": "1.0",
-				"secrets": [
-					"file@%s",
-					"k8s_secret@some_namespace/some_name/some_key",
-					"file@%s",
-					"k8s_secret@another_namespace/another_name/another_key"
-				]
-			}`, secretAbsPath("secret1"), secretAbsPath("secret2")),
-			out: fmt.Sprintf(`
-			{
-				"file@%s": {
-					"value": "secret1-value"
-				},
-				"k8s_secret@some_namespace/some_name/some_key": {
-					"value": "some_value"
-				},
-				"file@%s": {
-					"error": "secret does not exist"
-				},
-				"k8s_secret@another_namespace/another_name/another_key": {
-					"error": "secrets \"another_name\" not found"
-				}
-			}`, secretAbsPath("secret1"), secretAbsPath("secret2")),
-			usePrefixes: true,
-		},
 		{
 			name: "prefixes option enabled, but using old format",
 			in: `
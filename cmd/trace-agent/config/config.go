@@ -223,6 +223,20 @@ func applyDatadogConfig(c *config.AgentConfig) error {
 	if coreconfig.Datadog.IsSet("apm_config.rare_sampler.cardinality") {
 		c.RareSamplerCardinality = coreconfig.Datadog.GetInt("apm_config.rare_sampler.cardinality")
 	}
+	if coreconfig.Datadog.IsSet("apm_config.latency_sampler.enabled") {
+		c.LatencySamplerEnabled = coreconfig.Datadog.GetBool("apm_config.latency_sampler.enabled")
+	}
+	if coreconfig.Datadog.IsSet("apm_config.latency_sampler.percentile") {
+		percentile := coreconfig.Datadog.GetFloat64("apm_config.latency_sampler.percentile")
+		if percentile > 0 && percentile < 100 {
+			c.LatencySamplerPercentile = percentile
+		} else {
+			log.Warnf("Invalid apm_config.latency_sampler.percentile %v, it must be between 0 and 100: using %v", percentile, c.LatencySamplerPercentile)
+		}
+	}
+	if coreconfig.Datadog.IsSet("apm_config.latency_sampler.tps") {
+		c.LatencySamplerTPS = coreconfig.Datadog.GetFloat64("apm_config.latency_sampler.tps")
+	}
 
 	if coreconfig.Datadog.IsSet("apm_config.max_remote_traces_per_second") {
 		c.MaxRemoteTPS = coreconfig.Datadog.GetFloat64("apm_config.max_remote_traces_per_second")
@@ -231,6 +245,9 @@ func applyDatadogConfig(c *config.AgentConfig) error {
 	if k := "apm_config.ignore_resources"; coreconfig.Datadog.IsSet(k) {
 		c.Ignore["resource"] = coreconfig.Datadog.GetStringSlice(k)
 	}
+	if k := "apm_config.peer_tags"; coreconfig.Datadog.IsSet(k) {
+		c.PeerTags = coreconfig.Datadog.GetStringSlice(k)
+	}
 	if k := "apm_config.max_payload_size"; coreconfig.Datadog.IsSet(k) {
 		c.MaxRequestBytes = coreconfig.Datadog.GetInt64(k)
 	}
@@ -325,6 +342,17 @@ func applyDatadogConfig(c *config.AgentConfig) error {
 			c.RejectTags = append(c.RejectTags, splitTag(tag))
 		}
 	}
+	if k := "apm_config.filter_tags.rules"; coreconfig.Datadog.IsSet(k) {
+		rules := make([]*config.TagFilterRule, 0)
+		if err := coreconfig.Datadog.UnmarshalKey(k, &rules); err != nil {
+			log.Errorf("Bad format for %q it should be of the form '[{\"type\": \"reject\",\"key\": \"tag_name\",\"pattern\":\"pattern\"}]', error: %v", k, err)
+		} else {
+			if err := compileTagFilterRules(rules); err != nil {
+				osutil.Exitf("filter_tags.rules: %s", err)
+			}
+			c.TagFilterRules = rules
+		}
+	}
 
 	// undocumented
 	if coreconfig.Datadog.IsSet("apm_config.max_cpu_percent") {
@@ -349,6 +377,9 @@ func applyDatadogConfig(c *config.AgentConfig) error {
 	if coreconfig.Datadog.IsSet("apm_config.sync_flushing") {
 		c.SynchronousFlushing = coreconfig.Datadog.GetBool("apm_config.sync_flushing")
 	}
+	if coreconfig.Datadog.GetBool("apm_config.file_export.enabled") {
+		applyFileExportConfig(c.FileExport)
+	}
 
 	// undocumented deprecated
 	if coreconfig.Datadog.IsSet("apm_config.analyzed_rate_by_service") {
@@ -502,6 +533,57 @@ func compileReplaceRules(rules []*config.ReplaceRule) error {
 	return nil
 }
 
+// compileTagFilterRules validates the tag filter rules and compiles their regular expressions.
+// If it fails it returns the first error.
+func compileTagFilterRules(rules []*config.TagFilterRule) error {
+	for _, r := range rules {
+		if r.Type != config.TagFilterRequire && r.Type != config.TagFilterReject {
+			return fmt.Errorf("key %q: type must be %q or %q", r.Key, config.TagFilterRequire, config.TagFilterReject)
+		}
+		if r.Key == "" {
+			return errors.New(`all rules must have a "key"`)
+		}
+		if r.Pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("key %q: %s", r.Key, err)
+		}
+		r.Re = re
+	}
+	return nil
+}
+
+// applyFileExportConfig reads the export of the payloads to local files into fe.
+func applyFileExportConfig(fe *config.FileExportConfig) {
+	path := coreconfig.Datadog.GetString("apm_config.file_export.path")
+	if path == "" {
+		log.Error("apm_config.file_export.path must be set to export payloads to files: file export disabled")
+		return
+	}
+	fe.Enabled = true
+	fe.Path = path
+	if k := "apm_config.file_export.format"; coreconfig.Datadog.IsSet(k) {
+		switch format := coreconfig.Datadog.GetString(k); format {
+		case config.FileExportJSON, config.FileExportProtobuf:
+			fe.Format = format
+		default:
+			log.Warnf("Invalid %s %q, it must be %q or %q: using %q", k, format, config.FileExportJSON, config.FileExportProtobuf, fe.Format)
+		}
+	}
+	if k := "apm_config.file_export.max_file_size"; coreconfig.Datadog.IsSet(k) {
+		fe.MaxFileSize = coreconfig.Datadog.GetInt64(k)
+	}
+	if k := "apm_config.file_export.max_file_age"; coreconfig.Datadog.IsSet(k) {
+		fe.MaxFileAge = getDuration(coreconfig.Datadog.GetInt(k))
+	}
+	if k := "apm_config.file_export.max_files"; coreconfig.Datadog.IsSet(k) {
+		fe.MaxFiles = coreconfig.Datadog.GetInt(k)
+	}
+	fe.SendToIntake = coreconfig.Datadog.GetBool("apm_config.file_export.send_to_intake")
+}
+
 // getDuration returns the duration of the provided value in seconds
 func getDuration(seconds int) time.Duration {
 	return time.Duration(seconds) * time.Second
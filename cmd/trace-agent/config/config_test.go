@@ -457,6 +457,20 @@ func TestFullYamlConfig(t *testing.T) {
 
 	assert.ElementsMatch([]*config.Tag{{K: "env", V: "prod"}, {K: "db", V: "mongodb"}}, c.RequireTags)
 	assert.ElementsMatch([]*config.Tag{{K: "outcome", V: "success"}}, c.RejectTags)
+	assert.Equal([]*config.TagFilterRule{
+		{Type: "reject", Key: "http.url", Pattern: "/health$", Re: regexp.MustCompile("/health$"), Env: "prod"},
+		{Type: "require", Key: "user.id", Service: "web"},
+	}, c.TagFilterRules)
+
+	assert.Equal(&config.FileExportConfig{
+		Enabled:      true,
+		Path:         "/var/tmp/datadog-apm",
+		Format:       config.FileExportProtobuf,
+		MaxFileSize:  1048576,
+		MaxFileAge:   time.Minute,
+		MaxFiles:     4,
+		SendToIntake: true,
+	}, c.FileExport)
 
 	assert.ElementsMatch([]*config.ReplaceRule{
 		{
@@ -480,6 +494,7 @@ func TestFullYamlConfig(t *testing.T) {
 	}, c.ReplaceTags)
 
 	assert.EqualValues([]string{"/health", "/500"}, c.Ignore["resource"])
+	assert.Equal([]string{"peer.service", "db.instance"}, c.PeerTags)
 
 	o := c.Obfuscation
 	assert.NotNil(o)
@@ -761,6 +776,16 @@ func TestLoadEnv(t *testing.T) {
 		})
 	}
 
+	env = "DD_APM_PEER_TAGS"
+	t.Run(env, func(t *testing.T) {
+		defer cleanConfig()()
+		assert := assert.New(t)
+		t.Setenv(env, "peer.service messaging.destination")
+		cfg, err := LoadConfigFile("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Equal([]string{"peer.service", "messaging.destination"}, cfg.PeerTags)
+	})
+
 	env = "DD_APM_ANALYZED_SPANS"
 	t.Run(env, func(t *testing.T) {
 		defer cleanConfig()()
@@ -815,6 +840,26 @@ func TestLoadEnv(t *testing.T) {
 		assert.Equal(cfg.RequireTags, []*config.Tag{{K: "important1", V: "value with a space"}})
 	})
 
+	env = "DD_APM_FILTER_TAGS_RULES"
+	t.Run(env, func(t *testing.T) {
+		defer cleanConfig()()
+		assert := assert.New(t)
+		t.Setenv(env, `[{"type":"reject","key":"synthetics"}]`)
+		cfg, err := LoadConfigFile("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Equal([]*config.TagFilterRule{{Type: "reject", Key: "synthetics"}}, cfg.TagFilterRules)
+	})
+
+	env = "DD_APM_FILE_EXPORT_FORMAT"
+	t.Run(env, func(t *testing.T) {
+		defer cleanConfig()()
+		assert := assert.New(t)
+		t.Setenv(env, "json")
+		cfg, err := LoadConfigFile("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Equal(config.FileExportJSON, cfg.FileExport.Format)
+	})
+
 	env = "DD_APM_FILTER_TAGS_REJECT"
 	t.Run(env, func(t *testing.T) {
 		defer cleanConfig()()
@@ -991,3 +1036,21 @@ func TestLoadEnv(t *testing.T) {
 		}
 	})
 }
+
+func TestCompileTagFilterRules(t *testing.T) {
+	rules := []*config.TagFilterRule{
+		{Type: config.TagFilterReject, Key: "http.url", Pattern: "/health$"},
+		{Type: config.TagFilterRequire, Key: "user.id"},
+	}
+	assert.NoError(t, compileTagFilterRules(rules))
+	assert.True(t, rules[0].Re.MatchString("http://localhost/health"))
+	assert.Nil(t, rules[1].Re)
+
+	for _, rule := range []*config.TagFilterRule{
+		{Type: "drop", Key: "http.url"},
+		{Type: config.TagFilterReject},
+		{Type: config.TagFilterReject, Key: "http.url", Pattern: "(?=abc)"},
+	} {
+		assert.Error(t, compileTagFilterRules([]*config.TagFilterRule{rule}))
+	}
+}
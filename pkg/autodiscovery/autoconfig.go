@@ -7,6 +7,7 @@ package autodiscovery
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 
@@ -247,6 +248,30 @@ func (ac *AutoConfig) GetAllConfigs() []integration.Config {
 	return configs
 }
 
+// RefreshSecrets unschedules the configs named after one of origins, stores the refreshed
+// secrets by calling apply, and schedules the configs again with the new values of their
+// secrets. It implements secrets.RefreshHandler.
+func (ac *AutoConfig) RefreshSecrets(origins []string, apply func()) {
+	names := make(map[string]struct{}, len(origins))
+	for _, origin := range origins {
+		names[origin] = struct{}{}
+	}
+	var configs []integration.Config
+	for _, cp := range ac.getConfigPollers() {
+		configs = append(configs, cp.getConfigsNamed(names)...)
+	}
+	log.Infof("Secrets used by %s changed, rescheduling %d configs", strings.Join(origins, ", "), len(configs))
+
+	// the configs are unscheduled before the new secrets are stored, as their digests
+	// are computed from the decrypted configs
+	ac.processRemovedConfigs(configs)
+	apply()
+	for _, config := range configs {
+		changes := ac.processNewConfig(config)
+		ac.applyChanges(changes)
+	}
+}
+
 // processNewConfig store (in template cache) and resolves a given config,
 // returning the changes to be made.
 func (ac *AutoConfig) processNewConfig(config integration.Config) integration.ConfigChanges {
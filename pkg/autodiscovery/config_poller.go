@@ -104,6 +104,7 @@ func (cp *configPoller) stream(ch chan struct{}, provider providers.StreamingCon
 			if !changes.IsEmpty() {
 				log.Infof("%v provider: collected %d new configurations, removed %d", provider, len(changes.Schedule), len(changes.Unschedule))
 
+				cp.storeConfigChanges(changes)
 				ac.processRemovedConfigs(changes.Unschedule)
 
 				for _, added := range changes.Schedule {
@@ -215,6 +216,41 @@ func (cp *configPoller) collect(ctx context.Context, provider providers.Collecti
 	return cp.storeAndDiffConfigs(fetched)
 }
 
+// storeConfigChanges keeps track of the configs changed by a streaming provider.
+func (cp *configPoller) storeConfigChanges(changes integration.ConfigChanges) {
+	cp.configsMu.Lock()
+	defer cp.configsMu.Unlock()
+
+	for _, c := range changes.Unschedule {
+		delete(cp.configs, c.FastDigest())
+	}
+	for _, c := range changes.Schedule {
+		cp.configs[c.FastDigest()] = c
+	}
+}
+
+// getConfigsNamed returns the known configs of the provider with one of the given names,
+// as they are processed by AutoConfig.
+func (cp *configPoller) getConfigsNamed(names map[string]struct{}) []integration.Config {
+	cp.configsMu.Lock()
+	defer cp.configsMu.Unlock()
+
+	_, isFileProvider := cp.provider.(*providers.FileConfigProvider)
+	var configs []integration.Config
+	for _, c := range cp.configs {
+		if _, found := names[c.Name]; !found {
+			continue
+		}
+		// the metric configs of JMX checks are not scheduled
+		if isFileProvider && c.MetricConfig != nil {
+			continue
+		}
+		c.Provider = cp.provider.String()
+		configs = append(configs, c)
+	}
+	return configs
+}
+
 func (cp *configPoller) storeAndDiffConfigs(configs []integration.Config) ([]integration.Config, []integration.Config) {
 	cp.configsMu.Lock()
 	defer cp.configsMu.Unlock()
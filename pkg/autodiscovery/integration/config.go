@@ -108,6 +108,7 @@ type CommonInstanceConfig struct {
 	Service               string   `yaml:"service"`
 	Name                  string   `yaml:"name"`
 	Namespace             string   `yaml:"namespace"`
+	RunTimeout            int      `yaml:"run_timeout"`
 }
 
 // CommonGlobalConfig holds the reserved fields for the yaml init_config data
@@ -0,0 +1,366 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gopsnet "github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	processADIdentifierPrefix = "process:"
+	processServiceIDPrefix    = "process://"
+	processHostNetwork        = "host"
+
+	defaultProcessDiscoveryInterval = 60 * time.Second
+)
+
+func init() {
+	Register("process", NewProcessListener)
+}
+
+// ProcessListener implements a ServiceListener discovering the host processes
+// listening on TCP ports
+type ProcessListener struct {
+	sync.RWMutex
+	newService        chan<- Service
+	delService        chan<- Service
+	stop              chan bool
+	discoveryInterval time.Duration
+	services          map[int]*ProcessService // maps pids to services
+	listProcesses     func() ([]processInfo, error)
+}
+
+// ProcessService represents a host process discovered by the ProcessListener
+type ProcessService struct {
+	pid           int
+	adIdentifiers []string
+	cmdline       []string
+	host          string
+	ports         []ContainerPort
+}
+
+// Make sure ProcessService implements the Service interface
+var _ Service = &ProcessService{}
+
+// processInfo holds what the ProcessListener needs to know about a process
+type processInfo struct {
+	pid     int
+	name    string
+	cmdline []string
+	// listening addresses of the process, mapping ports to IPs
+	listening map[int]string
+}
+
+// NewProcessListener creates a ProcessListener
+func NewProcessListener(Config) (ServiceListener, error) {
+	discoveryInterval := time.Duration(config.Datadog.GetInt("process_listener.discovery_interval")) * time.Second
+	if discoveryInterval <= 0 {
+		log.Warnf("Invalid process_listener.discovery_interval %v, using the default of %v", discoveryInterval, defaultProcessDiscoveryInterval)
+		discoveryInterval = defaultProcessDiscoveryInterval
+	}
+	return &ProcessListener{
+		stop:              make(chan bool),
+		discoveryInterval: discoveryInterval,
+		services:          map[int]*ProcessService{},
+		listProcesses:     listListeningProcesses,
+	}, nil
+}
+
+// Listen periodically discovers the processes listening on TCP ports
+func (l *ProcessListener) Listen(newSvc chan<- Service, delSvc chan<- Service) {
+	l.newService = newSvc
+	l.delService = delSvc
+
+	go func() {
+		l.refreshServices()
+		ticker := time.NewTicker(l.discoveryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-l.stop:
+				return
+			case <-ticker.C:
+				l.refreshServices()
+			}
+		}
+	}()
+}
+
+// Stop stops the ProcessListener
+func (l *ProcessListener) Stop() {
+	l.stop <- true
+}
+
+func (l *ProcessListener) refreshServices() {
+	l.Lock()
+	defer l.Unlock()
+
+	processes, err := l.listProcesses()
+	if err != nil {
+		log.Warnf("Unable to list the processes listening on TCP ports: %s", err)
+		return
+	}
+
+	notSeen := make(map[int]struct{}, len(l.services))
+	for pid := range l.services {
+		notSeen[pid] = struct{}{}
+	}
+
+	for _, p := range processes {
+		svc := newProcessService(p)
+		if old, found := l.services[p.pid]; found {
+			if old.equal(svc) {
+				delete(notSeen, p.pid)
+				continue
+			}
+			// the pid was reused or the process now listens on other ports
+			l.delService <- old
+		}
+		delete(notSeen, p.pid)
+		log.Debugf("Process listener discovered process %d with AD identifiers %v", p.pid, svc.adIdentifiers)
+		l.services[p.pid] = svc
+		l.newService <- svc
+	}
+
+	for pid := range notSeen {
+		l.delService <- l.services[pid]
+		delete(l.services, pid)
+	}
+}
+
+// listListeningProcesses returns the processes with listening TCP sockets in the
+// network namespace of the agent
+func listListeningProcesses() ([]processInfo, error) {
+	connections, err := gopsnet.Connections("tcp")
+	if err != nil {
+		return nil, err
+	}
+
+	byPid := groupListeningSockets(connections, newProcessLookup())
+
+	processes := make([]processInfo, 0, len(byPid))
+	for pid, p := range byPid {
+		proc, err := process.NewProcess(int32(pid))
+		if err != nil {
+			log.Debugf("Unable to inspect process %d: %s", pid, err)
+			continue
+		}
+		if p.name, err = proc.Name(); err != nil {
+			log.Debugf("Unable to get the name of process %d: %s", pid, err)
+			continue
+		}
+		// the command line is not required to discover the process
+		p.cmdline, _ = proc.CmdlineSlice()
+		processes = append(processes, *p)
+	}
+	return processes, nil
+}
+
+// processLookup returns the parent and the executable of a process
+type processLookup func(pid int) (ppid int, exe string, err error)
+
+// newProcessLookup returns a processLookup caching the processes it inspects
+func newProcessLookup() processLookup {
+	type lookupResult struct {
+		ppid int
+		exe  string
+		err  error
+	}
+	cache := map[int]lookupResult{}
+	return func(pid int) (int, string, error) {
+		if res, found := cache[pid]; found {
+			return res.ppid, res.exe, res.err
+		}
+		var res lookupResult
+		proc, err := process.NewProcess(int32(pid))
+		if err == nil {
+			var ppid int32
+			if ppid, err = proc.Ppid(); err == nil {
+				res.ppid = int(ppid)
+				res.exe, err = proc.Exe()
+			}
+		}
+		res.err = err
+		cache[pid] = res
+		return res.ppid, res.exe, res.err
+	}
+}
+
+// groupListeningSockets maps the listening sockets to the processes owning them.
+// The workers of a pre-fork server (nginx, gunicorn...) share the sockets of their
+// master, and are reported as the master so that the server is discovered once.
+func groupListeningSockets(connections []gopsnet.ConnectionStat, lookup processLookup) map[int]*processInfo {
+	byPid := map[int]*processInfo{}
+	for _, c := range connections {
+		if c.Status != "LISTEN" || c.Pid <= 0 {
+			continue
+		}
+		pid := topmostProcess(int(c.Pid), lookup)
+		p, found := byPid[pid]
+		if !found {
+			p = &processInfo{pid: pid, listening: map[int]string{}}
+			byPid[pid] = p
+		}
+		p.listening[int(c.Laddr.Port)] = c.Laddr.IP
+	}
+	return byPid
+}
+
+// maxProcessDepth bounds the walk up the process tree
+const maxProcessDepth = 16
+
+// topmostProcess returns the topmost ancestor of the process running the same executable
+func topmostProcess(pid int, lookup processLookup) int {
+	ppid, exe, err := lookup(pid)
+	if err != nil || exe == "" {
+		return pid
+	}
+	for i := 0; i < maxProcessDepth && ppid > 1; i++ {
+		parentPpid, parentExe, err := lookup(ppid)
+		if err != nil || parentExe != exe {
+			break
+		}
+		pid, ppid = ppid, parentPpid
+	}
+	return pid
+}
+
+func newProcessService(p processInfo) *ProcessService {
+	svc := &ProcessService{
+		pid:     p.pid,
+		cmdline: p.cmdline,
+	}
+
+	svc.adIdentifiers = []string{processADIdentifierPrefix + p.name}
+	// the executable name can be truncated by the system, or differ from the program
+	// name in the command line for scripts
+	if len(p.cmdline) > 0 {
+		if fields := strings.Fields(p.cmdline[0]); len(fields) > 0 {
+			if program := filepath.Base(fields[0]); program != p.name {
+				svc.adIdentifiers = append(svc.adIdentifiers, processADIdentifierPrefix+program)
+			}
+		}
+	}
+
+	for port := range p.listening {
+		svc.ports = append(svc.ports, ContainerPort{Port: port})
+	}
+	sort.Slice(svc.ports, func(i, j int) bool { return svc.ports[i].Port < svc.ports[j].Port })
+	// the process is reached on the address of its lowest listening port
+	if len(svc.ports) > 0 {
+		svc.host = listenHost(p.listening[svc.ports[0].Port])
+	}
+
+	return svc
+}
+
+// listenHost returns the IP to reach a socket listening on ip
+func listenHost(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil || parsed.IsUnspecified() {
+		return "127.0.0.1"
+	}
+	return ip
+}
+
+func (s *ProcessService) equal(other *ProcessService) bool {
+	if s.host != other.host || len(s.ports) != len(other.ports) || len(s.adIdentifiers) != len(other.adIdentifiers) {
+		return false
+	}
+	for i := range s.ports {
+		if s.ports[i] != other.ports[i] {
+			return false
+		}
+	}
+	for i := range s.adIdentifiers {
+		if s.adIdentifiers[i] != other.adIdentifiers[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// GetServiceID returns the unique entity name linked to that service
+func (s *ProcessService) GetServiceID() string {
+	return processServiceIDPrefix + strconv.Itoa(s.pid)
+}
+
+// GetTaggerEntity returns the tagger entity
+func (s *ProcessService) GetTaggerEntity() string {
+	return ""
+}
+
+// GetADIdentifiers returns the identifiers of the process, of the form "process:<name>"
+func (s *ProcessService) GetADIdentifiers(context.Context) ([]string, error) {
+	return s.adIdentifiers, nil
+}
+
+// GetHosts returns the IP to reach the process
+func (s *ProcessService) GetHosts(context.Context) (map[string]string, error) {
+	return map[string]string{processHostNetwork: s.host}, nil
+}
+
+// GetPorts returns the TCP ports the process is listening on
+func (s *ProcessService) GetPorts(context.Context) ([]ContainerPort, error) {
+	return s.ports, nil
+}
+
+// GetTags returns no tags
+func (s *ProcessService) GetTags() ([]string, error) {
+	return nil, nil
+}
+
+// GetPid returns the pid of the process
+func (s *ProcessService) GetPid(context.Context) (int, error) {
+	return s.pid, nil
+}
+
+// GetHostname is not supported
+func (s *ProcessService) GetHostname(context.Context) (string, error) {
+	return "", ErrNotSupported
+}
+
+// IsReady is always true
+func (s *ProcessService) IsReady(context.Context) bool {
+	return true
+}
+
+// GetCheckNames is not supported
+func (s *ProcessService) GetCheckNames(context.Context) []string {
+	return nil
+}
+
+// HasFilter is not supported
+func (s *ProcessService) HasFilter(filter containers.FilterType) bool {
+	return false
+}
+
+// GetExtraConfig returns the command line of the process for "cmdline"
+func (s *ProcessService) GetExtraConfig(key string) (string, error) {
+	if key == "cmdline" {
+		return strings.Join(s.cmdline, " "), nil
+	}
+	return "", ErrNotSupported
+}
+
+// FilterTemplates does nothing.
+func (s *ProcessService) FilterTemplates(configs map[string]integration.Config) {
+}
@@ -0,0 +1,160 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gopsnet "github.com/shirou/gopsutil/v3/net"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestNewProcessService(t *testing.T) {
+	svc := newProcessService(processInfo{
+		pid:       42,
+		name:      "redis-server",
+		cmdline:   []string{"/usr/bin/redis-server 127.0.0.1:6379"},
+		listening: map[int]string{6379: "127.0.0.1", 16379: "0.0.0.0"},
+	})
+
+	ctx := context.Background()
+	assert.Equal(t, "process://42", svc.GetServiceID())
+	adIdentifiers, err := svc.GetADIdentifiers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"process:redis-server"}, adIdentifiers)
+	hosts, err := svc.GetHosts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"host": "127.0.0.1"}, hosts)
+	ports, err := svc.GetPorts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []ContainerPort{{Port: 6379}, {Port: 16379}}, ports)
+	pid, err := svc.GetPid(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 42, pid)
+	cmdline, err := svc.GetExtraConfig("cmdline")
+	require.NoError(t, err)
+	assert.Equal(t, "/usr/bin/redis-server 127.0.0.1:6379", cmdline)
+
+	svc = newProcessService(processInfo{
+		pid:       43,
+		name:      "python3",
+		cmdline:   []string{"/opt/app/server.py", "--port", "8080"},
+		listening: map[int]string{8080: "::", 8443: "10.0.0.1"},
+	})
+	adIdentifiers, err = svc.GetADIdentifiers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"process:python3", "process:server.py"}, adIdentifiers)
+	hosts, err = svc.GetHosts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"host": "127.0.0.1"}, hosts)
+}
+
+func TestNewProcessListenerDiscoveryInterval(t *testing.T) {
+	mockConfig := config.Mock(t)
+
+	for _, tc := range []struct {
+		interval int
+		expected time.Duration
+	}{
+		{interval: 10, expected: 10 * time.Second},
+		{interval: 0, expected: defaultProcessDiscoveryInterval},
+		{interval: -5, expected: defaultProcessDiscoveryInterval},
+	} {
+		mockConfig.Set("process_listener.discovery_interval", tc.interval)
+		listener, err := NewProcessListener(nil)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, listener.(*ProcessListener).discoveryInterval)
+	}
+}
+
+func TestProcessListenerRefreshServices(t *testing.T) {
+	newSvc := make(chan Service, 10)
+	delSvc := make(chan Service, 10)
+
+	processes := []processInfo{
+		{pid: 1, name: "nginx", listening: map[int]string{80: "0.0.0.0"}},
+		{pid: 2, name: "postgres", listening: map[int]string{5432: "127.0.0.1"}},
+	}
+	l := &ProcessListener{
+		newService:    newSvc,
+		delService:    delSvc,
+		services:      map[int]*ProcessService{},
+		listProcesses: func() ([]processInfo, error) { return processes, nil },
+	}
+
+	l.refreshServices()
+	require.Len(t, newSvc, 2)
+	require.Len(t, delSvc, 0)
+	<-newSvc
+	<-newSvc
+
+	// unchanged processes are not sent again
+	l.refreshServices()
+	assert.Len(t, newSvc, 0)
+	assert.Len(t, delSvc, 0)
+
+	// nginx listens on a new port, postgres exited
+	processes = []processInfo{
+		{pid: 1, name: "nginx", listening: map[int]string{80: "0.0.0.0", 443: "0.0.0.0"}},
+	}
+	l.refreshServices()
+	require.Len(t, newSvc, 1)
+	require.Len(t, delSvc, 2)
+
+	svc := <-newSvc
+	ports, err := svc.GetPorts(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []ContainerPort{{Port: 80}, {Port: 443}}, ports)
+	deleted := []string{(<-delSvc).GetServiceID(), (<-delSvc).GetServiceID()}
+	assert.ElementsMatch(t, []string{"process://1", "process://2"}, deleted)
+	assert.Len(t, l.services, 1)
+}
+
+func TestGroupListeningSockets(t *testing.T) {
+	type fakeProcess struct {
+		ppid int
+		exe  string
+	}
+	processes := map[int]fakeProcess{
+		// nginx master and its workers
+		10: {ppid: 1, exe: "/usr/sbin/nginx"},
+		11: {ppid: 10, exe: "/usr/sbin/nginx"},
+		12: {ppid: 10, exe: "/usr/sbin/nginx"},
+		// a program started by nginx, with its own socket
+		13: {ppid: 10, exe: "/usr/bin/helper"},
+		// a gunicorn worker whose master can't be inspected
+		21: {ppid: 20, exe: "/usr/bin/python3"},
+	}
+	lookup := func(pid int) (int, string, error) {
+		p, found := processes[pid]
+		if !found {
+			return 0, "", errors.New("no such process")
+		}
+		return p.ppid, p.exe, nil
+	}
+	listen := func(pid int32, ip string, port uint32) gopsnet.ConnectionStat {
+		return gopsnet.ConnectionStat{Pid: pid, Status: "LISTEN", Laddr: gopsnet.Addr{IP: ip, Port: port}}
+	}
+
+	byPid := groupListeningSockets([]gopsnet.ConnectionStat{
+		listen(11, "0.0.0.0", 80),
+		listen(12, "0.0.0.0", 443),
+		listen(13, "127.0.0.1", 9000),
+		listen(21, "0.0.0.0", 8000),
+		{Pid: 12, Status: "ESTABLISHED", Laddr: gopsnet.Addr{IP: "10.0.0.1", Port: 80}},
+	}, lookup)
+
+	require.Len(t, byPid, 3)
+	assert.Equal(t, map[int]string{80: "0.0.0.0", 443: "0.0.0.0"}, byPid[10].listening)
+	assert.Equal(t, map[int]string{9000: "127.0.0.1"}, byPid[13].listening)
+	assert.Equal(t, map[int]string{8000: "0.0.0.0"}, byPid[21].listening)
+}
@@ -31,7 +31,10 @@ func decryptConfig(conf integration.Config) (integration.Config, error) {
 		return conf, fmt.Errorf("error while decrypting secrets in 'init_config': %s", err)
 	}
 
-	// instances
+	// instances, copied so that the configs kept by the config pollers are not decrypted in place
+	if conf.Instances != nil {
+		conf.Instances = append(make([]integration.Data, 0, len(conf.Instances)), conf.Instances...)
+	}
 	for idx := range conf.Instances {
 		conf.Instances[idx], err = secretsDecrypt(conf.Instances[idx], conf.Name)
 		if err != nil {
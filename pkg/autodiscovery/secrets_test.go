@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/scheduler"
 	"github.com/DataDog/datadog-agent/pkg/config"
 )
 
@@ -136,3 +137,53 @@ func TestSkipSecretDecrypt(t *testing.T) {
 
 	assert.True(t, mockDecrypt.haveAllScenariosNotCalled())
 }
+
+func TestRefreshSecrets(t *testing.T) {
+	for _, ccaInAD := range []bool{false, true} {
+		t.Run(fmt.Sprintf("cca_in_ad=%t", ccaInAD), func(t *testing.T) {
+			config.Datadog.Set("logs_config.cca_in_ad", ccaInAD)
+			defer config.Datadog.Set("logs_config.cca_in_ad", false)
+			testRefreshSecrets(t)
+		})
+	}
+}
+
+func testRefreshSecrets(t *testing.T) {
+	secret := "old"
+	originalSecretsDecrypt := secretsDecrypt
+	defer func() { secretsDecrypt = originalSecretsDecrypt }()
+	secretsDecrypt = func(data []byte, origin string) ([]byte, error) {
+		return bytes.ReplaceAll(data, []byte("ENC[pass]"), []byte(secret)), nil
+	}
+
+	ms := &MockScheduler{scheduled: make(map[string]integration.Config)}
+	ac := NewAutoConfigNoStart(scheduler.NewMetaScheduler())
+	ac.AddScheduler("mock", ms, false)
+
+	redis := integration.Config{Name: "redis", Provider: "mocked", Instances: []integration.Data{integration.Data("password: ENC[pass]")}}
+	cpu := integration.Config{Name: "cpu", Provider: "mocked", Instances: []integration.Data{integration.Data("{}")}}
+	cp := newConfigPoller(&MockProvider{}, false, 0)
+	cp.storeAndDiffConfigs([]integration.Config{redis, cpu})
+	ac.configPollers = append(ac.configPollers, cp)
+	for _, c := range []integration.Config{redis, cpu} {
+		ac.applyChanges(ac.processNewConfig(c))
+	}
+	require.Len(t, ms.scheduled, 2)
+
+	applied := false
+	ac.RefreshSecrets([]string{"redis", "datadog.yaml"}, func() {
+		// the configs using the secrets are unscheduled first
+		assert.Len(t, ms.scheduled, 1)
+		secret = "new"
+		applied = true
+	})
+	assert.True(t, applied)
+
+	instances := map[string]string{}
+	for _, c := range ms.scheduled {
+		instances[c.Name] = string(c.Instances[0])
+		assert.Equal(t, "mocked", c.Provider)
+	}
+	assert.Equal(t, map[string]string{"redis": "password: new", "cpu": "{}"}, instances)
+	assert.Len(t, ac.LoadedConfigs(), 2)
+}
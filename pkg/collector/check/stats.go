@@ -46,6 +46,10 @@ var (
 		[]string{"check_name"}, "Histogram buckets count")
 	tlmExecutionTime = telemetry.NewGauge("checks", "execution_time",
 		[]string{"check_name"}, "Check execution time")
+	tlmTimeouts = telemetry.NewCounter("checks", "timeouts",
+		[]string{"check_name"}, "Check runs exceeding their timeout")
+	tlmStuck = telemetry.NewGauge("checks", "stuck",
+		[]string{"check_name"}, "Check instances whose run exceeded its timeout and did not return yet")
 )
 
 // SenderStats contains statistics showing the count of various types of telemetry sent by a check sender
@@ -101,6 +105,8 @@ type Stats struct {
 	LastError                string    // error that occurred in the last run, if any
 	LastWarnings             []string  // warnings that occurred in the last run, if any
 	UpdateTimestamp          int64     // latest update to this instance, unix timestamp in seconds
+	TotalTimeouts            uint64    // number of runs which exceeded their timeout
+	StuckSince               int64     // start date of the run which exceeded its timeout and did not return yet, unix timestamp in seconds
 	m                        sync.Mutex
 	telemetry                bool // do we want telemetry on this Check
 }
@@ -212,6 +218,33 @@ func (cs *Stats) Add(t time.Duration, err error, warnings []error, metricStats S
 	}
 }
 
+// SetStuck records that the run started at start exceeded its timeout
+func (cs *Stats) SetStuck(start time.Time) {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+
+	cs.TotalTimeouts++
+	cs.StuckSince = start.Unix()
+	if cs.telemetry {
+		tlmTimeouts.Inc(cs.CheckName)
+		tlmStuck.Inc(cs.CheckName)
+	}
+}
+
+// ClearStuck records that the run which exceeded its timeout returned
+func (cs *Stats) ClearStuck() {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+
+	if cs.StuckSince == 0 {
+		return
+	}
+	cs.StuckSince = 0
+	if cs.telemetry {
+		tlmStuck.Dec(cs.CheckName)
+	}
+}
+
 type aggStats struct {
 	EventPlatformEvents       map[string]interface{}
 	EventPlatformEventsErrors map[string]interface{}
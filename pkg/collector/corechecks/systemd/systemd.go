@@ -23,6 +23,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/metadata/inventories"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	systemdutil "github.com/DataDog/datadog-agent/pkg/util/systemd"
 
 	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
 )
@@ -176,7 +177,7 @@ type systemdStats interface {
 type defaultSystemdStats struct{}
 
 func (s *defaultSystemdStats) PrivateSocketConnection(privateSocket string) (*dbus.Conn, error) {
-	return NewSystemdConnection(privateSocket)
+	return systemdutil.NewSystemdConnection(privateSocket)
 }
 
 func (s *defaultSystemdStats) SystemBusSocketConnection() (*dbus.Conn, error) {
@@ -269,13 +270,12 @@ func (c *SystemdCheck) getDbusConnection() (*dbus.Conn, error) {
 	if c.config.instance.PrivateSocket != "" {
 		conn, err = c.getPrivateSocketConnection(c.config.instance.PrivateSocket)
 	} else {
-		defaultPrivateSocket := "/run/systemd/private"
 		if config.IsContainerized() {
-			conn, err = c.getPrivateSocketConnection("/host" + defaultPrivateSocket)
+			conn, err = c.getPrivateSocketConnection("/host" + systemdutil.DefaultPrivateSocket)
 		} else {
 			conn, err = c.getSystemBusSocketConnection()
 			if err != nil {
-				conn, err = c.getPrivateSocketConnection(defaultPrivateSocket)
+				conn, err = c.getPrivateSocketConnection(systemdutil.DefaultPrivateSocket)
 			}
 		}
 	}
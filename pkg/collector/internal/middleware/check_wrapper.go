@@ -9,6 +9,8 @@ import (
 	"sync"
 	"time"
 
+	yaml "gopkg.in/yaml.v2"
+
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
@@ -20,15 +22,29 @@ import (
 type CheckWrapper struct {
 	inner check.Check
 	wg    sync.WaitGroup
+	// runTimeout is the 'run_timeout' of the check instance, 0 if not set
+	runTimeout time.Duration
 }
 
 // NewCheckWrapper returns a wrapped check.
+// The check must be configured, its 'run_timeout' is resolved once from its instance configuration.
 func NewCheckWrapper(inner check.Check) *CheckWrapper {
+	commonOptions := integration.CommonInstanceConfig{}
+	var runTimeout time.Duration
+	if err := yaml.Unmarshal([]byte(inner.InstanceConfig()), &commonOptions); err == nil && commonOptions.RunTimeout > 0 {
+		runTimeout = time.Duration(commonOptions.RunTimeout) * time.Second
+	}
 	return &CheckWrapper{
-		inner: inner,
+		inner:      inner,
+		runTimeout: runTimeout,
 	}
 }
 
+// RunTimeout returns the 'run_timeout' of the check instance, 0 if not set
+func (c *CheckWrapper) RunTimeout() time.Duration {
+	return c.runTimeout
+}
+
 // Run implements Check#Run
 func (c *CheckWrapper) Run() error {
 	c.wg.Add(1)
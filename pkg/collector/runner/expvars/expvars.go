@@ -184,6 +184,20 @@ func CheckStats(id check.ID) (*check.Stats, bool) {
 	return check, true
 }
 
+// SetCheckStuck marks the check as stuck in a run started at start which exceeded its timeout
+func SetCheckStuck(id check.ID, start time.Time) {
+	if stats, found := CheckStats(id); found {
+		stats.SetStuck(start)
+	}
+}
+
+// ClearCheckStuck clears the stuck state of the check once its run returned
+func ClearCheckStuck(id check.ID) {
+	if stats, found := CheckStats(id); found {
+		stats.ClearStuck()
+	}
+}
+
 // Functions relating to running checks state map (`runningChecksStats`)
 
 // SetRunningStats sets the start time of a running check
@@ -14,6 +14,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/collector/runner/expvars"
 	"github.com/DataDog/datadog-agent/pkg/collector/runner/tracker"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/util/hostname"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -39,6 +40,8 @@ type Worker struct {
 	runnerID                int
 	shouldAddCheckStatsFunc func(id check.ID) bool
 	utilizationTracker      UtilizationTracker
+	// runTimeout is the default timeout of a check run, 0 if disabled
+	runTimeout time.Duration
 }
 
 // NewWorker returns an instance of a `Worker` after parameter sanity checks are passed
@@ -62,7 +65,7 @@ func NewWorker(
 		return nil, fmt.Errorf("worker cannot initialize using a nil shouldAddCheckStatsFunc")
 	}
 
-	return newWorkerWithOptions(
+	w, err := newWorkerWithOptions(
 		runnerID,
 		ID,
 		pendingChecksChan,
@@ -72,6 +75,11 @@ func NewWorker(
 		windowSize,
 		pollingInterval,
 	)
+	if err != nil {
+		return nil, err
+	}
+	w.runTimeout = time.Duration(config.Datadog.GetInt("check_run_timeout")) * time.Second
+	return w, nil
 }
 
 // newWorkerWithOptions returns an instance of a `Worker` with an override for the
@@ -144,11 +152,19 @@ func (w *Worker) Run() {
 
 		// Run the check
 		var checkErr error
-		checkErr = check.Run()
+		var pending <-chan error
+		if longRunning {
+			checkErr = check.Run()
+		} else {
+			pending, checkErr = w.runWithTimeout(check)
+		}
+		finished := pending == nil
 
 		w.utilizationTracker.CheckFinished()
 
-		expvars.DeleteRunningStats(check.ID())
+		if finished {
+			expvars.DeleteRunningStats(check.ID())
+		}
 
 		checkWarnings := check.GetWarnings()
 
@@ -159,6 +175,7 @@ func (w *Worker) Run() {
 		}
 		serviceCheckTags := []string{fmt.Sprintf("check:%s", check.String())}
 		serviceCheckStatus := metrics.ServiceCheckOK
+		serviceCheckMessage := ""
 
 		hname, _ := hostname.Get(context.TODO())
 
@@ -172,17 +189,23 @@ func (w *Worker) Run() {
 			expvars.AddErrorsCount(1)
 			serviceCheckStatus = metrics.ServiceCheckCritical
 		}
+		if !finished {
+			serviceCheckMessage = checkErr.Error()
+		}
 
 		if sender != nil && !longRunning {
-			sender.ServiceCheck(serviceCheckStatusKey, serviceCheckStatus, hname, serviceCheckTags, "")
+			sender.ServiceCheck(serviceCheckStatusKey, serviceCheckStatus, hname, serviceCheckTags, serviceCheckMessage)
 			sender.Commit()
 		}
 
-		// Remove the check from the running list
-		w.checksTracker.DeleteCheck(check.ID())
+		// Remove the check from the running list. A check exceeding its timeout stays in
+		// the list, so that it isn't run again, until its run returns.
+		if finished {
+			w.checksTracker.DeleteCheck(check.ID())
+			expvars.AddRunningCheckCount(-1)
+		}
 
 		// Publish statistics about this run
-		expvars.AddRunningCheckCount(-1)
 		expvars.AddRunsCount(1)
 
 		if !longRunning || len(checkWarnings) != 0 || checkErr != nil {
@@ -193,9 +216,63 @@ func (w *Worker) Run() {
 				expvars.AddCheckStats(check, time.Since(checkStartTime), checkErr, checkWarnings, sStats)
 			}
 		}
+		if !finished {
+			expvars.SetCheckStuck(check.ID(), checkStartTime)
+			go w.waitStuckCheck(check, checkStartTime, pending)
+		}
 
 		checkLogger.CheckFinished()
 	}
 
 	log.Debugf("Runner %d, worker %d: Finished processing checks.", w.runnerID, w.ID)
 }
+
+// runTimeoutProvider is implemented by the checks resolving the 'run_timeout' of their instance
+// when they are scheduled, so that it isn't parsed on every run.
+type runTimeoutProvider interface {
+	RunTimeout() time.Duration
+}
+
+// runTimeoutFor returns the timeout of the runs of a check, the 'run_timeout' of its
+// instance takes precedence over the default one.
+func (w *Worker) runTimeoutFor(c check.Check) time.Duration {
+	if provider, ok := c.(runTimeoutProvider); ok && provider.RunTimeout() > 0 {
+		return provider.RunTimeout()
+	}
+	return w.runTimeout
+}
+
+// runWithTimeout runs the check and returns its error. If the run exceeds its timeout,
+// an error is returned along with the channel on which the run result will be sent.
+func (w *Worker) runWithTimeout(c check.Check) (<-chan error, error) {
+	timeout := w.runTimeoutFor(c)
+	if timeout <= 0 {
+		return nil, c.Run()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Run()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return nil, err
+	case <-timer.C:
+		return done, fmt.Errorf("check run exceeded its timeout of %s", timeout)
+	}
+}
+
+// waitStuckCheck waits for the run of a check which exceeded its timeout, and removes the
+// check from the running checks once it returns so that it can be scheduled again.
+func (w *Worker) waitStuckCheck(c check.Check, startTime time.Time, pending <-chan error) {
+	err := <-pending
+	log.Warnf("Check %s returned %s after it started, exceeding its timeout (error: %v)", c, time.Since(startTime), err)
+
+	expvars.ClearCheckStuck(c.ID())
+	expvars.DeleteRunningStats(c.ID())
+	expvars.AddRunningCheckCount(-1)
+	w.checksTracker.DeleteCheck(c.ID())
+}
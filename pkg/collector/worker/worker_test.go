@@ -18,6 +18,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/collector/internal/middleware"
 	"github.com/DataDog/datadog-agent/pkg/collector/runner/expvars"
 	"github.com/DataDog/datadog-agent/pkg/collector/runner/tracker"
 	"github.com/DataDog/datadog-agent/pkg/config"
@@ -34,12 +35,15 @@ type testCheck struct {
 	t           *testing.T
 	runFunc     func(id check.ID)
 	runCount    *atomic.Uint64
+	instance    string
 }
 
 func (c *testCheck) ID() check.ID   { return check.ID(c.id) }
 func (c *testCheck) String() string { return check.IDToCheckName(c.ID()) }
 func (c *testCheck) RunCount() int  { return int(c.runCount.Load()) }
 
+func (c *testCheck) InstanceConfig() string { return c.instance }
+
 func (c *testCheck) Interval() time.Duration {
 	if c.longRunning {
 		return 0
@@ -554,6 +558,91 @@ func TestWorkerServiceCheckSending(t *testing.T) {
 	mockSender.AssertNumberOfCalls(t, "ServiceCheck", 3)
 }
 
+func TestWorkerRunTimeout(t *testing.T) {
+	expvars.Reset()
+	config.Datadog.Set("hostname", "myhost")
+
+	checksTracker := tracker.NewRunningChecksTracker()
+	pendingChecksChan := make(chan check.Check, 10)
+	mockShouldAddStatsFunc := func(id check.ID) bool { return true }
+
+	release := make(chan struct{})
+	stuckCheck := newCheck(t, "stuckcheck:123", false, func(check.ID) { <-release })
+
+	// the second run is skipped while the first one is stuck
+	pendingChecksChan <- stuckCheck
+	pendingChecksChan <- stuckCheck
+	close(pendingChecksChan)
+
+	mockSender := mocksender.NewMockSender("")
+	mockSender.On("Commit").Return().Times(1)
+	mockSender.On(
+		"ServiceCheck",
+		serviceCheckStatusKey,
+		metrics.ServiceCheckCritical,
+		"myhost",
+		[]string{"check:stuckcheck"},
+		"check run exceeded its timeout of 50ms",
+	).Return().Times(1)
+
+	worker, err := newWorkerWithOptions(
+		100,
+		200,
+		pendingChecksChan,
+		checksTracker,
+		mockShouldAddStatsFunc,
+		func() (aggregator.Sender, error) {
+			return mockSender, nil
+		},
+		windowSize,
+		pollingInterval,
+	)
+	require.Nil(t, err)
+	worker.runTimeout = 50 * time.Millisecond
+
+	worker.Run()
+
+	mockSender.AssertExpectations(t)
+	assert.Equal(t, 1, int(expvars.GetRunsCount()))
+	assert.Equal(t, 1, int(expvars.GetRunningCheckCount()))
+	_, found := checksTracker.Check(stuckCheck.ID())
+	assert.True(t, found)
+
+	stats, found := expvars.CheckStats(stuckCheck.ID())
+	require.True(t, found)
+	assert.Equal(t, uint64(1), stats.TotalErrors)
+	assert.Equal(t, uint64(1), stats.TotalTimeouts)
+	assert.NotZero(t, stats.StuckSince)
+
+	close(release)
+	require.Eventually(t, func() bool {
+		_, found := checksTracker.Check(stuckCheck.ID())
+		return !found
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, int(expvars.GetRunningCheckCount()))
+	assert.Zero(t, stats.StuckSince)
+	assert.Equal(t, 1, stuckCheck.RunCount())
+}
+
+func TestWorkerRunTimeoutFor(t *testing.T) {
+	worker := &Worker{runTimeout: 10 * time.Second}
+
+	c := newCheck(t, "testcheck:123", false, nil)
+	assert.Equal(t, 10*time.Second, worker.runTimeoutFor(c))
+	assert.Equal(t, 10*time.Second, worker.runTimeoutFor(middleware.NewCheckWrapper(c)))
+
+	// the 'run_timeout' of the instance is resolved when the check is wrapped
+	c.instance = "run_timeout: 3\nfoo: bar\n"
+	wrapped := middleware.NewCheckWrapper(c)
+	assert.Equal(t, 3*time.Second, worker.runTimeoutFor(wrapped))
+	c.instance = "run_timeout: 5\nfoo: bar\n"
+	assert.Equal(t, 3*time.Second, worker.runTimeoutFor(wrapped))
+
+	worker.runTimeout = 0
+	c.instance = "foo: bar\n"
+	assert.Equal(t, time.Duration(0), worker.runTimeoutFor(middleware.NewCheckWrapper(c)))
+}
+
 func TestWorkerSenderNil(t *testing.T) {
 	expvars.Reset()
 	config.Datadog.Set("hostname", "myhost")
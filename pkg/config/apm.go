@@ -39,6 +39,7 @@ func setupAPM(config Config) {
 	config.SetKnown("apm_config.obfuscation.memcached.enabled")
 	config.SetKnown("apm_config.filter_tags.require")
 	config.SetKnown("apm_config.filter_tags.reject")
+	config.SetKnown("apm_config.filter_tags.rules")
 	config.SetKnown("apm_config.extra_sample_rate")
 	config.SetKnown("apm_config.dd_agent_bin")
 	config.SetKnown("apm_config.trace_writer.connection_limit")
@@ -77,7 +78,17 @@ func setupAPM(config Config) {
 	config.BindEnv("apm_config.errors_per_second", "DD_APM_ERROR_TPS")
 	config.BindEnv("apm_config.enable_rare_sampler", "DD_APM_ENABLE_RARE_SAMPLER")
 	config.BindEnv("apm_config.disable_rare_sampler", "DD_APM_DISABLE_RARE_SAMPLER") //Deprecated
+	config.BindEnv("apm_config.latency_sampler.enabled", "DD_APM_LATENCY_SAMPLER_ENABLED")
+	config.BindEnv("apm_config.latency_sampler.percentile", "DD_APM_LATENCY_SAMPLER_PERCENTILE")
+	config.BindEnv("apm_config.latency_sampler.tps", "DD_APM_LATENCY_SAMPLER_TPS")
 	config.BindEnv("apm_config.max_remote_traces_per_second", "DD_APM_MAX_REMOTE_TPS")
+	config.BindEnv("apm_config.file_export.enabled", "DD_APM_FILE_EXPORT_ENABLED")
+	config.BindEnv("apm_config.file_export.path", "DD_APM_FILE_EXPORT_PATH")
+	config.BindEnv("apm_config.file_export.format", "DD_APM_FILE_EXPORT_FORMAT")
+	config.BindEnv("apm_config.file_export.max_file_size", "DD_APM_FILE_EXPORT_MAX_FILE_SIZE")
+	config.BindEnv("apm_config.file_export.max_file_age", "DD_APM_FILE_EXPORT_MAX_FILE_AGE")
+	config.BindEnv("apm_config.file_export.max_files", "DD_APM_FILE_EXPORT_MAX_FILES")
+	config.BindEnv("apm_config.file_export.send_to_intake", "DD_APM_FILE_EXPORT_SEND_TO_INTAKE")
 
 	config.BindEnv("apm_config.max_memory", "DD_APM_MAX_MEMORY")
 	config.BindEnv("apm_config.max_cpu_percent", "DD_APM_MAX_CPU_PERCENT")
@@ -92,11 +103,13 @@ func setupAPM(config Config) {
 	config.BindEnv("apm_config.replace_tags", "DD_APM_REPLACE_TAGS")
 	config.BindEnv("apm_config.analyzed_spans", "DD_APM_ANALYZED_SPANS")
 	config.BindEnv("apm_config.ignore_resources", "DD_APM_IGNORE_RESOURCES", "DD_IGNORE_RESOURCE")
+	config.BindEnv("apm_config.peer_tags", "DD_APM_PEER_TAGS")
 	config.BindEnv("apm_config.receiver_socket", "DD_APM_RECEIVER_SOCKET")
 	config.BindEnv("apm_config.windows_pipe_name", "DD_APM_WINDOWS_PIPE_NAME")
 	config.BindEnv("apm_config.sync_flushing", "DD_APM_SYNC_FLUSHING")
 	config.BindEnv("apm_config.filter_tags.require", "DD_APM_FILTER_TAGS_REQUIRE")
 	config.BindEnv("apm_config.filter_tags.reject", "DD_APM_FILTER_TAGS_REJECT")
+	config.BindEnv("apm_config.filter_tags.rules", "DD_APM_FILTER_TAGS_RULES")
 	config.BindEnv("apm_config.internal_profiling.enabled", "DD_APM_INTERNAL_PROFILING_ENABLED")
 	config.BindEnv("apm_config.debugger_dd_url", "DD_APM_DEBUGGER_DD_URL")
 	config.BindEnv("apm_config.debugger_api_key", "DD_APM_DEBUGGER_API_KEY")
@@ -119,6 +132,14 @@ func setupAPM(config Config) {
 
 	config.SetEnvKeyTransformer("apm_config.filter_tags.reject", parseKVList("apm_config.filter_tags.reject"))
 
+	config.SetEnvKeyTransformer("apm_config.filter_tags.rules", func(in string) interface{} {
+		var out []map[string]string
+		if err := json.Unmarshal([]byte(in), &out); err != nil {
+			log.Warnf(`"apm_config.filter_tags.rules" can not be parsed: %v`, err)
+		}
+		return out
+	})
+
 	config.SetEnvKeyTransformer("apm_config.replace_tags", func(in string) interface{} {
 		var out []map[string]string
 		if err := json.Unmarshal([]byte(in), &out); err != nil {
@@ -192,6 +192,22 @@ type MetricMapping struct {
 	Tags      map[string]string `mapstructure:"tags" json:"tags"`
 }
 
+// TagRule represent a rule rewriting the tags of the metrics matching a name glob
+type TagRule struct {
+	Match            string                `mapstructure:"match" json:"match"`
+	DropMetric       bool                  `mapstructure:"drop_metric" json:"drop_metric"`
+	DropTags         []string              `mapstructure:"drop_tags" json:"drop_tags"`
+	RenameTags       map[string]string     `mapstructure:"rename_tags" json:"rename_tags"`
+	ReplaceTagValues []TagValueReplacement `mapstructure:"replace_tag_values" json:"replace_tag_values"`
+}
+
+// TagValueReplacement represent a regex replacement applied to the values of a tag
+type TagValueReplacement struct {
+	Tag         string `mapstructure:"tag" json:"tag"`
+	Regex       string `mapstructure:"regex" json:"regex"`
+	Replacement string `mapstructure:"replacement" json:"replacement"`
+}
+
 // Endpoint represent a datadog endpoint
 type Endpoint struct {
 	Site   string `mapstructure:"site" json:"site"`
@@ -267,6 +283,7 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("enable_metadata_collection", true)
 	config.BindEnvAndSetDefault("enable_gohai", true)
 	config.BindEnvAndSetDefault("check_runners", int64(4))
+	config.BindEnvAndSetDefault("check_run_timeout", 0)
 	config.BindEnvAndSetDefault("auth_token_file_path", "")
 	config.BindEnv("bind_host")
 	config.BindEnvAndSetDefault("ipc_address", "localhost")
@@ -365,6 +382,9 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("secret_backend_timeout", 30)
 	config.BindEnvAndSetDefault("secret_backend_command_allow_group_exec_perm", false)
 	config.BindEnvAndSetDefault("secret_backend_skip_checks", false)
+	config.BindEnvAndSetDefault("secret_backend_type", "")
+	config.BindEnvAndSetDefault("secret_backend_http_token_file", "")
+	config.BindEnvAndSetDefault("secret_refresh_interval", 0)
 
 	// Use to output logs in JSON format
 	config.BindEnvAndSetDefault("log_format_json", false)
@@ -438,6 +458,9 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("enable_events_stream_payload_serialization", true)
 	config.BindEnvAndSetDefault("enable_sketch_stream_payload_serialization", true)
 	config.BindEnvAndSetDefault("enable_json_stream_shared_compressor_buffers", true)
+	config.BindEnvAndSetDefault("serializer_compressor_kind", "zlib")
+	config.BindEnvAndSetDefault("serializer_zstd_compressor_level", 1)
+	config.BindEnvAndSetDefault("serializer_compressor_kind_by_domain", map[string]string{})
 
 	// Warning: do not change the following values. Your payloads will get dropped by Datadog's intake.
 	config.BindEnvAndSetDefault("serializer_max_payload_size", 2*megaByte+megaByte/2)
@@ -487,6 +510,10 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("use_dogstatsd", true)
 	config.BindEnvAndSetDefault("dogstatsd_port", 8125)    // Notice: 0 means UDP port closed
 	config.BindEnvAndSetDefault("dogstatsd_pipe_name", "") // experimental and not officially supported for now.
+	config.BindEnvAndSetDefault("dogstatsd_tcp_port", 0)   // Notice: 0 means TCP port closed
+	config.BindEnvAndSetDefault("dogstatsd_tcp_max_connections", 1024)
+	config.BindEnvAndSetDefault("dogstatsd_tcp_tls_cert_file", "")
+	config.BindEnvAndSetDefault("dogstatsd_tcp_tls_key_file", "")
 	// Experimental and not officially supported for now.
 	// Options are: udp, uds, named_pipe
 	config.BindEnvAndSetDefault("dogstatsd_eol_required", []string{})
@@ -547,6 +574,11 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("dogstatsd_mem_based_rate_limiter.soft_limit_freeos_check.max", 0.1)
 	config.BindEnvAndSetDefault("dogstatsd_mem_based_rate_limiter.soft_limit_freeos_check.factor", 1.5)
 
+	// Cap the number of contexts per metric name, contexts beyond the cap are folded into an `overflow:true` context.
+	config.BindEnvAndSetDefault("dogstatsd_cardinality_limiter.enabled", false)
+	config.BindEnvAndSetDefault("dogstatsd_cardinality_limiter.max_contexts_per_metric", 1000)
+	config.BindEnvAndSetDefault("dogstatsd_cardinality_limiter.window", 5*time.Minute)
+
 	config.BindEnv("dogstatsd_mapper_profiles")
 	config.SetEnvKeyTransformer("dogstatsd_mapper_profiles", func(in string) interface{} {
 		var mappings []MappingProfile
@@ -556,6 +588,15 @@ func InitConfig(config Config) {
 		return mappings
 	})
 
+	config.BindEnv("dogstatsd_tag_rules")
+	config.SetEnvKeyTransformer("dogstatsd_tag_rules", func(in string) interface{} {
+		var rules []TagRule
+		if err := json.Unmarshal([]byte(in), &rules); err != nil {
+			log.Errorf(`"dogstatsd_tag_rules" can not be parsed: %v`, err)
+		}
+		return rules
+	})
+
 	config.BindEnvAndSetDefault("statsd_forward_host", "")
 	config.BindEnvAndSetDefault("statsd_forward_port", 0)
 	config.BindEnvAndSetDefault("statsd_metric_namespace", "")
@@ -648,6 +689,13 @@ func InitConfig(config Config) {
 	bindEnvAndSetLogsConfigKeys(config, "network_devices.metadata.")
 	config.BindEnvAndSetDefault("network_devices.namespace", "default")
 
+	// process listener
+	config.BindEnvAndSetDefault("process_listener.discovery_interval", 60)
+
+	// workloadmeta host collectors
+	config.BindEnvAndSetDefault("workloadmeta.host_processes.enabled", false)
+	config.BindEnvAndSetDefault("workloadmeta.systemd_units.enabled", false)
+
 	config.SetKnown("snmp_listener.discovery_interval")
 	config.SetKnown("snmp_listener.allowed_failures")
 	config.SetKnown("snmp_listener.discovery_allowed_failures")
@@ -675,6 +723,11 @@ func InitConfig(config Config) {
 	config.SetKnown("network_devices.netflow.aggregator_flow_context_ttl")
 	config.SetKnown("network_devices.netflow.aggregator_port_rollup_threshold")
 	config.SetKnown("network_devices.netflow.aggregator_rollup_tracker_refresh_interval")
+	config.SetKnown("network_devices.netflow.aggregator_deduplication_mode")
+	config.SetKnown("network_devices.netflow.top_talkers_count")
+	config.SetKnown("network_devices.netflow.top_talkers_dimensions")
+	config.SetKnown("network_devices.netflow.enrichment_geoip_database_path")
+	config.SetKnown("network_devices.netflow.enrichment_asn_database_path")
 	config.BindEnvAndSetDefault("network_devices.netflow.enabled", "false")
 	bindEnvAndSetLogsConfigKeys(config, "network_devices.netflow.forwarder.")
 
@@ -838,6 +891,8 @@ func InitConfig(config Config) {
 	}
 	// add global processing rules that are applied on all logs
 	config.BindEnv("logs_config.processing_rules")
+	// add metrics generated from the logs
+	config.BindEnv("logs_config.generate_metrics")
 	// enforce the agent to use files to collect container logs on kubernetes environment
 	config.BindEnvAndSetDefault("logs_config.k8s_container_use_file", false)
 	// Enable the agent to use files to collect container logs on standalone docker environment, containers
@@ -860,6 +915,11 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("logs_config.docker_client_read_timeout", 30)
 	// Internal Use Only: avoid modifying those configuration parameters, this could lead to unexpected results.
 	config.BindEnvAndSetDefault("logs_config.run_path", defaultRunPath)
+	// Store the logs payloads on disk while the intake is unreachable, 0 means disabled.
+	config.BindEnvAndSetDefault("logs_config.storage_max_size_in_bytes", 0)
+	// Do not store payloads on disk when the disk usage exceeds 80% of the disk capacity.
+	config.BindEnvAndSetDefault("logs_config.storage_max_disk_ratio", 0.80)
+	config.BindEnvAndSetDefault("logs_config.storage_path", "")
 	// DEPRECATED in favor of `logs_config.force_use_http`.
 	config.BindEnvAndSetDefault("logs_config.use_http", false)
 	config.BindEnvAndSetDefault("logs_config.force_use_http", false)
@@ -918,6 +978,11 @@ func InitConfig(config Config) {
 	// more disk I/O at the wildcard log paths
 	config.BindEnvAndSetDefault("logs_config.file_wildcard_selection_mode", "by_name")
 
+	// If true, the file tailers identify files by a fingerprint of their first
+	// `logs_config.fingerprint_byte_count` bytes rather than only by their path and inode.
+	config.BindEnvAndSetDefault("logs_config.fingerprint_enabled", false)
+	config.BindEnvAndSetDefault("logs_config.fingerprint_byte_count", 1024)
+
 	// temporary feature flag until this becomes the only option
 	config.BindEnvAndSetDefault("logs_config.cca_in_ad", true)
 
@@ -1590,8 +1655,12 @@ func ResolveSecrets(config Config, origin string) error {
 		config.GetInt("secret_backend_output_max_size"),
 		config.GetBool("secret_backend_command_allow_group_exec_perm"),
 	)
+	secrets.InitProviders(
+		config.GetString("secret_backend_type"),
+		config.GetString("secret_backend_http_token_file"),
+	)
 
-	if config.GetString("secret_backend_command") != "" {
+	if config.GetString("secret_backend_command") != "" || config.GetString("secret_backend_type") == "builtin" {
 		// Viper doesn't expose the final location of the file it
 		// loads. Since we are searching for 'datadog.yaml' in multiple
 		// locations we let viper determine the one to use before
@@ -1665,6 +1734,8 @@ func bindEnvAndSetLogsConfigKeys(config Config, prefix string) {
 	config.BindEnv(prefix + "additional_endpoints")
 	config.BindEnvAndSetDefault(prefix+"use_compression", true)
 	config.BindEnvAndSetDefault(prefix+"compression_level", 6) // Default level for the gzip/deflate algorithm
+	config.BindEnvAndSetDefault(prefix+"compression_kind", "gzip")
+	config.BindEnvAndSetDefault(prefix+"zstd_compression_level", 1)
 	config.BindEnvAndSetDefault(prefix+"batch_wait", DefaultBatchWait)
 	config.BindEnvAndSetDefault(prefix+"connection_reset_interval", 0) // in seconds, 0 means disabled
 	config.BindEnvAndSetDefault(prefix+"logs_no_ssl", false)
@@ -1920,6 +1991,22 @@ func getDogstatsdMappingProfilesConfig(config Config) ([]MappingProfile, error)
 	return mappings, nil
 }
 
+// GetDogstatsdTagRules returns tag rules used in DogStatsD mapper
+func GetDogstatsdTagRules() ([]TagRule, error) {
+	return getDogstatsdTagRulesConfig(Datadog)
+}
+
+func getDogstatsdTagRulesConfig(config Config) ([]TagRule, error) {
+	var rules []TagRule
+	if config.IsSet("dogstatsd_tag_rules") {
+		err := config.UnmarshalKey("dogstatsd_tag_rules", &rules)
+		if err != nil {
+			return []TagRule{}, log.Errorf("Could not parse dogstatsd_tag_rules: %v", err)
+		}
+	}
+	return rules, nil
+}
+
 // IsCLCRunner returns whether the Agent is in cluster check runner mode
 func IsCLCRunner() bool {
 	if !Datadog.GetBool("clc_runner_enabled") {
@@ -1040,6 +1040,44 @@ dogstatsd_mapper_profiles:
 	assert.EqualValues(t, expectedProfiles, profiles)
 }
 
+func TestDogstatsdTagRulesOk(t *testing.T) {
+	datadogYaml := `
+dogstatsd_tag_rules:
+  - match: "http.*"
+    drop_tags:
+      - request_id
+    rename_tags:
+      svc: service
+    replace_tag_values:
+      - tag: path
+        regex: '/users/\d+'
+        replacement: '/users/?'
+  - match: "noisy.*"
+    drop_metric: true
+`
+	testConfig := setupConfFromYAML(datadogYaml)
+
+	rules, err := getDogstatsdTagRulesConfig(testConfig)
+
+	expectedRules := []TagRule{
+		{
+			Match:      "http.*",
+			DropTags:   []string{"request_id"},
+			RenameTags: map[string]string{"svc": "service"},
+			ReplaceTagValues: []TagValueReplacement{
+				{Tag: "path", Regex: "/users/\\d+", Replacement: "/users/?"},
+			},
+		},
+		{
+			Match:      "noisy.*",
+			DropMetric: true,
+		},
+	}
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedRules, rules)
+}
+
 func TestDogstatsdMappingProfilesEmpty(t *testing.T) {
 	datadogYaml := `
 dogstatsd_mapper_profiles:
@@ -0,0 +1,208 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// overflowTag replaces the tags of the samples exceeding the cardinality limit of their metric.
+const overflowTag = "overflow:true"
+
+// cardinalityLimiterShards is the number of shards of the cardinality limiter, the shard of
+// a metric is picked from the hash of its name so that the workers rarely wait for each other.
+const cardinalityLimiterShards = 64
+
+// cardinalityLimitedMetricsTopN is the maximum number of limited metric names published in the
+// `CardinalityLimitedMetrics` expvar, so that the published set stays bounded.
+const cardinalityLimitedMetricsTopN = 10
+
+var tlmCardinalityLimitedSamples = telemetry.NewCounter("dogstatsd", "cardinality_limited_samples",
+	nil, "Count of metric samples folded into the overflow context because their metric exceeded its cardinality limit")
+
+// cardinalityLimiter caps the number of distinct contexts (tags and host) of each metric
+// name over a time window. Samples of a new context beyond the cap are folded into
+// a single `overflow:true` context.
+//
+// The limiter is shared by all the workers, it is safe for concurrent use.
+type cardinalityLimiter struct {
+	maxContextsPerMetric int
+	window               time.Duration
+	shards               [cardinalityLimiterShards]*cardinalityLimiterShard
+	clock                clock.Clock
+}
+
+// cardinalityLimiterShard holds the contexts of the metrics whose name hashes to the shard.
+type cardinalityLimiterShard struct {
+	sync.Mutex
+	windowStart time.Time
+	// contexts holds the contexts seen during the current window, by metric name.
+	contexts map[string]map[ckey.ContextKey]struct{}
+	// limited holds the count of samples folded during the current window, by metric name.
+	limited map[string]uint64
+
+	keyGen          *ckey.KeyGenerator
+	tagsAccumulator *tagset.HashingTagsAccumulator
+}
+
+func newCardinalityLimiter(maxContextsPerMetric int, window time.Duration, clock clock.Clock) *cardinalityLimiter {
+	l := &cardinalityLimiter{
+		maxContextsPerMetric: maxContextsPerMetric,
+		window:               window,
+		clock:                clock,
+	}
+	for i := range l.shards {
+		l.shards[i] = &cardinalityLimiterShard{
+			windowStart:     clock.Now(),
+			contexts:        make(map[string]map[ckey.ContextKey]struct{}),
+			limited:         make(map[string]uint64),
+			keyGen:          ckey.NewKeyGenerator(),
+			tagsAccumulator: tagset.NewHashingTagsAccumulator(),
+		}
+	}
+	return l
+}
+
+// shard returns the shard of the metric with the given name, using the FNV-1a hash of the name.
+func (l *cardinalityLimiter) shard(name string) *cardinalityLimiterShard {
+	hash := uint32(2166136261)
+	for i := 0; i < len(name); i++ {
+		hash ^= uint32(name[i])
+		hash *= 16777619
+	}
+	return l.shards[hash%cardinalityLimiterShards]
+}
+
+// limit folds the sample into the overflow context of its metric if the metric
+// already reached its maximum number of contexts. extraTags are kept on folded samples.
+// It returns true if the sample has been folded.
+func (l *cardinalityLimiter) limit(sample *metrics.MetricSample, extraTags []string) bool {
+	s := l.shard(sample.Name)
+	s.Lock()
+	defer s.Unlock()
+
+	s.maybeResetWindow(l.clock.Now(), l.window)
+
+	s.tagsAccumulator.Append(sample.Tags...)
+	key := s.keyGen.Generate(sample.Name, sample.Host, s.tagsAccumulator)
+	s.tagsAccumulator.Reset()
+
+	contexts, found := s.contexts[sample.Name]
+	if !found {
+		contexts = make(map[ckey.ContextKey]struct{})
+		s.contexts[sample.Name] = contexts
+	}
+	if _, found := contexts[key]; found {
+		return false
+	}
+	if len(contexts) < l.maxContextsPerMetric {
+		contexts[key] = struct{}{}
+		return false
+	}
+
+	// logged once per metric and window, the metric names are not used as telemetry tags as they are unbounded,
+	// the most limited ones are published by the `CardinalityLimitedMetrics` expvar instead
+	if s.limited[sample.Name] == 0 {
+		log.Warnf("Dogstatsd: metric %q reached the limit of %d contexts, samples of new contexts are folded into the %q context", sample.Name, l.maxContextsPerMetric, overflowTag)
+	}
+	s.limited[sample.Name]++
+	dogstatsdCardinalityLimitedSamples.Add(1)
+	tlmCardinalityLimitedSamples.Inc()
+
+	tags := make([]string, 0, len(extraTags)+1)
+	tags = append(tags, overflowTag)
+	sample.Tags = append(tags, extraTags...)
+	return true
+}
+
+// isLimited returns whether samples of the given metric have been folded during the current window.
+func (l *cardinalityLimiter) isLimited(name string) bool {
+	s := l.shard(name)
+	s.Lock()
+	defer s.Unlock()
+	return s.limited[name] > 0
+}
+
+// topLimitedMetrics returns the n metric names with the most samples folded during the current window,
+// with their count of folded samples.
+func (l *cardinalityLimiter) topLimitedMetrics(n int) map[string]uint64 {
+	type limitedMetric struct {
+		name  string
+		count uint64
+	}
+	var limited []limitedMetric
+	now := l.clock.Now()
+	for _, s := range l.shards {
+		s.Lock()
+		s.maybeResetWindow(now, l.window)
+		for name, count := range s.limited {
+			limited = append(limited, limitedMetric{name: name, count: count})
+		}
+		s.Unlock()
+	}
+
+	sort.Slice(limited, func(i, j int) bool {
+		if limited[i].count != limited[j].count {
+			return limited[i].count > limited[j].count
+		}
+		return limited[i].name < limited[j].name
+	})
+	if len(limited) > n {
+		limited = limited[:n]
+	}
+
+	top := make(map[string]uint64, len(limited))
+	for _, m := range limited {
+		top[m.name] = m.count
+	}
+	return top
+}
+
+// expire forgets the contexts of the shards whose window is over, including the shards
+// which did not receive any sample since then.
+func (l *cardinalityLimiter) expire() {
+	now := l.clock.Now()
+	for _, s := range l.shards {
+		s.Lock()
+		s.maybeResetWindow(now, l.window)
+		s.Unlock()
+	}
+}
+
+// expireLoop expires the contexts every window until stop is closed.
+func (l *cardinalityLimiter) expireLoop(stop <-chan bool) {
+	ticker := l.clock.Ticker(l.window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			l.expire()
+		}
+	}
+}
+
+// maybeResetWindow forgets the contexts seen once the window is over.
+// s must be locked.
+func (s *cardinalityLimiterShard) maybeResetWindow(now time.Time, window time.Duration) {
+	if now.Sub(s.windowStart) < window {
+		return
+	}
+	s.windowStart = now
+	s.contexts = make(map[string]map[ckey.ContextKey]struct{})
+	s.limited = make(map[string]uint64)
+}
@@ -0,0 +1,130 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestCardinalityLimiter(t *testing.T) {
+	clk := clock.NewMock()
+	limiter := newCardinalityLimiter(2, time.Minute, clk)
+
+	sample := func(name string, tags ...string) *metrics.MetricSample {
+		return &metrics.MetricSample{Name: name, Tags: tags}
+	}
+
+	assert.False(t, limiter.limit(sample("metric.a", "request:1", "env:prod"), nil))
+	assert.False(t, limiter.limit(sample("metric.a", "request:2", "env:prod"), nil))
+	// known contexts are not limited, whatever the order of their tags
+	assert.False(t, limiter.limit(sample("metric.a", "env:prod", "request:1"), nil))
+	// other metrics have their own limit
+	assert.False(t, limiter.limit(sample("metric.b", "request:3"), nil))
+	assert.False(t, limiter.isLimited("metric.a"))
+
+	overflow := sample("metric.a", "request:3", "env:prod")
+	assert.True(t, limiter.limit(overflow, []string{"extra:tag"}))
+	assert.Equal(t, []string{"overflow:true", "extra:tag"}, overflow.Tags)
+	assert.True(t, limiter.isLimited("metric.a"))
+	assert.False(t, limiter.isLimited("metric.b"))
+
+	// contexts are forgotten once the window is over
+	clk.Add(time.Minute)
+	assert.False(t, limiter.limit(sample("metric.a", "request:3", "env:prod"), nil))
+	assert.False(t, limiter.isLimited("metric.a"))
+}
+
+func TestCardinalityLimiterExpire(t *testing.T) {
+	clk := clock.NewMock()
+	limiter := newCardinalityLimiter(1, time.Minute, clk)
+
+	limiter.limit(&metrics.MetricSample{Name: "metric.a", Tags: []string{"request:1"}}, nil)
+	assert.True(t, limiter.limit(&metrics.MetricSample{Name: "metric.a", Tags: []string{"request:2"}}, nil))
+	assert.Len(t, limiter.shard("metric.a").contexts, 1)
+
+	// the contexts of metrics which do not receive samples anymore are forgotten too
+	clk.Add(time.Minute)
+	limiter.expire()
+	assert.Empty(t, limiter.shard("metric.a").contexts)
+	assert.False(t, limiter.isLimited("metric.a"))
+}
+
+func TestCardinalityLimiterExpireLoop(t *testing.T) {
+	clk := clock.NewMock()
+	limiter := newCardinalityLimiter(1, time.Minute, clk)
+	stop := make(chan bool)
+	defer close(stop)
+
+	limiter.limit(&metrics.MetricSample{Name: "metric.a", Tags: []string{"request:1"}}, nil)
+	go limiter.expireLoop(stop)
+	require.Eventually(t, func() bool {
+		clk.Add(time.Minute)
+		shard := limiter.shard("metric.a")
+		shard.Lock()
+		defer shard.Unlock()
+		return len(shard.contexts) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCardinalityLimiterSharedTags(t *testing.T) {
+	limiter := newCardinalityLimiter(1, time.Minute, clock.NewMock())
+
+	tags := []string{"request:1"}
+	limiter.limit(&metrics.MetricSample{Name: "metric.a", Tags: []string{"request:0"}}, nil)
+
+	// samples of a same message share their tags, folding one of them must not alter the others
+	samples := []metrics.MetricSample{{Name: "metric.a", Tags: tags}, {Name: "metric.a", Tags: tags}}
+	for idx := range samples {
+		assert.True(t, limiter.limit(&samples[idx], nil))
+	}
+	assert.Equal(t, []string{"request:1"}, tags)
+}
+
+func TestCardinalityLimiterTopLimitedMetrics(t *testing.T) {
+	clk := clock.NewMock()
+	limiter := newCardinalityLimiter(1, time.Minute, clk)
+
+	fold := func(name string, count int) {
+		limiter.limit(&metrics.MetricSample{Name: name, Tags: []string{"request:0"}}, nil)
+		for i := 0; i < count; i++ {
+			limiter.limit(&metrics.MetricSample{Name: name, Tags: []string{"request:1"}}, nil)
+		}
+	}
+	fold("metric.a", 3)
+	fold("metric.b", 1)
+	fold("metric.c", 2)
+	fold("metric.d", 0)
+
+	assert.Equal(t, map[string]uint64{"metric.a": 3, "metric.c": 2}, limiter.topLimitedMetrics(2))
+	assert.Equal(t, map[string]uint64{"metric.a": 3, "metric.b": 1, "metric.c": 2}, limiter.topLimitedMetrics(10))
+
+	// the limited metrics are forgotten once the window is over
+	clk.Add(time.Minute)
+	assert.Empty(t, limiter.topLimitedMetrics(10))
+}
+
+func TestFormatDebugStatsCardinalityLimited(t *testing.T) {
+	stats := map[uint64]metricStat{
+		1: {Name: "metric.a", Count: 3, Tags: "request:1"},
+		2: {Name: "metric.a", Count: 2, Tags: "overflow:true", CardinalityLimited: true},
+		3: {Name: "metric.b", Count: 1},
+	}
+	data, err := json.Marshal(stats)
+	require.NoError(t, err)
+
+	formatted, err := FormatDebugStats(data)
+	require.NoError(t, err)
+	assert.Contains(t, formatted, "Metrics limited by the cardinality limiter, their new contexts are folded into the \"overflow:true\" context:\n  metric.a\n")
+	assert.NotContains(t, formatted, "  metric.b\n")
+}
@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mapper
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// TagRuleSet contains tag rules and cache instance
+type TagRuleSet struct {
+	Rules []*TagRule
+	cache *tagRulesCache
+}
+
+// TagRule represent one rule rewriting the tags of the metrics matching a name glob
+type TagRule struct {
+	match            string
+	dropMetric       bool
+	dropTags         map[string]struct{}
+	renameTags       map[string]string
+	replaceTagValues []*tagValueReplacement
+}
+
+type tagValueReplacement struct {
+	tag         string
+	regex       *regexp.Regexp
+	replacement string
+}
+
+// NewTagRuleSet creates, validates, prepares a new TagRuleSet
+func NewTagRuleSet(configRules []config.TagRule, cacheSize int) (*TagRuleSet, error) {
+	rules := make([]*TagRule, 0, len(configRules))
+	for i, configRule := range configRules {
+		if configRule.Match == "" {
+			return nil, fmt.Errorf("tag rule num %d: match is required", i)
+		}
+		if _, err := path.Match(configRule.Match, ""); err != nil {
+			return nil, fmt.Errorf("tag rule num %d: invalid match glob `%s`: %v", i, configRule.Match, err)
+		}
+		rule := &TagRule{
+			match:      configRule.Match,
+			dropMetric: configRule.DropMetric,
+			dropTags:   make(map[string]struct{}, len(configRule.DropTags)),
+			renameTags: configRule.RenameTags,
+		}
+		for _, tagKey := range configRule.DropTags {
+			rule.dropTags[tagKey] = struct{}{}
+		}
+		for j, configReplacement := range configRule.ReplaceTagValues {
+			if configReplacement.Tag == "" {
+				return nil, fmt.Errorf("tag rule num %d, replacement num %d: tag is required", i, j)
+			}
+			regex, err := regexp.Compile(configReplacement.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("tag rule num %d, replacement num %d: cannot compile regex `%s`: %v", i, j, configReplacement.Regex, err)
+			}
+			rule.replaceTagValues = append(rule.replaceTagValues, &tagValueReplacement{
+				tag:         configReplacement.Tag,
+				regex:       regex,
+				replacement: configReplacement.Replacement,
+			})
+		}
+		rules = append(rules, rule)
+	}
+	cache, err := newTagRulesCache(cacheSize)
+	if err != nil {
+		return nil, err
+	}
+	return &TagRuleSet{Rules: rules, cache: cache}, nil
+}
+
+// Apply applies the rules matching the metric name to its tags, in the order they are defined.
+// It returns the rewritten tags and whether the metric must be dropped. The given tags slice
+// is not modified.
+func (r *TagRuleSet) Apply(metricName string, tags []string) ([]string, bool) {
+	rules := r.matchingRules(metricName)
+	if len(rules) == 0 {
+		return tags, false
+	}
+
+	for _, rule := range rules {
+		if rule.dropMetric {
+			return nil, true
+		}
+		rewritten := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			if tag, keep := rule.applyToTag(tag); keep {
+				rewritten = append(rewritten, tag)
+			}
+		}
+		tags = rewritten
+	}
+	return tags, false
+}
+
+// matchingRules returns the rules matching the metric name, using the cache if possible
+func (r *TagRuleSet) matchingRules(metricName string) []*TagRule {
+	if rules, cached := r.cache.get(metricName); cached {
+		return rules
+	}
+	var rules []*TagRule
+	for _, rule := range r.Rules {
+		// the pattern is validated when the rule is created
+		if matched, _ := path.Match(rule.match, metricName); matched {
+			rules = append(rules, rule)
+		}
+	}
+	r.cache.add(metricName, rules)
+	return rules
+}
+
+// applyToTag returns the rewritten tag and whether it must be kept.
+// Tags are dropped and renamed by key, values are replaced after the key is renamed.
+func (rule *TagRule) applyToTag(tag string) (string, bool) {
+	key, value, hasValue := strings.Cut(tag, ":")
+	if _, drop := rule.dropTags[key]; drop {
+		return "", false
+	}
+	if newKey, found := rule.renameTags[key]; found {
+		key = newKey
+	}
+	if hasValue {
+		for _, replacement := range rule.replaceTagValues {
+			if replacement.tag == key {
+				value = replacement.regex.ReplaceAllString(value, replacement.replacement)
+			}
+		}
+		return key + ":" + value, true
+	}
+	return key, true
+}
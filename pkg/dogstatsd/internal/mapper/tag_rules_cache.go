@@ -0,0 +1,38 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mapper
+
+import (
+	lru "github.com/hashicorp/golang-lru"
+)
+
+type tagRulesCache struct {
+	cache *lru.Cache
+}
+
+// newTagRulesCache creates a new tagRulesCache
+func newTagRulesCache(size int) (*tagRulesCache, error) {
+	cache, err := lru.New(size)
+	if err != nil {
+		return &tagRulesCache{}, err
+	}
+	return &tagRulesCache{cache: cache}, nil
+}
+
+// get returns:
+// - the rules matching the metric name, possibly none
+// - a boolean indicating if the metric name has been found
+func (m *tagRulesCache) get(metricName string) ([]*TagRule, bool) {
+	if rules, ok := m.cache.Get(metricName); ok {
+		return rules.([]*TagRule), true
+	}
+	return nil, false
+}
+
+// add adds the rules matching a metric name to cache with metric name as key
+func (m *tagRulesCache) add(metricName string, rules []*TagRule) {
+	m.cache.Add(metricName, rules)
+}
@@ -0,0 +1,36 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagRulesCache(t *testing.T) {
+	c, err := newTagRulesCache(10)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 0, c.cache.Len())
+
+	rule := &TagRule{match: "metric_*", dropMetric: true}
+	c.add("metric_name", []*TagRule{rule})
+	c.add("metric_miss", nil)
+	assert.Equal(t, 2, c.cache.Len())
+
+	rules, found := c.get("metric_name")
+	assert.Equal(t, true, found)
+	assert.Equal(t, []*TagRule{rule}, rules)
+
+	rules, found = c.get("metric_name_not_exist")
+	assert.Equal(t, false, found)
+	assert.Nil(t, rules)
+
+	rules, found = c.get("metric_miss")
+	assert.Equal(t, true, found)
+	assert.Nil(t, rules)
+}
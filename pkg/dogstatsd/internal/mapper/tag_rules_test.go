@@ -0,0 +1,200 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mapper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestTagRules(t *testing.T) {
+	scenarios := []struct {
+		name         string
+		config       string
+		metricName   string
+		tags         []string
+		expectedTags []string
+		expectedDrop bool
+	}{
+		{
+			name: "Drop tags",
+			config: `
+dogstatsd_tag_rules:
+  - match: "http.*"
+    drop_tags:
+      - request_id
+      - user
+`,
+			metricName:   "http.requests",
+			tags:         []string{"request_id:1234", "env:prod", "user:bob", "user"},
+			expectedTags: []string{"env:prod"},
+		},
+		{
+			name: "Rename tag keys",
+			config: `
+dogstatsd_tag_rules:
+  - match: "http.*"
+    rename_tags:
+      svc: service
+      legacy: modern
+`,
+			metricName:   "http.requests",
+			tags:         []string{"svc:web", "env:prod", "legacy"},
+			expectedTags: []string{"service:web", "env:prod", "modern"},
+		},
+		{
+			name: "Replace tag values",
+			config: `
+dogstatsd_tag_rules:
+  - match: "http.*"
+    rename_tags:
+      path: url_path
+    replace_tag_values:
+      - tag: url_path
+        regex: '/users/\d+'
+        replacement: '/users/?'
+      - tag: email
+        regex: '^[^@]+@(.*)$'
+        replacement: '$1'
+`,
+			metricName:   "http.requests",
+			tags:         []string{"path:/users/42/orders", "email:bob@example.com", "env:prod"},
+			expectedTags: []string{"url_path:/users/?/orders", "email:example.com", "env:prod"},
+		},
+		{
+			name: "Drop metric",
+			config: `
+dogstatsd_tag_rules:
+  - match: "noisy.lib.*"
+    drop_metric: true
+`,
+			metricName:   "noisy.lib.calls",
+			tags:         []string{"env:prod"},
+			expectedDrop: true,
+		},
+		{
+			name: "Not matching metric is untouched",
+			config: `
+dogstatsd_tag_rules:
+  - match: "http.*"
+    drop_tags:
+      - request_id
+`,
+			metricName:   "grpc.requests",
+			tags:         []string{"request_id:1234"},
+			expectedTags: []string{"request_id:1234"},
+		},
+		{
+			name: "Rules are applied in order",
+			config: `
+dogstatsd_tag_rules:
+  - match: "*"
+    rename_tags:
+      id: request_id
+  - match: "http.request?"
+    drop_tags:
+      - request_id
+`,
+			metricName:   "http.requests",
+			tags:         []string{"id:1234", "env:prod"},
+			expectedTags: []string{"env:prod"},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			ruleSet, err := getTagRuleSet(scenario.config)
+			require.NoError(t, err)
+
+			originalTags := append([]string{}, scenario.tags...)
+			// the second call is served by the cache
+			for i := 0; i < 2; i++ {
+				tags, drop := ruleSet.Apply(scenario.metricName, scenario.tags)
+				assert.Equal(t, scenario.expectedDrop, drop)
+				if !scenario.expectedDrop {
+					assert.Equal(t, scenario.expectedTags, tags)
+				}
+				assert.Equal(t, originalTags, scenario.tags, "the given tags should not be modified")
+			}
+		})
+	}
+}
+
+func TestTagRulesErrors(t *testing.T) {
+	scenarios := []struct {
+		name          string
+		config        string
+		expectedError string
+	}{
+		{
+			name: "Missing match",
+			config: `
+dogstatsd_tag_rules:
+  - drop_metric: true
+`,
+			expectedError: "match is required",
+		},
+		{
+			name: "Invalid glob",
+			config: `
+dogstatsd_tag_rules:
+  - match: "http.[a"
+    drop_metric: true
+`,
+			expectedError: "invalid match glob",
+		},
+		{
+			name: "Missing replacement tag",
+			config: `
+dogstatsd_tag_rules:
+  - match: "http.*"
+    replace_tag_values:
+      - regex: 'a'
+        replacement: 'b'
+`,
+			expectedError: "tag is required",
+		},
+		{
+			name: "Invalid replacement regex",
+			config: `
+dogstatsd_tag_rules:
+  - match: "http.*"
+    replace_tag_values:
+      - tag: path
+        regex: '(a'
+        replacement: 'b'
+`,
+			expectedError: "cannot compile regex",
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			_, err := getTagRuleSet(scenario.config)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), scenario.expectedError)
+		})
+	}
+}
+
+func getTagRuleSet(configString string) (*TagRuleSet, error) {
+	var rules []config.TagRule
+	config.Datadog.SetConfigType("yaml")
+	err := config.Datadog.ReadConfig(strings.NewReader(configString))
+	if err != nil {
+		return nil, err
+	}
+	err = config.Datadog.UnmarshalKey("dogstatsd_tag_rules", &rules)
+	if err != nil {
+		return nil, err
+	}
+	return NewTagRuleSet(rules, 1000)
+}
@@ -0,0 +1,315 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/packets"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var (
+	tcpExpvars             = expvar.NewMap("dogstatsd-tcp")
+	tcpConnectionsExpvars  = expvar.NewMap("dogstatsd-tcp-connections")
+	tcpConnections         = expvar.Int{}
+	tcpRejectedConnections = expvar.Int{}
+	tcpPacketReadingErrors = expvar.Int{}
+	tcpPackets             = expvar.Int{}
+	tcpBytes               = expvar.Int{}
+)
+
+func init() {
+	tcpExpvars.Set("Connections", &tcpConnections)
+	tcpExpvars.Set("RejectedConnections", &tcpRejectedConnections)
+	tcpExpvars.Set("PacketReadingErrors", &tcpPacketReadingErrors)
+	tcpExpvars.Set("Packets", &tcpPackets)
+	tcpExpvars.Set("Bytes", &tcpBytes)
+}
+
+// tcpConnectionTelemetry holds the stats of a single TCP connection,
+// published under the `dogstatsd-tcp-connections` expvar while the connection is open.
+type tcpConnectionTelemetry struct {
+	key     string
+	expvars *expvar.Map
+	packets expvar.Int
+	bytes   expvar.Int
+}
+
+func newTCPConnectionTelemetry(conn net.Conn) *tcpConnectionTelemetry {
+	t := &tcpConnectionTelemetry{
+		key:     conn.RemoteAddr().String(),
+		expvars: &expvar.Map{},
+	}
+	t.expvars.Set("Packets", &t.packets)
+	t.expvars.Set("Bytes", &t.bytes)
+	tcpConnectionsExpvars.Set(t.key, t.expvars)
+	return t
+}
+
+func (t *tcpConnectionTelemetry) onReadSuccess(n int) {
+	t.packets.Add(1)
+	t.bytes.Add(int64(n))
+	tcpPackets.Add(1)
+	tcpBytes.Add(int64(n))
+	tlmTCPPackets.Inc("ok")
+	tlmTCPPacketsBytes.Add(float64(n))
+}
+
+func (t *tcpConnectionTelemetry) close() {
+	tcpConnectionsExpvars.Delete(t.key)
+}
+
+// TCPListener implements the StatsdListener interface for TCP protocol.
+// It listens to a given TCP address, optionally with TLS, and sends back
+// newline-delimited messages ready to be processed.
+// Origin detection is not implemented for TCP.
+type TCPListener struct {
+	listener                net.Listener
+	packetsBuffer           *packets.Buffer
+	packetAssembler         *packets.Assembler
+	sharedPacketPoolManager *packets.PoolManager
+	bufferSize              int
+	maxConnections          int
+	trafficCapture          *replay.TrafficCapture
+
+	connsMutex sync.Mutex
+	conns      map[net.Conn]struct{}
+	stopped    bool
+	connsWg    sync.WaitGroup
+}
+
+// NewTCPListener returns an idle TCP Statsd listener
+func NewTCPListener(packetOut chan packets.Packets, sharedPacketPoolManager *packets.PoolManager, capture *replay.TrafficCapture) (*TCPListener, error) {
+	var url string
+
+	if config.Datadog.GetBool("dogstatsd_non_local_traffic") == true {
+		// Listen to all network interfaces
+		url = fmt.Sprintf(":%d", config.Datadog.GetInt("dogstatsd_tcp_port"))
+	} else {
+		url = net.JoinHostPort(config.GetBindHost(), config.Datadog.GetString("dogstatsd_tcp_port"))
+	}
+
+	var tlsConfig *tls.Config
+	certFile := config.Datadog.GetString("dogstatsd_tcp_tls_cert_file")
+	keyFile := config.Datadog.GetString("dogstatsd_tcp_tls_key_file")
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("dogstatsd-tcp: can't load TLS certificate: %s", err)
+		}
+		tlsConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+	}
+
+	listener, err := net.Listen("tcp", url)
+	if err != nil {
+		return nil, fmt.Errorf("can't listen: %s", err)
+	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	packetsBufferSize := config.Datadog.GetInt("dogstatsd_packet_buffer_size")
+	flushTimeout := config.Datadog.GetDuration("dogstatsd_packet_buffer_flush_timeout")
+
+	packetsBuffer := packets.NewBuffer(uint(packetsBufferSize), flushTimeout, packetOut)
+	packetAssembler := packets.NewAssembler(flushTimeout, packetsBuffer, sharedPacketPoolManager, packets.TCP)
+
+	tcpListener := &TCPListener{
+		listener:                listener,
+		packetsBuffer:           packetsBuffer,
+		packetAssembler:         packetAssembler,
+		sharedPacketPoolManager: sharedPacketPoolManager,
+		bufferSize:              config.Datadog.GetInt("dogstatsd_buffer_size"),
+		maxConnections:          config.Datadog.GetInt("dogstatsd_tcp_max_connections"),
+		trafficCapture:          capture,
+		conns:                   make(map[net.Conn]struct{}),
+	}
+
+	if tcpListener.trafficCapture != nil {
+		err = tcpListener.trafficCapture.RegisterSharedPoolManager(sharedPacketPoolManager)
+		if err != nil {
+			listener.Close()
+			return nil, err
+		}
+	}
+
+	log.Debugf("dogstatsd-tcp: %s successfully initialized (TLS: %t)", listener.Addr(), tlsConfig != nil)
+	return tcpListener, nil
+}
+
+// Listen runs the intake loop. Should be called in its own goroutine
+func (l *TCPListener) Listen() {
+	log.Infof("dogstatsd-tcp: starting to listen on %s", l.listener.Addr())
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			// listener has been closed
+			if errors.Is(err, net.ErrClosed) {
+				log.Debug("dogstatsd-tcp: stop listening")
+				return
+			}
+			log.Errorf("dogstatsd-tcp: error accepting connection: %v", err)
+			continue
+		}
+
+		if !l.addConnection(conn) {
+			conn.Close()
+			continue
+		}
+		go l.listenConnection(conn)
+	}
+}
+
+// addConnection tracks a new connection, it returns false if the
+// connection must be rejected.
+func (l *TCPListener) addConnection(conn net.Conn) bool {
+	l.connsMutex.Lock()
+	defer l.connsMutex.Unlock()
+
+	if l.stopped {
+		return false
+	}
+	if l.maxConnections > 0 && len(l.conns) >= l.maxConnections {
+		log.Debugf("dogstatsd-tcp: rejecting connection from %s, the maximum of %d connections is reached", conn.RemoteAddr(), l.maxConnections)
+		tcpRejectedConnections.Add(1)
+		tlmTCPRejectedConnections.Inc()
+		return false
+	}
+
+	l.conns[conn] = struct{}{}
+	l.connsWg.Add(1)
+	tcpConnections.Add(1)
+	tlmTCPConnections.Inc()
+	return true
+}
+
+func (l *TCPListener) removeConnection(conn net.Conn) {
+	l.connsMutex.Lock()
+	defer l.connsMutex.Unlock()
+
+	conn.Close()
+	delete(l.conns, conn)
+	l.connsWg.Done()
+	tcpConnections.Add(-1)
+	tlmTCPConnections.Dec()
+}
+
+func (l *TCPListener) listenConnection(conn net.Conn) {
+	defer l.removeConnection(conn)
+
+	log.Debugf("dogstatsd-tcp: start listening a new client from %s", conn.RemoteAddr())
+	connTelemetry := newTCPConnectionTelemetry(conn)
+	defer connTelemetry.close()
+
+	buffer := make([]byte, l.bufferSize)
+	startWriteIndex := 0
+	var t1, t2 time.Time
+	for {
+		bytesRead, err := conn.Read(buffer[startWriteIndex:])
+
+		t1 = time.Now()
+
+		if err != nil {
+			if err == io.EOF {
+				log.Debugf("dogstatsd-tcp: client %s disconnected", conn.RemoteAddr())
+				return
+			}
+			// connection has been closed by Stop
+			if errors.Is(err, net.ErrClosed) || strings.HasSuffix(err.Error(), " use of closed network connection") {
+				log.Debugf("dogstatsd-tcp: stop listening client %s", conn.RemoteAddr())
+				return
+			}
+			log.Errorf("dogstatsd-tcp: error reading packet from %s: %v", conn.RemoteAddr(), err)
+			tcpPacketReadingErrors.Add(1)
+			tlmTCPPackets.Inc("error")
+			return
+		}
+
+		endIndex := startWriteIndex + bytesRead
+
+		// When there is no '\n', the message is partial. LastIndexByte returns -1 and messageSize is 0.
+		// If there is a '\n', at least one message is completed and '\n' is part of this message.
+		messageSize := bytes.LastIndexByte(buffer[:endIndex], '\n') + 1
+		if messageSize > 0 {
+			connTelemetry.onReadSuccess(messageSize)
+			l.capture(buffer[:messageSize])
+
+			// packetAssembler merges multiple packets together and sends them when its buffer is full
+			l.packetAssembler.AddMessage(buffer[:messageSize])
+		}
+
+		startWriteIndex = endIndex - messageSize
+
+		// If the message is bigger than the buffer size, reset startWriteIndex to continue reading next messages.
+		if startWriteIndex >= len(buffer) {
+			startWriteIndex = 0
+		} else {
+			copy(buffer, buffer[messageSize:endIndex])
+		}
+
+		t2 = time.Now()
+		tlmListener.Observe(float64(t2.Sub(t1).Nanoseconds()), "tcp")
+	}
+}
+
+// capture enqueues the complete messages read from a connection to the
+// ongoing traffic capture, if any.
+func (l *TCPListener) capture(messages []byte) {
+	if l.trafficCapture == nil || !l.trafficCapture.IsOngoing() {
+		return
+	}
+
+	// The packet is pushed back to the pool by the capture writer once persisted.
+	packet := l.sharedPacketPoolManager.Get().(*packets.Packet)
+	n := copy(packet.Buffer, messages)
+
+	capBuff := replay.CapPool.Get().(*replay.CaptureBuffer)
+	capBuff.Pb.Timestamp = time.Now().UnixNano()
+	capBuff.Pb.Pid = 0
+	capBuff.Pb.AncillarySize = int32(0)
+	capBuff.Pb.Ancillary = nil
+	capBuff.Pb.PayloadSize = int32(n)
+	capBuff.Pb.Payload = packet.Buffer[:n]
+	capBuff.Pid = 0
+	capBuff.ContainerID = ""
+	capBuff.Oob = nil
+	capBuff.Buff = packet
+
+	l.trafficCapture.Enqueue(capBuff)
+}
+
+// Stop closes the TCP listener and all the client connections and stops listening
+func (l *TCPListener) Stop() {
+	l.listener.Close()
+
+	l.connsMutex.Lock()
+	l.stopped = true
+	for conn := range l.conns {
+		conn.Close()
+	}
+	l.connsMutex.Unlock()
+
+	// Wait until all connections are closed
+	l.connsWg.Wait()
+
+	l.packetAssembler.Close()
+	l.packetsBuffer.Close()
+}
@@ -0,0 +1,214 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+//go:build !windows
+// +build !windows
+
+package listeners
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/api/security"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/packets"
+)
+
+var (
+	packetPoolTCP        = packets.NewPool(config.Datadog.GetInt("dogstatsd_buffer_size"))
+	packetPoolManagerTCP = packets.NewPoolManager(packetPoolTCP)
+)
+
+func TestStartStopTCPListener(t *testing.T) {
+	port, err := getAvailableTCPPort()
+	require.Nil(t, err)
+	config.Datadog.SetDefault("dogstatsd_tcp_port", port)
+	config.Datadog.SetDefault("dogstatsd_non_local_traffic", false)
+	s, err := NewTCPListener(nil, packetPoolManagerTCP, nil)
+	require.Nil(t, err)
+	require.NotNil(t, s)
+
+	go s.Listen()
+	// Local port should be unavailable
+	_, err = net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	assert.NotNil(t, err)
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.Nil(t, err)
+	defer conn.Close()
+
+	s.Stop()
+
+	// check that the port can be bound, try for 100 ms
+	for i := 0; i < 10; i++ {
+		var l net.Listener
+		l, err = net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+		if err == nil {
+			l.Close()
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(t, err, "port is not available, it should be")
+}
+
+func TestTCPReceive(t *testing.T) {
+	port, err := getAvailableTCPPort()
+	require.Nil(t, err)
+	config.Datadog.SetDefault("dogstatsd_tcp_port", port)
+
+	packetChannel := make(chan packets.Packets)
+	s, err := NewTCPListener(packetChannel, packetPoolManagerTCP, nil)
+	require.Nil(t, err)
+	require.NotNil(t, s)
+
+	go s.Listen()
+	defer s.Stop()
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.Nil(t, err)
+	defer conn.Close()
+
+	conn.Write([]byte("daemon:666|g|#sometag1:somevalue1\ndaemon:777|g"))
+	assertTCPPacket(t, packetChannel, []byte("daemon:666|g|#sometag1:somevalue1\n"))
+
+	// The partial message is sent once its end of line is received
+	conn.Write([]byte("|#sometag2:somevalue2\n"))
+	assertTCPPacket(t, packetChannel, []byte("daemon:777|g|#sometag2:somevalue2\n"))
+}
+
+func TestTCPMaxConnections(t *testing.T) {
+	port, err := getAvailableTCPPort()
+	require.Nil(t, err)
+	config.Datadog.SetDefault("dogstatsd_tcp_port", port)
+	config.Datadog.SetDefault("dogstatsd_tcp_max_connections", 1)
+	defer config.Datadog.SetDefault("dogstatsd_tcp_max_connections", 1024)
+
+	packetChannel := make(chan packets.Packets)
+	s, err := NewTCPListener(packetChannel, packetPoolManagerTCP, nil)
+	require.Nil(t, err)
+	require.NotNil(t, s)
+
+	go s.Listen()
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.Nil(t, err)
+	defer conn.Close()
+	conn.Write([]byte("daemon:666|g\n"))
+	assertTCPPacket(t, packetChannel, []byte("daemon:666|g\n"))
+
+	// The second connection is closed by the listener
+	rejectedConn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.Nil(t, err)
+	defer rejectedConn.Close()
+	rejectedConn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = rejectedConn.Read(make([]byte, 1))
+	assert.NotNil(t, err)
+	assert.False(t, isTimeout(err), "the connection should have been closed")
+}
+
+func TestTCPReceiveTLS(t *testing.T) {
+	certFile, keyFile, certPool := writeTestCertificate(t)
+
+	port, err := getAvailableTCPPort()
+	require.Nil(t, err)
+	config.Datadog.SetDefault("dogstatsd_tcp_port", port)
+	config.Datadog.SetDefault("dogstatsd_tcp_tls_cert_file", certFile)
+	config.Datadog.SetDefault("dogstatsd_tcp_tls_key_file", keyFile)
+	defer config.Datadog.SetDefault("dogstatsd_tcp_tls_cert_file", "")
+	defer config.Datadog.SetDefault("dogstatsd_tcp_tls_key_file", "")
+
+	packetChannel := make(chan packets.Packets)
+	s, err := NewTCPListener(packetChannel, packetPoolManagerTCP, nil)
+	require.Nil(t, err)
+	require.NotNil(t, s)
+
+	go s.Listen()
+	defer s.Stop()
+
+	conn, err := tls.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port), &tls.Config{RootCAs: certPool})
+	require.Nil(t, err)
+	defer conn.Close()
+	conn.Write([]byte("daemon:666|g\n"))
+	assertTCPPacket(t, packetChannel, []byte("daemon:666|g\n"))
+}
+
+func TestNewTCPListenerInvalidTLSConfig(t *testing.T) {
+	port, err := getAvailableTCPPort()
+	require.Nil(t, err)
+	config.Datadog.SetDefault("dogstatsd_tcp_port", port)
+	config.Datadog.SetDefault("dogstatsd_tcp_tls_cert_file", "/does/not/exist.pem")
+	defer config.Datadog.SetDefault("dogstatsd_tcp_tls_cert_file", "")
+
+	s, err := NewTCPListener(nil, packetPoolManagerTCP, nil)
+	assert.Nil(t, s)
+	assert.NotNil(t, err)
+}
+
+func assertTCPPacket(t *testing.T, packetChannel chan packets.Packets, expected []byte) {
+	select {
+	case pkts := <-packetChannel:
+		require.Equal(t, 1, len(pkts))
+		packet := pkts[0]
+		assert.Equal(t, expected, packet.Contents)
+		assert.Equal(t, "", packet.Origin)
+		assert.Equal(t, packets.TCP, packet.Source)
+	case <-time.After(2 * time.Second):
+		assert.FailNow(t, "Timeout on receive channel")
+	}
+}
+
+func isTimeout(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
+
+// writeTestCertificate writes a self-signed certificate for 127.0.0.1 and its key in a temporary directory
+func writeTestCertificate(t *testing.T) (string, string, *x509.CertPool) {
+	cert, certPEM, key, err := security.GenerateRootCert([]string{"127.0.0.1"}, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0600))
+
+	certPool := x509.NewCertPool()
+	certPool.AddCert(cert)
+	return certFile, keyFile, certPool
+}
+
+// getAvailableTCPPort requests a random port number and makes sure it is available
+func getAvailableTCPPort() (int, error) {
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return -1, fmt.Errorf("can't find an available tcp port: %s", err)
+	}
+	defer l.Close()
+
+	_, portString, err := net.SplitHostPort(l.Addr().String())
+	if err != nil {
+		return -1, fmt.Errorf("can't find an available tcp port: %s", err)
+	}
+	portInt, err := strconv.Atoi(portString)
+	if err != nil {
+		return -1, fmt.Errorf("can't convert tcp port: %s", err)
+	}
+
+	return portInt, nil
+}
@@ -24,6 +24,16 @@ var (
 	tlmUDSPacketsBytes = telemetry.NewCounter("dogstatsd", "uds_packets_bytes",
 		nil, "Dogstatsd UDS packets bytes")
 
+	// TCP
+	tlmTCPPackets = telemetry.NewCounter("dogstatsd", "tcp_packets",
+		[]string{"state"}, "Dogstatsd TCP packets count")
+	tlmTCPPacketsBytes = telemetry.NewCounter("dogstatsd", "tcp_packets_bytes",
+		nil, "Dogstatsd TCP packets bytes count")
+	tlmTCPConnections = telemetry.NewGauge("dogstatsd", "tcp_connections",
+		nil, "Dogstatsd TCP active connections count")
+	tlmTCPRejectedConnections = telemetry.NewCounter("dogstatsd", "tcp_rejected_connections",
+		nil, "Dogstatsd TCP connections rejected because the maximum number of connections is reached")
+
 	tlmListener            = telemetry.NewHistogramNoOp()
 	defaultListenerBuckets = []float64{300, 500, 1000, 1500, 2000, 2500, 3000, 10000, 20000, 50000}
 )
@@ -125,7 +125,8 @@ func (tc *TrafficCaptureWriter) ProcessMessage(msg *CaptureBuffer) error {
 		tc.sharedPacketPoolManager.Put(msg.Buff)
 	}
 
-	if tc.oobPacketPoolManager != nil {
+	// Messages captured without ancillary data, like TCP ones, have no OOB buffer.
+	if tc.oobPacketPoolManager != nil && msg.Oob != nil {
 		tc.oobPacketPoolManager.Put(msg.Oob)
 	}
 	tc.Unlock()
@@ -350,8 +351,9 @@ func (tc *TrafficCaptureWriter) Enqueue(msg *CaptureBuffer) bool {
 
 // RegisterSharedPoolManager registers the shared pool manager with the TrafficCaptureWriter.
 func (tc *TrafficCaptureWriter) RegisterSharedPoolManager(p *packets.PoolManager) error {
-	if tc.sharedPacketPoolManager != nil {
-		return fmt.Errorf("OOB Pool Manager already registered with the writer")
+	// Several listeners share the same pool manager, registering it again is a no-op.
+	if tc.sharedPacketPoolManager != nil && tc.sharedPacketPoolManager != p {
+		return fmt.Errorf("Shared Pool Manager already registered with the writer")
 	}
 
 	tc.sharedPacketPoolManager = p
@@ -201,3 +201,15 @@ func TestValidateLocation(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, locationGood, l)
 }
+
+func TestRegisterSharedPoolManager(t *testing.T) {
+	writer := NewTrafficCaptureWriter(1)
+
+	manager := packets.NewPoolManager(packets.NewPool(config.Datadog.GetInt("dogstatsd_buffer_size")))
+	otherManager := packets.NewPoolManager(packets.NewPool(config.Datadog.GetInt("dogstatsd_buffer_size")))
+
+	assert.Nil(t, writer.RegisterSharedPoolManager(manager))
+	// listeners sharing the same pool manager can all register it
+	assert.Nil(t, writer.RegisterSharedPoolManager(manager))
+	assert.NotNil(t, writer.RegisterSharedPoolManager(otherManager))
+}
@@ -37,15 +37,17 @@ import (
 )
 
 var (
-	dogstatsdExpvars                  = expvar.NewMap("dogstatsd")
-	dogstatsdServiceCheckParseErrors  = expvar.Int{}
-	dogstatsdServiceCheckPackets      = expvar.Int{}
-	dogstatsdEventParseErrors         = expvar.Int{}
-	dogstatsdEventPackets             = expvar.Int{}
-	dogstatsdMetricParseErrors        = expvar.Int{}
-	dogstatsdMetricPackets            = expvar.Int{}
-	dogstatsdPacketsLastSec           = expvar.Int{}
-	dogstatsdUnterminatedMetricErrors = expvar.Int{}
+	dogstatsdExpvars                   = expvar.NewMap("dogstatsd")
+	dogstatsdServiceCheckParseErrors   = expvar.Int{}
+	dogstatsdServiceCheckPackets       = expvar.Int{}
+	dogstatsdEventParseErrors          = expvar.Int{}
+	dogstatsdEventPackets              = expvar.Int{}
+	dogstatsdMetricParseErrors         = expvar.Int{}
+	dogstatsdMetricPackets             = expvar.Int{}
+	dogstatsdPacketsLastSec            = expvar.Int{}
+	dogstatsdUnterminatedMetricErrors  = expvar.Int{}
+	dogstatsdCardinalityLimitedSamples = expvar.Int{}
+	dogstatsdMetricDroppedByTagRules   = expvar.Int{}
 
 	tlmProcessed = telemetry.NewCounter("dogstatsd", "processed",
 		[]string{"message_type", "state", "origin"}, "Count of service checks/events/metrics processed by dogstatsd")
@@ -71,6 +73,8 @@ func init() {
 	dogstatsdExpvars.Set("MetricParseErrors", &dogstatsdMetricParseErrors)
 	dogstatsdExpvars.Set("MetricPackets", &dogstatsdMetricPackets)
 	dogstatsdExpvars.Set("UnterminatedMetricErrors", &dogstatsdUnterminatedMetricErrors)
+	dogstatsdExpvars.Set("CardinalityLimitedSamples", &dogstatsdCardinalityLimitedSamples)
+	dogstatsdExpvars.Set("MetricDroppedByTagRules", &dogstatsdMetricDroppedByTagRules)
 }
 
 // used in debug mode to add the origin on the processed metric as a tag
@@ -146,6 +150,9 @@ type Server struct {
 	debugTagsAccumulator    *tagset.HashingTagsAccumulator
 	TCapture                *replay.TrafficCapture
 	mapper                  *mapper.MetricMapper
+	tagRules                *mapper.TagRuleSet
+	// cardinalityLimiter caps the number of contexts per metric name, nil if disabled
+	cardinalityLimiter      *cardinalityLimiter
 	eolTerminationUDP       bool
 	eolTerminationUDS       bool
 	eolTerminationNamedPipe bool
@@ -176,6 +183,8 @@ type metricStat struct {
 	Count    uint64    `json:"count"`
 	LastSeen time.Time `json:"last_seen"`
 	Tags     string    `json:"tags"`
+	// CardinalityLimited is true if the metric exceeded its cardinality limit
+	CardinalityLimited bool `json:"cardinality_limited,omitempty"`
 }
 
 type dsdServerDebug struct {
@@ -278,6 +287,15 @@ func NewServer(demultiplexer aggregator.Demultiplexer, serverless bool) (*Server
 		}
 	}
 
+	if config.Datadog.GetInt("dogstatsd_tcp_port") > 0 {
+		tcpListener, err := listeners.NewTCPListener(packetsChannel, sharedPacketPoolManager, capture)
+		if err != nil {
+			log.Errorf(err.Error())
+		} else {
+			tmpListeners = append(tmpListeners, tcpListener)
+		}
+	}
+
 	pipeName := config.Datadog.GetString("dogstatsd_pipe_name")
 	if len(pipeName) > 0 {
 		namedPipeListener, err := listeners.NewNamedPipeListener(pipeName, packetsChannel, sharedPacketPoolManager, capture)
@@ -289,7 +307,7 @@ func NewServer(demultiplexer aggregator.Demultiplexer, serverless bool) (*Server
 	}
 
 	if len(tmpListeners) == 0 {
-		return nil, fmt.Errorf("listening on neither udp nor socket, please check your configuration")
+		return nil, fmt.Errorf("listening on neither udp, tcp nor socket, please check your configuration")
 	}
 
 	// check configuration for custom namespace
@@ -388,6 +406,25 @@ func NewServer(demultiplexer aggregator.Demultiplexer, serverless bool) (*Server
 		}
 	}
 
+	// limit the cardinality of the metrics
+	// ----------------------
+
+	if config.Datadog.GetBool("dogstatsd_cardinality_limiter.enabled") {
+		maxContexts := config.Datadog.GetInt("dogstatsd_cardinality_limiter.max_contexts_per_metric")
+		window := config.Datadog.GetDuration("dogstatsd_cardinality_limiter.window")
+		if maxContexts <= 0 || window <= 0 {
+			log.Errorf("Dogstatsd: invalid cardinality limiter configuration, max_contexts_per_metric and window must be positive, the limiter is disabled")
+		} else {
+			log.Infof("Dogstatsd: limiting metrics to %d contexts per %s", maxContexts, window)
+			limiter := newCardinalityLimiter(maxContexts, window, clock.New())
+			s.cardinalityLimiter = limiter
+			dogstatsdExpvars.Set("CardinalityLimitedMetrics", expvar.Func(func() interface{} {
+				return limiter.topLimitedMetrics(cardinalityLimitedMetricsTopN)
+			}))
+			go limiter.expireLoop(s.stopChan)
+		}
+	}
+
 	// start the workers processing the packets read on the socket
 	// ----------------------
 
@@ -416,6 +453,21 @@ func NewServer(demultiplexer aggregator.Demultiplexer, serverless bool) (*Server
 			s.mapper = mapperInstance
 		}
 	}
+
+	// rewrite or drop some tags
+	// ----------------------
+
+	tagRules, err := config.GetDogstatsdTagRules()
+	if err != nil {
+		log.Warnf("Could not parse tag rules: %v", err)
+	} else if len(tagRules) != 0 {
+		tagRuleSet, err := mapper.NewTagRuleSet(tagRules, cacheSize)
+		if err != nil {
+			log.Warnf("Could not create tag rules: %v", err)
+		} else {
+			s.tagRules = tagRuleSet
+		}
+	}
 	return s, nil
 }
 
@@ -587,6 +639,10 @@ func (s *Server) parsePackets(batcher *batcher, parser *parser, packets []*packe
 				}
 
 				for idx := range samples {
+					if s.cardinalityLimiter != nil {
+						s.cardinalityLimiter.limit(&samples[idx], s.extraTags)
+					}
+
 					if debugEnabled {
 						s.storeMetricStats(samples[idx])
 					}
@@ -688,6 +744,19 @@ func (s *Server) parseMetricMessage(metricSamples []metrics.MetricSample, parser
 		}
 	}
 
+	if s.tagRules != nil {
+		tags, drop := s.tagRules.Apply(sample.name, sample.tags)
+		if drop {
+			log.Tracef("Dogstatsd tag rules: metric %q dropped", sample.name)
+			dogstatsdMetricDroppedByTagRules.Add(1)
+			if len(sample.values) > 0 {
+				s.sharedFloat64List.put(sample.values)
+			}
+			return metricSamples, nil
+		}
+		sample.tags = tags
+	}
+
 	metricSamples = enrichMetricSample(metricSamples, sample, origin, s.enrichConfig)
 
 	if len(sample.values) > 0 {
@@ -775,6 +844,9 @@ func (s *Server) storeMetricStats(sample metrics.MetricSample) {
 	ms.LastSeen = now
 	ms.Name = sample.Name
 	ms.Tags = strings.Join(s.debugTagsAccumulator.Get(), " ") // we don't want/need to share the underlying array
+	if s.cardinalityLimiter != nil && s.cardinalityLimiter.isLimited(sample.Name) {
+		ms.CardinalityLimited = true
+	}
 	s.Debug.Stats[key] = ms
 
 	s.Debug.metricsCounts.metricChan <- struct{}{}
@@ -899,6 +971,24 @@ func FormatDebugStats(stats []byte) (string, error) {
 		buf.Write([]byte("No metrics processed yet."))
 	}
 
+	limited := make(map[string]struct{})
+	for _, stats := range dogStats {
+		if stats.CardinalityLimited {
+			limited[stats.Name] = struct{}{}
+		}
+	}
+	if len(limited) > 0 {
+		names := make([]string, 0, len(limited))
+		for name := range limited {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		buf.Write([]byte(fmt.Sprintf("\nMetrics limited by the cardinality limiter, their new contexts are folded into the %q context:\n", overflowTag)))
+		for _, name := range names {
+			buf.Write([]byte(fmt.Sprintf("  %s\n", name)))
+		}
+	}
+
 	return buf.String(), nil
 }
 
@@ -930,6 +930,44 @@ dogstatsd_mapper_profiles:
 	}
 }
 
+func TestTagRulesCases(t *testing.T) {
+	cfg := `
+dogstatsd_tag_rules:
+  - match: "http.*"
+    drop_tags:
+      - request_id
+    rename_tags:
+      svc: service
+  - match: "noisy.*"
+    drop_metric: true
+`
+	config.Datadog.SetConfigType("yaml")
+	err := config.Datadog.ReadConfig(strings.NewReader(cfg))
+	require.NoError(t, err)
+	defer config.Datadog.Set("dogstatsd_tag_rules", nil)
+
+	port, err := getAvailableUDPPort()
+	require.NoError(t, err)
+	config.Datadog.SetDefault("dogstatsd_port", port)
+
+	demux := mockDemultiplexer()
+	defer demux.Stop(false)
+	s, err := NewServer(demux, false)
+	require.NoError(t, err)
+	defer s.Stop()
+
+	parser := newParser(newFloat64ListPool())
+	samples, err := s.parseMetricMessage(nil, parser, []byte("http.requests:1|c|#request_id:1234,svc:web"), "", false)
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Equal(t, "http.requests", samples[0].Name)
+	assert.Equal(t, []string{"service:web"}, samples[0].Tags)
+
+	samples, err = s.parseMetricMessage(nil, parser, []byte("noisy.calls:1|c|#svc:web"), "", false)
+	require.NoError(t, err)
+	assert.Len(t, samples, 0)
+}
+
 func TestNewServerExtraTags(t *testing.T) {
 	// restore env/config after having runned the test
 	p := config.Datadog.Get("dogstatsd_port")
@@ -243,10 +243,7 @@ func newHTTPPassthroughPipeline(desc passthroughPipelineDesc, destinationsContex
 	inputChan := make(chan *message.Message, endpoints.InputChanSize)
 	senderInput := make(chan *message.Payload, 1) // Only buffer 1 message since payloads can be large
 
-	encoder := sender.IdentityContentType
-	if endpoints.Main.UseCompression {
-		encoder = sender.NewGzipContentEncoding(endpoints.Main.CompressionLevel)
-	}
+	encoder := sender.NewDestinationsContentEncoding(destinations, endpoints.Main)
 
 	strategy := sender.NewBatchStrategy(inputChan,
 		senderInput,
@@ -22,6 +22,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/forwarder/internal/retry"
 	"github.com/DataDog/datadog-agent/pkg/forwarder/transaction"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/version"
@@ -197,9 +198,13 @@ type DefaultForwarder struct {
 
 	domainForwarders map[string]*domainForwarder
 	domainResolvers  map[string]resolver.DomainResolver
-	healthChecker    *forwarderHealth
-	internalState    *atomic.Uint32
-	m                sync.Mutex // To control Start/Stop races
+	// codecs holds the codec configured for each domain
+	codecs map[string]compression.Codec
+	// rejectedEncodings holds the content encodings rejected by each domain
+	rejectedEncodings map[string]*compression.RejectedEncodings
+	healthChecker     *forwarderHealth
+	internalState     *atomic.Uint32
+	m                 sync.Mutex // To control Start/Stop races
 
 	completionHandler transaction.HTTPCompletionHandler
 
@@ -212,10 +217,12 @@ type DefaultForwarder struct {
 func NewDefaultForwarder(options *Options) *DefaultForwarder {
 	agentName := getAgentName(options)
 	f := &DefaultForwarder{
-		NumberOfWorkers:  options.NumberOfWorkers,
-		domainForwarders: map[string]*domainForwarder{},
-		domainResolvers:  map[string]resolver.DomainResolver{},
-		internalState:    atomic.NewUint32(Stopped),
+		NumberOfWorkers:   options.NumberOfWorkers,
+		domainForwarders:  map[string]*domainForwarder{},
+		domainResolvers:   map[string]resolver.DomainResolver{},
+		codecs:            map[string]compression.Codec{},
+		rejectedEncodings: map[string]*compression.RejectedEncodings{},
+		internalState:     atomic.NewUint32(Stopped),
 		healthChecker: &forwarderHealth{
 			domainResolvers:       options.DomainResolvers,
 			disableAPIKeyChecking: options.DisableAPIKeyChecking,
@@ -263,6 +270,7 @@ func NewDefaultForwarder(options *Options) *DefaultForwarder {
 	transactionContainerSort := transaction.SortByCreatedTimeAndPriority{HighPriorityFirst: false}
 
 	for domain, resolver := range options.DomainResolvers {
+		codec := newDomainCodec(domain)
 		domain, _ := config.AddAgentVersionToDomain(domain, "app")
 		resolver.SetBaseDomain(domain)
 		if resolver.GetAPIKeys() == nil || len(resolver.GetAPIKeys()) == 0 {
@@ -285,6 +293,8 @@ func NewDefaultForwarder(options *Options) *DefaultForwarder {
 				transactionContainerSort,
 				resolver)
 			f.domainResolvers[domain] = resolver
+			f.codecs[domain] = codec
+			f.rejectedEncodings[domain] = compression.NewRejectedEncodings(fmt.Sprintf("The intake of %q", domain))
 			fwd := newDomainForwarder(
 				domain,
 				transactionContainer,
@@ -319,6 +329,72 @@ func NewDefaultForwarder(options *Options) *DefaultForwarder {
 	return f
 }
 
+// newDomainCodec returns the codec configured for the payloads sent to the domain,
+// `serializer_compressor_kind_by_domain` overrides `serializer_compressor_kind` for each domain.
+func newDomainCodec(domain string) compression.Codec {
+	kind := config.Datadog.GetString("serializer_compressor_kind")
+	// the keys of the configuration maps are lower-cased
+	if domainKind, found := config.Datadog.GetStringMapString("serializer_compressor_kind_by_domain")[strings.ToLower(domain)]; found {
+		kind = domainKind
+	}
+	codec, err := compression.NewCodec(kind, config.Datadog.GetInt("serializer_zstd_compressor_level"))
+	if err != nil {
+		log.Warnf("Invalid compression kind for %q: %v, using %q instead", domain, err, compression.ZlibKind)
+	}
+	return codec
+}
+
+// domainCodec returns the codec of the payloads sent to the domain:
+// the configured codec, or the default one once the intake rejected it.
+func (f *DefaultForwarder) domainCodec(domain string) compression.Codec {
+	codec, found := f.codecs[domain]
+	if !found || f.rejectedEncodings[domain].IsRejected(codec.ContentEncoding()) {
+		return compression.DefaultCodec
+	}
+	return codec
+}
+
+// PayloadCodec returns the codec compressing the payloads sent to all the domains: the codec of the domains
+// when they all use the same one, the default codec otherwise. In the latter case, the payloads are compressed
+// again for each domain using another codec when their transactions are created.
+func (f *DefaultForwarder) PayloadCodec() compression.Codec {
+	var payloadCodec compression.Codec
+	for domain := range f.domainResolvers {
+		codec := f.domainCodec(domain)
+		if payloadCodec != nil && codec.ContentEncoding() != payloadCodec.ContentEncoding() {
+			return compression.DefaultCodec
+		}
+		payloadCodec = codec
+	}
+	if payloadCodec == nil {
+		return compression.DefaultCodec
+	}
+	return payloadCodec
+}
+
+// domainPayload returns the payload compressed with the codec of the domain, and its content encoding.
+// encodedPayloads holds the payload compressed with each codec already used, so that the domains
+// sharing a codec share the payload. The payload is returned as is if it cannot be compressed again.
+func (f *DefaultForwarder) domainPayload(domain string, payload *transaction.BytesPayload, encoding string, encodedPayloads map[string]*transaction.BytesPayload) (*transaction.BytesPayload, string) {
+	codec := f.domainCodec(domain)
+	if encodedPayload, found := encodedPayloads[codec.ContentEncoding()]; found {
+		return encodedPayload, codec.ContentEncoding()
+	}
+	decompressed, err := compression.DecompressEncoding(payload.GetContent(), encoding)
+	if err != nil {
+		log.Errorf("Could not decompress the %q payload to compress it again for %q: %s", encoding, domain, err)
+		return payload, encoding
+	}
+	content, err := codec.Compress(decompressed)
+	if err != nil {
+		log.Errorf("Could not compress the payload again with %q for %q: %s", codec.ContentEncoding(), domain, err)
+		return payload, encoding
+	}
+	encodedPayload := transaction.NewBytesPayload(content, payload.GetPointCount())
+	encodedPayloads[codec.ContentEncoding()] = encodedPayload
+	return encodedPayload, codec.ContentEncoding()
+}
+
 func getAgentName(options *Options) string {
 	if HasFeature(options.EnabledFeatures, CoreFeatures) {
 		return "core"
@@ -424,8 +500,17 @@ func (f *DefaultForwarder) createAdvancedHTTPTransactions(endpoint transaction.E
 	transactions := make([]*transaction.HTTPTransaction, 0, len(payloads)*len(f.domainForwarders))
 	allowArbitraryTags := config.Datadog.GetBool("allow_arbitrary_tags")
 
+	// payloads compressed with the codec shared by all the domains are compressed again for the domains using another codec
+	payloadEncoding := extra.Get("Content-Encoding")
+	reencode := payloadEncoding == f.PayloadCodec().ContentEncoding()
+
 	for _, payload := range payloads {
+		encodedPayloads := map[string]*transaction.BytesPayload{payloadEncoding: payload}
 		for domain, dr := range f.domainResolvers {
+			domainPayload, domainEncoding := payload, payloadEncoding
+			if reencode {
+				domainPayload, domainEncoding = f.domainPayload(domain, payload, payloadEncoding, encodedPayloads)
+			}
 			for _, apiKey := range dr.GetAPIKeys() {
 				t := transaction.NewHTTPTransaction()
 				t.Domain, _ = dr.Resolve(endpoint)
@@ -433,9 +518,10 @@ func (f *DefaultForwarder) createAdvancedHTTPTransactions(endpoint transaction.E
 				if apiKeyInQueryString {
 					t.Endpoint.Route = fmt.Sprintf("%s?api_key=%s", endpoint.Route, apiKey)
 				}
-				t.Payload = payload
+				t.Payload = domainPayload
 				t.Priority = priority
 				t.StorableOnDisk = storableOnDisk
+				t.RejectedEncodings = f.rejectedEncodings[domain]
 				t.Headers.Set(apiHTTPHeaderKey, apiKey)
 				t.Headers.Set(versionHTTPHeaderKey, version.AgentVersion)
 				t.Headers.Set(useragentHTTPHeaderKey, fmt.Sprintf("datadog-agent/%s", version.AgentVersion))
@@ -455,6 +541,13 @@ func (f *DefaultForwarder) createAdvancedHTTPTransactions(endpoint transaction.E
 				for key := range extra {
 					t.Headers.Set(key, extra.Get(key))
 				}
+				if domainEncoding != payloadEncoding {
+					if domainEncoding == "" {
+						t.Headers.Del("Content-Encoding")
+					} else {
+						t.Headers.Set("Content-Encoding", domainEncoding)
+					}
+				}
 				transactions = append(transactions, t)
 			}
 		}
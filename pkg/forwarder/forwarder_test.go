@@ -23,6 +23,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/config/resolver"
 	"github.com/DataDog/datadog-agent/pkg/forwarder/endpoints"
 	"github.com/DataDog/datadog-agent/pkg/forwarder/transaction"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 	"github.com/DataDog/datadog-agent/pkg/version"
 )
 
@@ -288,6 +289,88 @@ func TestArbitraryTagsHTTPHeader(t *testing.T) {
 	assert.Equal(t, "true", transactions[0].Headers.Get(arbitraryTagHTTPHeaderKey))
 }
 
+func TestPayloadCodec(t *testing.T) {
+	mockConfig := config.Mock(t)
+	mockConfig.Set("serializer_compressor_kind", compression.ZstdKind)
+	defer mockConfig.Set("serializer_compressor_kind", compression.ZlibKind)
+
+	forwarder := NewDefaultForwarder(NewOptionsWithResolvers(resolver.NewSingleDomainResolvers(keysPerDomains)))
+	assert.Equal(t, compression.ZstdEncoding, forwarder.PayloadCodec().ContentEncoding())
+
+	// the domain falls back to the default codec once its intake rejected zstd
+	forwarder.rejectedEncodings[testVersionDomain].Report(compression.ZstdEncoding)
+	assert.Equal(t, compression.DefaultCodec.ContentEncoding(), forwarder.PayloadCodec().ContentEncoding())
+
+	// the payloads are compressed with the default codec when the domains use different codecs
+	mockConfig.Set("serializer_compressor_kind_by_domain", map[string]string{"datadog.bar": compression.ZlibKind})
+	defer mockConfig.Set("serializer_compressor_kind_by_domain", map[string]string{})
+	forwarder = NewDefaultForwarder(NewOptionsWithResolvers(resolver.NewSingleDomainResolvers(keysWithMultipleDomains)))
+	assert.Equal(t, compression.ZstdEncoding, forwarder.domainCodec(testVersionDomain).ContentEncoding())
+	assert.Equal(t, compression.DefaultCodec.ContentEncoding(), forwarder.PayloadCodec().ContentEncoding())
+}
+
+func TestPayloadCodecByDomain(t *testing.T) {
+	type received struct {
+		encoding string
+		content  []byte
+	}
+	newIntake := func(payloads chan<- received) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == endpoints.SeriesEndpoint.Route {
+				body, _ := ioutil.ReadAll(r.Body)
+				payloads <- received{encoding: r.Header.Get("Content-Encoding"), content: body}
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+	zstdPayloads := make(chan received, 1)
+	zstdIntake := newIntake(zstdPayloads)
+	defer zstdIntake.Close()
+	zlibPayloads := make(chan received, 1)
+	zlibIntake := newIntake(zlibPayloads)
+	defer zlibIntake.Close()
+
+	mockConfig := config.Mock(t)
+	mockConfig.Set("serializer_compressor_kind_by_domain", map[string]string{zstdIntake.URL: compression.ZstdKind})
+	defer mockConfig.Set("serializer_compressor_kind_by_domain", map[string]string{})
+
+	f := NewDefaultForwarder(NewOptionsWithResolvers(resolver.NewSingleDomainResolvers(map[string][]string{
+		zstdIntake.URL: {"api_key1"},
+		zlibIntake.URL: {"api_key2"},
+	})))
+	require.NoError(t, f.Start())
+	defer f.Stop()
+
+	// the domains use different codecs: the serializer compresses the payloads with the default codec
+	codec := f.PayloadCodec()
+	require.Equal(t, compression.DefaultCodec.ContentEncoding(), codec.ContentEncoding())
+	data := []byte("data payload")
+	compressed, err := codec.Compress(data)
+	require.NoError(t, err)
+	headers := http.Header{}
+	headers.Set("Content-Encoding", codec.ContentEncoding())
+	require.NoError(t, f.SubmitSeries(transaction.NewBytesPayloadsWithoutMetaData([]*[]byte{&compressed}), headers))
+
+	// each domain receives the payload compressed with its own codec
+	for _, tc := range []struct {
+		payloads <-chan received
+		encoding string
+	}{
+		{zstdPayloads, compression.ZstdEncoding},
+		{zlibPayloads, compression.DefaultCodec.ContentEncoding()},
+	} {
+		select {
+		case payload := <-tc.payloads:
+			assert.Equal(t, tc.encoding, payload.encoding)
+			decompressed, err := compression.DecompressEncoding(payload.content, payload.encoding)
+			require.NoError(t, err)
+			assert.Equal(t, data, decompressed)
+		case <-time.After(10 * time.Second):
+			require.Fail(t, "the payload was not received")
+		}
+	}
+}
+
 func TestSendHTTPTransactions(t *testing.T) {
 	forwarder := NewDefaultForwarder(NewOptionsWithResolvers(resolver.NewSingleDomainResolvers(keysPerDomains)))
 	endpoint := transaction.Endpoint{Route: "/api/foo", Name: "foo"}
@@ -13,6 +13,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/config/resolver"
 	"github.com/DataDog/datadog-agent/pkg/forwarder/endpoints"
 	"github.com/DataDog/datadog-agent/pkg/forwarder/transaction"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 	utilhttp "github.com/DataDog/datadog-agent/pkg/util/http"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -44,6 +45,11 @@ func (f *SyncForwarder) Start() error {
 func (f *SyncForwarder) Stop() {
 }
 
+// PayloadCodec returns the codec compressing the payloads sent to all the domains.
+func (f *SyncForwarder) PayloadCodec() compression.Codec {
+	return f.defaultForwarder.PayloadCodec()
+}
+
 func (f *SyncForwarder) sendHTTPTransactions(transactions []*transaction.HTTPTransaction) error {
 	for _, t := range transactions {
 		if err := t.Process(context.Background(), f.client); err != nil {
@@ -19,6 +19,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 )
@@ -190,6 +191,9 @@ type HTTPTransaction struct {
 	// CompletionHandler will be called with a transaction after it has been successfully sent
 	// This field is not restored when a transaction is deserialized from the disk (the default value is used).
 	CompletionHandler HTTPCompletionHandler
+	// RejectedEncodings holds the content encodings rejected by the domain, it is shared by the transactions of the domain.
+	// This field is not restored when a transaction is deserialized from the disk (the default value is used).
+	RejectedEncodings *compression.RejectedEncodings
 
 	Priority Priority
 }
@@ -295,6 +299,11 @@ func (t *HTTPTransaction) Process(ctx context.Context, client *http.Client) erro
 // internalProcess does the  work of actually sending the http request to the specified domain
 // This will return  (http status code, response body, error).
 func (t *HTTPTransaction) internalProcess(ctx context.Context, client *http.Client) (int, []byte, error) {
+	// The payload is compressed once for all the domains: use the default codec directly
+	// when this domain already rejected its content encoding.
+	if t.RejectedEncodings.IsRejected(t.Headers.Get("Content-Encoding")) {
+		t.reencodePayload()
+	}
 	reader := bytes.NewReader(t.Payload.GetContent())
 	url := t.Domain + t.Endpoint.Route
 	transactionEndpointName := t.GetEndpointName()
@@ -344,6 +353,16 @@ func (t *HTTPTransaction) internalProcess(ctx context.Context, client *http.Clie
 		tlmTxHTTPErrors.Inc(t.Domain, transactionEndpointName, statusCode)
 	}
 
+	// The intake does not support the content encoding of the payload (zstd for instance):
+	// compress it again with the default codec and retry it.
+	encoding := t.Headers.Get("Content-Encoding")
+	if resp.StatusCode == http.StatusUnsupportedMediaType && t.reencodePayload() {
+		t.RejectedEncodings.Report(encoding)
+		transactionsErrors.Add(1)
+		tlmTxErrors.Inc(t.Domain, transactionEndpointName, "unsupported_encoding")
+		return resp.StatusCode, body, fmt.Errorf("error %q while sending transaction to %q, rescheduling it with the %q content encoding", resp.Status, logURL, compression.DefaultCodec.ContentEncoding())
+	}
+
 	// We want to retry 404s even if that means that the agent would retry
 	// payloads on endpoints that don’t exist at the intake it’s sending data
 	// to (example: a specific DD region, or a http proxy)
@@ -388,6 +407,33 @@ func (t *HTTPTransaction) internalProcess(ctx context.Context, client *http.Clie
 	return resp.StatusCode, body, nil
 }
 
+// reencodePayload compresses the payload again with the default codec once the intake rejected its content encoding.
+// It returns false if the payload cannot be compressed with another codec.
+func (t *HTTPTransaction) reencodePayload() bool {
+	encoding := t.Headers.Get("Content-Encoding")
+	defaultEncoding := compression.DefaultCodec.ContentEncoding()
+	if encoding == "" || encoding == defaultEncoding {
+		return false
+	}
+	decompressed, err := compression.DecompressEncoding(t.Payload.GetContent(), encoding)
+	if err != nil {
+		log.Errorf("Could not decompress the payload after its %q content encoding was rejected: %s", encoding, err)
+		return false
+	}
+	content, err := compression.DefaultCodec.Compress(decompressed)
+	if err != nil {
+		log.Errorf("Could not compress the payload again after its %q content encoding was rejected: %s", encoding, err)
+		return false
+	}
+	t.Payload = NewBytesPayload(content, t.Payload.GetPointCount())
+	if defaultEncoding == "" {
+		t.Headers.Del("Content-Encoding")
+	} else {
+		t.Headers.Set("Content-Encoding", defaultEncoding)
+	}
+	return true
+}
+
 // SerializeTo serializes the transaction using TransactionsSerializer
 func (t *HTTPTransaction) SerializeTo(serializer TransactionsSerializer) error {
 	if t.StorableOnDisk {
@@ -7,6 +7,7 @@ package transaction
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
@@ -15,6 +16,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 )
 
 func TestNewHTTPTransaction(t *testing.T) {
@@ -114,6 +118,58 @@ func TestProcessHTTPError(t *testing.T) {
 	assert.Equal(t, transaction.ErrorCount, 1)
 }
 
+func TestProcessUnsupportedEncoding(t *testing.T) {
+	var received []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") == compression.ZstdEncoding {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received, _ = compression.DefaultCodec.Decompress(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	payload := []byte("test payload")
+	compressed, err := compression.NewZstdCodec(compression.ZstdDefaultLevel).Compress(payload)
+	require.NoError(t, err)
+
+	transaction := NewHTTPTransaction()
+	transaction.Domain = ts.URL
+	transaction.Endpoint.Route = "/endpoint/test"
+	transaction.Headers.Set("Content-Encoding", compression.ZstdEncoding)
+	transaction.Payload = NewBytesPayload(compressed, 3)
+	transaction.RejectedEncodings = compression.NewRejectedEncodings("test")
+
+	client := &http.Client{}
+
+	// the payload is compressed again with the default codec and rescheduled
+	err = transaction.Process(context.Background(), client)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "415 Unsupported Media Type")
+	assert.True(t, transaction.RejectedEncodings.IsRejected(compression.ZstdEncoding))
+	assert.Equal(t, 3, transaction.GetPointCount())
+
+	err = transaction.Process(context.Background(), client)
+	assert.Nil(t, err)
+	assert.Equal(t, payload, received)
+
+	// the next transactions to the domain are compressed with the default codec before being sent
+	received = nil
+	next := NewHTTPTransaction()
+	next.Domain = ts.URL
+	next.Endpoint.Route = "/endpoint/test"
+	next.Headers.Set("Content-Encoding", compression.ZstdEncoding)
+	next.Payload = NewBytesPayload(compressed, 3)
+	next.RejectedEncodings = transaction.RejectedEncodings
+
+	err = next.Process(context.Background(), client)
+	assert.Nil(t, err)
+	assert.Equal(t, payload, received)
+	assert.Equal(t, 0, next.ErrorCount)
+}
+
 func TestProcessCancel(t *testing.T) {
 	transaction := NewHTTPTransaction()
 	transaction.Domain = "example.com"
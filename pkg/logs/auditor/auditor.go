@@ -11,6 +11,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -30,10 +31,20 @@ const defaultCleanupPeriod = 300 * time.Second
 // latest version of the API used by the auditor to retrieve the registry from disk.
 const registryAPIVersion = 2
 
+// fingerprintIdentifierPrefix prefixes the identifiers of the entries tracking the offsets by file fingerprint.
+const fingerprintIdentifierPrefix = "fingerprint:"
+
+// fileIdentifierPrefix is the prefix of the identifiers of the files tailed by path
+const fileIdentifierPrefix = "file:"
+
+// ArchiveCompletedOffset is the offset recorded for an archive which has been read to completion
+const ArchiveCompletedOffset = "completed"
+
 // Registry holds a list of offsets.
 type Registry interface {
 	GetOffset(identifier string) string
 	GetTailingMode(identifier string) string
+	GetFingerprint(identifier string) string
 }
 
 // A RegistryEntry represents an entry in the registry where we keep track
@@ -43,6 +54,7 @@ type RegistryEntry struct {
 	Offset             string
 	TailingMode        string
 	IngestionTimestamp int64
+	Fingerprint        string `json:",omitempty"`
 }
 
 // JSONRegistry represents the registry that will be written on disk
@@ -149,6 +161,23 @@ func (a *RegistryAuditor) GetTailingMode(identifier string) string {
 	return entry.TailingMode
 }
 
+// GetFingerprint returns the fingerprint of the file last recorded for a given identifier,
+// returns an empty string if it does not exist.
+func (a *RegistryAuditor) GetFingerprint(identifier string) string {
+	r := a.readOnlyRegistryCopy()
+	entry, exists := r[identifier]
+	if !exists {
+		return ""
+	}
+	return entry.Fingerprint
+}
+
+// FingerprintIdentifier returns the identifier of the registry entry tracking the offset of
+// the file with the given fingerprint, whatever its path.
+func FingerprintIdentifier(fingerprint string) string {
+	return fingerprintIdentifierPrefix + fingerprint
+}
+
 // run keeps up to date the registry depending on different events
 func (a *RegistryAuditor) run() {
 	cleanUpTicker := time.NewTicker(defaultCleanupPeriod)
@@ -171,7 +200,11 @@ func (a *RegistryAuditor) run() {
 			}
 			// update the registry with new entry
 			for _, msg := range payload.Messages {
-				a.updateRegistry(msg.Origin.Identifier, msg.Origin.Offset, msg.Origin.LogSource.Config.TailingMode, msg.IngestionTimestamp)
+				a.updateRegistry(msg.Origin.Identifier, msg.Origin.Offset, msg.Origin.LogSource.Config.TailingMode, msg.Origin.Fingerprint, msg.IngestionTimestamp)
+				if msg.Origin.Fingerprint != "" {
+					// also track the offset by fingerprint to resume a file after it has been renamed
+					a.updateRegistry(FingerprintIdentifier(msg.Origin.Fingerprint), msg.Origin.Offset, msg.Origin.LogSource.Config.TailingMode, msg.Origin.Fingerprint, msg.IngestionTimestamp)
+				}
 			}
 		case <-cleanUpTicker.C:
 			// remove expired offsets from registry
@@ -211,20 +244,32 @@ func (a *RegistryAuditor) recoverRegistry() map[string]*RegistryEntry {
 	return r
 }
 
-// cleanupRegistry removes expired entries from the registry
+// cleanupRegistry removes expired entries from the registry.
+// The archives read to completion do not expire, to not read them again, until their file is removed.
 func (a *RegistryAuditor) cleanupRegistry() {
 	a.registryMutex.Lock()
 	defer a.registryMutex.Unlock()
 	expireBefore := time.Now().UTC().Add(-a.entryTTL)
-	for path, entry := range a.registry {
+	for identifier, entry := range a.registry {
+		if entry.Offset == ArchiveCompletedOffset {
+			if path := strings.TrimPrefix(identifier, fileIdentifierPrefix); path != identifier {
+				if _, err := os.Stat(path); os.IsNotExist(err) {
+					delete(a.registry, identifier)
+					if entry.Fingerprint != "" {
+						delete(a.registry, FingerprintIdentifier(entry.Fingerprint))
+					}
+				}
+			}
+			continue
+		}
 		if entry.LastUpdated.Before(expireBefore) {
-			delete(a.registry, path)
+			delete(a.registry, identifier)
 		}
 	}
 }
 
 // updateRegistry updates the registry entry matching identifier with new the offset and timestamp
-func (a *RegistryAuditor) updateRegistry(identifier string, offset string, tailingMode string, ingestionTimestamp int64) {
+func (a *RegistryAuditor) updateRegistry(identifier string, offset string, tailingMode string, fingerprint string, ingestionTimestamp int64) {
 	a.registryMutex.Lock()
 	defer a.registryMutex.Unlock()
 	if identifier == "" {
@@ -247,6 +292,7 @@ func (a *RegistryAuditor) updateRegistry(identifier string, offset string, taili
 		Offset:             offset,
 		TailingMode:        tailingMode,
 		IngestionTimestamp: ingestionTimestamp,
+		Fingerprint:        fingerprint,
 	}
 }
 
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/sources"
 )
 
@@ -59,11 +61,11 @@ func (suite *AuditorTestSuite) TestAuditorStartStop() {
 func (suite *AuditorTestSuite) TestAuditorUpdatesRegistry() {
 	suite.a.registry = make(map[string]*RegistryEntry)
 	suite.Equal(0, len(suite.a.registry))
-	suite.a.updateRegistry(suite.source.Config.Path, "42", "end", 0)
+	suite.a.updateRegistry(suite.source.Config.Path, "42", "end", "", 0)
 	suite.Equal(1, len(suite.a.registry))
 	suite.Equal("42", suite.a.registry[suite.source.Config.Path].Offset)
 	suite.Equal("end", suite.a.registry[suite.source.Config.Path].TailingMode)
-	suite.a.updateRegistry(suite.source.Config.Path, "43", "beginning", 1)
+	suite.a.updateRegistry(suite.source.Config.Path, "43", "beginning", "", 1)
 	suite.Equal(1, len(suite.a.registry))
 	suite.Equal("43", suite.a.registry[suite.source.Config.Path].Offset)
 	suite.Equal("beginning", suite.a.registry[suite.source.Config.Path].TailingMode)
@@ -120,6 +122,49 @@ func (suite *AuditorTestSuite) TestAuditorCleansupRegistry() {
 	suite.Equal("43", suite.a.registry[otherpath].Offset)
 }
 
+func (suite *AuditorTestSuite) TestAuditorKeepsCompletedArchives() {
+	archivePath := filepath.Join(suite.testDir, "archive.log.gz")
+	suite.Nil(ioutil.WriteFile(archivePath, []byte("archive"), 0644))
+	removedPath := filepath.Join(suite.testDir, "removed.log.gz")
+
+	expired := time.Date(2006, time.January, 12, 1, 1, 1, 1, time.UTC)
+	suite.a.registry = map[string]*RegistryEntry{
+		"file:" + archivePath:          {LastUpdated: expired, Offset: ArchiveCompletedOffset, Fingerprint: "abc"},
+		FingerprintIdentifier("abc"):   {LastUpdated: expired, Offset: ArchiveCompletedOffset},
+		"file:" + removedPath:          {LastUpdated: expired, Offset: ArchiveCompletedOffset, Fingerprint: "def"},
+		FingerprintIdentifier("def"):   {LastUpdated: expired, Offset: ArchiveCompletedOffset},
+		"file:" + suite.testPath + "x": {LastUpdated: expired, Offset: "42"},
+	}
+
+	// the completion of an archive does not expire until its file is removed
+	suite.a.cleanupRegistry()
+	suite.Equal(2, len(suite.a.registry))
+	suite.Equal(ArchiveCompletedOffset, suite.a.registry["file:"+archivePath].Offset)
+	suite.Equal(ArchiveCompletedOffset, suite.a.registry[FingerprintIdentifier("abc")].Offset)
+}
+
+func (suite *AuditorTestSuite) TestAuditorTracksFingerprints() {
+	suite.a.Start()
+	defer suite.a.Stop()
+
+	origin := message.NewOrigin(suite.source)
+	origin.Identifier = "file:" + testpath
+	origin.Offset = "42"
+	origin.Fingerprint = "abc"
+	suite.a.Channel() <- &message.Payload{Messages: []*message.Message{message.NewMessage(nil, origin, "", 0)}}
+
+	suite.Eventually(func() bool {
+		return suite.a.GetOffset(FingerprintIdentifier("abc")) == "42"
+	}, 5*time.Second, 10*time.Millisecond)
+	suite.Equal("42", suite.a.GetOffset("file:"+testpath))
+	suite.Equal("abc", suite.a.GetFingerprint("file:"+testpath))
+
+	suite.a.flushRegistry()
+	registry := suite.a.recoverRegistry()
+	suite.Equal("abc", registry["file:"+testpath].Fingerprint)
+	suite.Equal("42", registry[FingerprintIdentifier("abc")].Offset)
+}
+
 func TestScannerTestSuite(t *testing.T) {
 	suite.Run(t, new(AuditorTestSuite))
 }
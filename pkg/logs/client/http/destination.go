@@ -23,6 +23,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/backoff"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/version"
@@ -46,6 +47,10 @@ var (
 	expVarInUseMsMapKey = "inUseMs"
 )
 
+// errUnsupportedEncoding is returned when the intake rejects the encoding of the payload,
+// the payload can be sent again with the gzip encoding.
+var errUnsupportedEncoding = errors.New("unsupported encoding")
+
 // emptyPayload is an empty payload used to check HTTP connectivity without sending logs.
 var emptyPayload = message.Payload{}
 
@@ -60,6 +65,9 @@ type Destination struct {
 	destinationsContext *client.DestinationsContext
 	protocol            config.IntakeProtocol
 	origin              config.IntakeOrigin
+	codec               compression.Codec
+	fallbackCodec       compression.Codec
+	rejectedEncodings   *compression.RejectedEncodings
 
 	// Concurrency
 	climit chan struct{} // semaphore for limiting concurrent background sends
@@ -129,9 +137,11 @@ func newDestination(endpoint config.Endpoint,
 		endpoint.RecoveryReset,
 	)
 
+	url := buildURL(endpoint)
+
 	return &Destination{
 		host:                endpoint.Host,
-		url:                 buildURL(endpoint),
+		url:                 url,
 		apiKey:              endpoint.APIKey,
 		contentType:         contentType,
 		client:              httputils.NewResetClient(endpoint.ConnectionResetInterval, httpClientFactory(timeout)),
@@ -141,6 +151,9 @@ func newDestination(endpoint config.Endpoint,
 		backoff:             policy,
 		protocol:            endpoint.Protocol,
 		origin:              endpoint.Origin,
+		codec:               endpoint.GetCodec(),
+		fallbackCodec:       compression.NewGzipCodec(endpoint.CompressionLevel),
+		rejectedEncodings:   compression.NewRejectedEncodings(fmt.Sprintf("The logs intake %q", url)),
 		lastRetryError:      nil,
 		retryLock:           sync.Mutex{},
 		shouldRetry:         shouldRetry,
@@ -203,6 +216,7 @@ func (d *Destination) sendConcurrent(payload *message.Payload, output chan *mess
 
 // Send sends a payload over HTTP,
 func (d *Destination) sendAndRetry(payload *message.Payload, output chan *message.Payload, isRetrying chan bool) {
+	toSend := d.payloadToSend(payload)
 	for {
 
 		d.retryLock.Lock()
@@ -213,7 +227,16 @@ func (d *Destination) sendAndRetry(payload *message.Payload, output chan *messag
 		}
 		d.retryLock.Unlock()
 
-		err := d.unconditionalSend(payload)
+		err := d.unconditionalSend(toSend)
+
+		if err == errUnsupportedEncoding {
+			d.rejectedEncodings.Report(toSend.Encoding)
+			// the destination falls back to gzip for this payload and the next ones
+			if toSend = d.payloadToSend(payload); !d.rejectedEncodings.IsRejected(toSend.Encoding) {
+				continue
+			}
+			err = errClient
+		}
 
 		if err != nil {
 			metrics.DestinationErrors.Add(1)
@@ -300,6 +323,10 @@ func (d *Destination) unconditionalSend(payload *message.Payload) (err error) {
 	if resp.StatusCode >= http.StatusBadRequest {
 		log.Warnf("failed to post http payload. code=%d host=%s response=%s", resp.StatusCode, d.host, string(response))
 	}
+	if resp.StatusCode == http.StatusUnsupportedMediaType && payload.Encoding == compression.ZstdEncoding {
+		// the intake does not support zstd yet, the payload can be sent again using gzip.
+		return errUnsupportedEncoding
+	}
 	if resp.StatusCode == http.StatusBadRequest ||
 		resp.StatusCode == http.StatusUnauthorized ||
 		resp.StatusCode == http.StatusForbidden ||
@@ -316,6 +343,56 @@ func (d *Destination) unconditionalSend(payload *message.Payload) (err error) {
 	}
 }
 
+// Codec returns the codec compressing the payloads sent by the destination, nil if they are not compressed.
+// It returns the gzip codec once the intake rejected the configured one.
+func (d *Destination) Codec() compression.Codec {
+	if d.codec != nil && d.rejectedEncodings.IsRejected(d.codec.ContentEncoding()) {
+		return d.fallbackCodec
+	}
+	return d.codec
+}
+
+// payloadToSend returns the payload encoded with the codec of the destination. The payloads are encoded
+// for all the destinations by the strategy, they are only transcoded when they were encoded before the
+// intake rejected their encoding.
+func (d *Destination) payloadToSend(payload *message.Payload) *message.Payload {
+	codec := d.Codec()
+	if codec == nil || codec.ContentEncoding() == payload.Encoding {
+		return payload
+	}
+	if encoded, found := payload.AlternateEncodings[codec.ContentEncoding()]; found {
+		toSend := *payload
+		toSend.Encoded = encoded
+		toSend.Encoding = codec.ContentEncoding()
+		return &toSend
+	}
+	if !d.rejectedEncodings.IsRejected(payload.Encoding) {
+		return payload
+	}
+	transcoded, err := transcodePayload(payload, codec)
+	if err != nil {
+		log.Warnf("Could not re-encode payload: %v", err)
+		return payload
+	}
+	return transcoded
+}
+
+// transcodePayload returns a copy of the payload encoded with the codec.
+func transcodePayload(payload *message.Payload, codec compression.Codec) (*message.Payload, error) {
+	decoded, err := compression.DecompressEncoding(payload.Encoded, payload.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := codec.Compress(decoded)
+	if err != nil {
+		return nil, err
+	}
+	transcoded := *payload
+	transcoded.Encoded = encoded
+	transcoded.Encoding = codec.ContentEncoding()
+	return &transcoded, nil
+}
+
 func (d *Destination) updateRetryState(err error, isRetrying chan bool) {
 	d.retryLock.Lock()
 	defer d.retryLock.Unlock()
@@ -6,13 +6,23 @@
 package http
 
 import (
+	"bytes"
+	"compress/gzip"
 	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 )
@@ -331,3 +341,74 @@ func TestBackoffDelayDisabledServerless(t *testing.T) {
 
 	assert.False(t, dest.shouldRetry)
 }
+
+func TestDestinationUnsupportedEncodingFallback(t *testing.T) {
+	received := make(chan []byte, 1)
+	var zstdRequests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") == "zstd" {
+			zstdRequests.Inc()
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		reader, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(reader)
+		require.NoError(t, err)
+		received <- body
+	}))
+	defer ts.Close()
+
+	url := strings.Split(ts.URL, ":")
+	port, _ := strconv.Atoi(url[2])
+	destCtx := client.NewDestinationsContext()
+	destCtx.Start()
+	defer destCtx.Stop()
+	endpoint := config.Endpoint{
+		APIKey:           "test",
+		Host:             strings.Replace(url[1], "/", "", -1),
+		Port:             port,
+		BackoffFactor:    1,
+		BackoffBase:      1,
+		BackoffMax:       10,
+		RecoveryInterval: 1,
+		UseCompression:   true,
+		CompressionLevel: gzip.BestSpeed,
+		CompressionKind:  config.ZstdCompressionKind,
+	}
+	dest := NewDestination(endpoint, JSONContentType, destCtx, 0, true, "test")
+	assert.Equal(t, "zstd", dest.Codec().ContentEncoding())
+
+	encoded, err := compression.NewZstdCodec(compression.ZstdDefaultLevel).Compress([]byte("payload"))
+	require.NoError(t, err)
+	payload := &message.Payload{Messages: []*message.Message{}, Encoded: encoded, Encoding: "zstd"}
+
+	input := make(chan *message.Payload)
+	output := make(chan *message.Payload)
+	dest.Start(input, output, nil)
+	input <- payload
+
+	assert.Equal(t, []byte("payload"), <-received)
+	// the payload shared with the other destinations is left untouched
+	sent := <-output
+	assert.Equal(t, "zstd", sent.Encoding)
+	assert.True(t, bytes.Equal(encoded, sent.Encoded))
+	assert.True(t, dest.rejectedEncodings.IsRejected("zstd"))
+	// the new payloads are encoded with gzip for the destination
+	assert.Equal(t, "gzip", dest.Codec().ContentEncoding())
+
+	// the payloads encoded before the fallback are sent with gzip directly
+	input <- payload
+	assert.Equal(t, []byte("payload"), <-received)
+	<-output
+
+	// the gzip encoding of the payloads encoded for another destination is sent as is
+	alternate, err := compression.NewGzipCodec(gzip.BestSpeed).Compress([]byte("alternate"))
+	require.NoError(t, err)
+	input <- &message.Payload{Messages: []*message.Message{}, Encoded: encoded, Encoding: "zstd", AlternateEncodings: map[string][]byte{"gzip": alternate}}
+	assert.Equal(t, []byte("alternate"), <-received)
+	<-output
+	assert.Equal(t, int32(1), zstdRequests.Load())
+	close(input)
+}
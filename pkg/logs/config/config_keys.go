@@ -7,6 +7,7 @@ package config
 
 import (
 	"encoding/json"
+	"path/filepath"
 	"time"
 
 	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
@@ -110,6 +111,14 @@ func (l *LogsConfigKeys) compressionLevel() int {
 	return l.getConfig().GetInt(l.getConfigKey("compression_level"))
 }
 
+func (l *LogsConfigKeys) compressionKind() string {
+	return l.getConfig().GetString(l.getConfigKey("compression_kind"))
+}
+
+func (l *LogsConfigKeys) zstdCompressionLevel() int {
+	return l.getConfig().GetInt(l.getConfigKey("zstd_compression_level"))
+}
+
 func (l *LogsConfigKeys) useCompression() bool {
 	return l.getConfig().GetBool(l.getConfigKey("use_compression"))
 }
@@ -254,6 +263,23 @@ func (l *LogsConfigKeys) senderRecoveryReset() bool {
 	return l.getConfig().GetBool(l.getConfigKey("sender_recovery_reset"))
 }
 
+// diskBuffer returns the settings of the storage of the payloads on disk, nil if it is disabled.
+func (l *LogsConfigKeys) diskBuffer() *DiskBuffer {
+	maxSizeInBytes := l.getConfig().GetInt64(l.getConfigKey("storage_max_size_in_bytes"))
+	if maxSizeInBytes <= 0 {
+		return nil
+	}
+	storagePath := l.getConfig().GetString(l.getConfigKey("storage_path"))
+	if storagePath == "" {
+		storagePath = filepath.Join(l.getConfig().GetString("logs_config.run_path"), "logs_to_retry")
+	}
+	return &DiskBuffer{
+		Path:           storagePath,
+		MaxSizeInBytes: maxSizeInBytes,
+		MaxDiskRatio:   l.getConfig().GetFloat64(l.getConfigKey("storage_max_disk_ratio")),
+	}
+}
+
 // AggregationTimeout is used when performing aggregation operations
 func (l *LogsConfigKeys) aggregationTimeout() time.Duration {
 	return l.getConfig().GetDuration(l.getConfigKey("aggregation_timeout")) * time.Millisecond
@@ -98,6 +98,29 @@ func (suite *ConfigTestSuite) TestGlobalProcessingRulesShouldReturnRulesWithVali
 	suite.NotNil(rule.Regex)
 }
 
+func (suite *ConfigTestSuite) TestGlobalLogMetrics() {
+	logMetrics, err := GlobalLogMetrics()
+	suite.Nil(err)
+	suite.Equal(0, len(logMetrics))
+
+	suite.config.Set("logs_config.generate_metrics", `[{"name":"nginx.requests.duration","type":"distribution","source":"nginx","pattern":"request_time=%{NUMBER:duration}","value":"duration","group_by":["status"],"tags":["team:web"]}]`)
+
+	logMetrics, err = GlobalLogMetrics()
+	suite.Nil(err)
+	suite.Equal(1, len(logMetrics))
+	suite.Equal(DistributionMetric, logMetrics[0].Type)
+	suite.Equal("nginx", logMetrics[0].Source)
+	suite.Equal("duration", logMetrics[0].Value)
+	suite.Equal([]string{"status"}, logMetrics[0].GroupBy)
+	suite.Equal([]string{"team:web"}, logMetrics[0].Tags)
+	suite.NotNil(logMetrics[0].Regex)
+
+	suite.config.Set("logs_config.generate_metrics", `[{"name":"nginx.requests.duration","type":"distribution"}]`)
+
+	_, err = GlobalLogMetrics()
+	suite.NotNil(err)
+}
+
 func (suite *ConfigTestSuite) TestGlobalProcessingRulesShouldReturnRulesWithValidJSONString() {
 	var (
 		rules []*ProcessingRule
@@ -153,18 +176,20 @@ func (suite *ConfigTestSuite) TestMultipleHttpEndpointsEnvVar() {
 	{"api_key": "789", "host": "additional.endpoint.2", "port": 1234, "use_compression": true, "compression_level": 2}]`)
 
 	expectedMainEndpoint := Endpoint{
-		APIKey:           "123",
-		Host:             "agent-http-intake.logs.datadoghq.com",
-		Port:             443,
-		UseSSL:           true,
-		UseCompression:   true,
-		CompressionLevel: 6,
-		BackoffFactor:    3,
-		BackoffBase:      1.0,
-		BackoffMax:       2.0,
-		RecoveryInterval: 10,
-		RecoveryReset:    true,
-		Version:          EPIntakeVersion1,
+		APIKey:               "123",
+		Host:                 "agent-http-intake.logs.datadoghq.com",
+		Port:                 443,
+		UseSSL:               true,
+		UseCompression:       true,
+		CompressionLevel:     6,
+		CompressionKind:      "gzip",
+		ZstdCompressionLevel: 1,
+		BackoffFactor:        3,
+		BackoffBase:          1.0,
+		BackoffMax:           2.0,
+		RecoveryInterval:     10,
+		RecoveryReset:        true,
+		Version:              EPIntakeVersion1,
 	}
 	expectedAdditionalEndpoint1 := Endpoint{
 		APIKey:           "456",
@@ -258,22 +283,25 @@ func (suite *ConfigTestSuite) TestMultipleHttpEndpointsInConfig() {
 			"host":              "additional.endpoint.2",
 			"port":              1234,
 			"use_compression":   true,
-			"compression_level": 2},
+			"compression_level": 2,
+			"compression_kind":  "zstd"},
 	}
 	suite.config.Set("logs_config.additional_endpoints", endpointsInConfig)
 
 	expectedMainEndpoint := Endpoint{
-		APIKey:           "123",
-		Host:             "agent-http-intake.logs.datadoghq.com",
-		Port:             443,
-		UseSSL:           true,
-		UseCompression:   true,
-		CompressionLevel: 6,
-		BackoffFactor:    coreConfig.DefaultLogsSenderBackoffFactor,
-		BackoffBase:      coreConfig.DefaultLogsSenderBackoffBase,
-		BackoffMax:       coreConfig.DefaultLogsSenderBackoffMax,
-		RecoveryInterval: coreConfig.DefaultLogsSenderBackoffRecoveryInterval,
-		Version:          EPIntakeVersion1,
+		APIKey:               "123",
+		Host:                 "agent-http-intake.logs.datadoghq.com",
+		Port:                 443,
+		UseSSL:               true,
+		UseCompression:       true,
+		CompressionLevel:     6,
+		CompressionKind:      "gzip",
+		ZstdCompressionLevel: 1,
+		BackoffFactor:        coreConfig.DefaultLogsSenderBackoffFactor,
+		BackoffBase:          coreConfig.DefaultLogsSenderBackoffBase,
+		BackoffMax:           coreConfig.DefaultLogsSenderBackoffMax,
+		RecoveryInterval:     coreConfig.DefaultLogsSenderBackoffRecoveryInterval,
+		Version:              EPIntakeVersion1,
 	}
 	expectedAdditionalEndpoint1 := Endpoint{
 		APIKey:           "456",
@@ -295,6 +323,7 @@ func (suite *ConfigTestSuite) TestMultipleHttpEndpointsInConfig() {
 		UseSSL:           true,
 		UseCompression:   true,
 		CompressionLevel: 6,
+		CompressionKind:  "zstd",
 		BackoffFactor:    coreConfig.DefaultLogsSenderBackoffFactor,
 		BackoffBase:      coreConfig.DefaultLogsSenderBackoffBase,
 		BackoffMax:       coreConfig.DefaultLogsSenderBackoffMax,
@@ -335,20 +364,22 @@ func (suite *ConfigTestSuite) TestMultipleHttpEndpointsInConfig2() {
 	suite.config.Set("logs_config.additional_endpoints", endpointsInConfig)
 
 	expectedMainEndpoint := Endpoint{
-		APIKey:           "123",
-		Host:             "agent-http-intake.logs.datadoghq.com",
-		Port:             443,
-		UseSSL:           true,
-		UseCompression:   true,
-		CompressionLevel: 6,
-		BackoffFactor:    coreConfig.DefaultLogsSenderBackoffFactor,
-		BackoffBase:      coreConfig.DefaultLogsSenderBackoffBase,
-		BackoffMax:       coreConfig.DefaultLogsSenderBackoffMax,
-		RecoveryInterval: coreConfig.DefaultLogsSenderBackoffRecoveryInterval,
-		Version:          EPIntakeVersion2,
-		TrackType:        "test-track",
-		Protocol:         "test-proto",
-		Origin:           "test-source",
+		APIKey:               "123",
+		Host:                 "agent-http-intake.logs.datadoghq.com",
+		Port:                 443,
+		UseSSL:               true,
+		UseCompression:       true,
+		CompressionLevel:     6,
+		CompressionKind:      "gzip",
+		ZstdCompressionLevel: 1,
+		BackoffFactor:        coreConfig.DefaultLogsSenderBackoffFactor,
+		BackoffBase:          coreConfig.DefaultLogsSenderBackoffBase,
+		BackoffMax:           coreConfig.DefaultLogsSenderBackoffMax,
+		RecoveryInterval:     coreConfig.DefaultLogsSenderBackoffRecoveryInterval,
+		Version:              EPIntakeVersion2,
+		TrackType:            "test-track",
+		Protocol:             "test-proto",
+		Origin:               "test-source",
 	}
 	expectedAdditionalEndpoint1 := Endpoint{
 		APIKey:           "456",
@@ -438,20 +469,22 @@ func (suite *ConfigTestSuite) TestEndpointsSetLogsDDUrl() {
 	suite.Nil(err)
 
 	main := Endpoint{
-		APIKey:           "123",
-		Host:             "my-proxy",
-		Port:             443,
-		UseSSL:           true,
-		UseCompression:   true,
-		CompressionLevel: 6,
-		BackoffFactor:    coreConfig.DefaultLogsSenderBackoffFactor,
-		BackoffBase:      coreConfig.DefaultLogsSenderBackoffBase,
-		BackoffMax:       coreConfig.DefaultLogsSenderBackoffMax,
-		RecoveryInterval: coreConfig.DefaultLogsSenderBackoffRecoveryInterval,
-		Version:          EPIntakeVersion2,
-		TrackType:        "test-track",
-		Protocol:         "test-proto",
-		Origin:           "test-source",
+		APIKey:               "123",
+		Host:                 "my-proxy",
+		Port:                 443,
+		UseSSL:               true,
+		UseCompression:       true,
+		CompressionLevel:     6,
+		CompressionKind:      "gzip",
+		ZstdCompressionLevel: 1,
+		BackoffFactor:        coreConfig.DefaultLogsSenderBackoffFactor,
+		BackoffBase:          coreConfig.DefaultLogsSenderBackoffBase,
+		BackoffMax:           coreConfig.DefaultLogsSenderBackoffMax,
+		RecoveryInterval:     coreConfig.DefaultLogsSenderBackoffRecoveryInterval,
+		Version:              EPIntakeVersion2,
+		TrackType:            "test-track",
+		Protocol:             "test-proto",
+		Origin:               "test-source",
 	}
 
 	expectedEndpoints := &Endpoints{
@@ -482,20 +515,22 @@ func (suite *ConfigTestSuite) TestEndpointsSetDDSite() {
 	suite.Nil(err)
 
 	main := Endpoint{
-		APIKey:           "123",
-		Host:             "default-intake.logs.mydomain.com",
-		Port:             0,
-		UseSSL:           true,
-		UseCompression:   true,
-		CompressionLevel: 6,
-		BackoffFactor:    coreConfig.DefaultLogsSenderBackoffFactor,
-		BackoffBase:      coreConfig.DefaultLogsSenderBackoffBase,
-		BackoffMax:       coreConfig.DefaultLogsSenderBackoffMax,
-		RecoveryInterval: coreConfig.DefaultLogsSenderBackoffRecoveryInterval,
-		Version:          EPIntakeVersion2,
-		TrackType:        "test-track",
-		Origin:           "test-source",
-		Protocol:         "test-proto",
+		APIKey:               "123",
+		Host:                 "default-intake.logs.mydomain.com",
+		Port:                 0,
+		UseSSL:               true,
+		UseCompression:       true,
+		CompressionLevel:     6,
+		CompressionKind:      "gzip",
+		ZstdCompressionLevel: 1,
+		BackoffFactor:        coreConfig.DefaultLogsSenderBackoffFactor,
+		BackoffBase:          coreConfig.DefaultLogsSenderBackoffBase,
+		BackoffMax:           coreConfig.DefaultLogsSenderBackoffMax,
+		RecoveryInterval:     coreConfig.DefaultLogsSenderBackoffRecoveryInterval,
+		Version:              EPIntakeVersion2,
+		TrackType:            "test-track",
+		Origin:               "test-source",
+		Protocol:             "test-proto",
 	}
 
 	expectedEndpoints := &Endpoints{
@@ -518,20 +553,22 @@ func (suite *ConfigTestSuite) TestBuildServerlessEndpoints() {
 	suite.config.Set("logs_config.batch_wait", 1)
 
 	main := Endpoint{
-		APIKey:           "123",
-		Host:             "http-intake.logs.datadoghq.com",
-		Port:             0,
-		UseSSL:           true,
-		UseCompression:   true,
-		CompressionLevel: 6,
-		BackoffFactor:    coreConfig.DefaultLogsSenderBackoffFactor,
-		BackoffBase:      coreConfig.DefaultLogsSenderBackoffBase,
-		BackoffMax:       coreConfig.DefaultLogsSenderBackoffMax,
-		RecoveryInterval: coreConfig.DefaultLogsSenderBackoffRecoveryInterval,
-		Version:          EPIntakeVersion2,
-		TrackType:        "test-track",
-		Origin:           "lambda-extension",
-		Protocol:         "test-proto",
+		APIKey:               "123",
+		Host:                 "http-intake.logs.datadoghq.com",
+		Port:                 0,
+		UseSSL:               true,
+		UseCompression:       true,
+		CompressionLevel:     6,
+		CompressionKind:      "gzip",
+		ZstdCompressionLevel: 1,
+		BackoffFactor:        coreConfig.DefaultLogsSenderBackoffFactor,
+		BackoffBase:          coreConfig.DefaultLogsSenderBackoffBase,
+		BackoffMax:           coreConfig.DefaultLogsSenderBackoffMax,
+		RecoveryInterval:     coreConfig.DefaultLogsSenderBackoffRecoveryInterval,
+		Version:              EPIntakeVersion2,
+		TrackType:            "test-track",
+		Origin:               "lambda-extension",
+		Protocol:             "test-proto",
 	}
 
 	expectedEndpoints := &Endpoints{
@@ -553,20 +590,22 @@ func (suite *ConfigTestSuite) TestBuildServerlessEndpoints() {
 
 func getTestEndpoint(host string, port int, ssl bool) Endpoint {
 	return Endpoint{
-		APIKey:           "123",
-		Host:             host,
-		Port:             port,
-		UseSSL:           ssl,
-		UseCompression:   true,
-		CompressionLevel: 6,
-		BackoffFactor:    coreConfig.DefaultLogsSenderBackoffFactor,
-		BackoffBase:      coreConfig.DefaultLogsSenderBackoffBase,
-		BackoffMax:       coreConfig.DefaultLogsSenderBackoffMax,
-		RecoveryInterval: coreConfig.DefaultLogsSenderBackoffRecoveryInterval,
-		Version:          EPIntakeVersion2,
-		TrackType:        "test-track",
-		Protocol:         "test-proto",
-		Origin:           "test-source",
+		APIKey:               "123",
+		Host:                 host,
+		Port:                 port,
+		UseSSL:               ssl,
+		UseCompression:       true,
+		CompressionLevel:     6,
+		CompressionKind:      "gzip",
+		ZstdCompressionLevel: 1,
+		BackoffFactor:        coreConfig.DefaultLogsSenderBackoffFactor,
+		BackoffBase:          coreConfig.DefaultLogsSenderBackoffBase,
+		BackoffMax:           coreConfig.DefaultLogsSenderBackoffMax,
+		RecoveryInterval:     coreConfig.DefaultLogsSenderBackoffRecoveryInterval,
+		Version:              EPIntakeVersion2,
+		TrackType:            "test-track",
+		Protocol:             "test-proto",
+		Origin:               "test-source",
 	}
 }
 
@@ -644,6 +683,8 @@ func (suite *ConfigTestSuite) TestEndpointsSetNonDefaultCustomConfigs() {
 
 	suite.config.Set("network_devices.netflow.forwarder.use_compression", false)
 	suite.config.Set("network_devices.netflow.forwarder.compression_level", 10)
+	suite.config.Set("network_devices.netflow.forwarder.compression_kind", "zstd")
+	suite.config.Set("network_devices.netflow.forwarder.zstd_compression_level", 3)
 	suite.config.Set("network_devices.netflow.forwarder.batch_wait", 10)
 	suite.config.Set("network_devices.netflow.forwarder.connection_reset_interval", 3)
 	suite.config.Set("network_devices.netflow.forwarder.logs_no_ssl", true)
@@ -670,6 +711,8 @@ func (suite *ConfigTestSuite) TestEndpointsSetNonDefaultCustomConfigs() {
 		UseSSL:                  true,
 		UseCompression:          false,
 		CompressionLevel:        10,
+		CompressionKind:         "zstd",
+		ZstdCompressionLevel:    3,
 		BackoffFactor:           4,
 		BackoffBase:             2,
 		BackoffMax:              150,
@@ -696,3 +739,23 @@ func (suite *ConfigTestSuite) TestEndpointsSetNonDefaultCustomConfigs() {
 	suite.Nil(err)
 	suite.Equal(expectedEndpoints, endpoints)
 }
+
+func (suite *ConfigTestSuite) TestEndpointsDiskBuffer() {
+	suite.config.Set("api_key", "123")
+
+	endpoints, err := BuildHTTPEndpoints("test-track", "test-proto", "test-source")
+	suite.Nil(err)
+	suite.Nil(endpoints.DiskBuffer)
+
+	suite.config.Set("logs_config.run_path", "/opt/datadog-agent/run")
+	suite.config.Set("logs_config.storage_max_size_in_bytes", 1024)
+	endpoints, err = BuildHTTPEndpoints("test-track", "test-proto", "test-source")
+	suite.Nil(err)
+	suite.Equal(&DiskBuffer{Path: "/opt/datadog-agent/run/logs_to_retry", MaxSizeInBytes: 1024, MaxDiskRatio: 0.8}, endpoints.DiskBuffer)
+
+	suite.config.Set("logs_config.storage_path", "/tmp/logs")
+	suite.config.Set("logs_config.storage_max_disk_ratio", 0.5)
+	endpoints, err = BuildHTTPEndpoints("test-track", "test-proto", "test-source")
+	suite.Nil(err)
+	suite.Equal(&DiskBuffer{Path: "/tmp/logs", MaxSizeInBytes: 1024, MaxDiskRatio: 0.5}, endpoints.DiskBuffer)
+}
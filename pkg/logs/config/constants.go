@@ -16,3 +16,11 @@ const (
 	// DateFormat is the default date format.
 	DateFormat = "2006-01-02T15:04:05.000000000Z"
 )
+
+// Compression algorithms of the HTTP payloads
+const (
+	// GzipCompressionKind compresses the payloads with gzip
+	GzipCompressionKind = "gzip"
+	// ZstdCompressionKind compresses the payloads with zstd
+	ZstdCompressionKind = "zstd"
+)
@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 // EPIntakeVersion is the events platform intake API version
@@ -38,8 +40,10 @@ type Endpoint struct {
 	Host                    string
 	Port                    int
 	UseSSL                  bool
-	UseCompression          bool `mapstructure:"use_compression" json:"use_compression"`
-	CompressionLevel        int  `mapstructure:"compression_level" json:"compression_level"`
+	UseCompression          bool   `mapstructure:"use_compression" json:"use_compression"`
+	CompressionLevel        int    `mapstructure:"compression_level" json:"compression_level"`
+	CompressionKind         string `mapstructure:"compression_kind" json:"compression_kind"`
+	ZstdCompressionLevel    int    `mapstructure:"zstd_compression_level" json:"zstd_compression_level"`
 	ProxyAddress            string
 	IsReliable              *bool `mapstructure:"is_reliable" json:"is_reliable"`
 	ConnectionResetInterval time.Duration
@@ -93,6 +97,21 @@ func (e *Endpoint) GetStatus(prefix string, useHTTP bool) string {
 	return fmt.Sprintf("%sSending %s logs in %s to %s on port %d", prefix, compression, protocol, host, port)
 }
 
+// GetCodec returns the codec compressing the payloads sent to the endpoint, nil if they are not compressed.
+func (e *Endpoint) GetCodec() compression.Codec {
+	if !e.UseCompression {
+		return nil
+	}
+	switch e.CompressionKind {
+	case ZstdCompressionKind:
+		return compression.NewZstdCodec(e.ZstdCompressionLevel)
+	case GzipCompressionKind, "":
+	default:
+		log.Warnf("Invalid compression kind %q for %s, using %q instead", e.CompressionKind, e.Host, GzipCompressionKind)
+	}
+	return compression.NewGzipCodec(e.CompressionLevel)
+}
+
 // GetIsReliable returns true if the endpoint is reliable. Endpoints are reliable by default.
 func (e *Endpoint) GetIsReliable() bool {
 	return e.IsReliable == nil || *e.IsReliable
@@ -109,6 +128,15 @@ type Endpoints struct {
 	BatchMaxSize           int
 	BatchMaxContentSize    int
 	InputChanSize          int
+	// Optional. Payloads are stored on disk while the reliable endpoints are unreachable when set.
+	DiskBuffer *DiskBuffer
+}
+
+// DiskBuffer holds the settings of the storage of the payloads on disk
+type DiskBuffer struct {
+	Path           string
+	MaxSizeInBytes int64
+	MaxDiskRatio   float64
 }
 
 // GetStatus returns the endpoints status, one line per endpoint
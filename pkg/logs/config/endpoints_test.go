@@ -482,6 +482,13 @@ func (suite *EndpointsTestSuite) TestIsReliableDefaultTrue() {
 	suite.Len(endpoints.GetReliableEndpoints(), 3)
 }
 
+func (suite *EndpointsTestSuite) TestGetCodec() {
+	suite.Nil((&Endpoint{}).GetCodec())
+	suite.Equal("gzip", (&Endpoint{UseCompression: true}).GetCodec().ContentEncoding())
+	suite.Equal("gzip", (&Endpoint{UseCompression: true, CompressionKind: "unknown"}).GetCodec().ContentEncoding())
+	suite.Equal("zstd", (&Endpoint{UseCompression: true, CompressionKind: "zstd"}).GetCodec().ContentEncoding())
+}
+
 func TestEndpointsTestSuite(t *testing.T) {
 	suite.Run(t, new(EndpointsTestSuite))
 }
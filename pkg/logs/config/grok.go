@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// grokPatterns are the patterns that can be referenced as %{PATTERN} or %{PATTERN:field}
+// in the pattern of an extract_fields processing rule.
+var grokPatterns = map[string]string{
+	"WORD":              `\b\w+\b`,
+	"NOTSPACE":          `\S+`,
+	"SPACE":             `\s*`,
+	"DATA":              `.*?`,
+	"GREEDYDATA":        `.*`,
+	"INT":               `[+-]?\d+`,
+	"NUMBER":            `[+-]?(?:\d+(?:\.\d*)?|\.\d+)`,
+	"IPV4":              `(?:\d{1,3}\.){3}\d{1,3}`,
+	"IPV6":              `[0-9A-Fa-f:]*:[0-9A-Fa-f:.]+`,
+	"IP":                `(?:(?:\d{1,3}\.){3}\d{1,3}|[0-9A-Fa-f:]*:[0-9A-Fa-f:.]+)`,
+	"HOSTNAME":          `\b[0-9A-Za-z][0-9A-Za-z-]{0,62}(?:\.[0-9A-Za-z][0-9A-Za-z-]{0,62})*\.?\b`,
+	"UUID":              `[A-Fa-f0-9]{8}-(?:[A-Fa-f0-9]{4}-){3}[A-Fa-f0-9]{12}`,
+	"QUOTEDSTRING":      `"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'`,
+	"LOGLEVEL":          `(?i:trace|debug|info|notice|warn(?:ing)?|err(?:or)?|crit(?:ical)?|fatal|severe|emerg(?:ency)?|alert)`,
+	"TIMESTAMP_ISO8601": `\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}(?::\d{2}(?:[.,]\d+)?)?(?:Z|[+-]\d{2}:?\d{2})?`,
+}
+
+// grokReferenceRegex matches the %{PATTERN} and %{PATTERN:field} references.
+var grokReferenceRegex = regexp.MustCompile(`%\{(\w+)(?::(\w+))?\}`)
+
+// compileExtractFieldsPattern compiles the pattern of an extract_fields processing rule.
+// The pattern is a regular expression that can contain named capture groups and
+// references to the grok patterns, a reference with a field name is turned into
+// a named capture group.
+func compileExtractFieldsPattern(pattern string) (*regexp.Regexp, error) {
+	var err error
+	expanded := grokReferenceRegex.ReplaceAllStringFunc(pattern, func(reference string) string {
+		parts := grokReferenceRegex.FindStringSubmatch(reference)
+		grokPattern, found := grokPatterns[parts[1]]
+		if !found {
+			if err == nil {
+				err = fmt.Errorf("unknown grok pattern %s", parts[1])
+			}
+			return reference
+		}
+		if parts[2] == "" {
+			return "(?:" + grokPattern + ")"
+		}
+		return "(?P<" + parts[2] + ">" + grokPattern + ")"
+	})
+	if err != nil {
+		return nil, err
+	}
+	return regexp.Compile(expanded)
+}
@@ -24,6 +24,9 @@ const (
 	WindowsEventType  = "windows_event"
 	StringChannelType = "string_channel"
 
+	// SyslogParser parses the messages of network sources as syslog messages
+	SyslogParser = "syslog"
+
 	// UTF16BE for UTF-16 Big endian encoding
 	UTF16BE string = "utf-16-be"
 	// UTF16LE for UTF-16 Little Endian encoding
@@ -39,11 +42,13 @@ type LogsConfig struct {
 
 	Port        int    // Network
 	IdleTimeout string `mapstructure:"idle_timeout" json:"idle_timeout"` // Network
+	Parser      string // Network
 	Path        string // File, Journald
 
-	Encoding     string   `mapstructure:"encoding" json:"encoding"`             // File
-	ExcludePaths []string `mapstructure:"exclude_paths" json:"exclude_paths"`   // File
-	TailingMode  string   `mapstructure:"start_position" json:"start_position"` // File
+	Encoding            string   `mapstructure:"encoding" json:"encoding"`                           // File
+	ExcludePaths        []string `mapstructure:"exclude_paths" json:"exclude_paths"`                 // File
+	TailingMode         string   `mapstructure:"start_position" json:"start_position"`               // File
+	ReadCompressedFiles bool     `mapstructure:"read_compressed_files" json:"read_compressed_files"` // File
 
 	ConfigId           string   `mapstructure:"config_id" json:"config_id"`                   // Journald
 	IncludeSystemUnits []string `mapstructure:"include_units" json:"include_units"`           // Journald
@@ -107,15 +112,18 @@ func (c *LogsConfig) Dump(multiline bool) string {
 	case TCPType:
 		fmt.Fprintf(&b, ws("Port: %d,"), c.Port)
 		fmt.Fprintf(&b, ws("IdleTimeout: %#v,"), c.IdleTimeout)
+		fmt.Fprintf(&b, ws("Parser: %#v,"), c.Parser)
 	case UDPType:
 		fmt.Fprintf(&b, ws("Port: %d,"), c.Port)
 		fmt.Fprintf(&b, ws("IdleTimeout: %#v,"), c.IdleTimeout)
+		fmt.Fprintf(&b, ws("Parser: %#v,"), c.Parser)
 	case FileType:
 		fmt.Fprintf(&b, ws("Path: %#v,"), c.Path)
 		fmt.Fprintf(&b, ws("Encoding: %#v,"), c.Encoding)
 		fmt.Fprintf(&b, ws("Identifier: %#v,"), c.Identifier)
 		fmt.Fprintf(&b, ws("ExcludePaths: %#v,"), c.ExcludePaths)
 		fmt.Fprintf(&b, ws("TailingMode: %#v,"), c.TailingMode)
+		fmt.Fprintf(&b, ws("ReadCompressedFiles: %t,"), c.ReadCompressedFiles)
 	case DockerType, ContainerdType:
 		fmt.Fprintf(&b, ws("Image: %#v,"), c.Image)
 		fmt.Fprintf(&b, ws("Label: %#v,"), c.Label)
@@ -213,6 +221,8 @@ func (c *LogsConfig) Validate() error {
 		return fmt.Errorf("tcp source must have a port")
 	case c.Type == UDPType && c.Port == 0:
 		return fmt.Errorf("udp source must have a port")
+	case c.Parser != "" && c.Parser != SyslogParser:
+		return fmt.Errorf("invalid parser '%v'", c.Parser)
 	}
 	err := ValidateProcessingRules(c.ProcessingRules)
 	if err != nil {
@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// Log metric types
+const (
+	CountMetric        = "count"
+	DistributionMetric = "distribution"
+)
+
+// LogMetric defines a metric generated from the logs matching its source and pattern
+type LogMetric struct {
+	Name string
+	Type string
+	// Source restricts the metric to the logs with this source, all the logs are matched when empty.
+	Source string
+	// Pattern is matched against the raw message, or the structured attribute set in Field.
+	// All the logs are matched when empty. Its named capture groups can be used in Value and GroupBy.
+	Pattern string
+	Field   string
+	// Value is the named capture group or the structured attribute holding the value of a distribution.
+	Value string
+	// GroupBy lists the named capture groups or the structured attributes whose values are added as tags.
+	GroupBy []string `mapstructure:"group_by" json:"group_by"`
+	Tags    []string
+	// TODO: should be moved out
+	Regex *regexp.Regexp
+}
+
+// GlobalLogMetrics returns the metrics to generate from all logs.
+func GlobalLogMetrics() ([]*LogMetric, error) {
+	var logMetrics []*LogMetric
+	var err error
+	raw := coreConfig.Datadog.Get("logs_config.generate_metrics")
+	if raw == nil {
+		return logMetrics, nil
+	}
+	if s, ok := raw.(string); ok && s != "" {
+		err = json.Unmarshal([]byte(s), &logMetrics)
+	} else {
+		err = coreConfig.Datadog.UnmarshalKey("logs_config.generate_metrics", &logMetrics)
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = ValidateLogMetrics(logMetrics)
+	if err != nil {
+		return nil, err
+	}
+	err = CompileLogMetrics(logMetrics)
+	if err != nil {
+		return nil, err
+	}
+	return logMetrics, nil
+}
+
+// ValidateLogMetrics validates the log metrics and raises an error if one is misconfigured.
+// Each log metric must have:
+// - a valid name
+// - a valid type
+// - a valid pattern that compiles, if any
+// - a value when it is a distribution
+func ValidateLogMetrics(logMetrics []*LogMetric) error {
+	for _, logMetric := range logMetrics {
+		if logMetric.Name == "" {
+			return fmt.Errorf("all log metrics must have a name")
+		}
+
+		switch logMetric.Type {
+		case CountMetric:
+			break
+		case DistributionMetric:
+			if logMetric.Value == "" {
+				return fmt.Errorf("value must be set for distribution log metric `%s`", logMetric.Name)
+			}
+		case "":
+			return fmt.Errorf("type must be set for log metric `%s`", logMetric.Name)
+		default:
+			return fmt.Errorf("type %s is not supported for log metric `%s`", logMetric.Type, logMetric.Name)
+		}
+
+		if logMetric.Pattern == "" {
+			continue
+		}
+		if _, err := compileExtractFieldsPattern(logMetric.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern %s for log metric: %s: %v", logMetric.Pattern, logMetric.Name, err)
+		}
+	}
+	return nil
+}
+
+// CompileLogMetrics compiles all log metric regular expressions.
+func CompileLogMetrics(logMetrics []*LogMetric) error {
+	for _, logMetric := range logMetrics {
+		if logMetric.Pattern == "" {
+			continue
+		}
+		re, err := compileExtractFieldsPattern(logMetric.Pattern)
+		if err != nil {
+			return err
+		}
+		logMetric.Regex = re
+	}
+	return nil
+}
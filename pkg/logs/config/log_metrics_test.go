@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateLogMetrics(t *testing.T) {
+	validLogMetrics := []*LogMetric{
+		{Name: "count", Type: CountMetric},
+		{Name: "count with pattern", Type: CountMetric, Source: "nginx", Pattern: "status=5\\d\\d"},
+		{Name: "grok", Type: DistributionMetric, Pattern: "took %{NUMBER:duration}ms", Value: "duration"},
+		{Name: "field", Type: DistributionMetric, Field: "http_status", Pattern: "5..", Value: "duration"},
+	}
+	for _, logMetric := range validLogMetrics {
+		assert.Nil(t, ValidateLogMetrics([]*LogMetric{logMetric}), logMetric.Name)
+	}
+
+	invalidLogMetrics := []*LogMetric{
+		{Type: CountMetric},
+		{Name: "no type"},
+		{Name: "unknown type", Type: "gauge"},
+		{Name: "no value", Type: DistributionMetric},
+		{Name: "invalid pattern", Type: CountMetric, Pattern: "(?=abf)"},
+		{Name: "unknown grok pattern", Type: CountMetric, Pattern: "%{UNKNOWN:foo}"},
+	}
+	for _, logMetric := range invalidLogMetrics {
+		assert.NotNil(t, ValidateLogMetrics([]*LogMetric{logMetric}), logMetric.Name)
+	}
+}
+
+func TestCompileLogMetrics(t *testing.T) {
+	logMetrics := []*LogMetric{
+		{Name: "all", Type: CountMetric},
+		{Name: "grok", Type: DistributionMetric, Pattern: "took %{NUMBER:duration}ms", Value: "duration"},
+	}
+	assert.Nil(t, CompileLogMetrics(logMetrics))
+	assert.Nil(t, logMetrics[0].Regex)
+	assert.Equal(t, []string{"", "duration"}, logMetrics[1].Regex.SubexpNames())
+	assert.Equal(t, []string{"took 3.5ms", "3.5"}, logMetrics[1].Regex.FindStringSubmatch("took 3.5ms"))
+}
@@ -12,10 +12,13 @@ import (
 
 // Processing rule types
 const (
-	ExcludeAtMatch = "exclude_at_match"
-	IncludeAtMatch = "include_at_match"
-	MaskSequences  = "mask_sequences"
-	MultiLine      = "multi_line"
+	ExcludeAtMatch   = "exclude_at_match"
+	IncludeAtMatch   = "include_at_match"
+	MaskSequences    = "mask_sequences"
+	MultiLine        = "multi_line"
+	ExtractFields    = "extract_fields"
+	SampleAtMatch    = "sample_at_match"
+	RateLimitAtMatch = "rate_limit_at_match"
 )
 
 // ProcessingRule defines an exclusion or a masking rule to
@@ -25,6 +28,13 @@ type ProcessingRule struct {
 	Name               string
 	ReplacePlaceholder string `mapstructure:"replace_placeholder" json:"replace_placeholder"`
 	Pattern            string
+	// Field is the structured attribute the rule applies to instead of the raw message.
+	// Not supported by the multi_line and extract_fields rules.
+	Field string
+	// KeepOneIn is the sampling of the sample_at_match rule, one matching message out of KeepOneIn is kept.
+	KeepOneIn int `mapstructure:"keep_one_in" json:"keep_one_in"`
+	// MaxPerSecond is the maximum number of matching messages per second kept by the rate_limit_at_match rule.
+	MaxPerSecond float64 `mapstructure:"max_per_second" json:"max_per_second"`
 	// TODO: should be moved out
 	Regex       *regexp.Regexp
 	Placeholder []byte
@@ -42,17 +52,41 @@ func ValidateProcessingRules(rules []*ProcessingRule) error {
 		}
 
 		switch rule.Type {
-		case ExcludeAtMatch, IncludeAtMatch, MaskSequences, MultiLine:
+		case ExcludeAtMatch, IncludeAtMatch, MaskSequences, MultiLine, ExtractFields:
 			break
+		case SampleAtMatch:
+			if rule.KeepOneIn < 1 {
+				return fmt.Errorf("keep_one_in must be greater than 0 for processing rule `%s`", rule.Name)
+			}
+		case RateLimitAtMatch:
+			if rule.MaxPerSecond <= 0 {
+				return fmt.Errorf("max_per_second must be greater than 0 for processing rule `%s`", rule.Name)
+			}
 		case "":
 			return fmt.Errorf("type must be set for processing rule `%s`", rule.Name)
 		default:
 			return fmt.Errorf("type %s is not supported for processing rule `%s`", rule.Type, rule.Name)
 		}
 
+		if rule.Field != "" && (rule.Type == MultiLine || rule.Type == ExtractFields) {
+			return fmt.Errorf("field is not supported by the %s processing rule: %s", rule.Type, rule.Name)
+		}
+
 		if rule.Pattern == "" {
 			return fmt.Errorf("no pattern provided for processing rule: %s", rule.Name)
 		}
+
+		if rule.Type == ExtractFields {
+			re, err := compileExtractFieldsPattern(rule.Pattern)
+			if err != nil {
+				return fmt.Errorf("invalid pattern %s for processing rule: %s: %v", rule.Pattern, rule.Name, err)
+			}
+			if !hasNamedGroup(re) {
+				return fmt.Errorf("pattern %s has no named capture group for processing rule: %s", rule.Pattern, rule.Name)
+			}
+			continue
+		}
+
 		_, err := regexp.Compile(rule.Pattern)
 		if err != nil {
 			return fmt.Errorf("invalid pattern %s for processing rule: %s", rule.Pattern, rule.Name)
@@ -64,12 +98,20 @@ func ValidateProcessingRules(rules []*ProcessingRule) error {
 // CompileProcessingRules compiles all processing rule regular expressions.
 func CompileProcessingRules(rules []*ProcessingRule) error {
 	for _, rule := range rules {
+		if rule.Type == ExtractFields {
+			re, err := compileExtractFieldsPattern(rule.Pattern)
+			if err != nil {
+				return err
+			}
+			rule.Regex = re
+			continue
+		}
 		re, err := regexp.Compile(rule.Pattern)
 		if err != nil {
 			return err
 		}
 		switch rule.Type {
-		case ExcludeAtMatch, IncludeAtMatch:
+		case ExcludeAtMatch, IncludeAtMatch, SampleAtMatch, RateLimitAtMatch:
 			rule.Regex = re
 		case MaskSequences:
 			rule.Regex = re
@@ -83,3 +125,13 @@ func CompileProcessingRules(rules []*ProcessingRule) error {
 	}
 	return nil
 }
+
+// hasNamedGroup returns whether the regular expression has at least one named capture group.
+func hasNamedGroup(re *regexp.Regexp) bool {
+	for _, name := range re.SubexpNames() {
+		if name != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -34,3 +34,39 @@ func TestCompileShouldFailWithInvalidRules(t *testing.T) {
 		assert.Nil(t, rule.Regex)
 	}
 }
+
+func TestCompileExtractFieldsRule(t *testing.T) {
+	rules := []*ProcessingRule{{Type: ExtractFields, Pattern: `user=%{NOTSPACE:user} status=%{INT:status}%{SPACE}(?P<rest>.*)`}}
+	err := CompileProcessingRules(rules)
+	assert.Nil(t, err)
+
+	match := rules[0].Regex.FindStringSubmatch("user=bob status=200 took 3ms")
+	assert.Equal(t, []string{"", "user", "status", "rest"}, rules[0].Regex.SubexpNames())
+	assert.Equal(t, []string{"user=bob status=200 took 3ms", "bob", "200", "took 3ms"}, match)
+}
+
+func TestValidateProcessingRules(t *testing.T) {
+	validRules := []*ProcessingRule{
+		{Name: "extract", Type: ExtractFields, Pattern: "user=(?P<user>\\w+)"},
+		{Name: "grok", Type: ExtractFields, Pattern: "%{WORD:level} %{GREEDYDATA:msg}"},
+		{Name: "field", Type: MaskSequences, Field: "user", Pattern: ".+"},
+		{Name: "sample", Type: SampleAtMatch, Pattern: "DEBUG", KeepOneIn: 10},
+		{Name: "rate limit", Type: RateLimitAtMatch, Pattern: "DEBUG", MaxPerSecond: 0.5},
+	}
+	for _, rule := range validRules {
+		assert.Nil(t, ValidateProcessingRules([]*ProcessingRule{rule}), rule.Name)
+	}
+
+	invalidRules := []*ProcessingRule{
+		{Name: "no named group", Type: ExtractFields, Pattern: "user=\\w+"},
+		{Name: "unknown grok pattern", Type: ExtractFields, Pattern: "%{UNKNOWN:user}"},
+		{Name: "invalid regex", Type: ExtractFields, Pattern: "(?P<user>"},
+		{Name: "field on extract", Type: ExtractFields, Field: "user", Pattern: "(?P<user>.*)"},
+		{Name: "field on multi line", Type: MultiLine, Field: "user", Pattern: "\\d+"},
+		{Name: "no sampling", Type: SampleAtMatch, Pattern: "DEBUG"},
+		{Name: "no rate", Type: RateLimitAtMatch, Pattern: "DEBUG", MaxPerSecond: -1},
+	}
+	for _, rule := range invalidRules {
+		assert.NotNil(t, ValidateProcessingRules([]*ProcessingRule{rule}), rule.Name)
+	}
+}
@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"sync"
+	"time"
+)
+
+// RuleLimiter decides which messages matching a sample_at_match or a rate_limit_at_match
+// processing rule are kept. It is safe for concurrent use, as messages of a same
+// source can be processed by several pipelines.
+type RuleLimiter struct {
+	mu sync.Mutex
+
+	// sampling
+	keepOneIn int
+	matched   uint64
+
+	// rate limiting, with a token bucket refilled at maxPerSecond tokens per second
+	maxPerSecond float64
+	tokens       float64
+	lastRefill   time.Time
+}
+
+// NewRuleLimiter returns a limiter for the given rule.
+func NewRuleLimiter(rule *ProcessingRule) *RuleLimiter {
+	limiter := &RuleLimiter{}
+	switch rule.Type {
+	case SampleAtMatch:
+		limiter.keepOneIn = rule.KeepOneIn
+	case RateLimitAtMatch:
+		limiter.maxPerSecond = rule.MaxPerSecond
+		limiter.tokens = limiter.burst()
+	}
+	return limiter
+}
+
+// Allow returns whether a matching message must be kept.
+func (l *RuleLimiter) Allow(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.keepOneIn > 0 {
+		l.matched++
+		return (l.matched-1)%uint64(l.keepOneIn) == 0
+	}
+	if l.maxPerSecond > 0 {
+		if !l.lastRefill.IsZero() {
+			l.tokens += now.Sub(l.lastRefill).Seconds() * l.maxPerSecond
+			if burst := l.burst(); l.tokens > burst {
+				l.tokens = burst
+			}
+		}
+		l.lastRefill = now
+		if l.tokens < 1 {
+			return false
+		}
+		l.tokens--
+		return true
+	}
+	return true
+}
+
+// burst returns the maximum number of messages kept at once, one second worth of messages
+// but at least one so that rates below one message per second keep some messages.
+func (l *RuleLimiter) burst() float64 {
+	if l.maxPerSecond < 1 {
+		return 1
+	}
+	return l.maxPerSecond
+}
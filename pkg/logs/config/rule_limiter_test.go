@@ -0,0 +1,53 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleLimiterSampling(t *testing.T) {
+	limiter := NewRuleLimiter(&ProcessingRule{Type: SampleAtMatch, KeepOneIn: 3})
+	now := time.Now()
+
+	var kept []bool
+	for i := 0; i < 7; i++ {
+		kept = append(kept, limiter.Allow(now))
+	}
+	assert.Equal(t, []bool{true, false, false, true, false, false, true}, kept)
+}
+
+func TestRuleLimiterRateLimiting(t *testing.T) {
+	limiter := NewRuleLimiter(&ProcessingRule{Type: RateLimitAtMatch, MaxPerSecond: 2})
+	now := time.Now()
+
+	assert.True(t, limiter.Allow(now))
+	assert.True(t, limiter.Allow(now))
+	assert.False(t, limiter.Allow(now))
+
+	// half a second refills one token
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, limiter.Allow(now))
+	assert.False(t, limiter.Allow(now))
+
+	// the bucket never holds more than a second worth of messages
+	now = now.Add(time.Minute)
+	assert.True(t, limiter.Allow(now))
+	assert.True(t, limiter.Allow(now))
+	assert.False(t, limiter.Allow(now))
+}
+
+func TestRuleLimiterRateLimitingBelowOnePerSecond(t *testing.T) {
+	limiter := NewRuleLimiter(&ProcessingRule{Type: RateLimitAtMatch, MaxPerSecond: 0.5})
+	now := time.Now()
+
+	assert.True(t, limiter.Allow(now))
+	assert.False(t, limiter.Allow(now.Add(time.Second)))
+	assert.True(t, limiter.Allow(now.Add(2*time.Second)))
+}
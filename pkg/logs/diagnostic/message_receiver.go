@@ -153,7 +153,14 @@ func formatMessage(m *message.Message, redactedMsg []byte) string {
 		ts = m.Timestamp
 	}
 
-	return fmt.Sprintf("Integration Name: %s | Type: %s | Status: %s | Timestamp: %s | Hostname: %s | Service: %s | Source: %s | Tags: %s | Message: %s\n",
+	// report the messages of the source dropped by the sampling and rate limiting rules,
+	// they never reach the message receiver.
+	var dropped string
+	if counter := m.Origin.LogSource.DroppedMessages; counter != nil && counter.Load() > 0 {
+		dropped = fmt.Sprintf(" | Dropped by processing rules: %d", counter.Load())
+	}
+
+	return fmt.Sprintf("Integration Name: %s | Type: %s | Status: %s | Timestamp: %s | Hostname: %s | Service: %s | Source: %s | Tags: %s%s | Message: %s\n",
 		m.Origin.LogSource.Name,
 		m.Origin.LogSource.Config.Type,
 		m.GetStatus(),
@@ -162,5 +169,6 @@ func formatMessage(m *message.Message, redactedMsg []byte) string {
 		m.Origin.Service(),
 		m.Origin.Source(),
 		m.Origin.TagsToString(),
+		dropped,
 		string(redactedMsg))
 }
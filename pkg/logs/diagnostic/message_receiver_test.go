@@ -213,6 +213,14 @@ func TestNoFilters(t *testing.T) {
 	readFilteredLines(t, b, &filters, 15)
 }
 
+func TestFormatMessageDroppedMessages(t *testing.T) {
+	msg := newMessage("test1", "a", "b", "service_a")
+	assert.NotContains(t, formatMessage(&msg, []byte("a")), "Dropped by processing rules")
+
+	msg.Origin.LogSource.RecordDroppedMessage()
+	assert.Contains(t, formatMessage(&msg, []byte("a")), "| Dropped by processing rules: 1 | Message: a")
+}
+
 func newMessage(name, typ, source, service string) message.Message {
 	cfg := &config.LogsConfig{
 		Type:    typ,
@@ -41,6 +41,11 @@ type Message struct {
 	RawDataLen         int
 	Timestamp          string
 	IngestionTimestamp int64
+	// Optional.
+	// The host, tags and attributes parsed from the line
+	Hostname   string
+	Tags       []string
+	Attributes map[string]string
 }
 
 // NewMessage returns a new output.
@@ -31,6 +31,7 @@ type MultiLineHandler struct {
 	linesLen          int
 	status            string
 	timestamp         string
+	firstLine         *Message
 	countInfo         *status.CountInfo
 	linesCombinedInfo *status.CountInfo
 	telemetryEnabled  bool
@@ -97,6 +98,9 @@ func (h *MultiLineHandler) process(message *Message) {
 		// the buffer already contains some data which means that
 		// the current line is not the first line of the message
 		h.buffer.Write(escapedLineFeed)
+	} else {
+		// the metadata parsed from the first line applies to the whole message
+		h.firstLine = message
 	}
 
 	if isTruncated {
@@ -134,6 +138,7 @@ func (h *MultiLineHandler) sendBuffer() {
 		h.linesLen = 0
 		h.linesCombined = 0
 		h.shouldTruncate = false
+		h.firstLine = nil
 	}()
 
 	data := bytes.TrimSpace(h.buffer.Bytes())
@@ -150,6 +155,12 @@ func (h *MultiLineHandler) sendBuffer() {
 			}
 		}
 
-		h.outputFn(NewMessage(content, h.status, h.linesLen, h.timestamp))
+		output := NewMessage(content, h.status, h.linesLen, h.timestamp)
+		if h.firstLine != nil {
+			output.Hostname = h.firstLine.Hostname
+			output.Tags = h.firstLine.Tags
+			output.Attributes = h.firstLine.Attributes
+		}
+		h.outputFn(output)
 	}
 }
@@ -101,6 +101,16 @@ func TestLineBreaking(t *testing.T) {
 		t.Run("one-byte chunks", test(framing, chunk(utf16, 1), lines, lens))
 	})
 
+	t.Run("Syslog", func(t *testing.T) {
+		syslog := []byte("<34>1 line1\n12 <34>1 line2\n<34>1 line3\n11 <34>1 \nline")
+		lines := []string{"<34>1 line1", "<34>1 line2\n", "<34>1 line3", "<34>1 \nline"}
+		lens := []int{12, 15, 12, 14}
+		framing := Syslog
+		t.Run("one chunk", test(framing, [][]byte{syslog}, lines, lens))
+		t.Run("split chunks", test(framing, [][]byte{syslog[:14], syslog[14:40], syslog[40:]}, lines, lens))
+		t.Run("not octet-counted", test(framing, [][]byte{[]byte("2022-01-01 line\n1a line\n")}, []string{"2022-01-01 line", "1a line"}, []int{16, 8}))
+	})
+
 	t.Run("UTF-16-BE", func(t *testing.T) {
 		utf16 := []byte("\x00l\x00i\x00n\x00e\x001\x00\n\x00l\x00i\x00n\x00e\x002\x00\n\x00l\x00i\x00n\x00e\x003\x00\n\x00l\x00i\x00n\x00e\x004\x00\n")
 		lines := []string{"\x00l\x00i\x00n\x00e\x001", "\x00l\x00i\x00n\x00e\x002", "\x00l\x00i\x00n\x00e\x003", "\x00l\x00i\x00n\x00e\x004"}
@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package framer
+
+import "strconv"
+
+// maxMsgLenDigits is the maximum number of digits of the length of an
+// octet-counted syslog message.
+const maxMsgLenDigits = 9
+
+// syslogMatcher matches syslog messages framed as described in RFC 6587: with
+// octet counting, where each message is prefixed by its length and a space
+// (`MSG-LEN SP SYSLOG-MSG`), or terminated by a newline.  The framing is
+// detected for each message: octet-counted messages start with a digit while
+// syslog messages start with `<`.
+type syslogMatcher struct {
+	// newLineMatcher matches the messages which are not octet-counted.
+	newLineMatcher oneByteNewLineMatcher
+}
+
+// FindFrame implements EndLineMatcher#FindFrame.
+func (s *syslogMatcher) FindFrame(buf []byte, seen int) ([]byte, int) {
+	if len(buf) == 0 || buf[0] < '1' || buf[0] > '9' {
+		return s.newLineMatcher.FindFrame(buf, seen)
+	}
+
+	for i := 1; i < len(buf); i++ {
+		switch {
+		case buf[i] == ' ':
+			msgLen, _ := strconv.Atoi(string(buf[:i]))
+			end := i + 1 + msgLen
+			if end > len(buf) {
+				// wait for the rest of the message
+				return nil, 0
+			}
+			return buf[i+1 : end], end
+		case buf[i] < '0' || buf[i] > '9' || i > maxMsgLenDigits:
+			// this is not a message length
+			return s.newLineMatcher.FindFrame(buf, seen)
+		}
+	}
+	return nil, 0
+}
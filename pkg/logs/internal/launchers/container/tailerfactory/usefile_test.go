@@ -41,6 +41,11 @@ func (r *fakeRegistry) GetTailingMode(identifier string) string {
 	panic("unused")
 }
 
+// GetFingerprint implements auditor.Registry#GetFingerprint.
+func (r *fakeRegistry) GetFingerprint(identifier string) string {
+	panic("unused")
+}
+
 func TestUseFile(t *testing.T) {
 	ctrs := containersorpods.LogContainers
 	pods := containersorpods.LogPods
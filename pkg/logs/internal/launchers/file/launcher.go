@@ -58,6 +58,9 @@ type Launcher struct {
 	// Feature flag defaulting to false, use `logs_config.validate_pod_container_id`.
 	validatePodContainerID bool
 	scanPeriod             time.Duration
+	// readArchives holds the paths of the archives read to completion, to not
+	// read them again before their completion is recorded in the registry.
+	readArchives map[string]bool
 }
 
 // NewLauncher returns a new launcher.
@@ -82,6 +85,7 @@ func NewLauncher(tailingLimit int, tailerSleepDuration time.Duration, validatePo
 		stop:                   make(chan struct{}),
 		validatePodContainerID: validatePodContainerID,
 		scanPeriod:             scanPeriod,
+		readArchives:           make(map[string]bool),
 	}
 }
 
@@ -156,6 +160,9 @@ func (s *Launcher) scan() {
 		scanKey := file.GetScanKey()
 		tailer, isTailed := s.tailers[scanKey]
 		if isTailed && tailer.IsFinished() {
+			if tailer.IsArchive() && tailer.IsArchiveCompleted() {
+				s.readArchives[file.Path] = true
+			}
 			// skip this tailer as it must be stopped
 			continue
 		}
@@ -210,6 +217,8 @@ func (s *Launcher) scan() {
 		}
 	}
 	log.Debugf("After starting new tailers, there are %d tailers running. Limit is %d.\n", tailersLen, s.tailingLimit)
+
+	s.pruneReadArchives()
 }
 
 // addSource keeps track of the new source and launch new tailers for this source.
@@ -290,13 +299,20 @@ func (s *Launcher) startNewTailer(file *tailer.File, m config.TailingMode) bool
 		return false
 	}
 
+	// check the archives already read before opening them to create their tailer
+	if s.readArchives[file.Path] || isArchiveCompleted(s.registry.GetOffset(file.Identifier())) {
+		log.Tracef("The archive %s has already been read", file.Path)
+		return false
+	}
+
 	tailer := s.createTailer(file, s.pipelineProvider.NextPipelineChan())
+	identifier := s.registryIdentifier(tailer)
 
 	var offset int64
 	var whence int
 	mode := s.handleTailingModeChange(tailer.Identifier(), m)
 
-	offset, whence, err := Position(s.registry, tailer.Identifier(), mode)
+	offset, whence, err := Position(s.registry, identifier, mode)
 	if err != nil {
 		log.Warnf("Could not recover offset for file with path %v: %v", file.Path, err)
 	}
@@ -312,6 +328,41 @@ func (s *Launcher) startNewTailer(file *tailer.File, m config.TailingMode) bool
 	return true
 }
 
+// registryIdentifier returns the identifier of the registry entry holding the offset from which the
+// tailer should resume. When the file is fingerprinted, the offset recorded for its content is used,
+// even if it was recorded under another path, and the offset recorded for the path is ignored if it
+// belonged to another file.
+func (s *Launcher) registryIdentifier(tailer *tailer.Tailer) string {
+	identifier := tailer.Identifier()
+	fingerprint := tailer.Fingerprint()
+	if fingerprint == "" {
+		return identifier
+	}
+	if fingerprintIdentifier := auditor.FingerprintIdentifier(fingerprint); s.registry.GetOffset(fingerprintIdentifier) != "" {
+		return fingerprintIdentifier
+	}
+	if recordedFingerprint := s.registry.GetFingerprint(identifier); recordedFingerprint != "" && recordedFingerprint != fingerprint {
+		log.Debugf("The offset recorded for %s belongs to another file, ignoring it", identifier)
+		return ""
+	}
+	return identifier
+}
+
+// pruneReadArchives forgets the archives read to completion which no longer exist.
+func (s *Launcher) pruneReadArchives() {
+	for path := range s.readArchives {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			delete(s.readArchives, path)
+		}
+	}
+}
+
+// isArchiveCompleted returns true if the offset recorded for an archive
+// indicates that it has been read to completion.
+func isArchiveCompleted(offset string) bool {
+	return offset == tailer.ArchiveCompletedOffset
+}
+
 // shouldIgnore resolves symlinks in /var/log/containers in order to use that redirection
 // to validate that we will be reading a file for the correct container.
 func (s *Launcher) shouldIgnore(file *tailer.File) bool {
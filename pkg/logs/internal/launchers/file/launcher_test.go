@@ -9,6 +9,7 @@
 package file
 
 import (
+	"compress/gzip"
 	"fmt"
 	"os"
 	"testing"
@@ -17,6 +18,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
 	auditor "github.com/DataDog/datadog-agent/pkg/logs/auditor/mock"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/launchers"
@@ -713,3 +715,86 @@ func TestLauncherFileDetectionSingleScan(t *testing.T) {
 func getScanKey(path string, source *sources.LogSource) string {
 	return filetailer.NewFile(path, source, false).GetScanKey()
 }
+
+func TestLauncherRegistryIdentifierWithFingerprint(t *testing.T) {
+	testDir := t.TempDir()
+	path := fmt.Sprintf("%s/test.log", testDir)
+	assert.Nil(t, os.WriteFile(path, []byte("hello world\n"), 0644))
+
+	launcher := NewLauncher(1, 20*time.Millisecond, false, 10*time.Second, "by_name")
+	launcher.pipelineProvider = mock.NewMockProvider()
+	registry := auditor.NewRegistry()
+	launcher.registry = registry
+	source := sources.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path})
+	file := filetailer.NewFile(path, source, false)
+
+	// fingerprinting is disabled, the path identifies the file
+	tailer := launcher.createTailer(file, launcher.pipelineProvider.NextPipelineChan())
+	assert.Equal(t, tailer.Identifier(), launcher.registryIdentifier(tailer))
+
+	coreConfig.Datadog.Set("logs_config.fingerprint_enabled", true)
+	coreConfig.Datadog.Set("logs_config.fingerprint_byte_count", 8)
+	defer coreConfig.Datadog.Set("logs_config.fingerprint_enabled", false)
+	tailer = launcher.createTailer(file, launcher.pipelineProvider.NextPipelineChan())
+	assert.NotEmpty(t, tailer.Fingerprint())
+
+	// nothing is known about the content of the file, the path identifies it
+	assert.Equal(t, tailer.Identifier(), launcher.registryIdentifier(tailer))
+
+	// the offset recorded for the path belongs to another file
+	registry.SetFingerprint("other")
+	assert.Equal(t, "", launcher.registryIdentifier(tailer))
+
+	// the content of the file has been tailed before, possibly under another path
+	registry.SetOffset("42")
+	assert.Equal(t, "fingerprint:"+tailer.Fingerprint(), launcher.registryIdentifier(tailer))
+}
+
+func TestLauncherReadsArchivesOnce(t *testing.T) {
+	testDir := t.TempDir()
+	path := fmt.Sprintf("%s/test.log.gz", testDir)
+	f, err := os.Create(path)
+	assert.Nil(t, err)
+	w := gzip.NewWriter(f)
+	_, err = w.Write([]byte("hello\nworld\n"))
+	assert.Nil(t, err)
+	assert.Nil(t, w.Close())
+	assert.Nil(t, f.Close())
+
+	launcher := NewLauncher(1, 20*time.Millisecond, false, 10*time.Second, "by_name")
+	launcher.pipelineProvider = mock.NewMockProvider()
+	registry := auditor.NewRegistry()
+	launcher.registry = registry
+	outputChan := launcher.pipelineProvider.NextPipelineChan()
+	source := sources.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: fmt.Sprintf("%s/*.gz", testDir), ReadCompressedFiles: true})
+
+	launcher.addSource(source)
+	assert.Equal(t, 1, len(launcher.tailers))
+	msg := <-outputChan
+	assert.Equal(t, "hello", string(msg.Content))
+	msg = <-outputChan
+	assert.Equal(t, "world", string(msg.Content))
+	assert.Equal(t, tailer.ArchiveCompletedOffset, msg.Origin.Offset)
+
+	archiveTailer := launcher.tailers[getScanKey(path, source)]
+	assert.Eventually(t, archiveTailer.IsFinished, 5*time.Second, 10*time.Millisecond)
+
+	// the archive is not read again, even before its completion is recorded
+	launcher.scan()
+	launcher.scan()
+	assert.Equal(t, 0, len(launcher.tailers))
+
+	// the completion of the archive is recorded in the registry
+	launcher = NewLauncher(1, 20*time.Millisecond, false, 10*time.Second, "by_name")
+	launcher.pipelineProvider = mock.NewMockProvider()
+	registry.SetOffset(tailer.ArchiveCompletedOffset)
+	launcher.registry = registry
+	launcher.addSource(source)
+	assert.Equal(t, 0, len(launcher.tailers))
+
+	// the archives read are forgotten once removed
+	launcher.readArchives[path] = true
+	assert.Nil(t, os.Remove(path))
+	launcher.scan()
+	assert.Empty(t, launcher.readArchives)
+}
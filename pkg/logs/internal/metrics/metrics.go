@@ -24,6 +24,11 @@ var (
 	// TlmLogsProcessed is the total number of processed logs.
 	TlmLogsProcessed = telemetry.NewCounter("logs", "processed",
 		nil, "Total number of processed logs")
+	// LogsDroppedByProcessingRules is the total number of logs dropped by the sampling and rate limiting processing rules.
+	LogsDroppedByProcessingRules = expvar.Int{}
+	// TlmLogsDroppedByProcessingRules is the total number of logs dropped by the sampling and rate limiting processing rules.
+	TlmLogsDroppedByProcessingRules = telemetry.NewCounter("logs", "dropped_by_processing_rules",
+		nil, "Total number of logs dropped by the sampling and rate limiting processing rules")
 
 	// LogsSent is the total number of sent logs.
 	LogsSent = expvar.Int{}
@@ -66,6 +71,7 @@ func init() {
 	LogsExpvars = expvar.NewMap("logs-agent")
 	LogsExpvars.Set("LogsDecoded", &LogsDecoded)
 	LogsExpvars.Set("LogsProcessed", &LogsProcessed)
+	LogsExpvars.Set("LogsDroppedByProcessingRules", &LogsDroppedByProcessingRules)
 	LogsExpvars.Set("LogsSent", &LogsSent)
 	LogsExpvars.Set("DestinationErrors", &DestinationErrors)
 	LogsExpvars.Set("DestinationLogsDropped", &DestinationLogsDropped)
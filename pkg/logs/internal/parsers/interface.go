@@ -24,6 +24,16 @@ type Message struct {
 	// which do not contain a timestamp (such as files) leave this set to "".
 	Timestamp string
 
+	// Hostname is the host which emitted the message, if any.  Log sources
+	// which do not contain a hostname leave this set to "".
+	Hostname string
+
+	// Tags are the tags parsed from the message, if any.
+	Tags []string
+
+	// Attributes are the structured attributes parsed from the message, if any.
+	Attributes map[string]string
+
 	// IsPartial indicates that this is a partial message.  If the parser
 	// supports partial lines, then this is true only for the message returned
 	// from the last parsed line in a multi-line message.
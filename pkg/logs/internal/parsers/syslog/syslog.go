@@ -0,0 +1,294 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package syslog implements a parser for syslog messages in the RFC 5424 and
+// RFC 3164 (BSD) formats.
+package syslog
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// nilValue is the value of the RFC 5424 header fields which are not provided.
+const nilValue = "-"
+
+// utf8BOM may prefix the MSG part of RFC 5424 messages.
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+var (
+	errNoPriority   = errors.New("cannot parse the syslog priority")
+	errInvalidField = errors.New("cannot parse the syslog header")
+)
+
+// facilities are the names of the syslog facilities, indexed by their code.
+var facilities = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// statuses are the log statuses matching the syslog severities, indexed by their code.
+var statuses = []string{
+	message.StatusEmergency,
+	message.StatusAlert,
+	message.StatusCritical,
+	message.StatusError,
+	message.StatusWarning,
+	message.StatusNotice,
+	message.StatusInfo,
+	message.StatusDebug,
+}
+
+// New creates a new parser that parses syslog messages.
+//
+// RFC 5424 messages follow the pattern
+// '<PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG', for example:
+// `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3"] An application event`
+//
+// RFC 3164 messages follow the pattern '<PRI>TIMESTAMP HOSTNAME TAG: MSG', for example:
+// `<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8`
+//
+// The severity gives the status of the message, the hostname its host, the
+// facility and the application name are added as tags, and all the fields of
+// the header, including the structured data, are added as attributes.
+func New() parsers.Parser {
+	return &syslogFormat{now: time.Now}
+}
+
+type syslogFormat struct {
+	// now returns the current time, used to complete RFC 3164 timestamps which have no year.
+	now func() time.Time
+}
+
+// Parse implements Parser#Parse
+func (p *syslogFormat) Parse(msg []byte) (parsers.Message, error) {
+	msg = bytes.TrimRight(msg, "\r\n")
+	priority, rest, err := parsePriority(msg)
+	if err != nil {
+		return parsers.Message{Content: msg, Status: message.StatusInfo}, err
+	}
+
+	facility, severity := priority/8, priority%8
+	parsed := parsers.Message{
+		Status: statuses[severity],
+		Attributes: map[string]string{
+			"syslog.priority": strconv.Itoa(priority),
+			"syslog.severity": strconv.Itoa(severity),
+		},
+	}
+	if facility < len(facilities) {
+		parsed.Attributes["syslog.facility"] = facilities[facility]
+		parsed.Tags = append(parsed.Tags, "syslog_facility:"+facilities[facility])
+	}
+
+	if len(rest) > 1 && rest[0] == '1' && rest[1] == ' ' {
+		err = p.parseRFC5424(rest[2:], &parsed)
+	} else {
+		p.parseRFC3164(rest, &parsed)
+	}
+	if err != nil {
+		return parsers.Message{Content: msg, Status: message.StatusInfo}, err
+	}
+	if appName, ok := parsed.Attributes["syslog.appname"]; ok {
+		parsed.Tags = append(parsed.Tags, "syslog_appname:"+appName)
+	}
+	return parsed, nil
+}
+
+// SupportsPartialLine implements Parser#SupportsPartialLine
+func (p *syslogFormat) SupportsPartialLine() bool {
+	return false
+}
+
+// parsePriority parses the `<PRI>` prefix of the message and returns the priority
+// and the rest of the message.
+func parsePriority(msg []byte) (int, []byte, error) {
+	if len(msg) < 3 || msg[0] != '<' {
+		return 0, nil, errNoPriority
+	}
+	end := bytes.IndexByte(msg[:min(len(msg), 5)], '>')
+	if end < 2 {
+		return 0, nil, errNoPriority
+	}
+	priority, err := strconv.Atoi(string(msg[1:end]))
+	if err != nil || priority > 191 {
+		return 0, nil, errNoPriority
+	}
+	return priority, msg[end+1:], nil
+}
+
+// parseRFC5424 parses the header following the version of an RFC 5424 message.
+func (p *syslogFormat) parseRFC5424(msg []byte, parsed *parsers.Message) error {
+	parsed.Attributes["syslog.version"] = "1"
+
+	var fields [5]string
+	for i := range fields {
+		var field []byte
+		field, msg = nextField(msg)
+		if len(field) == 0 {
+			return errInvalidField
+		}
+		fields[i] = string(field)
+	}
+	timestamp, hostname, appName, procID, msgID := fields[0], fields[1], fields[2], fields[3], fields[4]
+
+	if timestamp != nilValue {
+		ts, err := time.Parse(time.RFC3339Nano, timestamp)
+		if err != nil {
+			return errInvalidField
+		}
+		parsed.Timestamp = ts.UTC().Format(config.DateFormat)
+	}
+	if hostname != nilValue {
+		parsed.Hostname = hostname
+	}
+	setAttribute(parsed, "syslog.appname", appName)
+	setAttribute(parsed, "syslog.procid", procID)
+	setAttribute(parsed, "syslog.msgid", msgID)
+
+	content, err := parseStructuredData(msg, parsed)
+	if err != nil {
+		return err
+	}
+	parsed.Content = bytes.TrimPrefix(content, utf8BOM)
+	return nil
+}
+
+// parseStructuredData parses the STRUCTURED-DATA of an RFC 5424 message and
+// returns the MSG following it.
+func parseStructuredData(msg []byte, parsed *parsers.Message) ([]byte, error) {
+	if len(msg) > 0 && msg[0] == '-' && (len(msg) == 1 || msg[1] == ' ') {
+		return trimSpace(msg[1:]), nil
+	}
+	for len(msg) > 0 && msg[0] == '[' {
+		end := bytes.IndexAny(msg, " ]")
+		if end < 2 {
+			return nil, errInvalidField
+		}
+		id := string(msg[1:end])
+		msg = msg[end:]
+		for {
+			msg = trimSpace(msg)
+			if len(msg) == 0 {
+				return nil, errInvalidField
+			}
+			if msg[0] == ']' {
+				msg = msg[1:]
+				break
+			}
+			eq := bytes.IndexByte(msg, '=')
+			if eq < 1 || eq+1 >= len(msg) || msg[eq+1] != '"' {
+				return nil, errInvalidField
+			}
+			name := string(msg[:eq])
+			value, rest, ok := parseParamValue(msg[eq+2:])
+			if !ok {
+				return nil, errInvalidField
+			}
+			parsed.Attributes["syslog.structured_data."+id+"."+name] = value
+			msg = rest
+		}
+	}
+	return trimSpace(msg), nil
+}
+
+// parseParamValue parses a quoted parameter value, with `"`, `\` and `]`
+// escaped by a backslash, and returns the rest of the message following the
+// closing quote.
+func parseParamValue(msg []byte) (string, []byte, bool) {
+	var value []byte
+	for i := 0; i < len(msg); i++ {
+		switch msg[i] {
+		case '\\':
+			if i+1 < len(msg) && (msg[i+1] == '"' || msg[i+1] == '\\' || msg[i+1] == ']') {
+				i++
+			}
+			value = append(value, msg[i])
+		case '"':
+			return string(value), msg[i+1:], true
+		default:
+			value = append(value, msg[i])
+		}
+	}
+	return "", nil, false
+}
+
+// parseRFC3164 parses the header of a BSD syslog message, leniently: the parts
+// of the header which can't be parsed are kept in the content.
+func (p *syslogFormat) parseRFC3164(msg []byte, parsed *parsers.Message) {
+	// the timestamp has the fixed length format `Mmm dd hh:mm:ss`
+	if len(msg) >= len(time.Stamp) {
+		if ts, err := time.ParseInLocation(time.Stamp, string(msg[:len(time.Stamp)]), time.Local); err == nil {
+			parsed.Timestamp = p.withYear(ts).UTC().Format(config.DateFormat)
+			msg = trimSpace(msg[len(time.Stamp):])
+
+			if hostname, rest := nextField(msg); len(hostname) > 0 && !isTag(hostname) {
+				parsed.Hostname = string(hostname)
+				msg = rest
+			}
+		}
+	}
+
+	// the tag is the name of the program optionally followed by its pid: `su[123]:`
+	if tag, rest := nextField(msg); isTag(tag) {
+		tag = tag[:len(tag)-1]
+		if open := bytes.IndexByte(tag, '['); open > 0 && tag[len(tag)-1] == ']' {
+			setAttribute(parsed, "syslog.procid", string(tag[open+1:len(tag)-1]))
+			tag = tag[:open]
+		}
+		setAttribute(parsed, "syslog.appname", string(tag))
+		msg = rest
+	}
+	parsed.Content = msg
+}
+
+// withYear completes a timestamp without year with the current one, or the
+// previous one when the timestamp would be too far in the future, around new year.
+func (p *syslogFormat) withYear(ts time.Time) time.Time {
+	now := p.now()
+	ts = ts.AddDate(now.Year()-ts.Year(), 0, 0)
+	if ts.After(now.Add(24 * time.Hour)) {
+		ts = ts.AddDate(-1, 0, 0)
+	}
+	return ts
+}
+
+// isTag returns true if the field is an RFC 3164 tag, ending with a colon.
+func isTag(field []byte) bool {
+	return len(field) > 1 && field[len(field)-1] == ':'
+}
+
+// setAttribute sets the attribute unless the value is nil.
+func setAttribute(parsed *parsers.Message, key string, value string) {
+	if value != "" && value != nilValue {
+		parsed.Attributes[key] = value
+	}
+}
+
+// nextField returns the next space delimited field and the rest of the message.
+func nextField(msg []byte) ([]byte, []byte) {
+	if end := bytes.IndexByte(msg, ' '); end >= 0 {
+		return msg[:end], trimSpace(msg[end+1:])
+	}
+	return msg, nil
+}
+
+func trimSpace(msg []byte) []byte {
+	return bytes.TrimLeft(msg, " ")
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,110 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func newTestParser(now time.Time) *syslogFormat {
+	return &syslogFormat{now: func() time.Time { return now }}
+}
+
+func TestSyslogParserRFC5424(t *testing.T) {
+	msg, err := New().Parse([]byte(`<165>1 2003-10-11T22:14:15.003+02:00 mymachine.example.com evntslog 1234 ID47 [exampleSDID@32473 iut="3" eventSource="Application"][examplePriority@32473 class="high \"quoted\" \] \\"] ` + "\xef\xbb\xbfAn application event\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("An application event"), msg.Content)
+	assert.Equal(t, message.StatusNotice, msg.Status)
+	assert.Equal(t, "2003-10-11T20:14:15.003000000Z", msg.Timestamp)
+	assert.Equal(t, "mymachine.example.com", msg.Hostname)
+	assert.Equal(t, []string{"syslog_facility:local4", "syslog_appname:evntslog"}, msg.Tags)
+	assert.Equal(t, map[string]string{
+		"syslog.priority": "165",
+		"syslog.severity": "5",
+		"syslog.facility": "local4",
+		"syslog.version":  "1",
+		"syslog.appname":  "evntslog",
+		"syslog.procid":   "1234",
+		"syslog.msgid":    "ID47",
+		"syslog.structured_data.exampleSDID@32473.iut":         "3",
+		"syslog.structured_data.exampleSDID@32473.eventSource": "Application",
+		"syslog.structured_data.examplePriority@32473.class":   `high "quoted" ] \`,
+	}, msg.Attributes)
+}
+
+func TestSyslogParserRFC5424NilValues(t *testing.T) {
+	msg, err := New().Parse([]byte("<0>1 - - - - - - message"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("message"), msg.Content)
+	assert.Equal(t, message.StatusEmergency, msg.Status)
+	assert.Equal(t, "", msg.Timestamp)
+	assert.Equal(t, "", msg.Hostname)
+	assert.Equal(t, []string{"syslog_facility:kern"}, msg.Tags)
+	assert.Equal(t, map[string]string{
+		"syslog.priority": "0",
+		"syslog.severity": "0",
+		"syslog.facility": "kern",
+		"syslog.version":  "1",
+	}, msg.Attributes)
+}
+
+func TestSyslogParserRFC5424Invalid(t *testing.T) {
+	for _, line := range []string{
+		"<13>1 2003-10-11T22:14:15.003Z host",
+		"<13>1 not-a-date host app - - - message",
+		`<13>1 - host app - - [id unquoted=value] message`,
+		`<13>1 - host app - - [id key="unterminated] message`,
+	} {
+		msg, err := New().Parse([]byte(line))
+		assert.NotNil(t, err, line)
+		assert.Equal(t, []byte(line), msg.Content)
+		assert.Equal(t, message.StatusInfo, msg.Status)
+	}
+}
+
+func TestSyslogParserRFC3164(t *testing.T) {
+	p := newTestParser(time.Date(2022, 5, 1, 0, 0, 0, 0, time.Local))
+
+	msg, err := p.Parse([]byte("<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("'su root' failed for lonvick on /dev/pts/8"), msg.Content)
+	assert.Equal(t, message.StatusCritical, msg.Status)
+	assert.Equal(t, time.Date(2021, 10, 11, 22, 14, 15, 0, time.Local).UTC().Format(config.DateFormat), msg.Timestamp)
+	assert.Equal(t, "mymachine", msg.Hostname)
+	assert.Equal(t, []string{"syslog_facility:auth", "syslog_appname:su"}, msg.Tags)
+	assert.Equal(t, "123", msg.Attributes["syslog.procid"])
+
+	// without hostname
+	msg, err = p.Parse([]byte("<13>Apr  3 08:00:00 cron: job done"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("job done"), msg.Content)
+	assert.Equal(t, time.Date(2022, 4, 3, 8, 0, 0, 0, time.Local).UTC().Format(config.DateFormat), msg.Timestamp)
+	assert.Equal(t, "", msg.Hostname)
+	assert.Equal(t, "cron", msg.Attributes["syslog.appname"])
+
+	// without header
+	msg, err = p.Parse([]byte("<13>just a message"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("just a message"), msg.Content)
+	assert.Equal(t, message.StatusNotice, msg.Status)
+	assert.Equal(t, "", msg.Timestamp)
+	assert.Equal(t, []string{"syslog_facility:user"}, msg.Tags)
+}
+
+func TestSyslogParserWithoutPriority(t *testing.T) {
+	for _, line := range []string{"", "message", "<>message", "<1234>message", "<192>message", "<1a>message"} {
+		msg, err := New().Parse([]byte(line))
+		assert.NotNil(t, err, line)
+		assert.Equal(t, []byte(line), msg.Content)
+		assert.Equal(t, message.StatusInfo, msg.Status)
+	}
+}
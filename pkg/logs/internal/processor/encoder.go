@@ -6,6 +6,7 @@
 package processor
 
 import (
+	"encoding/json"
 	"unicode"
 	"unicode/utf8"
 
@@ -33,3 +34,40 @@ func toValidUtf8(msg []byte) string {
 	}
 	return string(str)
 }
+
+// reservedJSONFields are the fields of the JSON payloads that the structured attributes can't override.
+var reservedJSONFields = map[string]struct{}{
+	"message":   {},
+	"status":    {},
+	"timestamp": {},
+	"hostname":  {},
+	"service":   {},
+	"ddsource":  {},
+	"ddtags":    {},
+}
+
+// withAttributes adds the structured attributes of the message as top level fields
+// of the given JSON object.
+func withAttributes(payload []byte, attributes map[string]string) ([]byte, error) {
+	if len(attributes) == 0 {
+		return payload, nil
+	}
+	fields := make(map[string]string, len(attributes))
+	for key, value := range attributes {
+		if _, reserved := reservedJSONFields[key]; !reserved {
+			fields[key] = value
+		}
+	}
+	if len(fields) == 0 {
+		return payload, nil
+	}
+	encodedFields, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	// merge the two objects: `{"message":...}` and `{"key":...}` become `{"message":...,"key":...}`
+	merged := make([]byte, 0, len(payload)+len(encodedFields))
+	merged = append(merged, payload[:len(payload)-1]...)
+	merged = append(merged, ',')
+	return append(merged, encodedFields[1:]...), nil
+}
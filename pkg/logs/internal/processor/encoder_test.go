@@ -223,6 +223,31 @@ func TestJsonEncoder(t *testing.T) {
 	assert.NotEmpty(t, log.Timestamp)
 }
 
+func TestJsonEncoderWithAttributes(t *testing.T) {
+	source := sources.NewLogSource("", &config.LogsConfig{Service: "Service"})
+	msg := newMessage([]byte("message"), source, message.StatusInfo)
+	msg.SetAttribute("user", "bob")
+	msg.SetAttribute("http_status", "200")
+	// attributes can't override the reserved fields
+	msg.SetAttribute("service", "other")
+	msg.SetAttribute("status", "200")
+
+	for _, encoder := range []Encoder{JSONEncoder, JSONServerlessEncoder} {
+		jsonMessage, err := encoder.Encode(msg, []byte("redacted"))
+		assert.Nil(t, err)
+
+		fields := make(map[string]interface{})
+		err = json.Unmarshal(jsonMessage, &fields)
+		assert.Nil(t, err)
+
+		assert.Equal(t, "bob", fields["user"])
+		assert.Equal(t, "200", fields["http_status"])
+		assert.Equal(t, "Service", fields["service"])
+		assert.Equal(t, message.StatusInfo, fields["status"])
+		assert.NotEmpty(t, fields["message"])
+	}
+}
+
 func TestEncoderToValidUTF8(t *testing.T) {
 	assert.Equal(t, "a�z", toValidUtf8([]byte("a\xfez")))
 	assert.Equal(t, "a��z", toValidUtf8([]byte("a\xc0\xafz")))
@@ -0,0 +1,263 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// logMetricsFlushInterval is the interval at which each pipeline sends the metrics it generated
+// to the aggregator.
+const logMetricsFlushInterval = 5 * time.Second
+
+// MetricAggregator aggregates the metrics generated from the logs,
+// it is implemented by the aggregator demultiplexer.
+type MetricAggregator interface {
+	AggregateSamples(shard aggregator.TimeSamplerID, samples metrics.MetricSampleBatch)
+	GetMetricSamplePool() *metrics.MetricSamplePool
+}
+
+// LogMetricsGenerator generates metrics from the logs matching its log metrics.
+// It is shared by the pipelines, which each aggregate their metrics in a logMetricsBuffer.
+type LogMetricsGenerator struct {
+	logMetrics []*config.LogMetric
+	aggregator MetricAggregator
+}
+
+// NewLogMetricsGenerator returns a generator sending the metrics to the aggregator,
+// or nil when there is no metric to generate.
+func NewLogMetricsGenerator(logMetrics []*config.LogMetric, aggregator MetricAggregator) *LogMetricsGenerator {
+	if len(logMetrics) == 0 || aggregator == nil {
+		return nil
+	}
+	return &LogMetricsGenerator{
+		logMetrics: logMetrics,
+		aggregator: aggregator,
+	}
+}
+
+// newBuffer returns a buffer aggregating the metrics of a pipeline, a nil generator returns a nil buffer.
+func (g *LogMetricsGenerator) newBuffer() *logMetricsBuffer {
+	if g == nil {
+		return nil
+	}
+	return &logMetricsBuffer{
+		generator:     g,
+		counts:        make(map[string]*metrics.MetricSample),
+		distributions: make(map[string]*logMetricDistribution),
+	}
+}
+
+// logMetricsBuffer aggregates the metrics generated by a pipeline until they are flushed,
+// so that the aggregator is not called for every log.
+// Counts are summed by context, identical distribution values are counted and sent on flush.
+type logMetricsBuffer struct {
+	generator     *LogMetricsGenerator
+	mu            sync.Mutex
+	counts        map[string]*metrics.MetricSample
+	distributions map[string]*logMetricDistribution
+}
+
+// logMetricDistribution is a distribution value and the number of times it was generated.
+// The occurrences are sent as samples of rate 1: a weight given as a sample rate is truncated
+// by the sketches and would undercount the value.
+type logMetricDistribution struct {
+	sample metrics.MetricSample
+	count  int
+}
+
+// appliesTo returns whether metrics may be generated from the message, a nil buffer returns false.
+func (b *logMetricsBuffer) appliesTo(msg *message.Message) bool {
+	if b == nil {
+		return false
+	}
+	for _, logMetric := range b.generator.logMetrics {
+		if logMetric.Source == "" || logMetric.Source == msg.Origin.Source() {
+			return true
+		}
+	}
+	return false
+}
+
+// process generates the metrics matching the message, whose content is given redacted.
+// A nil buffer does nothing.
+func (b *logMetricsBuffer) process(msg *message.Message, content []byte) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, logMetric := range b.generator.logMetrics {
+		if logMetric.Source != "" && logMetric.Source != msg.Origin.Source() {
+			continue
+		}
+		captures, matched := matchLogMetric(msg, content, logMetric)
+		if !matched {
+			continue
+		}
+		tags := logMetricTags(msg, logMetric, captures)
+		host := msg.GetHostname()
+		switch logMetric.Type {
+		case config.CountMetric:
+			key := logMetricContextKey(logMetric.Name, host, tags)
+			if sample, found := b.counts[key]; found {
+				sample.Value++
+				continue
+			}
+			b.counts[key] = &metrics.MetricSample{
+				Name:       logMetric.Name,
+				Value:      1,
+				Mtype:      metrics.CountType,
+				Tags:       tags,
+				Host:       host,
+				SampleRate: 1,
+			}
+		case config.DistributionMetric:
+			value, found := lookupLogMetricValue(msg, captures, logMetric.Value)
+			if !found {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			key := logMetricContextKey(logMetric.Name, host, tags) + "|" + strconv.FormatFloat(parsed, 'g', -1, 64)
+			if distribution, found := b.distributions[key]; found {
+				distribution.count++
+				continue
+			}
+			b.distributions[key] = &logMetricDistribution{
+				sample: metrics.MetricSample{
+					Name:       logMetric.Name,
+					Value:      parsed,
+					Mtype:      metrics.DistributionType,
+					Tags:       tags,
+					Host:       host,
+					SampleRate: 1,
+				},
+				count: 1,
+			}
+		}
+	}
+}
+
+// flush sends the aggregated metrics to the aggregator in batches. A nil buffer does nothing.
+func (b *logMetricsBuffer) flush() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	if len(b.counts) == 0 && len(b.distributions) == 0 {
+		b.mu.Unlock()
+		return
+	}
+	counts, distributions := b.counts, b.distributions
+	b.counts = make(map[string]*metrics.MetricSample)
+	b.distributions = make(map[string]*logMetricDistribution)
+	b.mu.Unlock()
+
+	pool := b.generator.aggregator.GetMetricSamplePool()
+	timestamp := float64(time.Now().Unix())
+	batch := pool.GetBatch()
+	n := 0
+	send := func(sample *metrics.MetricSample) {
+		sample.Timestamp = timestamp
+		batch[n] = *sample
+		n++
+		if n == len(batch) {
+			b.generator.aggregator.AggregateSamples(0, batch)
+			batch = pool.GetBatch()
+			n = 0
+		}
+	}
+	for _, sample := range counts {
+		send(sample)
+	}
+	for _, distribution := range distributions {
+		for i := 0; i < distribution.count; i++ {
+			send(&distribution.sample)
+		}
+	}
+	if n > 0 {
+		b.generator.aggregator.AggregateSamples(0, batch[:n])
+	} else {
+		pool.PutBatch(batch)
+	}
+}
+
+func logMetricContextKey(name string, host string, tags []string) string {
+	return name + "|" + host + "|" + strings.Join(tags, ",")
+}
+
+// matchLogMetric returns whether the message matches the pattern of the log metric,
+// and the values of the named capture groups of the pattern.
+// The pattern applies to the redacted content of the message or to the structured attribute set in the log metric field,
+// a missing attribute does not match.
+func matchLogMetric(msg *message.Message, content []byte, logMetric *config.LogMetric) (map[string]string, bool) {
+	if logMetric.Regex == nil {
+		return nil, logMetric.Field == "" || hasAttribute(msg, logMetric.Field)
+	}
+	var match [][]byte
+	if logMetric.Field != "" {
+		value, found := msg.Attributes[logMetric.Field]
+		if !found {
+			return nil, false
+		}
+		match = logMetric.Regex.FindSubmatch([]byte(value))
+	} else {
+		match = logMetric.Regex.FindSubmatch(content)
+	}
+	if match == nil {
+		return nil, false
+	}
+	var captures map[string]string
+	for i, name := range logMetric.Regex.SubexpNames() {
+		if name == "" || match[i] == nil {
+			continue
+		}
+		if captures == nil {
+			captures = make(map[string]string)
+		}
+		captures[name] = toValidUtf8(match[i])
+	}
+	return captures, true
+}
+
+// logMetricTags returns the tags of the log metric and the tags built from its group by values,
+// a missing value is not added.
+func logMetricTags(msg *message.Message, logMetric *config.LogMetric, captures map[string]string) []string {
+	tags := make([]string, 0, len(logMetric.Tags)+len(logMetric.GroupBy))
+	tags = append(tags, logMetric.Tags...)
+	for _, name := range logMetric.GroupBy {
+		if value, found := lookupLogMetricValue(msg, captures, name); found {
+			tags = append(tags, name+":"+value)
+		}
+	}
+	return tags
+}
+
+// lookupLogMetricValue returns the value of a named capture group,
+// or of the structured attribute when there is no such capture group.
+func lookupLogMetricValue(msg *message.Message, captures map[string]string, name string) (string, bool) {
+	if value, found := captures[name]; found {
+		return value, true
+	}
+	value, found := msg.Attributes[name]
+	return value, found
+}
+
+func hasAttribute(msg *message.Message, name string) bool {
+	_, found := msg.Attributes[name]
+	return found
+}
@@ -0,0 +1,276 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+type mockAggregator struct {
+	pool    *metrics.MetricSamplePool
+	batches int
+	samples []metrics.MetricSample
+}
+
+func newMockAggregator() *mockAggregator {
+	return &mockAggregator{pool: metrics.NewMetricSamplePool(2)}
+}
+
+func (a *mockAggregator) AggregateSamples(shard aggregator.TimeSamplerID, samples metrics.MetricSampleBatch) {
+	a.batches++
+	a.samples = append(a.samples, samples...)
+	a.pool.PutBatch(samples)
+}
+
+func (a *mockAggregator) GetMetricSamplePool() *metrics.MetricSamplePool {
+	return a.pool
+}
+
+// testLogMetrics processes the messages with a pipeline buffer and flushes it on each call
+type testLogMetrics struct {
+	buffer *logMetricsBuffer
+}
+
+func (m testLogMetrics) Process(msg *message.Message) {
+	m.buffer.process(msg, msg.Content)
+	m.buffer.flush()
+}
+
+func newTestLogMetricsGenerator(t *testing.T, logMetrics ...*config.LogMetric) (testLogMetrics, *mockAggregator) {
+	generator, aggregator := newCompiledLogMetricsGenerator(t, logMetrics...)
+	return testLogMetrics{generator.newBuffer()}, aggregator
+}
+
+func newCompiledLogMetricsGenerator(t *testing.T, logMetrics ...*config.LogMetric) (*LogMetricsGenerator, *mockAggregator) {
+	require.Nil(t, config.ValidateLogMetrics(logMetrics))
+	require.Nil(t, config.CompileLogMetrics(logMetrics))
+	aggregator := newMockAggregator()
+	return NewLogMetricsGenerator(logMetrics, aggregator), aggregator
+}
+
+func newLogMetricsMessage(content string, sourceName string) *message.Message {
+	source := sources.NewLogSource("", &config.LogsConfig{Source: sourceName})
+	msg := message.NewMessageWithSource([]byte(content), message.StatusInfo, source, 0)
+	msg.Hostname = "host"
+	return msg
+}
+
+func TestNewLogMetricsGenerator(t *testing.T) {
+	assert.Nil(t, NewLogMetricsGenerator(nil, newMockAggregator()))
+	assert.Nil(t, NewLogMetricsGenerator([]*config.LogMetric{{Name: "foo", Type: config.CountMetric}}, nil))
+
+	// a nil generator does nothing
+	var generator *LogMetricsGenerator
+	buffer := generator.newBuffer()
+	buffer.process(newLogMetricsMessage("foo", ""), []byte("foo"))
+	buffer.flush()
+}
+
+func TestLogMetricsCount(t *testing.T) {
+	generator, aggregator := newTestLogMetricsGenerator(t, &config.LogMetric{
+		Name:    "nginx.requests.server_errors",
+		Type:    config.CountMetric,
+		Source:  "nginx",
+		Pattern: "status=(?P<http_status>5\\d\\d)",
+		GroupBy: []string{"http_status", "user"},
+		Tags:    []string{"team:web"},
+	})
+
+	generator.Process(newLogMetricsMessage("GET / status=200", "nginx"))
+	generator.Process(newLogMetricsMessage("GET / status=503", "apache"))
+	assert.Empty(t, aggregator.samples)
+
+	msg := newLogMetricsMessage("GET / status=503", "nginx")
+	msg.SetAttribute("user", "bob")
+	generator.Process(msg)
+	require.Len(t, aggregator.samples, 1)
+	sample := aggregator.samples[0]
+	assert.Equal(t, "nginx.requests.server_errors", sample.Name)
+	assert.Equal(t, metrics.CountType, sample.Mtype)
+	assert.Equal(t, 1.0, sample.Value)
+	assert.Equal(t, 1.0, sample.SampleRate)
+	assert.Equal(t, "host", sample.Host)
+	assert.Equal(t, []string{"team:web", "http_status:503", "user:bob"}, sample.Tags)
+}
+
+func TestLogMetricsDistribution(t *testing.T) {
+	generator, aggregator := newTestLogMetricsGenerator(t,
+		&config.LogMetric{
+			Name:    "requests.duration",
+			Type:    config.DistributionMetric,
+			Pattern: "took %{NUMBER:duration}ms",
+			Value:   "duration",
+		},
+		&config.LogMetric{
+			Name:  "requests.size",
+			Type:  config.DistributionMetric,
+			Field: "size",
+			Value: "size",
+		},
+	)
+
+	generator.Process(newLogMetricsMessage("request took 12.5ms", ""))
+	require.Len(t, aggregator.samples, 1)
+	assert.Equal(t, "requests.duration", aggregator.samples[0].Name)
+	assert.Equal(t, metrics.DistributionType, aggregator.samples[0].Mtype)
+	assert.Equal(t, 12.5, aggregator.samples[0].Value)
+
+	// the value is read from the structured attributes
+	msg := newLogMetricsMessage("request", "")
+	msg.SetAttribute("size", "2048")
+	generator.Process(msg)
+	require.Len(t, aggregator.samples, 2)
+	assert.Equal(t, "requests.size", aggregator.samples[1].Name)
+	assert.Equal(t, 2048.0, aggregator.samples[1].Value)
+
+	// a value which is not a number is ignored
+	msg = newLogMetricsMessage("request", "")
+	msg.SetAttribute("size", "large")
+	generator.Process(msg)
+	assert.Len(t, aggregator.samples, 2)
+}
+
+func TestLogMetricsField(t *testing.T) {
+	generator, aggregator := newTestLogMetricsGenerator(t, &config.LogMetric{
+		Name:    "errors",
+		Type:    config.CountMetric,
+		Field:   "level",
+		Pattern: "^(?P<level>ERROR|FATAL)$",
+		GroupBy: []string{"level"},
+	})
+
+	generator.Process(newLogMetricsMessage("ERROR", ""))
+	assert.Empty(t, aggregator.samples)
+
+	msg := newLogMetricsMessage("something failed", "")
+	msg.SetAttribute("level", "FATAL")
+	generator.Process(msg)
+	require.Len(t, aggregator.samples, 1)
+	assert.Equal(t, []string{"level:FATAL"}, aggregator.samples[0].Tags)
+}
+
+func TestProcessorGeneratesLogMetricsFromExcludedLogs(t *testing.T) {
+	generator, aggregator := newCompiledLogMetricsGenerator(t, &config.LogMetric{
+		Name:    "health_checks",
+		Type:    config.CountMetric,
+		Pattern: "GET /health",
+	})
+	excludeRule := newProcessingRule(config.ExcludeAtMatch, "", "GET /health")
+	outputChan := make(chan *message.Message, 1)
+	p := New(nil, outputChan, []*config.ProcessingRule{excludeRule}, generator, RawEncoder, nil)
+
+	p.processMessage(newLogMetricsMessage("GET /health", ""))
+	p.logMetrics.flush()
+	assert.Len(t, aggregator.samples, 1)
+	assert.Len(t, outputChan, 0)
+}
+
+func TestProcessorSkipsRulesOfDroppedLogsWithoutLogMetrics(t *testing.T) {
+	generator, aggregator := newCompiledLogMetricsGenerator(t, &config.LogMetric{
+		Name:    "health_checks",
+		Type:    config.CountMetric,
+		Source:  "nginx",
+		Pattern: "GET /health",
+	})
+	excludeRule := newProcessingRule(config.ExcludeAtMatch, "", "GET /health")
+	extractRule := &config.ProcessingRule{Type: config.ExtractFields, Name: "extract", Pattern: `user=(?P<user>\S+)`}
+	require.Nil(t, config.CompileProcessingRules([]*config.ProcessingRule{extractRule}))
+	p := New(nil, make(chan *message.Message, 1), []*config.ProcessingRule{excludeRule, extractRule}, generator, RawEncoder, nil)
+
+	// the log metric does not apply to the source of the message: the rules after the exclusion are skipped
+	msg := newLogMetricsMessage("GET /health user=bob", "apache")
+	p.processMessage(msg)
+	assert.Nil(t, msg.Attributes)
+
+	msg = newLogMetricsMessage("GET /health user=bob", "nginx")
+	p.processMessage(msg)
+	assert.Equal(t, map[string]string{"user": "bob"}, msg.Attributes)
+	p.logMetrics.flush()
+	assert.Len(t, aggregator.samples, 1)
+}
+
+func TestProcessorGeneratesLogMetricsFromRedactedLogs(t *testing.T) {
+	generator, aggregator := newCompiledLogMetricsGenerator(t, &config.LogMetric{
+		Name:    "logins",
+		Type:    config.CountMetric,
+		Pattern: "login user=(?P<user>\\S+)",
+		GroupBy: []string{"user"},
+	})
+	maskRule := newProcessingRule(config.MaskSequences, "[masked_email]", "[a-z]+@[a-z.]+")
+	p := New(nil, make(chan *message.Message, 1), []*config.ProcessingRule{maskRule}, generator, RawEncoder, &diagnostic.NoopMessageReceiver{})
+
+	p.processMessage(newLogMetricsMessage("login user=bob@datadoghq.com", ""))
+	require.Len(t, aggregator.samples, 0)
+	p.logMetrics.flush()
+	require.Len(t, aggregator.samples, 1)
+	assert.Equal(t, []string{"user:[masked_email]"}, aggregator.samples[0].Tags)
+}
+
+func TestLogMetricsBufferAggregates(t *testing.T) {
+	generator, aggregator := newCompiledLogMetricsGenerator(t,
+		&config.LogMetric{Name: "requests", Type: config.CountMetric, Pattern: "status=(?P<status>\\d+)", GroupBy: []string{"status"}},
+		&config.LogMetric{Name: "requests.duration", Type: config.DistributionMetric, Pattern: "took=(?P<duration>\\d+)", Value: "duration"},
+	)
+	buffer := generator.newBuffer()
+
+	for _, content := range []string{"status=200 took=10", "status=200 took=10", "status=200 took=30", "status=500 took=10"} {
+		msg := newLogMetricsMessage(content, "")
+		buffer.process(msg, msg.Content)
+	}
+	assert.Empty(t, aggregator.samples)
+
+	buffer.flush()
+	// the samples are sent in batches of the pool size
+	assert.Equal(t, 3, aggregator.batches)
+	require.Len(t, aggregator.samples, 6)
+	counts := map[string]float64{}
+	durations := map[float64]float64{}
+	for _, sample := range aggregator.samples {
+		switch sample.Mtype {
+		case metrics.CountType:
+			counts[sample.Tags[0]] = sample.Value
+		case metrics.DistributionType:
+			assert.Equal(t, 1.0, sample.SampleRate)
+			durations[sample.Value]++
+		}
+	}
+	assert.Equal(t, map[string]float64{"status:200": 3, "status:500": 1}, counts)
+	assert.Equal(t, map[float64]float64{10: 3, 30: 1}, durations)
+
+	// the buffer is empty once flushed
+	buffer.flush()
+	assert.Equal(t, 3, aggregator.batches)
+}
+
+func TestLogMetricsBufferRepeatedDistributionValues(t *testing.T) {
+	generator, aggregator := newCompiledLogMetricsGenerator(t,
+		&config.LogMetric{Name: "requests.duration", Type: config.DistributionMetric, Pattern: "took=(?P<duration>\\d+)", Value: "duration"},
+	)
+	buffer := generator.newBuffer()
+
+	// a weight encoded in the sample rate is truncated by the sketches from about a hundred values
+	for i := 0; i < 1000; i++ {
+		msg := newLogMetricsMessage("took=10", "")
+		buffer.process(msg, msg.Content)
+	}
+	buffer.flush()
+
+	require.Len(t, aggregator.samples, 1000)
+	for _, sample := range aggregator.samples {
+		assert.Equal(t, 10.0, sample.Value)
+		assert.Equal(t, 1.0, sample.SampleRate)
+	}
+}
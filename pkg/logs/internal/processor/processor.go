@@ -8,6 +8,7 @@ package processor
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
@@ -23,6 +24,7 @@ type Processor struct {
 	inputChan                 chan *message.Message
 	outputChan                chan *message.Message
 	processingRules           []*config.ProcessingRule
+	logMetrics                *logMetricsBuffer
 	encoder                   Encoder
 	done                      chan struct{}
 	diagnosticMessageReceiver diagnostic.MessageReceiver
@@ -30,11 +32,12 @@ type Processor struct {
 }
 
 // New returns an initialized Processor.
-func New(inputChan, outputChan chan *message.Message, processingRules []*config.ProcessingRule, encoder Encoder, diagnosticMessageReceiver diagnostic.MessageReceiver) *Processor {
+func New(inputChan, outputChan chan *message.Message, processingRules []*config.ProcessingRule, logMetrics *LogMetricsGenerator, encoder Encoder, diagnosticMessageReceiver diagnostic.MessageReceiver) *Processor {
 	return &Processor{
 		inputChan:                 inputChan,
 		outputChan:                outputChan,
 		processingRules:           processingRules,
+		logMetrics:                logMetrics.newBuffer(),
 		encoder:                   encoder,
 		done:                      make(chan struct{}),
 		diagnosticMessageReceiver: diagnosticMessageReceiver,
@@ -63,6 +66,7 @@ func (p *Processor) Flush(ctx context.Context) {
 			return
 		default:
 			if len(p.inputChan) == 0 {
+				p.logMetrics.flush()
 				return
 			}
 			msg := <-p.inputChan
@@ -74,20 +78,36 @@ func (p *Processor) Flush(ctx context.Context) {
 // run starts the processing of the inputChan
 func (p *Processor) run() {
 	defer func() {
+		p.logMetrics.flush()
 		p.done <- struct{}{}
 	}()
-	for msg := range p.inputChan {
-		p.processMessage(msg)
-		p.mu.Lock() // block here if we're trying to flush synchronously
-		//nolint:staticcheck
-		p.mu.Unlock()
+	ticker := time.NewTicker(logMetricsFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case msg, ok := <-p.inputChan:
+			if !ok {
+				return
+			}
+			p.processMessage(msg)
+			p.mu.Lock() // block here if we're trying to flush synchronously
+			//nolint:staticcheck
+			p.mu.Unlock()
+		case <-ticker.C:
+			p.logMetrics.flush()
+		}
 	}
 }
 
 func (p *Processor) processMessage(msg *message.Message) {
 	metrics.LogsDecoded.Add(1)
 	metrics.TlmLogsDecoded.Inc()
-	if shouldProcess, redactedMsg := p.applyRedactingRules(msg); shouldProcess {
+	shouldProcess, redactedMsg := p.applyProcessingRules(msg)
+	if p.logMetrics != nil {
+		// metrics are generated from the redacted content of the excluded logs too
+		p.logMetrics.process(msg, redactedMsg)
+	}
+	if shouldProcess {
 		metrics.LogsProcessed.Add(1)
 		metrics.TlmLogsProcessed.Inc()
 
@@ -104,24 +124,113 @@ func (p *Processor) processMessage(msg *message.Message) {
 	}
 }
 
-// applyRedactingRules returns given a message if we should process it or not,
-// and a copy of the message with some fields redacted, depending on config
-func (p *Processor) applyRedactingRules(msg *message.Message) (bool, []byte) {
+// applyProcessingRules applies the rules in order, and returns whether the message should be
+// processed and its redacted content.
+// Once a rule drops the message, the following rules are skipped, unless log metrics are generated
+// from the message: the following filtering rules are then skipped but the masking and extraction
+// rules still apply, so that the metrics are generated from the redacted content and attributes.
+// A mask_sequences rule also masks the structured attributes of the message, the ones set by its
+// parser and the ones extracted by the rules before it.
+func (p *Processor) applyProcessingRules(msg *message.Message) (bool, []byte) {
 	content := msg.Content
+	shouldProcess := true
+	redactDropped := p.logMetrics.appliesTo(msg)
 	rules := append(p.processingRules, msg.Origin.LogSource.Config.ProcessingRules...)
 	for _, rule := range rules {
+		if !shouldProcess {
+			if !redactDropped {
+				return false, nil
+			}
+			if isFilteringRule(rule) {
+				continue
+			}
+		}
+		if rule.Field != "" {
+			if !applyFieldRule(msg, rule) {
+				shouldProcess = false
+			}
+			continue
+		}
 		switch rule.Type {
+		case config.ExtractFields:
+			extractFields(msg, rule, content)
 		case config.ExcludeAtMatch:
 			if rule.Regex.Match(content) {
-				return false, nil
+				shouldProcess = false
 			}
 		case config.IncludeAtMatch:
 			if !rule.Regex.Match(content) {
-				return false, nil
+				shouldProcess = false
 			}
 		case config.MaskSequences:
 			content = rule.Regex.ReplaceAll(content, rule.Placeholder)
+			for name, value := range msg.Attributes {
+				msg.Attributes[name] = string(rule.Regex.ReplaceAll([]byte(value), rule.Placeholder))
+			}
+		case config.SampleAtMatch, config.RateLimitAtMatch:
+			if rule.Regex.Match(content) && !allowMessage(msg, rule) {
+				shouldProcess = false
+			}
+		}
+	}
+	if !shouldProcess && !redactDropped {
+		return false, nil
+	}
+	return shouldProcess, content
+}
+
+// isFilteringRule returns whether the rule can drop a message
+func isFilteringRule(rule *config.ProcessingRule) bool {
+	switch rule.Type {
+	case config.ExcludeAtMatch, config.IncludeAtMatch, config.SampleAtMatch, config.RateLimitAtMatch:
+		return true
+	}
+	return false
+}
+
+// allowMessage returns whether a message matching a sampling or rate limiting rule is kept,
+// and counts the dropped messages on their source.
+func allowMessage(msg *message.Message, rule *config.ProcessingRule) bool {
+	source := msg.Origin.LogSource
+	if source.RuleLimiter(rule).Allow(time.Now()) {
+		return true
+	}
+	source.RecordDroppedMessage()
+	metrics.LogsDroppedByProcessingRules.Add(1)
+	metrics.TlmLogsDroppedByProcessingRules.Inc()
+	return false
+}
+
+// extractFields sets the named captures of the rule pattern as structured attributes of the message.
+func extractFields(msg *message.Message, rule *config.ProcessingRule, content []byte) {
+	match := rule.Regex.FindSubmatch(content)
+	if match == nil {
+		return
+	}
+	for i, name := range rule.Regex.SubexpNames() {
+		if name == "" || match[i] == nil {
+			continue
+		}
+		msg.SetAttribute(name, toValidUtf8(match[i]))
+	}
+}
+
+// applyFieldRule applies the rule to the structured attribute it targets,
+// and returns whether the message should be processed.
+// A missing attribute does not match the rule.
+func applyFieldRule(msg *message.Message, rule *config.ProcessingRule) bool {
+	value, found := msg.Attributes[rule.Field]
+	switch rule.Type {
+	case config.ExcludeAtMatch:
+		return !(found && rule.Regex.MatchString(value))
+	case config.IncludeAtMatch:
+		return found && rule.Regex.MatchString(value)
+	case config.MaskSequences:
+		if found {
+			msg.Attributes[rule.Field] = string(rule.Regex.ReplaceAll([]byte(value), rule.Placeholder))
 		}
+	case config.SampleAtMatch, config.RateLimitAtMatch:
+		return !(found && rule.Regex.MatchString(value)) || allowMessage(msg, rule)
 	}
-	return true, content
+	return true
 }
@@ -23,18 +23,18 @@ func TestExclusion(t *testing.T) {
 	var redactedMessage []byte
 
 	source := newSource("exclude_at_match", "", "world")
-	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("hello"), &source, ""))
+	shouldProcess, redactedMessage = p.applyProcessingRules(newMessage([]byte("hello"), &source, ""))
 	assert.Equal(t, true, shouldProcess)
 	assert.Equal(t, []byte("hello"), redactedMessage)
 
-	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte("world"), &source, ""))
+	shouldProcess, _ = p.applyProcessingRules(newMessage([]byte("world"), &source, ""))
 	assert.Equal(t, false, shouldProcess)
 
-	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte("a brand new world"), &source, ""))
+	shouldProcess, _ = p.applyProcessingRules(newMessage([]byte("a brand new world"), &source, ""))
 	assert.Equal(t, false, shouldProcess)
 
 	source = newSource("exclude_at_match", "", "$world")
-	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte("a brand new world"), &source, ""))
+	shouldProcess, _ = p.applyProcessingRules(newMessage([]byte("a brand new world"), &source, ""))
 	assert.Equal(t, true, shouldProcess)
 }
 
@@ -45,20 +45,20 @@ func TestInclusion(t *testing.T) {
 	var redactedMessage []byte
 
 	source := sources.LogSource{Config: &config.LogsConfig{}}
-	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("hello"), &source, ""))
+	shouldProcess, redactedMessage = p.applyProcessingRules(newMessage([]byte("hello"), &source, ""))
 	assert.Equal(t, false, shouldProcess)
 	assert.Nil(t, redactedMessage)
 
-	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("world"), &source, ""))
+	shouldProcess, redactedMessage = p.applyProcessingRules(newMessage([]byte("world"), &source, ""))
 	assert.Equal(t, true, shouldProcess)
 	assert.Equal(t, []byte("world"), redactedMessage)
 
-	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("a brand new world"), &source, ""))
+	shouldProcess, redactedMessage = p.applyProcessingRules(newMessage([]byte("a brand new world"), &source, ""))
 	assert.Equal(t, true, shouldProcess)
 	assert.Equal(t, []byte("a brand new world"), redactedMessage)
 
 	source = newSource("include_at_match", "", "^world")
-	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("a brand new world"), &source, ""))
+	shouldProcess, redactedMessage = p.applyProcessingRules(newMessage([]byte("a brand new world"), &source, ""))
 	assert.Equal(t, false, shouldProcess)
 	assert.Nil(t, redactedMessage)
 }
@@ -74,19 +74,19 @@ func TestExclusionWithInclusion(t *testing.T) {
 
 	source := sources.LogSource{Config: &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{iRule}}}
 
-	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("bob@datadoghq.com"), &source, ""))
+	shouldProcess, redactedMessage = p.applyProcessingRules(newMessage([]byte("bob@datadoghq.com"), &source, ""))
 	assert.Equal(t, false, shouldProcess)
 	assert.Nil(t, redactedMessage)
 
-	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("bill@datadoghq.com"), &source, ""))
+	shouldProcess, redactedMessage = p.applyProcessingRules(newMessage([]byte("bill@datadoghq.com"), &source, ""))
 	assert.Equal(t, true, shouldProcess)
 	assert.Equal(t, []byte("bill@datadoghq.com"), redactedMessage)
 
-	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("bob@amail.com"), &source, ""))
+	shouldProcess, redactedMessage = p.applyProcessingRules(newMessage([]byte("bob@amail.com"), &source, ""))
 	assert.Equal(t, false, shouldProcess)
 	assert.Nil(t, redactedMessage)
 
-	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("bill@amail.com"), &source, ""))
+	shouldProcess, redactedMessage = p.applyProcessingRules(newMessage([]byte("bill@amail.com"), &source, ""))
 	assert.Equal(t, false, shouldProcess)
 	assert.Nil(t, redactedMessage)
 }
@@ -98,34 +98,34 @@ func TestMask(t *testing.T) {
 	var redactedMessage []byte
 
 	source := newSource("mask_sequences", "[masked_world]", "world")
-	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("hello"), &source, ""))
+	shouldProcess, redactedMessage = p.applyProcessingRules(newMessage([]byte("hello"), &source, ""))
 	assert.Equal(t, true, shouldProcess)
 	assert.Equal(t, []byte("hello"), redactedMessage)
 
-	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("hello world!"), &source, ""))
+	shouldProcess, redactedMessage = p.applyProcessingRules(newMessage([]byte("hello world!"), &source, ""))
 	assert.Equal(t, true, shouldProcess)
 	assert.Equal(t, []byte("hello [masked_world]!"), redactedMessage)
 
 	source = newSource("mask_sequences", "[masked_user]", "User=\\w+@datadoghq.com")
-	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("new test launched by User=beats@datadoghq.com on localhost"), &source, ""))
+	shouldProcess, redactedMessage = p.applyProcessingRules(newMessage([]byte("new test launched by User=beats@datadoghq.com on localhost"), &source, ""))
 	assert.Equal(t, true, shouldProcess)
 	assert.Equal(t, []byte("new test launched by [masked_user] on localhost"), redactedMessage)
 
 	source = newSource("mask_sequences", "[masked_credit_card]", "(?:4[0-9]{12}(?:[0-9]{3})?|[25][1-7][0-9]{14}|6(?:011|5[0-9][0-9])[0-9]{12}|3[47][0-9]{13}|3(?:0[0-5]|[68][0-9])[0-9]{11}|(?:2131|1800|35\\d{3})\\d{11})")
-	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("The credit card 4323124312341234 was used to buy some time"), &source, ""))
+	shouldProcess, redactedMessage = p.applyProcessingRules(newMessage([]byte("The credit card 4323124312341234 was used to buy some time"), &source, ""))
 	assert.Equal(t, true, shouldProcess)
 	assert.Equal(t, []byte("The credit card [masked_credit_card] was used to buy some time"), redactedMessage)
 
 	source = newSource("mask_sequences", "${1}[masked_value]", "([Dd]ata_?values=)\\S+")
-	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("New data added to Datavalues=123456 on prod"), &source, ""))
+	shouldProcess, redactedMessage = p.applyProcessingRules(newMessage([]byte("New data added to Datavalues=123456 on prod"), &source, ""))
 	assert.Equal(t, true, shouldProcess)
 	assert.Equal(t, []byte("New data added to Datavalues=[masked_value] on prod"), redactedMessage)
 
-	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("New data added to data_values=123456 on prod"), &source, ""))
+	shouldProcess, redactedMessage = p.applyProcessingRules(newMessage([]byte("New data added to data_values=123456 on prod"), &source, ""))
 	assert.Equal(t, true, shouldProcess)
 	assert.Equal(t, []byte("New data added to data_values=[masked_value] on prod"), redactedMessage)
 
-	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("New data added to data_values= on prod"), &source, ""))
+	shouldProcess, redactedMessage = p.applyProcessingRules(newMessage([]byte("New data added to data_values= on prod"), &source, ""))
 	assert.Equal(t, true, shouldProcess)
 	assert.Equal(t, []byte("New data added to data_values= on prod"), redactedMessage)
 }
@@ -136,10 +136,144 @@ func TestTruncate(t *testing.T) {
 	source := sources.NewLogSource("", &config.LogsConfig{})
 	var redactedMessage []byte
 
-	_, redactedMessage = p.applyRedactingRules(newMessage([]byte("hello"), source, ""))
+	_, redactedMessage = p.applyProcessingRules(newMessage([]byte("hello"), source, ""))
 	assert.Equal(t, []byte("hello"), redactedMessage)
 }
 
+func TestExtractFields(t *testing.T) {
+	extractRule := &config.ProcessingRule{Type: config.ExtractFields, Name: "extract", Pattern: `user=%{NOTSPACE:user} status=%{INT:status}`}
+	assert.Nil(t, config.CompileProcessingRules([]*config.ProcessingRule{extractRule}))
+	p := &Processor{processingRules: []*config.ProcessingRule{extractRule}}
+	source := sources.NewLogSource("", &config.LogsConfig{})
+
+	msg := newMessage([]byte("request user=bob status=200 done"), source, "")
+	shouldProcess, redactedMessage := p.applyProcessingRules(msg)
+	assert.True(t, shouldProcess)
+	assert.Equal(t, []byte("request user=bob status=200 done"), redactedMessage)
+	assert.Equal(t, map[string]string{"user": "bob", "status": "200"}, msg.Attributes)
+
+	msg = newMessage([]byte("no fields"), source, "")
+	shouldProcess, _ = p.applyProcessingRules(msg)
+	assert.True(t, shouldProcess)
+	assert.Nil(t, msg.Attributes)
+}
+
+func TestExtractFieldsOrder(t *testing.T) {
+	extractRule := &config.ProcessingRule{Type: config.ExtractFields, Name: "extract", Pattern: `card=%{NOTSPACE:card}`}
+	assert.Nil(t, config.CompileProcessingRules([]*config.ProcessingRule{extractRule}))
+	maskRule := newProcessingRule("mask_sequences", "[masked_card]", `\d{16}`)
+	excludeRule := newProcessingRule("exclude_at_match", "", "DEBUG")
+	userRule := &config.ProcessingRule{Type: config.ExtractFields, Name: "extract_user", Pattern: `user=%{NOTSPACE:user}`}
+	assert.Nil(t, config.CompileProcessingRules([]*config.ProcessingRule{userRule}))
+	p := &Processor{processingRules: []*config.ProcessingRule{extractRule, maskRule, excludeRule, userRule}}
+	source := sources.NewLogSource("", &config.LogsConfig{})
+
+	// the attributes extracted before a mask_sequences rule are masked too
+	msg := newMessage([]byte("INFO card=4323124312341234 user=bob"), source, "")
+	shouldProcess, redactedMessage := p.applyProcessingRules(msg)
+	assert.True(t, shouldProcess)
+	assert.Equal(t, []byte("INFO card=[masked_card] user=bob"), redactedMessage)
+	assert.Equal(t, map[string]string{"card": "[masked_card]", "user": "bob"}, msg.Attributes)
+
+	// the rules after an exclusion are skipped when no log metric is generated from the dropped message
+	msg = newMessage([]byte("DEBUG card=4323124312341234 user=bob"), source, "")
+	shouldProcess, content := p.applyProcessingRules(msg)
+	assert.False(t, shouldProcess)
+	assert.Nil(t, content)
+	assert.Equal(t, map[string]string{"card": "[masked_card]"}, msg.Attributes)
+
+	// the rules after an exclusion still redact and extract the fields of the dropped message
+	// when log metrics are generated from it
+	generator, _ := newCompiledLogMetricsGenerator(t, &config.LogMetric{Name: "debug_logs", Type: config.CountMetric, Pattern: "DEBUG"})
+	p.logMetrics = generator.newBuffer()
+	msg = newMessage([]byte("DEBUG card=4323124312341234 user=bob"), source, "")
+	shouldProcess, content = p.applyProcessingRules(msg)
+	assert.False(t, shouldProcess)
+	assert.Equal(t, []byte("DEBUG card=[masked_card] user=bob"), content)
+	assert.Equal(t, map[string]string{"card": "[masked_card]", "user": "bob"}, msg.Attributes)
+
+	// the attributes set by the parser are masked too
+	msg = newMessage([]byte("INFO user=bob"), source, "")
+	msg.SetAttribute("syslog.structured_data.payment.card", "4323124312341234")
+	_, _ = p.applyProcessingRules(msg)
+	assert.Equal(t, map[string]string{"syslog.structured_data.payment.card": "[masked_card]", "user": "bob"}, msg.Attributes)
+}
+
+func TestFieldRules(t *testing.T) {
+	extractRule := &config.ProcessingRule{Type: config.ExtractFields, Name: "extract", Pattern: `user=(?P<user>\S+) status=(?P<status>\d+)`}
+	assert.Nil(t, config.CompileProcessingRules([]*config.ProcessingRule{extractRule}))
+
+	excludeRule := newProcessingRule("exclude_at_match", "", "^5")
+	excludeRule.Field = "status"
+	maskRule := newProcessingRule("mask_sequences", "[masked_user]", ".+")
+	maskRule.Field = "user"
+	p := &Processor{processingRules: []*config.ProcessingRule{extractRule, excludeRule, maskRule}}
+	source := sources.NewLogSource("", &config.LogsConfig{})
+
+	msg := newMessage([]byte("user=bob status=200"), source, "")
+	shouldProcess, redactedMessage := p.applyProcessingRules(msg)
+	assert.True(t, shouldProcess)
+	// field rules leave the raw message untouched
+	assert.Equal(t, []byte("user=bob status=200"), redactedMessage)
+	assert.Equal(t, map[string]string{"user": "[masked_user]", "status": "200"}, msg.Attributes)
+
+	shouldProcess, _ = p.applyProcessingRules(newMessage([]byte("user=bob status=503"), source, ""))
+	assert.False(t, shouldProcess)
+
+	// a missing field does not match
+	shouldProcess, _ = p.applyProcessingRules(newMessage([]byte("status=503"), source, ""))
+	assert.True(t, shouldProcess)
+
+	includeRule := newProcessingRule("include_at_match", "", "^2")
+	includeRule.Field = "status"
+	p = &Processor{processingRules: []*config.ProcessingRule{extractRule, includeRule}}
+	shouldProcess, _ = p.applyProcessingRules(newMessage([]byte("user=bob status=200"), source, ""))
+	assert.True(t, shouldProcess)
+	shouldProcess, _ = p.applyProcessingRules(newMessage([]byte("status=200"), source, ""))
+	assert.False(t, shouldProcess)
+}
+
+func TestSampling(t *testing.T) {
+	rule := newProcessingRule("sample_at_match", "", "DEBUG")
+	rule.KeepOneIn = 2
+	p := &Processor{processingRules: []*config.ProcessingRule{rule}}
+	source := sources.NewLogSource("", &config.LogsConfig{})
+	otherSource := sources.NewLogSource("", &config.LogsConfig{})
+
+	var kept []bool
+	for i := 0; i < 4; i++ {
+		shouldProcess, _ := p.applyProcessingRules(newMessage([]byte("DEBUG hello"), source, ""))
+		kept = append(kept, shouldProcess)
+	}
+	assert.Equal(t, []bool{true, false, true, false}, kept)
+	assert.Equal(t, int64(2), source.DroppedMessages.Load())
+
+	// messages not matching the rule are always kept
+	shouldProcess, _ := p.applyProcessingRules(newMessage([]byte("INFO hello"), source, ""))
+	assert.True(t, shouldProcess)
+
+	// sources are sampled independently
+	shouldProcess, _ = p.applyProcessingRules(newMessage([]byte("DEBUG hello"), otherSource, ""))
+	assert.True(t, shouldProcess)
+	assert.Equal(t, int64(0), otherSource.DroppedMessages.Load())
+}
+
+func TestRateLimiting(t *testing.T) {
+	rule := newProcessingRule("rate_limit_at_match", "", "DEBUG")
+	rule.MaxPerSecond = 1
+	p := &Processor{processingRules: []*config.ProcessingRule{rule}}
+	parentSource := sources.NewLogSource("", &config.LogsConfig{})
+	source := sources.NewLogSource("", &config.LogsConfig{})
+	source.ParentSource = parentSource
+
+	shouldProcess, _ := p.applyProcessingRules(newMessage([]byte("DEBUG hello"), source, ""))
+	assert.True(t, shouldProcess)
+	shouldProcess, _ = p.applyProcessingRules(newMessage([]byte("DEBUG hello"), source, ""))
+	assert.False(t, shouldProcess)
+	assert.Equal(t, int64(1), source.DroppedMessages.Load())
+	assert.Equal(t, int64(1), parentSource.DroppedMessages.Load())
+}
+
 func newProcessingRule(ruleType, replacePlaceholder, pattern string) *config.ProcessingRule {
 	return &config.ProcessingRule{
 		Type:               ruleType,
@@ -0,0 +1,175 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
+	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// ArchiveCompletedOffset is the offset recorded in the registry for an archive
+// which has been read to completion.
+const ArchiveCompletedOffset = auditor.ArchiveCompletedOffset
+
+// compressions of the archives the tailer can read
+const (
+	gzipCompression = "gzip"
+	zstdCompression = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// detectCompression returns the compression of the file at path from its
+// magic number, or an empty string if it is not compressed.
+func detectCompression(path string) (string, error) {
+	f, err := filesystem.OpenShared(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	header := make([]byte, len(zstdMagic))
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	header = header[:n]
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return gzipCompression, nil
+	case bytes.HasPrefix(header, zstdMagic):
+		return zstdCompression, nil
+	default:
+		return "", nil
+	}
+}
+
+// zstdReadCloser adapts a zstd decoder to io.ReadCloser.
+type zstdReadCloser struct {
+	*zstd.Decoder
+}
+
+func (r zstdReadCloser) Close() error {
+	r.Decoder.Close()
+	return nil
+}
+
+// newDecompressingReader returns a reader decompressing r with the given compression.
+func newDecompressingReader(r io.Reader, compression string) (io.ReadCloser, error) {
+	switch compression {
+	case gzipCompression:
+		return gzip.NewReader(r)
+	case zstdCompression:
+		decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return zstdReadCloser{decoder}, nil
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", compression)
+	}
+}
+
+// IsArchive returns true if the tailer reads a compressed archive once to
+// completion rather than tailing a file.
+func (t *Tailer) IsArchive() bool {
+	return t.compression != ""
+}
+
+// IsArchiveCompleted returns true if the tailer read its archive to completion.
+func (t *Tailer) IsArchiveCompleted() bool {
+	return t.archiveCompleted.Load()
+}
+
+// setupArchive opens the archive and skips the decompressed content already
+// read, archives are always read from their beginning or their last offset.
+func (t *Tailer) setupArchive(offset int64, whence int) error {
+	fullpath, err := filepath.Abs(t.file.Path)
+	if err != nil {
+		return err
+	}
+	t.fullpath = fullpath
+
+	// adds metadata to enable users to filter logs by filename
+	t.tags = t.buildTailerTags()
+
+	log.Info("Opening archive", t.file.Path, "for tailer key", t.file.GetScanKey())
+	f, err := filesystem.OpenShared(fullpath)
+	if err != nil {
+		return err
+	}
+	reader, err := newDecompressingReader(f, t.compression)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if whence != io.SeekStart {
+		offset = 0
+	}
+	skipped, err := io.CopyN(ioutil.Discard, reader, offset)
+	if err != nil && err != io.EOF {
+		reader.Close()
+		f.Close()
+		return err
+	}
+
+	t.osFile = f
+	t.archiveReader = reader
+	t.lastReadOffset.Store(skipped)
+	t.decodedOffset.Store(skipped)
+
+	return nil
+}
+
+// readArchive decompresses the archive until its end or until the tailer is stopped.
+func (t *Tailer) readArchive() {
+	defer func() {
+		t.archiveReader.Close()
+		t.osFile.Close()
+		t.decoder.Stop()
+		log.Info("Closed archive", t.file.Path, "for tailer key", t.file.GetScanKey(), "read", t.Source().BytesRead.Load(), "bytes and", t.decoder.GetLineCount(), "lines")
+	}()
+
+	for {
+		select {
+		case <-t.stop:
+			return
+		default:
+		}
+
+		inBuf := make([]byte, 4096)
+		n, err := t.archiveReader.Read(inBuf)
+		if n > 0 {
+			t.decoder.InputChan <- decoder.NewInput(inBuf[:n])
+			t.lastReadOffset.Add(int64(n))
+			t.recordBytes(int64(n))
+		}
+		if err == io.EOF {
+			log.Infof("Finished reading archive %s", t.file.Path)
+			t.archiveCompleted.Store(true)
+			return
+		}
+		if err != nil {
+			t.file.Source.Status().Error(err)
+			log.Warnf("Unexpected error occurred while reading archive %s: %v", t.file.Path, err)
+			return
+		}
+	}
+}
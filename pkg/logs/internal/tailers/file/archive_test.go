@@ -0,0 +1,126 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+)
+
+const archiveContent = "first line\nsecond line\nthird line\n"
+
+func writeArchive(t *testing.T, path string, compression string) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var w io.WriteCloser
+	switch compression {
+	case gzipCompression:
+		w = gzip.NewWriter(f)
+	case zstdCompression:
+		w, err = zstd.NewWriter(f)
+		require.NoError(t, err)
+	default:
+		w = f
+	}
+	_, err = w.Write([]byte(archiveContent))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+}
+
+func newArchiveTailer(path string, readCompressedFiles bool, outputChan chan *message.Message) *Tailer {
+	source := sources.NewReplaceableSource(sources.NewLogSource("", &config.LogsConfig{
+		Type:                config.FileType,
+		Path:                path,
+		ReadCompressedFiles: readCompressedFiles,
+	}))
+	return NewTailer(outputChan, NewFile(path, source.UnderlyingSource(), false), 10*time.Millisecond, decoder.NewDecoderFromSource(source))
+}
+
+func TestDetectCompression(t *testing.T) {
+	dir := t.TempDir()
+	for _, compression := range []string{gzipCompression, zstdCompression, ""} {
+		path := filepath.Join(dir, "test.log"+compression)
+		writeArchive(t, path, compression)
+		detected, err := detectCompression(path)
+		assert.NoError(t, err)
+		assert.Equal(t, compression, detected)
+	}
+
+	path := filepath.Join(dir, "empty.log")
+	require.NoError(t, os.WriteFile(path, nil, 0644))
+	detected, err := detectCompression(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "", detected)
+}
+
+func TestReadArchive(t *testing.T) {
+	for _, compression := range []string{gzipCompression, zstdCompression} {
+		t.Run(compression, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.log."+compression)
+			writeArchive(t, path, compression)
+
+			outputChan := make(chan *message.Message, 10)
+			tailer := newArchiveTailer(path, true, outputChan)
+			require.True(t, tailer.IsArchive())
+			require.NoError(t, tailer.StartFromBeginning())
+
+			msg := <-outputChan
+			assert.Equal(t, "first line", string(msg.Content))
+			assert.Equal(t, "11", msg.Origin.Offset)
+			msg = <-outputChan
+			assert.Equal(t, "second line", string(msg.Content))
+			assert.Equal(t, "23", msg.Origin.Offset)
+			msg = <-outputChan
+			assert.Equal(t, "third line", string(msg.Content))
+			assert.Equal(t, ArchiveCompletedOffset, msg.Origin.Offset)
+
+			assert.Eventually(t, tailer.IsFinished, 5*time.Second, 10*time.Millisecond)
+			assert.True(t, tailer.IsArchiveCompleted())
+
+			didRotate, err := tailer.DidRotate()
+			assert.NoError(t, err)
+			assert.False(t, didRotate)
+			tailer.Stop()
+		})
+	}
+}
+
+func TestReadArchiveFromOffset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log.gz")
+	writeArchive(t, path, gzipCompression)
+
+	outputChan := make(chan *message.Message, 10)
+	tailer := newArchiveTailer(path, true, outputChan)
+	require.NoError(t, tailer.Start(23, io.SeekStart))
+
+	msg := <-outputChan
+	assert.Equal(t, "third line", string(msg.Content))
+	assert.Equal(t, ArchiveCompletedOffset, msg.Origin.Offset)
+	tailer.Stop()
+}
+
+func TestCompressedFileNotReadWithoutOption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log.gz")
+	writeArchive(t, path, gzipCompression)
+
+	tailer := newArchiveTailer(path, false, make(chan *message.Message, 10))
+	assert.False(t, tailer.IsArchive())
+}
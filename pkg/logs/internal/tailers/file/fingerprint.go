@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"hash/crc64"
+	"io"
+	"strconv"
+
+	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
+)
+
+var crc64Table = crc64.MakeTable(crc64.ECMA)
+
+// computeFingerprint returns a checksum of the first byteCount bytes of r,
+// or an empty string when fewer bytes are available, in which case the
+// content is not significant enough yet to identify the file.
+func computeFingerprint(r io.ReaderAt, byteCount int) (string, error) {
+	if byteCount <= 0 {
+		return "", nil
+	}
+	buf := make([]byte, byteCount)
+	n, err := r.ReadAt(buf, 0)
+	if n < byteCount {
+		if err == io.EOF {
+			err = nil
+		}
+		return "", err
+	}
+	return strconv.FormatUint(crc64.Checksum(buf, crc64Table), 16), nil
+}
+
+// computeFileFingerprint returns the fingerprint of the file currently found at path.
+func computeFileFingerprint(path string, byteCount int) (string, error) {
+	if byteCount <= 0 {
+		return "", nil
+	}
+	f, err := filesystem.OpenShared(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return computeFingerprint(f, byteCount)
+}
+
+// Fingerprint returns the fingerprint identifying the tailed file, or an
+// empty string if fingerprinting is disabled or the file is too small to
+// be identified yet.
+func (t *Tailer) Fingerprint() string {
+	if fingerprint := t.fingerprint.Load(); fingerprint != "" || t.fingerprintByteCount <= 0 {
+		return fingerprint
+	}
+	var fingerprint string
+	var err error
+	if t.osFile != nil {
+		fingerprint, err = computeFingerprint(t.osFile, t.fingerprintByteCount)
+	} else {
+		fingerprint, err = computeFileFingerprint(t.file.Path, t.fingerprintByteCount)
+	}
+	if err != nil {
+		return ""
+	}
+	t.fingerprint.Store(fingerprint)
+	return fingerprint
+}
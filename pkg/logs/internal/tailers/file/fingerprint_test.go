@@ -0,0 +1,36 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeFingerprint(t *testing.T) {
+	fingerprint, err := computeFingerprint(strings.NewReader("short"), 8)
+	assert.NoError(t, err)
+	assert.Empty(t, fingerprint, "a file smaller than the byte count can not be identified")
+
+	fingerprint, err = computeFingerprint(strings.NewReader("hello world"), 0)
+	assert.NoError(t, err)
+	assert.Empty(t, fingerprint, "fingerprinting is disabled")
+
+	fingerprint, err = computeFingerprint(strings.NewReader("hello world"), 8)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, fingerprint)
+
+	// only the first bytes are significant
+	other, err := computeFingerprint(strings.NewReader("hello wo, and more content"), 8)
+	assert.NoError(t, err)
+	assert.Equal(t, fingerprint, other)
+
+	other, err = computeFingerprint(strings.NewReader("good bye world"), 8)
+	assert.NoError(t, err)
+	assert.NotEqual(t, fingerprint, other)
+}
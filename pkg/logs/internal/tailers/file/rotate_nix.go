@@ -21,7 +21,17 @@ import (
 // - renamed and recreated
 // - removed and recreated
 // - truncated
+//
+// When fingerprinting is enabled and both files are large enough to be
+// fingerprinted, the file is also considered rotated when the content at its
+// beginning changed, which detects a truncated file that grew again past the
+// last offset read and a new file reusing the inode of the previous one.
 func (t *Tailer) DidRotate() (bool, error) {
+	if t.IsArchive() {
+		// archives are read once and never rotated
+		return false, nil
+	}
+
 	f, err := filesystem.OpenShared(t.osFile.Name())
 	if err != nil {
 		return false, err
@@ -48,6 +58,15 @@ func (t *Tailer) DidRotate() (bool, error) {
 		log.Debugf("File rotation detected due to recreation, f1: %+v, f2: %+v", fi1, fi2)
 	} else if truncated {
 		log.Debugf("File rotation detected due to size change, lastReadOffset=%d, fileSize=%d", lastReadOffset, fileSize)
+	} else if fingerprint := t.Fingerprint(); fingerprint != "" {
+		currentFingerprint, err := computeFingerprint(f, t.fingerprintByteCount)
+		if err != nil {
+			return false, err
+		}
+		if currentFingerprint != "" && currentFingerprint != fingerprint {
+			log.Debugf("File rotation detected due to fingerprint change, fingerprint=%s, currentFingerprint=%s", fingerprint, currentFingerprint)
+			return true, nil
+		}
 	}
 
 	return recreated || truncated, nil
@@ -18,6 +18,11 @@ import (
 // On Windows, log rotation is identified by the file size being smaller
 // than the last offset read.
 func (t *Tailer) DidRotate() (bool, error) {
+	if t.IsArchive() {
+		// archives are read once and never rotated
+		return false, nil
+	}
+
 	f, err := filesystem.OpenShared(t.fullpath)
 	if err != nil {
 		return false, err
@@ -94,6 +94,24 @@ type Tailer struct {
 	// didFileRotate is true when we are tailing a file after it has been rotated
 	didFileRotate *atomic.Bool
 
+	// fingerprintByteCount is the number of bytes at the beginning of the file
+	// used to fingerprint it, 0 when fingerprinting is disabled.
+	fingerprintByteCount int
+
+	// fingerprint identifies the tailed file by its content, it is empty until
+	// the file is large enough to be fingerprinted.
+	fingerprint *atomic.String
+
+	// compression is the compression of the file when it is an archive read
+	// once to completion, empty for a plain file.
+	compression string
+
+	// archiveReader decompresses the content of the archive.
+	archiveReader io.ReadCloser
+
+	// archiveCompleted is true when the archive has been read to completion.
+	archiveCompleted *atomic.Bool
+
 	// stop is monitored by the readForever component, and causes it to stop reading
 	// and close the channel to the decoder.
 	stop chan struct{}
@@ -133,6 +151,18 @@ func NewTailer(outputChan chan *message.Message, file *File, sleepDuration time.
 	forwardContext, stopForward := context.WithCancel(context.Background())
 	closeTimeout := coreConfig.Datadog.GetDuration("logs_config.close_timeout") * time.Second
 	windowsOpenFileTimeout := coreConfig.Datadog.GetDuration("logs_config.windows_open_file_timeout") * time.Second
+	var fingerprintByteCount int
+	if coreConfig.Datadog.GetBool("logs_config.fingerprint_enabled") {
+		fingerprintByteCount = coreConfig.Datadog.GetInt("logs_config.fingerprint_byte_count")
+	}
+
+	var compression string
+	if file.Source.Config().ReadCompressedFiles {
+		var err error
+		if compression, err = detectCompression(file.Path); err != nil {
+			log.Debugf("Could not detect the compression of %s: %v", file.Path, err)
+		}
+	}
 
 	return &Tailer{
 		file:                   file,
@@ -150,6 +180,10 @@ func NewTailer(outputChan chan *message.Message, file *File, sleepDuration time.
 		stopForward:            stopForward,
 		isFinished:             atomic.NewBool(false),
 		didFileRotate:          atomic.NewBool(false),
+		fingerprintByteCount:   fingerprintByteCount,
+		fingerprint:            atomic.NewString(""),
+		compression:            compression,
+		archiveCompleted:       atomic.NewBool(false),
 	}
 }
 
@@ -168,22 +202,33 @@ func (t *Tailer) Identifier() string {
 	//
 	// This is the identifier used in the registry, so changing it will invalidate existing
 	// registry entries on upgrade.
-	return fmt.Sprintf("file:%s", t.file.Path)
+	return t.file.Identifier()
 }
 
 // Start begins the tailer's operation in a dedicated goroutine.
 func (t *Tailer) Start(offset int64, whence int) error {
-	err := t.setup(offset, whence)
+	var err error
+	if t.IsArchive() {
+		err = t.setupArchive(offset, whence)
+	} else {
+		err = t.setup(offset, whence)
+	}
 	if err != nil {
 		t.file.Source.Status().Error(err)
 		return err
 	}
 	t.file.Source.Status().Success()
 	t.file.Source.AddInput(t.file.Path)
+	// identify the file before reading it when fingerprinting is enabled
+	t.Fingerprint()
 
 	go t.forwardMessages()
 	t.decoder.Start()
-	go t.readForever()
+	if t.IsArchive() {
+		go t.readArchive()
+	} else {
+		go t.readForever()
+	}
 
 	return nil
 }
@@ -282,30 +327,58 @@ func (t *Tailer) forwardMessages() {
 		t.isFinished.Store(true)
 		close(t.done)
 	}()
+	// the last message of an archive is held until the end of the archive to
+	// record its completion
+	var pending *message.Message
 	for output := range t.decoder.OutputChan {
 		offset := t.decodedOffset.Load() + int64(output.RawDataLen)
 		identifier := t.Identifier()
+		fingerprint := t.fingerprint.Load()
 		if t.didFileRotate.Load() {
-			offset = 0
+			// the path now belongs to another file, only the fingerprint
+			// still identifies the rotated one
+			if fingerprint == "" {
+				offset = 0
+			}
 			identifier = ""
 		}
 		t.decodedOffset.Store(offset)
 		origin := message.NewOrigin(t.file.Source.UnderlyingSource())
 		origin.Identifier = identifier
+		origin.Fingerprint = fingerprint
 		origin.Offset = strconv.FormatInt(offset, 10)
 		origin.SetTags(append(t.tags, t.tagProvider.GetTags()...))
 		// Ignore empty lines once the registry offset is updated
 		if len(output.Content) == 0 {
 			continue
 		}
-		// Make the write to the output chan cancellable to be able to stop the tailer
-		// after a file rotation when it is stuck on it.
-		// We don't return directly to keep the same shutdown sequence that in the
-		// normal case.
-		select {
-		case t.outputChan <- message.NewMessage(output.Content, origin, output.Status, output.IngestionTimestamp):
-		case <-t.forwardContext.Done():
+		msg := message.NewMessage(output.Content, origin, output.Status, output.IngestionTimestamp)
+		if !t.IsArchive() {
+			t.forward(msg)
+			continue
+		}
+		if pending != nil {
+			t.forward(pending)
 		}
+		pending = msg
+	}
+	if pending != nil {
+		if t.archiveCompleted.Load() {
+			pending.Origin.Offset = ArchiveCompletedOffset
+		}
+		t.forward(pending)
+	}
+}
+
+// forward sends a message to the output channel.
+func (t *Tailer) forward(msg *message.Message) {
+	// Make the write to the output chan cancellable to be able to stop the tailer
+	// after a file rotation when it is stuck on it.
+	// We don't return directly to keep the same shutdown sequence that in the
+	// normal case.
+	select {
+	case t.outputChan <- msg:
+	case <-t.forwardContext.Done():
 	}
 }
 
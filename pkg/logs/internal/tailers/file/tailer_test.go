@@ -231,6 +231,31 @@ func (suite *TailerTestSuite) TestTailerIdentifier() {
 	suite.Equal(fmt.Sprintf("file:%s/tailer.log", suite.testDir), suite.tailer.Identifier())
 }
 
+func (suite *TailerTestSuite) TestDidRotateWithFingerprint() {
+	suite.tailer.fingerprintByteCount = 8
+
+	_, err := suite.testFile.WriteString("hello world\n")
+	suite.Nil(err)
+	suite.Nil(suite.tailer.StartFromBeginning())
+
+	msg := <-suite.outputChan
+	suite.NotEmpty(msg.Origin.Fingerprint)
+	suite.Equal(suite.tailer.Fingerprint(), msg.Origin.Fingerprint)
+
+	didRotate, err := suite.tailer.DidRotate()
+	suite.Nil(err)
+	suite.False(didRotate)
+
+	// copytruncate, the file grew past the last offset read before the next scan
+	suite.Nil(suite.testFile.Truncate(0))
+	_, err = suite.testFile.WriteAt([]byte("good bye world, see you soon\n"), 0)
+	suite.Nil(err)
+
+	didRotate, err = suite.tailer.DidRotate()
+	suite.Nil(err)
+	suite.True(didRotate)
+}
+
 func (suite *TailerTestSuite) TestOriginTagsWhenTailingFiles() {
 
 	suite.tailer.StartFromBeginning()
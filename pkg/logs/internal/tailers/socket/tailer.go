@@ -8,11 +8,15 @@ package socket
 import (
 	"io"
 	"net"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/framer"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers/noop"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers/syslog"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/sources"
 )
@@ -36,12 +40,20 @@ func NewTailer(source *sources.LogSource, conn net.Conn, outputChan chan *messag
 		Conn:       conn,
 		outputChan: outputChan,
 		read:       read,
-		decoder:    decoder.InitializeDecoder(sources.NewReplaceableSource(source), noop.New()),
+		decoder:    newDecoder(source),
 		stop:       make(chan struct{}, 1),
 		done:       make(chan struct{}, 1),
 	}
 }
 
+// newDecoder returns a decoder parsing the messages with the parser of the source.
+func newDecoder(source *sources.LogSource) *decoder.Decoder {
+	if source.Config.Parser == config.SyslogParser {
+		return decoder.NewDecoderWithFraming(sources.NewReplaceableSource(source), syslog.New(), framer.Syslog, nil)
+	}
+	return decoder.InitializeDecoder(sources.NewReplaceableSource(source), noop.New())
+}
+
 // Start prepares the tailer to read and decode data from the connection
 func (t *Tailer) Start() {
 	go t.forwardMessages()
@@ -64,9 +76,29 @@ func (t *Tailer) forwardMessages() {
 	}()
 	for output := range t.decoder.OutputChan {
 		if len(output.Content) > 0 {
-			t.outputChan <- message.NewMessageWithSource(output.Content, message.StatusInfo, t.source, output.IngestionTimestamp)
+			t.outputChan <- t.newMessage(output)
+		}
+	}
+}
+
+// newMessage returns a message with the content and the metadata parsed from the connection.
+func (t *Tailer) newMessage(output *decoder.Message) *message.Message {
+	status := output.Status
+	if status == "" {
+		status = message.StatusInfo
+	}
+	msg := message.NewMessageWithSource(output.Content, status, t.source, output.IngestionTimestamp)
+	msg.Hostname = output.Hostname
+	msg.Attributes = output.Attributes
+	if len(output.Tags) > 0 {
+		msg.Origin.SetTags(output.Tags)
+	}
+	if output.Timestamp != "" {
+		if timestamp, err := time.Parse(config.DateFormat, output.Timestamp); err == nil {
+			msg.Timestamp = timestamp
 		}
 	}
+	return msg
 }
 
 // readForever reads the data from conn.
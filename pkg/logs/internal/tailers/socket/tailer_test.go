@@ -7,8 +7,10 @@ package socket
 
 import (
 	"errors"
+	"fmt"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -40,6 +42,35 @@ func TestReadAndForwardShouldSucceedWithSuccessfulRead(t *testing.T) {
 	tailer.Stop()
 }
 
+func TestReadAndForwardSyslogMessages(t *testing.T) {
+	msgChan := make(chan *message.Message)
+	r, w := net.Pipe()
+	tailer := NewTailer(sources.NewLogSource("", &config.LogsConfig{Parser: config.SyslogParser}), r, msgChan, read)
+	tailer.Start()
+
+	var msg *message.Message
+
+	// octet-counted message
+	syslogMsg := "<11>1 2003-10-11T22:14:15.003Z mymachine.example.com su - ID47 - 'su root' failed"
+	go w.Write([]byte(fmt.Sprintf("%d %s", len(syslogMsg), syslogMsg)))
+	msg = <-msgChan
+	assert.Equal(t, "'su root' failed", string(msg.Content))
+	assert.Equal(t, message.StatusError, msg.GetStatus())
+	assert.Equal(t, "mymachine.example.com", msg.GetHostname())
+	assert.Equal(t, time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC), msg.Timestamp)
+	assert.ElementsMatch(t, []string{"syslog_facility:user", "syslog_appname:su"}, msg.Origin.Tags())
+	assert.Equal(t, "ID47", msg.Attributes["syslog.msgid"])
+
+	// newline-terminated message
+	go w.Write([]byte("<34>Oct 11 22:14:15 mymachine su: 'su root' failed\n"))
+	msg = <-msgChan
+	assert.Equal(t, "'su root' failed", string(msg.Content))
+	assert.Equal(t, message.StatusCritical, msg.GetStatus())
+	assert.Equal(t, "mymachine", msg.GetHostname())
+
+	tailer.Stop()
+}
+
 func TestReadShouldFailWithError(t *testing.T) {
 	msgChan := make(chan *message.Message)
 	r, w := net.Pipe()
@@ -15,6 +15,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery"
 	"github.com/DataDog/datadog-agent/pkg/logs/client/http"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/metrics"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/processor"
 	"github.com/DataDog/datadog-agent/pkg/logs/sources"
 	"github.com/DataDog/datadog-agent/pkg/metadata/inventories"
 
@@ -32,6 +33,7 @@ import (
 const (
 	// key used to display a warning message on the agent status
 	invalidProcessingRules = "invalid_global_processing_rules"
+	invalidLogMetrics      = "invalid_log_metrics"
 	invalidEndpoints       = "invalid_endpoints"
 	intakeTrackType        = "logs"
 
@@ -55,13 +57,15 @@ var (
 // instead of directly using it.
 // The parameter serverless indicates whether or not this Logs Agent is running
 // in a serverless environment.
-func Start(ac *autodiscovery.AutoConfig) (*Agent, error) {
-	return start(ac, false)
+// The metrics generated from the logs are sent to the aggregator, they are not
+// generated when it is nil.
+func Start(ac *autodiscovery.AutoConfig, aggregator processor.MetricAggregator) (*Agent, error) {
+	return start(ac, aggregator, false)
 }
 
 // StartServerless starts a Serverless instance of the Logs Agent.
 func StartServerless() (*Agent, error) {
-	return start(nil, true)
+	return start(nil, nil, true)
 }
 
 // buildEndpoints builds endpoints for the logs agent
@@ -76,7 +80,7 @@ func buildEndpoints(serverless bool) (*config.Endpoints, error) {
 	return config.BuildEndpointsWithVectorOverride(httpConnectivity, intakeTrackType, AgentJSONIntakeProtocol, config.DefaultIntakeOrigin)
 }
 
-func start(ac *autodiscovery.AutoConfig, serverless bool) (*Agent, error) {
+func start(ac *autodiscovery.AutoConfig, aggregator processor.MetricAggregator, serverless bool) (*Agent, error) {
 	if IsAgentRunning() {
 		return agent, nil
 	}
@@ -115,11 +119,23 @@ func start(ac *autodiscovery.AutoConfig, serverless bool) (*Agent, error) {
 		status.AddGlobalWarning(invalidProcessingRules, multiLineWarning)
 	}
 
+	// setup the metrics generated from the logs
+	var logMetrics *processor.LogMetricsGenerator
+	if !serverless {
+		globalLogMetrics, err := config.GlobalLogMetrics()
+		if err != nil {
+			message := fmt.Sprintf("Invalid log metrics: %v", err)
+			status.AddGlobalError(invalidLogMetrics, message)
+			return nil, errors.New(message)
+		}
+		logMetrics = processor.NewLogMetricsGenerator(globalLogMetrics, aggregator)
+	}
+
 	// setup and start the logs agent
 	if !serverless {
 		// regular logs agent
 		log.Info("Starting logs-agent...")
-		agent = NewAgent(sources, services, processingRules, endpoints)
+		agent = NewAgent(sources, services, processingRules, logMetrics, endpoints)
 	} else {
 		// serverless logs agent
 		log.Info("Starting a serverless logs-agent...")
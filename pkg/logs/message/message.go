@@ -21,6 +21,8 @@ type Payload struct {
 	Encoded []byte
 	// The content encoding. A header for HTTP, empty for TCP
 	Encoding string
+	// The encoded bytes for the other content encodings of the destinations, by content encoding
+	AlternateEncodings map[string][]byte
 	// The size of the unencoded payload
 	UnencodedSize int
 }
@@ -37,6 +39,13 @@ type Message struct {
 	// Optional.
 	// Used in the Serverless Agent
 	Lambda *Lambda
+	// Optional.
+	// Structured attributes extracted from the content by the processing rules,
+	// sent as JSON fields of the log
+	Attributes map[string]string
+	// Optional.
+	// The host which emitted the log, the hostname of the agent is used if not provided
+	Hostname string
 }
 
 // Lambda is a struct storing information about the Lambda function and function execution.
@@ -75,6 +84,14 @@ func NewMessageFromLambda(content []byte, origin *Origin, status string, utcTime
 	}
 }
 
+// SetAttribute sets the value of a structured attribute of the message.
+func (m *Message) SetAttribute(key, value string) {
+	if m.Attributes == nil {
+		m.Attributes = make(map[string]string)
+	}
+	m.Attributes[key] = value
+}
+
 // GetStatus gets the status of the message.
 // if status is not set, StatusInfo will be returned.
 func (m *Message) GetStatus() string {
@@ -94,6 +111,9 @@ func (m *Message) GetHostname() string {
 	if m.Lambda != nil {
 		return m.Lambda.ARN
 	}
+	if m.Hostname != "" {
+		return m.Hostname
+	}
 	hname, err := hostname.Get(context.TODO())
 	if err != nil {
 		// this scenario is not likely to happen since
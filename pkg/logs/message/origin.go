@@ -13,12 +13,13 @@ import (
 
 // Origin represents the Origin of a message
 type Origin struct {
-	Identifier string
-	LogSource  *sources.LogSource
-	Offset     string
-	service    string
-	source     string
-	tags       []string
+	Identifier  string
+	LogSource   *sources.LogSource
+	Offset      string
+	Fingerprint string
+	service     string
+	source      string
+	tags        []string
 }
 
 // NewOrigin returns a new Origin
@@ -8,6 +8,9 @@ package pipeline
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/client/http"
@@ -17,6 +20,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/processor"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/sender"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 // Pipeline processes and sends messages to the backend
@@ -30,11 +34,13 @@ type Pipeline struct {
 // NewPipeline returns a new Pipeline
 func NewPipeline(outputChan chan *message.Payload,
 	processingRules []*config.ProcessingRule,
+	logMetrics *processor.LogMetricsGenerator,
 	endpoints *config.Endpoints,
 	destinationsContext *client.DestinationsContext,
 	diagnosticMessageReceiver diagnostic.MessageReceiver,
 	serverless bool,
-	pipelineID int) *Pipeline {
+	pipelineID int,
+	numberOfPipelines int) *Pipeline {
 
 	mainDestinations := getDestinations(endpoints, destinationsContext, pipelineID)
 
@@ -43,8 +49,12 @@ func NewPipeline(outputChan chan *message.Payload,
 
 	var logsSender *sender.Sender
 
-	strategy := getStrategy(strategyInput, senderInput, endpoints, serverless, pipelineID)
-	logsSender = sender.NewSender(senderInput, outputChan, mainDestinations, config.DestinationPayloadChanSize)
+	strategy := getStrategy(strategyInput, senderInput, endpoints, mainDestinations, serverless, pipelineID)
+	if diskQueue := getDiskQueue(endpoints, serverless, pipelineID, numberOfPipelines); diskQueue != nil {
+		logsSender = sender.NewSenderWithDiskQueue(senderInput, outputChan, mainDestinations, config.DestinationPayloadChanSize, diskQueue)
+	} else {
+		logsSender = sender.NewSender(senderInput, outputChan, mainDestinations, config.DestinationPayloadChanSize)
+	}
 
 	var encoder processor.Encoder
 	if serverless {
@@ -58,7 +68,7 @@ func NewPipeline(outputChan chan *message.Payload,
 	}
 
 	inputChan := make(chan *message.Message, config.ChanSize)
-	processor := processor.New(inputChan, strategyInput, processingRules, encoder, diagnosticMessageReceiver)
+	processor := processor.New(inputChan, strategyInput, processingRules, logMetrics, encoder, diagnosticMessageReceiver)
 
 	return &Pipeline{
 		InputChan: inputChan,
@@ -111,12 +121,50 @@ func getDestinations(endpoints *config.Endpoints, destinationsContext *client.De
 	return client.NewDestinations(reliable, additionals)
 }
 
-func getStrategy(inputChan chan *message.Message, outputChan chan *message.Payload, endpoints *config.Endpoints, serverless bool, pipelineID int) sender.Strategy {
-	if endpoints.UseHTTP || serverless {
-		encoder := sender.IdentityContentType
-		if endpoints.Main.UseCompression {
-			encoder = sender.NewGzipContentEncoding(endpoints.Main.CompressionLevel)
+// getDiskQueue returns the queue storing the payloads of the pipeline on disk, nil if the storage is disabled.
+// Each pipeline has its own directory as payloads are stored and sent by the sender of the pipeline,
+// and an equal share of the maximum size of the storage.
+func getDiskQueue(endpoints *config.Endpoints, serverless bool, pipelineID int, numberOfPipelines int) *sender.DiskPayloadQueue {
+	if endpoints.DiskBuffer == nil || serverless || numberOfPipelines <= 0 {
+		return nil
+	}
+	storagePath := filepath.Join(endpoints.DiskBuffer.Path, strconv.Itoa(pipelineID))
+	maxSizeInBytes := endpoints.DiskBuffer.MaxSizeInBytes / int64(numberOfPipelines)
+	diskQueue, err := sender.NewDiskPayloadQueue(storagePath, maxSizeInBytes, endpoints.DiskBuffer.MaxDiskRatio)
+	if err != nil {
+		log.Errorf("Could not create the storage of the logs payloads in %s, payloads won't be stored on disk: %v", storagePath, err)
+		return nil
+	}
+	return diskQueue
+}
+
+// redistributeStoredPayloads moves the payloads stored by the pipelines which don't exist anymore,
+// as the agent restarted with fewer pipelines, to the directories of the current pipelines so
+// that they are sent too.
+func redistributeStoredPayloads(endpoints *config.Endpoints, serverless bool, numberOfPipelines int) {
+	if endpoints.DiskBuffer == nil || serverless || numberOfPipelines <= 0 {
+		return
+	}
+	entries, err := ioutil.ReadDir(endpoints.DiskBuffer.Path)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		pipelineID, err := strconv.Atoi(entry.Name())
+		if !entry.IsDir() || err != nil || pipelineID < numberOfPipelines {
+			continue
 		}
+		fromPath := filepath.Join(endpoints.DiskBuffer.Path, entry.Name())
+		toPath := filepath.Join(endpoints.DiskBuffer.Path, strconv.Itoa(pipelineID%numberOfPipelines))
+		if err := sender.MoveStoredPayloads(fromPath, toPath); err != nil {
+			log.Warnf("Could not move the logs payloads stored in %s to %s: %v", fromPath, toPath, err)
+		}
+	}
+}
+
+func getStrategy(inputChan chan *message.Message, outputChan chan *message.Payload, endpoints *config.Endpoints, destinations *client.Destinations, serverless bool, pipelineID int) sender.Strategy {
+	if endpoints.UseHTTP || serverless {
+		encoder := sender.NewDestinationsContentEncoding(destinations, endpoints.Main)
 		return sender.NewBatchStrategy(inputChan, outputChan, sender.ArraySerializer, endpoints.BatchWait, endpoints.BatchMaxSize, endpoints.BatchMaxContentSize, "logs", encoder)
 	}
 	return sender.NewStreamStrategy(inputChan, outputChan)
@@ -6,6 +6,10 @@
 package pipeline
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -13,6 +17,8 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/DataDog/datadog-agent/pkg/status/health"
@@ -27,7 +33,7 @@ type ProviderTestSuite struct {
 }
 
 func (suite *ProviderTestSuite) SetupTest() {
-	suite.a = auditor.New("", auditor.DefaultRegistryFilename, time.Hour, health.RegisterLiveness("fake"))
+	suite.a = auditor.New(suite.T().TempDir(), auditor.DefaultRegistryFilename, time.Hour, health.RegisterLiveness("fake"))
 	suite.p = &provider{
 		numberOfPipelines:    3,
 		auditor:              suite.a,
@@ -67,3 +73,29 @@ func (suite *ProviderTestSuite) TestProvider() {
 func TestProviderTestSuite(t *testing.T) {
 	suite.Run(t, new(ProviderTestSuite))
 }
+
+func TestRedistributeStoredPayloads(t *testing.T) {
+	path := t.TempDir()
+	endpoints := config.NewEndpoints(config.Endpoint{}, nil, true, true)
+	endpoints.DiskBuffer = &config.DiskBuffer{Path: path, MaxSizeInBytes: 3000, MaxDiskRatio: 0.8}
+
+	// the previous run of the agent had 3 pipelines
+	for i, name := range []string{"0", "1", "2"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(path, name), 0700))
+		payloadFile := filepath.Join(path, name, fmt.Sprintf("%020d_0.payload", i))
+		require.NoError(t, ioutil.WriteFile(payloadFile, []byte("{}\npayload"), 0600))
+	}
+
+	redistributeStoredPayloads(endpoints, false, 2)
+
+	entries, err := ioutil.ReadDir(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	// the payloads of the third pipeline are sent by the first one, with a share of the storage
+	queue := getDiskQueue(endpoints, false, 0, 2)
+	require.NotNil(t, queue)
+	assert.Equal(t, 2, queue.Len())
+	queue = getDiskQueue(endpoints, false, 1, 2)
+	require.NotNil(t, queue)
+	assert.Equal(t, 1, queue.Len())
+}
@@ -136,16 +136,14 @@ func (s *batchStrategy) sendMessages(messages []*message.Message, outputChan cha
 	serializedMessage := s.serializer.Serialize(messages)
 	log.Debugf("Send messages (msg_count:%d, content_size=%d, avg_msg_size=%.2f)", len(messages), len(serializedMessage), float64(len(serializedMessage))/float64(len(messages)))
 
-	encodedPayload, err := s.contentEncoding.encode(serializedMessage)
-	if err != nil {
+	payload := &message.Payload{
+		Messages:      messages,
+		UnencodedSize: len(serializedMessage),
+	}
+	if err := encodePayload(s.contentEncoding, payload, serializedMessage); err != nil {
 		log.Warn("Encoding failed - dropping payload", err)
 		return
 	}
 
-	outputChan <- &message.Payload{
-		Messages:      messages,
-		Encoded:       encodedPayload,
-		Encoding:      s.contentEncoding.name(),
-		UnencodedSize: len(serializedMessage),
-	}
+	outputChan <- payload
 }
@@ -6,8 +6,10 @@
 package sender
 
 import (
-	"bytes"
-	"compress/gzip"
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 )
 
 // ContentEncoding encodes the payload
@@ -31,43 +33,126 @@ func (c *identityContentType) encode(payload []byte) ([]byte, error) {
 
 // GzipContentEncoding encodes the payload using gzip algorithm
 type GzipContentEncoding struct {
-	level int
+	codec *compression.GzipCodec
 }
 
 // NewGzipContentEncoding creates a new Gzip content type
 func NewGzipContentEncoding(level int) *GzipContentEncoding {
-	if level < gzip.NoCompression {
-		level = gzip.NoCompression
-	} else if level > gzip.BestCompression {
-		level = gzip.BestCompression
-	}
-
 	return &GzipContentEncoding{
-		level,
+		codec: compression.NewGzipCodec(level),
 	}
 }
 
 func (c *GzipContentEncoding) name() string {
-	return "gzip"
+	return c.codec.ContentEncoding()
 }
 
 func (c *GzipContentEncoding) encode(payload []byte) ([]byte, error) {
-	var compressedPayload bytes.Buffer
-	gzipWriter, err := gzip.NewWriterLevel(&compressedPayload, c.level)
-	if err != nil {
-		return nil, err
+	return c.codec.Compress(payload)
+}
+
+// codecContentEncoding encodes the payload with a compression codec
+type codecContentEncoding struct {
+	codec compression.Codec
+}
+
+func (c *codecContentEncoding) name() string {
+	return c.codec.ContentEncoding()
+}
+
+func (c *codecContentEncoding) encode(payload []byte) ([]byte, error) {
+	return c.codec.Compress(payload)
+}
+
+// newCodecContentEncoding returns the content encoding compressing with the codec, identity for a nil codec
+func newCodecContentEncoding(codec compression.Codec) ContentEncoding {
+	if codec == nil {
+		return IdentityContentType
 	}
-	_, err = gzipWriter.Write(payload)
-	if err != nil {
-		return nil, err
+	return &codecContentEncoding{codec: codec}
+}
+
+// CodecSelector returns the codec a destination currently compresses its payloads with,
+// nil if it does not compress them
+type CodecSelector interface {
+	Codec() compression.Codec
+}
+
+// DestinationsContentEncoding encodes each payload once for each of the codecs the destinations currently use.
+// A destination falling back to another codec after its intake rejected its own gets the new payloads encoded
+// with the fallback codec directly.
+type DestinationsContentEncoding struct {
+	selectors []CodecSelector
+}
+
+// NewDestinationsContentEncoding returns the content encoding of the payloads sent to the destinations,
+// the content encoding configured for the endpoint if none of the destinations selects its codec.
+func NewDestinationsContentEncoding(destinations *client.Destinations, endpoint config.Endpoint) ContentEncoding {
+	var selectors []CodecSelector
+	for _, destination := range append(destinations.Reliable, destinations.Unreliable...) {
+		if selector, ok := destination.(CodecSelector); ok {
+			selectors = append(selectors, selector)
+		}
 	}
-	err = gzipWriter.Flush()
-	if err != nil {
-		return nil, err
+	if len(selectors) == 0 {
+		return newCodecContentEncoding(endpoint.GetCodec())
+	}
+	return &DestinationsContentEncoding{selectors: selectors}
+}
+
+// name returns the name of the content encoding of the first destination
+func (c *DestinationsContentEncoding) name() string {
+	return newCodecContentEncoding(c.selectors[0].Codec()).name()
+}
+
+// encode encodes the payload with the codec of the first destination
+func (c *DestinationsContentEncoding) encode(payload []byte) ([]byte, error) {
+	return newCodecContentEncoding(c.selectors[0].Codec()).encode(payload)
+}
+
+// encodePayload encodes the payload with the codecs of the destinations. The encoding of the first
+// destination, the main one, is the encoding of the payload, the others are its alternate encodings.
+func (c *DestinationsContentEncoding) encodePayload(payload *message.Payload, serialized []byte) error {
+	for i, selector := range c.selectors {
+		contentEncoding := newCodecContentEncoding(selector.Codec())
+		name := contentEncoding.name()
+		if i > 0 {
+			if _, found := payload.AlternateEncodings[name]; found || name == payload.Encoding {
+				continue
+			}
+		}
+		encoded, err := contentEncoding.encode(serialized)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			payload.Encoded = encoded
+			payload.Encoding = name
+			continue
+		}
+		if payload.AlternateEncodings == nil {
+			payload.AlternateEncodings = map[string][]byte{}
+		}
+		payload.AlternateEncodings[name] = encoded
+	}
+	return nil
+}
+
+// payloadEncoder encodes a payload for several content encodings at once
+type payloadEncoder interface {
+	encodePayload(payload *message.Payload, serialized []byte) error
+}
+
+// encodePayload encodes the serialized messages of the payload with the content encoding
+func encodePayload(contentEncoding ContentEncoding, payload *message.Payload, serialized []byte) error {
+	if encoder, ok := contentEncoding.(payloadEncoder); ok {
+		return encoder.encodePayload(payload, serialized)
 	}
-	err = gzipWriter.Close()
+	encoded, err := contentEncoding.encode(serialized)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return compressedPayload.Bytes(), nil
+	payload.Encoded = encoded
+	payload.Encoding = contentEncoding.name()
+	return nil
 }
@@ -11,6 +11,11 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 )
 
 func TestIdentityContentType(t *testing.T) {
@@ -42,6 +47,62 @@ func TestGzipContentEncodingName(t *testing.T) {
 	assert.Equal(t, NewGzipContentEncoding(gzip.BestCompression).name(), "gzip")
 }
 
+func TestCodecContentEncoding(t *testing.T) {
+	payload := []byte("my payload")
+
+	assert.Equal(t, IdentityContentType, newCodecContentEncoding(nil))
+
+	contentEncoding := newCodecContentEncoding(compression.NewZstdCodec(compression.ZstdDefaultLevel))
+	assert.Equal(t, "zstd", contentEncoding.name())
+
+	encodedPayload, err := contentEncoding.encode(payload)
+	assert.Nil(t, err)
+
+	decompressedPayload, err := compression.DecompressEncoding(encodedPayload, "zstd")
+	assert.Nil(t, err)
+	assert.Equal(t, payload, decompressedPayload)
+}
+
+type codecDestination struct {
+	blackholeDestination
+	codec compression.Codec
+}
+
+func (d *codecDestination) Codec() compression.Codec {
+	return d.codec
+}
+
+func TestNewDestinationsContentEncoding(t *testing.T) {
+	endpoint := config.Endpoint{UseCompression: true, CompressionKind: "zstd"}
+
+	// the destinations not selecting their codec use the codec of the endpoint
+	contentEncoding := NewDestinationsContentEncoding(client.NewDestinations([]client.Destination{&blackholeDestination{}}, nil), endpoint)
+	assert.Equal(t, "zstd", contentEncoding.name())
+	assert.Equal(t, IdentityContentType, NewDestinationsContentEncoding(client.NewDestinations(nil, nil), config.Endpoint{}))
+
+	main := &codecDestination{codec: compression.NewZstdCodec(compression.ZstdDefaultLevel)}
+	additional := &codecDestination{codec: compression.NewGzipCodec(6)}
+	contentEncoding = NewDestinationsContentEncoding(client.NewDestinations([]client.Destination{main}, []client.Destination{additional}), config.Endpoint{})
+
+	payload := &message.Payload{}
+	assert.Nil(t, encodePayload(contentEncoding, payload, []byte("my payload")))
+	assert.Equal(t, "zstd", payload.Encoding)
+	decompressedPayload, err := compression.DecompressEncoding(payload.Encoded, "zstd")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("my payload"), decompressedPayload)
+	assert.Len(t, payload.AlternateEncodings, 1)
+	decompressedPayload, err = decompress(payload.AlternateEncodings["gzip"])
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("my payload"), decompressedPayload)
+
+	// once the main destination falls back to gzip, the payloads are encoded once
+	main.codec = compression.NewGzipCodec(6)
+	payload = &message.Payload{}
+	assert.Nil(t, encodePayload(contentEncoding, payload, []byte("my payload")))
+	assert.Equal(t, "gzip", payload.Encoding)
+	assert.Empty(t, payload.AlternateEncodings)
+}
+
 func decompress(payload []byte) ([]byte, error) {
 	reader, err := gzip.NewReader(bytes.NewReader(payload))
 	if err != nil {
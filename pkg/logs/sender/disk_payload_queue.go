@@ -0,0 +1,365 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	payloadFileExtension = ".payload"
+	// payloads are written to a temporary file renamed once synced, so that a crash
+	// never leaves a truncated payload behind
+	tempFileExtension = ".tmp"
+)
+
+var (
+	tlmDiskPayloadsStored  = telemetry.NewCounter("logs_sender", "disk_payloads_stored", nil, "Payloads stored on disk")
+	tlmDiskPayloadsDropped = telemetry.NewCounter("logs_sender", "disk_payloads_dropped", nil, "Payloads dropped from the disk because the storage is full")
+	tlmDiskPayloadsErrors  = telemetry.NewCounter("logs_sender", "disk_payloads_errors", nil, "Payloads that could not be stored on disk or read from it")
+	tlmDiskSizeInBytes     = telemetry.NewGauge("logs_sender", "disk_size_in_bytes", []string{"path"}, "Size of the payloads stored on disk")
+)
+
+type diskUsageRetriever interface {
+	GetUsage(path string) (*filesystem.DiskUsage, error)
+}
+
+// storedPayloadHeader is the first line of a payload file, followed by the encoded payload.
+type storedPayloadHeader struct {
+	Encoding      string `json:"encoding"`
+	UnencodedSize int    `json:"unencoded_size"`
+}
+
+// DiskPayloadQueue stores payloads on disk, one file per payload, until they are sent.
+// The payloads stored by a previous run of the Agent are reloaded when the queue is created.
+// Only the encoded content of the payloads is stored, not the messages they contain.
+//
+// A payload returned by Next stays on disk until it is removed once sent, so that
+// the payloads being sent when the Agent stops are sent again by the next run.
+type DiskPayloadQueue struct {
+	storagePath    string
+	maxSizeInBytes int64
+	maxDiskRatio   float64
+	disk           diskUsageRetriever
+
+	mu                 sync.Mutex
+	filenames          []string
+	currentSizeInBytes int64
+	// the first `sending` files hold the payloads being sent
+	sending      int
+	sendingFiles map[*message.Payload]string
+	// next is the oldest payload not being sent, kept in memory once read
+	next *message.Payload
+}
+
+// NewDiskPayloadQueue creates a new DiskPayloadQueue storing at most maxSizeInBytes of payloads
+// in storagePath, and stopping storing payloads once the disk usage exceeds maxDiskRatio.
+func NewDiskPayloadQueue(storagePath string, maxSizeInBytes int64, maxDiskRatio float64) (*DiskPayloadQueue, error) {
+	return newDiskPayloadQueue(storagePath, maxSizeInBytes, maxDiskRatio, filesystem.NewDisk())
+}
+
+func newDiskPayloadQueue(storagePath string, maxSizeInBytes int64, maxDiskRatio float64, disk diskUsageRetriever) (*DiskPayloadQueue, error) {
+	if err := os.MkdirAll(storagePath, 0700); err != nil {
+		return nil, err
+	}
+
+	q := &DiskPayloadQueue{
+		storagePath:    storagePath,
+		maxSizeInBytes: maxSizeInBytes,
+		maxDiskRatio:   maxDiskRatio,
+		disk:           disk,
+		sendingFiles:   map[*message.Payload]string{},
+	}
+	if err := q.reloadExistingPayloads(); err != nil {
+		return nil, err
+	}
+
+	// Check if there is an error when computing the available space
+	// to warn the user sooner (and not when there is an outage)
+	_, err := q.computeAvailableSpace()
+	return q, err
+}
+
+// Len returns the number of payloads stored and not being sent.
+func (q *DiskPayloadQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.filenames) - q.sending
+}
+
+// Store stores a payload on disk, dropping the oldest payloads if there is not enough room for it.
+func (q *DiskPayloadQueue) Store(payload *message.Payload) error {
+	header, err := json.Marshal(storedPayloadHeader{
+		Encoding:      payload.Encoding,
+		UnencodedSize: payload.UnencodedSize,
+	})
+	if err != nil {
+		return err
+	}
+	content := make([]byte, 0, len(header)+1+len(payload.Encoded))
+	content = append(content, header...)
+	content = append(content, '\n')
+	content = append(content, payload.Encoded...)
+	size := int64(len(content))
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.makeRoomFor(size); err != nil {
+		return err
+	}
+
+	// the file names start with the time to reload the payloads in order
+	prefix := fmt.Sprintf("%020d_", time.Now().UnixNano())
+	file, err := ioutil.TempFile(q.storagePath, prefix+"*"+tempFileExtension)
+	if err != nil {
+		return err
+	}
+	filename, err := writeSynced(file, content)
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return err
+	}
+	q.filenames = append(q.filenames, filename)
+	q.currentSizeInBytes += size
+	tlmDiskPayloadsStored.Inc()
+	tlmDiskSizeInBytes.Set(float64(q.currentSizeInBytes), q.storagePath)
+	return nil
+}
+
+// writeSynced writes the content to the temporary file, syncs it to disk and renames it to its
+// final name, which is returned once the directory is synced too.
+func writeSynced(file *os.File, content []byte) (string, error) {
+	if _, err := file.Write(content); err != nil {
+		_ = file.Close()
+		return "", err
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+	filename := strings.TrimSuffix(file.Name(), tempFileExtension) + payloadFileExtension
+	if err := os.Rename(file.Name(), filename); err != nil {
+		return "", err
+	}
+	if err := syncDir(filepath.Dir(filename)); err != nil {
+		_ = os.Remove(filename)
+		return "", err
+	}
+	return filename, nil
+}
+
+// Next returns the oldest payload stored and not being sent, and marks it as being sent.
+// The payload is nil if there is none. The messages of the payload are not restored.
+func (q *DiskPayloadQueue) Next() (*message.Payload, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.next == nil && q.sending < len(q.filenames) {
+		payload, err := readPayload(q.filenames[q.sending])
+		if err == nil {
+			q.next = payload
+			break
+		}
+		// the payload can't be sent, remove it to not block the queue
+		log.Warnf("Could not read the payload stored in %s, dropping it: %v", q.filenames[q.sending], err)
+		tlmDiskPayloadsErrors.Inc()
+		if err := q.removeFileAt(q.sending); err != nil {
+			return nil, err
+		}
+	}
+	if q.next == nil {
+		return nil, nil
+	}
+	payload := q.next
+	q.next = nil
+	q.sendingFiles[payload] = q.filenames[q.sending]
+	q.sending++
+	return payload, nil
+}
+
+// Release marks the last payload returned by Next as not being sent anymore,
+// it is returned again by the next call to Next.
+func (q *DiskPayloadQueue) Release(payload *message.Payload) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	filename, found := q.sendingFiles[payload]
+	if !found {
+		return
+	}
+	delete(q.sendingFiles, payload)
+	if q.sending > 0 && q.filenames[q.sending-1] == filename {
+		q.sending--
+		q.next = payload
+	}
+}
+
+// Remove removes a payload returned by Next once it is sent.
+// It does nothing for the payloads which were not returned by Next, or already dropped.
+func (q *DiskPayloadQueue) Remove(payload *message.Payload) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	filename, found := q.sendingFiles[payload]
+	if !found {
+		return nil
+	}
+	delete(q.sendingFiles, payload)
+	for i := 0; i < q.sending; i++ {
+		if q.filenames[i] == filename {
+			return q.removeFileAt(i)
+		}
+	}
+	return nil
+}
+
+func (q *DiskPayloadQueue) makeRoomFor(size int64) error {
+	if size > q.maxSizeInBytes {
+		return fmt.Errorf("the payload is too big. Current:%v Maximum:%v", size, q.maxSizeInBytes)
+	}
+
+	maxStorageInBytes, err := q.computeAvailableSpace()
+	if err != nil {
+		return err
+	}
+	for len(q.filenames) > 0 && q.currentSizeInBytes+size > maxStorageInBytes {
+		log.Warnf("Maximum disk space for logs payloads is reached. Removing %s", q.filenames[0])
+		if err := q.removeFileAt(0); err != nil {
+			return err
+		}
+		tlmDiskPayloadsDropped.Inc()
+	}
+	if q.currentSizeInBytes+size > maxStorageInBytes {
+		return fmt.Errorf("not enough disk space to store the payload")
+	}
+	return nil
+}
+
+// computeAvailableSpace returns the amount of disk space that can be used to store payloads.
+func (q *DiskPayloadQueue) computeAvailableSpace() (int64, error) {
+	usage, err := q.disk.GetUsage(q.storagePath)
+	if err != nil {
+		return 0, err
+	}
+	diskReserved := float64(usage.Total) * (1 - q.maxDiskRatio)
+	availableDiskUsage := int64(usage.Available) - int64(math.Ceil(diskReserved))
+
+	available := q.currentSizeInBytes + availableDiskUsage
+	if q.maxSizeInBytes < available {
+		return q.maxSizeInBytes, nil
+	}
+	return available, nil
+}
+
+func (q *DiskPayloadQueue) removeFileAt(index int) error {
+	filename := q.filenames[index]
+	if index < q.sending {
+		q.sending--
+	} else if index == q.sending {
+		q.next = nil
+	}
+
+	// Remove the file from q.filenames also in case of error to not
+	// fail on the next call.
+	q.filenames = append(q.filenames[:index], q.filenames[index+1:]...)
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filename); err != nil {
+		return err
+	}
+	q.currentSizeInBytes -= info.Size()
+	tlmDiskSizeInBytes.Set(float64(q.currentSizeInBytes), q.storagePath)
+	return nil
+}
+
+func (q *DiskPayloadQueue) reloadExistingPayloads() error {
+	entries, err := ioutil.ReadDir(q.storagePath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.Mode().IsRegular() {
+			continue
+		}
+		// a temporary file is a payload the agent failed to store, it was never reported as stored
+		if filepath.Ext(entry.Name()) == tempFileExtension {
+			_ = os.Remove(filepath.Join(q.storagePath, entry.Name()))
+			continue
+		}
+		if filepath.Ext(entry.Name()) == payloadFileExtension {
+			q.filenames = append(q.filenames, filepath.Join(q.storagePath, entry.Name()))
+			q.currentSizeInBytes += entry.Size()
+		}
+	}
+	sort.Strings(q.filenames)
+	if len(q.filenames) > 0 {
+		log.Infof("Reloaded %d logs payloads stored in %s", len(q.filenames), q.storagePath)
+	}
+	tlmDiskSizeInBytes.Set(float64(q.currentSizeInBytes), q.storagePath)
+	return nil
+}
+
+// MoveStoredPayloads moves the payloads stored in fromPath to toPath, and removes fromPath.
+func MoveStoredPayloads(fromPath string, toPath string) error {
+	entries, err := ioutil.ReadDir(fromPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(toPath, 0700); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.Mode().IsRegular() || filepath.Ext(entry.Name()) != payloadFileExtension {
+			continue
+		}
+		// the file names are unique, they start with the time the payloads were stored
+		if err := os.Rename(filepath.Join(fromPath, entry.Name()), filepath.Join(toPath, entry.Name())); err != nil {
+			return err
+		}
+	}
+	if err := syncDir(toPath); err != nil {
+		return err
+	}
+	return os.RemoveAll(fromPath)
+}
+
+func readPayload(filename string) (*message.Payload, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	headerEnd := bytes.IndexByte(content, '\n')
+	if headerEnd < 0 {
+		return nil, fmt.Errorf("missing header")
+	}
+	var header storedPayloadHeader
+	if err := json.Unmarshal(content[:headerEnd], &header); err != nil {
+		return nil, fmt.Errorf("invalid header: %v", err)
+	}
+	return &message.Payload{
+		Encoded:       content[headerEnd+1:],
+		Encoding:      header.Encoding,
+		UnencodedSize: header.UnencodedSize,
+	}, nil
+}
@@ -0,0 +1,185 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
+)
+
+type mockDisk struct {
+	total     uint64
+	available uint64
+}
+
+func (m mockDisk) GetUsage(_ string) (*filesystem.DiskUsage, error) {
+	return &filesystem.DiskUsage{Total: m.total, Available: m.available}, nil
+}
+
+func newTestDiskQueue(t *testing.T, path string, maxSizeInBytes int64) *DiskPayloadQueue {
+	queue, err := newDiskPayloadQueue(path, maxSizeInBytes, 0.8, mockDisk{total: 10000, available: 10000})
+	require.NoError(t, err)
+	return queue
+}
+
+func newTestPayload(content string) *message.Payload {
+	return &message.Payload{
+		Messages:      []*message.Message{message.NewMessage([]byte(content), nil, "", 0)},
+		Encoded:       []byte(content),
+		Encoding:      "gzip",
+		UnencodedSize: len(content) * 2,
+	}
+}
+
+func TestDiskPayloadQueue(t *testing.T) {
+	queue := newTestDiskQueue(t, t.TempDir(), 1000)
+
+	payload, err := queue.Next()
+	assert.NoError(t, err)
+	assert.Nil(t, payload)
+
+	require.NoError(t, queue.Store(newTestPayload("first")))
+	require.NoError(t, queue.Store(newTestPayload("second")))
+	assert.Equal(t, 2, queue.Len())
+
+	first, err := queue.Next()
+	require.NoError(t, err)
+	assert.Equal(t, &message.Payload{Encoded: []byte("first"), Encoding: "gzip", UnencodedSize: 10}, first)
+	assert.Equal(t, 1, queue.Len())
+
+	second, err := queue.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("second"), second.Encoded)
+	assert.Equal(t, 0, queue.Len())
+
+	// the payloads being sent stay on disk until they are removed, in any order
+	assert.Len(t, queue.filenames, 2)
+	require.NoError(t, queue.Remove(second))
+	assert.Len(t, queue.filenames, 1)
+	require.NoError(t, queue.Remove(first))
+	assert.Empty(t, queue.filenames)
+	assert.Equal(t, int64(0), queue.currentSizeInBytes)
+
+	// the payloads which are not stored are ignored
+	require.NoError(t, queue.Remove(newTestPayload("live")))
+}
+
+func TestDiskPayloadQueueRelease(t *testing.T) {
+	queue := newTestDiskQueue(t, t.TempDir(), 1000)
+	require.NoError(t, queue.Store(newTestPayload("first")))
+	require.NoError(t, queue.Store(newTestPayload("second")))
+
+	payload, err := queue.Next()
+	require.NoError(t, err)
+	queue.Release(payload)
+	assert.Equal(t, 2, queue.Len())
+	// the released payload is returned again without reading it from the disk
+	next, err := queue.Next()
+	require.NoError(t, err)
+	assert.Same(t, payload, next)
+}
+
+func TestDiskPayloadQueueReload(t *testing.T) {
+	path := t.TempDir()
+	queue := newTestDiskQueue(t, path, 1000)
+	for _, content := range []string{"1", "2", "3"} {
+		require.NoError(t, queue.Store(newTestPayload(content)))
+	}
+	// a payload being sent when the agent stops is sent again by the next run
+	_, err := queue.Next()
+	require.NoError(t, err)
+
+	reloaded := newTestDiskQueue(t, path, 1000)
+	assert.Equal(t, 3, reloaded.Len())
+	assert.Equal(t, queue.currentSizeInBytes, reloaded.currentSizeInBytes)
+	for _, content := range []string{"1", "2", "3"} {
+		payload, err := reloaded.Next()
+		require.NoError(t, err)
+		assert.Equal(t, []byte(content), payload.Encoded)
+		require.NoError(t, reloaded.Remove(payload))
+	}
+}
+
+func TestDiskPayloadQueueMaxSize(t *testing.T) {
+	queue := newTestDiskQueue(t, t.TempDir(), 150)
+
+	// each payload file takes about 50 bytes
+	for _, content := range []string{"1", "2", "3", "4"} {
+		require.NoError(t, queue.Store(newTestPayload(content)))
+	}
+	assert.LessOrEqual(t, queue.currentSizeInBytes, int64(150))
+
+	// the oldest payloads are dropped
+	payload, err := queue.Next()
+	require.NoError(t, err)
+	assert.NotEqual(t, []byte("1"), payload.Encoded)
+
+	assert.Error(t, queue.Store(newTestPayload(string(make([]byte, 200)))))
+}
+
+func TestDiskPayloadQueueMaxDiskRatio(t *testing.T) {
+	// 85% of the disk is used, more than the 80% allowed
+	queue, err := newDiskPayloadQueue(t.TempDir(), 1000, 0.8, mockDisk{total: 10000, available: 1500})
+	require.NoError(t, err)
+
+	assert.Error(t, queue.Store(newTestPayload("payload")))
+	assert.Equal(t, 0, queue.Len())
+}
+
+func TestDiskPayloadQueueInvalidFile(t *testing.T) {
+	path := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(path, "0_invalid"+payloadFileExtension), []byte("invalid"), 0600))
+	queue := newTestDiskQueue(t, path, 1000)
+	require.NoError(t, queue.Store(newTestPayload("valid")))
+
+	// invalid payloads are dropped
+	payload, err := queue.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("valid"), payload.Encoded)
+	assert.Len(t, queue.filenames, 1)
+}
+
+func TestDiskPayloadQueueDropsPayloadsBeingSent(t *testing.T) {
+	queue := newTestDiskQueue(t, t.TempDir(), 100)
+	require.NoError(t, queue.Store(newTestPayload("1")))
+	payload, err := queue.Next()
+	require.NoError(t, err)
+
+	// each payload file takes about 50 bytes, the payload being sent is dropped
+	// to make room for the new ones
+	require.NoError(t, queue.Store(newTestPayload("2")))
+	require.NoError(t, queue.Store(newTestPayload("3")))
+	assert.Equal(t, 2, queue.Len())
+	require.NoError(t, queue.Remove(payload))
+	assert.Len(t, queue.filenames, 2)
+
+	next, err := queue.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("2"), next.Encoded)
+}
+
+func TestDiskPayloadQueueRemovesTemporaryFiles(t *testing.T) {
+	path := t.TempDir()
+	tempFile := filepath.Join(path, "0_partial"+tempFileExtension)
+	require.NoError(t, ioutil.WriteFile(tempFile, []byte("{}\npart"), 0600))
+
+	queue := newTestDiskQueue(t, path, 1000)
+	assert.Equal(t, 0, queue.Len())
+	assert.NoFileExists(t, tempFile)
+
+	require.NoError(t, queue.Store(newTestPayload("stored")))
+	entries, err := ioutil.ReadDir(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, payloadFileExtension, filepath.Ext(entries[0].Name()))
+}
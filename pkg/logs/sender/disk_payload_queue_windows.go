@@ -0,0 +1,15 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build windows
+// +build windows
+
+package sender
+
+// syncDir does nothing, directories can't be synced on Windows where NTFS journals the
+// metadata changes.
+func syncDir(path string) error {
+	return nil
+}
@@ -12,8 +12,12 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
+// diskQueueReplayInterval is the interval at which the payloads stored on disk are sent again.
+const diskQueueReplayInterval = 100 * time.Millisecond
+
 var (
 	tlmPayloadsDropped = telemetry.NewCounter("logs_sender", "payloads_dropped", []string{"reliable", "destination"}, "Payloads dropped")
 	tlmMessagesDropped = telemetry.NewCounter("logs_sender", "messages_dropped", []string{"reliable", "destination"}, "Messages dropped")
@@ -27,12 +31,20 @@ var (
 // one reliable destination is also sending logs. However they do not update
 // the auditor or block the pipeline if they fail. There will always be at
 // least 1 reliable destination (the main destination).
+//
+// When a disk queue is set, the payloads that can't be sent to any reliable
+// destination are stored on disk instead of blocking the pipeline, and sent
+// once a reliable destination is available again. A stored payload is removed
+// from the disk once a reliable destination reports it sent.
 type Sender struct {
 	inputChan    chan *message.Payload
 	outputChan   chan *message.Payload
 	destinations *client.Destinations
 	done         chan struct{}
 	bufferSize   int
+
+	diskQueue          *DiskPayloadQueue
+	lastDiskStoreError error
 }
 
 // NewSender returns a new sender.
@@ -46,6 +58,14 @@ func NewSender(inputChan chan *message.Payload, outputChan chan *message.Payload
 	}
 }
 
+// NewSenderWithDiskQueue returns a new sender storing the payloads in diskQueue while
+// the reliable destinations are unavailable.
+func NewSenderWithDiskQueue(inputChan chan *message.Payload, outputChan chan *message.Payload, destinations *client.Destinations, bufferSize int, diskQueue *DiskPayloadQueue) *Sender {
+	sender := NewSender(inputChan, outputChan, destinations, bufferSize)
+	sender.diskQueue = diskQueue
+	return sender
+}
+
 // Start starts the sender.
 func (s *Sender) Start() {
 	go s.run()
@@ -59,51 +79,51 @@ func (s *Sender) Stop() {
 }
 
 func (s *Sender) run() {
-	reliableDestinations := buildDestinationSenders(s.destinations.Reliable, s.outputChan, s.bufferSize)
+	reliableOutput := s.outputChan
+	var sentChan chan *message.Payload
+	var forwardDone chan struct{}
+	if s.diskQueue != nil {
+		sentChan = make(chan *message.Payload, s.bufferSize)
+		forwardDone = make(chan struct{})
+		reliableOutput = sentChan
+		go s.forwardSentPayloads(sentChan, forwardDone)
+	}
+	reliableDestinations := buildDestinationSenders(s.destinations.Reliable, reliableOutput, s.bufferSize)
 
 	sink := additionalDestinationsSink(s.bufferSize)
 	unreliableDestinations := buildDestinationSenders(s.destinations.Unreliable, sink, s.bufferSize)
 
-	for payload := range s.inputChan {
-		var startInUse = time.Now()
+	var replayChan <-chan time.Time
+	if s.diskQueue != nil {
+		replayTicker := time.NewTicker(diskQueueReplayInterval)
+		defer replayTicker.Stop()
+		replayChan = replayTicker.C
+	}
 
-		sent := false
-		for !sent {
-			for _, destSender := range reliableDestinations {
-				if destSender.Send(payload) {
-					sent = true
-				}
+loop:
+	for {
+		select {
+		case payload, isOpen := <-s.inputChan:
+			if !isOpen {
+				break loop
 			}
+			var startInUse = time.Now()
 
-			if !sent {
-				// Throttle the poll loop while waiting for a send to succeed
-				// This will only happen when all reliable destinations
-				// are blocked so logs have no where to go.
-				time.Sleep(100 * time.Millisecond)
-			}
-		}
+			s.sendToReliableDestinations(payload, reliableDestinations)
 
-		for i, destSender := range reliableDestinations {
-			// If an endpoint is stuck in the previous step, try to buffer the payloads if we have room to mitigate
-			// loss on intermittent failures.
-			if !destSender.lastSendSucceeded {
+			// Attempt to send to unreliable destinations
+			for i, destSender := range unreliableDestinations {
 				if !destSender.NonBlockingSend(payload) {
-					tlmPayloadsDropped.Inc("true", strconv.Itoa(i))
-					tlmMessagesDropped.Add(float64(len(payload.Messages)), "true", strconv.Itoa(i))
+					tlmPayloadsDropped.Inc("false", strconv.Itoa(i))
+					tlmMessagesDropped.Add(float64(len(payload.Messages)), "false", strconv.Itoa(i))
 				}
 			}
-		}
 
-		// Attempt to send to unreliable destinations
-		for i, destSender := range unreliableDestinations {
-			if !destSender.NonBlockingSend(payload) {
-				tlmPayloadsDropped.Inc("false", strconv.Itoa(i))
-				tlmMessagesDropped.Add(float64(len(payload.Messages)), "false", strconv.Itoa(i))
-			}
+			inUse := float64(time.Since(startInUse) / time.Millisecond)
+			tlmSendWaitTime.Add(inUse)
+		case <-replayChan:
+			s.replayStoredPayloads(reliableDestinations)
 		}
-
-		inUse := float64(time.Since(startInUse) / time.Millisecond)
-		tlmSendWaitTime.Add(inUse)
 	}
 
 	// Cleanup the destinations
@@ -113,10 +133,117 @@ func (s *Sender) run() {
 	for _, destSender := range unreliableDestinations {
 		destSender.Stop()
 	}
+	if sentChan != nil {
+		close(sentChan)
+		<-forwardDone
+	}
 	close(sink)
 	s.done <- struct{}{}
 }
 
+// sendToReliableDestinations blocks until the payload is sent to at least one reliable destination,
+// or stored on disk if there is a disk queue.
+func (s *Sender) sendToReliableDestinations(payload *message.Payload, reliableDestinations []*DestinationSender) {
+	// Keep the payloads ordered, new payloads go to the disk until the stored ones are sent.
+	storeFirst := s.diskQueue != nil && s.diskQueue.Len() > 0
+
+	sent := false
+	for !sent {
+		if !storeFirst {
+			sent = trySend(payload, reliableDestinations)
+		}
+
+		if !sent && s.diskQueue != nil {
+			if s.store(payload) {
+				// The payload is durably stored, the auditor can commit the offsets of its messages.
+				s.outputChan <- payload
+				return
+			}
+			storeFirst = false
+		}
+
+		if !sent {
+			// Throttle the poll loop while waiting for a send to succeed
+			// This will only happen when all reliable destinations
+			// are blocked so logs have no where to go.
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+
+	bufferInStuckDestinations(payload, reliableDestinations)
+}
+
+// replayStoredPayloads sends the payloads stored on disk, oldest first,
+// until no reliable destination is available.
+func (s *Sender) replayStoredPayloads(reliableDestinations []*DestinationSender) {
+	for {
+		payload, err := s.diskQueue.Next()
+		if err != nil || payload == nil {
+			return
+		}
+		if !trySend(payload, reliableDestinations) {
+			s.diskQueue.Release(payload)
+			return
+		}
+		// The payload stays on disk until a destination reports it sent.
+		// Its messages are not restored so the auditor does not commit their offsets again.
+		bufferInStuckDestinations(payload, reliableDestinations)
+	}
+}
+
+// forwardSentPayloads forwards the payloads reported by the reliable destinations to the output,
+// and removes the stored ones from the disk. A reliable destination reports a payload once the
+// intake accepted it, or rejected it for good, in which case sending it again would not succeed.
+func (s *Sender) forwardSentPayloads(sentChan <-chan *message.Payload, done chan<- struct{}) {
+	for payload := range sentChan {
+		if err := s.diskQueue.Remove(payload); err != nil {
+			log.Warnf("Could not remove the logs payload sent from the disk: %v", err)
+		}
+		s.outputChan <- payload
+	}
+	close(done)
+}
+
+// store stores the payload in the disk queue and returns whether it succeeded.
+func (s *Sender) store(payload *message.Payload) bool {
+	err := s.diskQueue.Store(payload)
+	if err != nil {
+		tlmDiskPayloadsErrors.Inc()
+		// log once per outage, the storage is retried every time the send fails
+		if s.lastDiskStoreError == nil {
+			log.Warnf("Could not store the logs payload on disk: %v", err)
+		}
+	}
+	s.lastDiskStoreError = err
+	return err == nil
+}
+
+// trySend sends the payload to all the reliable destinations that are not retrying,
+// and returns whether at least one of them accepted it.
+func trySend(payload *message.Payload, reliableDestinations []*DestinationSender) bool {
+	sent := false
+	for _, destSender := range reliableDestinations {
+		if destSender.Send(payload) {
+			sent = true
+		}
+	}
+	return sent
+}
+
+// bufferInStuckDestinations buffers the payload in the destinations which did not accept it.
+func bufferInStuckDestinations(payload *message.Payload, reliableDestinations []*DestinationSender) {
+	for i, destSender := range reliableDestinations {
+		// If an endpoint is stuck in the previous step, try to buffer the payloads if we have room to mitigate
+		// loss on intermittent failures.
+		if !destSender.lastSendSucceeded {
+			if !destSender.NonBlockingSend(payload) {
+				tlmPayloadsDropped.Inc("true", strconv.Itoa(i))
+				tlmMessagesDropped.Add(float64(len(payload.Messages)), "true", strconv.Itoa(i))
+			}
+		}
+	}
+}
+
 // Drains the output channel from destinations that don't update the auditor.
 func additionalDestinationsSink(bufferSize int) chan *message.Payload {
 	sink := make(chan *message.Payload, bufferSize)
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/client/http"
@@ -301,3 +302,107 @@ func TestSenderReliableWhenOneFailsAndRecovers(t *testing.T) {
 	reliableServer2.Stop()
 	sender.Stop()
 }
+
+// forwardingDestination forwards the payloads to the output as if they were sent.
+type forwardingDestination struct{}
+
+func (d *forwardingDestination) Start(input chan *message.Payload, output chan *message.Payload, isRetrying chan bool) (stopChan <-chan struct{}) {
+	stop := make(chan struct{}, 1)
+	go func() {
+		for payload := range input {
+			output <- payload
+		}
+		stop <- struct{}{}
+	}()
+	return stop
+}
+
+// blackholeDestination accepts the payloads but never reports them sent.
+type blackholeDestination struct{}
+
+func (d *blackholeDestination) Start(input chan *message.Payload, output chan *message.Payload, isRetrying chan bool) (stopChan <-chan struct{}) {
+	stop := make(chan struct{}, 1)
+	go func() {
+		for range input {
+		}
+		stop <- struct{}{}
+	}()
+	return stop
+}
+
+func TestSenderDiskQueue(t *testing.T) {
+	queue := newTestDiskQueue(t, t.TempDir(), 1000)
+	output := make(chan *message.Payload, 10)
+	sentChan := make(chan *message.Payload, 10)
+	destSender := NewDestinationSender(&forwardingDestination{}, sentChan, 10)
+	reliableDestinations := []*DestinationSender{destSender}
+	sender := NewSenderWithDiskQueue(nil, output, nil, 10, queue)
+	forwardDone := make(chan struct{})
+	go sender.forwardSentPayloads(sentChan, forwardDone)
+	defer func() {
+		destSender.Stop()
+		close(sentChan)
+		<-forwardDone
+	}()
+
+	setRetrying := func(retrying bool) {
+		destSender.retryLock.Lock()
+		destSender.lastRetryState = retrying
+		destSender.retryLock.Unlock()
+	}
+
+	// the destination is retrying, the payload is stored and the auditor gets it
+	setRetrying(true)
+	first := newTestPayload("first")
+	sender.sendToReliableDestinations(first, reliableDestinations)
+	assert.Equal(t, 1, queue.Len())
+	assert.Equal(t, first, <-output)
+
+	// the payloads are kept in order, the new ones are stored until the stored ones are sent
+	setRetrying(false)
+	second := newTestPayload("second")
+	sender.sendToReliableDestinations(second, reliableDestinations)
+	assert.Equal(t, 2, queue.Len())
+	assert.Equal(t, second, <-output)
+
+	// nothing is sent while the destination is retrying
+	setRetrying(true)
+	sender.replayStoredPayloads(reliableDestinations)
+	assert.Equal(t, 2, queue.Len())
+
+	setRetrying(false)
+	sender.replayStoredPayloads(reliableDestinations)
+	assert.Equal(t, 0, queue.Len())
+	for _, expected := range []string{"first", "second"} {
+		payload := <-output
+		assert.Equal(t, []byte(expected), payload.Encoded)
+		// the messages are not sent to the auditor twice
+		assert.Empty(t, payload.Messages)
+	}
+	// the payloads reported sent are removed from the disk
+	assert.Empty(t, queue.filenames)
+
+	// the queue is empty, payloads are sent directly
+	third := newTestPayload("third")
+	sender.sendToReliableDestinations(third, reliableDestinations)
+	assert.Equal(t, 0, queue.Len())
+	assert.Equal(t, third, <-output)
+}
+
+func TestSenderDiskQueueKeepsPayloadsUntilSent(t *testing.T) {
+	path := t.TempDir()
+	queue := newTestDiskQueue(t, path, 1000)
+	require.NoError(t, queue.Store(newTestPayload("stored")))
+	output := make(chan *message.Payload, 10)
+	destSender := NewDestinationSender(&blackholeDestination{}, output, 10)
+	defer destSender.Stop()
+	sender := NewSenderWithDiskQueue(nil, output, nil, 10, queue)
+
+	// the destination accepts the payload but never sends it, the payload stays on disk
+	sender.replayStoredPayloads([]*DestinationSender{destSender})
+	assert.Equal(t, 0, queue.Len())
+	assert.Len(t, queue.filenames, 1)
+
+	// and is sent again by the next run of the agent
+	assert.Equal(t, 1, newTestDiskQueue(t, path, 1000).Len())
+}
@@ -48,9 +48,13 @@ type LogSource struct {
 	ParentSource *LogSource
 	// LatencyStats tracks internal stats on the time spent by messages from this source in a processing pipeline, i.e.
 	// the duration between when a message is decoded by the tailer/listener/decoder and when the message is handled by a sender
-	LatencyStats     *util.StatsTracker
-	BytesRead        *atomic.Int64
+	LatencyStats *util.StatsTracker
+	BytesRead    *atomic.Int64
+	// DroppedMessages counts the messages dropped by the sampling and rate limiting processing rules
+	DroppedMessages  *atomic.Int64
 	hiddenFromStatus bool
+	// ruleLimiters holds the state of the sampling and rate limiting processing rules applied to this source
+	ruleLimiters map[*config.ProcessingRule]*config.RuleLimiter
 }
 
 // NewLogSource creates a new log source.
@@ -63,6 +67,7 @@ func NewLogSource(name string, cfg *config.LogsConfig) *LogSource {
 		lock:             &sync.Mutex{},
 		Messages:         config.NewMessages(),
 		BytesRead:        atomic.NewInt64(0),
+		DroppedMessages:  atomic.NewInt64(0),
 		info:             make(map[string]status.InfoProvider),
 		LatencyStats:     util.NewStatsTracker(time.Hour*24, time.Hour),
 		hiddenFromStatus: false,
@@ -164,6 +169,32 @@ func (s *LogSource) RecordBytes(n int64) {
 	}
 }
 
+// RecordDroppedMessage counts a message dropped by a sampling or rate limiting processing rule,
+// the count is reported to the parent source as well, like the byte count.
+func (s *LogSource) RecordDroppedMessage() {
+	s.DroppedMessages.Inc()
+
+	if s.ParentSource != nil {
+		s.ParentSource.DroppedMessages.Inc()
+	}
+}
+
+// RuleLimiter returns the limiter of a sampling or rate limiting processing rule for this source,
+// messages are sampled and rate limited per source.
+func (s *LogSource) RuleLimiter(rule *config.ProcessingRule) *config.RuleLimiter {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.ruleLimiters == nil {
+		s.ruleLimiters = make(map[*config.ProcessingRule]*config.RuleLimiter)
+	}
+	limiter, found := s.ruleLimiters[rule]
+	if !found {
+		limiter = config.NewRuleLimiter(rule)
+		s.ruleLimiters[rule] = limiter
+	}
+	return limiter
+}
+
 // Dump provides a dump of the LogSource contents, for debugging purposes.  If
 // multiline is true, the result contains newlines for readability.
 func (s *LogSource) Dump(multiline bool) string {
@@ -201,6 +232,7 @@ func (s *LogSource) Dump(multiline bool) string {
 	fmt.Fprintf(&b, ws("parentSource: %p,"), s.ParentSource)
 	fmt.Fprintf(&b, ws("LatencyStats: %#v,"), s.LatencyStats)
 	fmt.Fprintf(&b, ws("BytesRead: %d,"), s.BytesRead.Load())
+	fmt.Fprintf(&b, ws("DroppedMessages: %d,"), s.DroppedMessages.Load())
 	fmt.Fprintf(&b, ws("hiddenFromStatus: %t}"), s.hiddenFromStatus)
 	return b.String()
 }
@@ -38,6 +38,7 @@ var (
 // Source provides some information about a logs source.
 type Source struct {
 	BytesRead          int64                  `json:"bytes_read"`
+	DroppedMessages    int64                  `json:"dropped_messages"`
 	AllTimeAvgLatency  int64                  `json:"all_time_avg_latency"`
 	AllTimePeakLatency int64                  `json:"all_time_peak_latency"`
 	RecentAvgLatency   int64                  `json:"recent_avg_latency"`
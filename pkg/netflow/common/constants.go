@@ -21,6 +21,47 @@ const (
 	// DefaultAggregatorRollupTrackerRefreshInterval is the default aggregator rollup tracker refresh interval
 	DefaultAggregatorRollupTrackerRefreshInterval = 300 // 5min
 
+	// DeduplicationModeDisabled keeps every copy of a flow reported by different exporters
+	DeduplicationModeDisabled = "disabled"
+
+	// DeduplicationModeIngressInterface keeps, for each 5-tuple, only the copies reported on the lowest
+	// ingress interface (then by the lowest exporter address) during the aggregator flush interval
+	DeduplicationModeIngressInterface = "ingress_interface"
+
+	// TopTalkersDimensionSourceIP aggregates top talkers by source IP
+	TopTalkersDimensionSourceIP = "source_ip"
+
+	// TopTalkersDimensionDestinationIP aggregates top talkers by destination IP
+	TopTalkersDimensionDestinationIP = "destination_ip"
+
+	// TopTalkersDimensionSourcePort aggregates top talkers by source port
+	TopTalkersDimensionSourcePort = "source_port"
+
+	// TopTalkersDimensionDestinationPort aggregates top talkers by destination port
+	TopTalkersDimensionDestinationPort = "destination_port"
+
+	// TopTalkersDimensionIPProtocol aggregates top talkers by IP protocol
+	TopTalkersDimensionIPProtocol = "ip_protocol"
+
 	// DefaultBindHost is the default bind host used for flow listeners
 	DefaultBindHost = "0.0.0.0"
 )
+
+// DefaultTopTalkersDimensions are the top talkers dimensions used when none is configured
+var DefaultTopTalkersDimensions = []string{
+	TopTalkersDimensionSourceIP,
+	TopTalkersDimensionDestinationIP,
+	TopTalkersDimensionDestinationPort,
+	TopTalkersDimensionIPProtocol,
+}
+
+// GetAllTopTalkersDimensions returns all the valid top talkers dimensions
+func GetAllTopTalkersDimensions() []string {
+	return []string{
+		TopTalkersDimensionSourceIP,
+		TopTalkersDimensionDestinationIP,
+		TopTalkersDimensionSourcePort,
+		TopTalkersDimensionDestinationPort,
+		TopTalkersDimensionIPProtocol,
+	}
+}
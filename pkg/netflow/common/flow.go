@@ -64,6 +64,18 @@ type Flow struct {
 	Tos uint32 // FLOW KEY
 
 	NextHop []byte // FLOW KEY
+
+	// GeoIP information, only set when GeoIP enrichment is enabled
+	SrcCountry string
+	DstCountry string
+	SrcCity    string
+	DstCity    string
+
+	// Autonomous System information, only set when GeoIP enrichment is enabled
+	SrcASN   uint32
+	DstASN   uint32
+	SrcASOrg string
+	DstASOrg string
 }
 
 // AggregationHash return a hash used as aggregation key
@@ -81,6 +93,18 @@ func (f *Flow) AggregationHash() uint64 {
 	return h.Sum64()
 }
 
+// DeduplicationHash return a hash identifying the 5-tuple of a flow, regardless of the exporter that reported it
+func (f *Flow) DeduplicationHash() uint64 {
+	h := fnv.New64()
+	h.Write([]byte(f.Namespace))                       //nolint:errcheck
+	h.Write(f.SrcAddr)                                 //nolint:errcheck
+	h.Write(f.DstAddr)                                 //nolint:errcheck
+	binary.Write(h, binary.LittleEndian, f.SrcPort)    //nolint:errcheck
+	binary.Write(h, binary.LittleEndian, f.DstPort)    //nolint:errcheck
+	binary.Write(h, binary.LittleEndian, f.IPProtocol) //nolint:errcheck
+	return h.Sum64()
+}
+
 // IsEqualFlowContext check if the flow and another flow have equal values for all fields used in `AggregationHash`.
 // This method is used for hash collision detection.
 func IsEqualFlowContext(a Flow, b Flow) bool {
@@ -24,6 +24,15 @@ type NetflowConfig struct {
 	AggregatorFlowContextTTL      int              `mapstructure:"aggregator_flow_context_ttl"`
 	AggregatorPortRollupThreshold int              `mapstructure:"aggregator_port_rollup_threshold"`
 	AggregatorPortRollupDisabled  bool             `mapstructure:"aggregator_port_rollup_disabled"`
+	AggregatorDeduplicationMode   string           `mapstructure:"aggregator_deduplication_mode"`
+
+	// Top talkers metrics are submitted when TopTalkersCount is greater than 0
+	TopTalkersCount      int      `mapstructure:"top_talkers_count"`
+	TopTalkersDimensions []string `mapstructure:"top_talkers_dimensions"`
+
+	// GeoIP enrichment is enabled when at least one of the databases is set
+	EnrichmentGeoIPDatabasePath string `mapstructure:"enrichment_geoip_database_path"`
+	EnrichmentASNDatabasePath   string `mapstructure:"enrichment_asn_database_path"`
 
 	// AggregatorRollupTrackerRefreshInterval is useful to speed up testing to avoid wait for 1h default
 	AggregatorRollupTrackerRefreshInterval uint `mapstructure:"aggregator_rollup_tracker_refresh_interval"`
@@ -36,6 +45,9 @@ type ListenerConfig struct {
 	BindHost  string          `mapstructure:"bind_host"`
 	Workers   int             `mapstructure:"workers"`
 	Namespace string          `mapstructure:"namespace"`
+
+	// SamplingRate overrides the sampling rate reported by exporters when set
+	SamplingRate uint64 `mapstructure:"sampling_rate"`
 }
 
 // ReadConfig builds and returns configuration from Agent configuration.
@@ -93,6 +105,23 @@ func ReadConfig() (*NetflowConfig, error) {
 	if mainConfig.AggregatorPortRollupThreshold == 0 {
 		mainConfig.AggregatorPortRollupThreshold = common.DefaultAggregatorPortRollupThreshold
 	}
+	switch mainConfig.AggregatorDeduplicationMode {
+	case "":
+		mainConfig.AggregatorDeduplicationMode = common.DeduplicationModeDisabled
+	case common.DeduplicationModeDisabled, common.DeduplicationModeIngressInterface:
+	default:
+		return nil, fmt.Errorf("the provided deduplication mode `%s` is not valid (valid modes: %v)", mainConfig.AggregatorDeduplicationMode, []string{common.DeduplicationModeDisabled, common.DeduplicationModeIngressInterface})
+	}
+	if mainConfig.TopTalkersCount > 0 {
+		if len(mainConfig.TopTalkersDimensions) == 0 {
+			mainConfig.TopTalkersDimensions = common.DefaultTopTalkersDimensions
+		}
+		for _, dimension := range mainConfig.TopTalkersDimensions {
+			if !isValidTopTalkersDimension(dimension) {
+				return nil, fmt.Errorf("the provided top talkers dimension `%s` is not valid (valid dimensions: %v)", dimension, common.GetAllTopTalkersDimensions())
+			}
+		}
+	}
 	if mainConfig.AggregatorRollupTrackerRefreshInterval == 0 {
 		mainConfig.AggregatorRollupTrackerRefreshInterval = common.DefaultAggregatorRollupTrackerRefreshInterval
 	}
@@ -104,3 +133,12 @@ func ReadConfig() (*NetflowConfig, error) {
 func (c *ListenerConfig) Addr() string {
 	return fmt.Sprintf("%s:%d", c.BindHost, c.Port)
 }
+
+func isValidTopTalkersDimension(dimension string) bool {
+	for _, validDimension := range common.GetAllTopTalkersDimensions() {
+		if dimension == validDimension {
+			return true
+		}
+	}
+	return false
+}
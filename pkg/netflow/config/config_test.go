@@ -37,12 +37,16 @@ network_devices:
     aggregator_rollup_tracker_refresh_interval: 60
     log_payloads: true
     aggregator_port_rollup_disabled: true
+    aggregator_deduplication_mode: ingress_interface
+    enrichment_geoip_database_path: /etc/geo.mmdb
+    enrichment_asn_database_path: /etc/asn.mmdb
     listeners:
       - flow_type: netflow9
         bind_host: 127.0.0.1
         port: 1234
         workers: 10
         namespace: my-ns1
+        sampling_rate: 1000
       - flow_type: netflow5
         bind_host: 127.0.0.2
         port: 2222
@@ -59,13 +63,17 @@ network_devices:
 				AggregatorPortRollupThreshold:          20,
 				AggregatorRollupTrackerRefreshInterval: 60,
 				AggregatorPortRollupDisabled:           true,
+				AggregatorDeduplicationMode:            "ingress_interface",
+				EnrichmentGeoIPDatabasePath:            "/etc/geo.mmdb",
+				EnrichmentASNDatabasePath:              "/etc/asn.mmdb",
 				Listeners: []ListenerConfig{
 					{
-						FlowType:  common.TypeNetFlow9,
-						BindHost:  "127.0.0.1",
-						Port:      uint16(1234),
-						Workers:   10,
-						Namespace: "my-ns1",
+						FlowType:     common.TypeNetFlow9,
+						BindHost:     "127.0.0.1",
+						Port:         uint16(1234),
+						Workers:      10,
+						Namespace:    "my-ns1",
+						SamplingRate: 1000,
 					},
 					{
 						FlowType:  common.TypeNetFlow5,
@@ -93,6 +101,7 @@ network_devices:
 				AggregatorFlowContextTTL:               300,
 				AggregatorPortRollupThreshold:          10,
 				AggregatorRollupTrackerRefreshInterval: 300,
+				AggregatorDeduplicationMode:            "disabled",
 				Listeners: []ListenerConfig{
 					{
 						FlowType:  common.TypeNetFlow9,
@@ -121,6 +130,7 @@ network_devices:
 				AggregatorFlowContextTTL:               50,
 				AggregatorPortRollupThreshold:          10,
 				AggregatorRollupTrackerRefreshInterval: 300,
+				AggregatorDeduplicationMode:            "disabled",
 				Listeners: []ListenerConfig{
 					{
 						FlowType:  common.TypeNetFlow9,
@@ -143,6 +153,51 @@ network_devices:
 `,
 			expectedError: "the provided flow type `invalidType` is not valid",
 		},
+		{
+			name: "invalid deduplication mode",
+			configYaml: `
+network_devices:
+  netflow:
+    enabled: true
+    aggregator_deduplication_mode: invalidMode
+    listeners:
+      - flow_type: netflow9
+`,
+			expectedError: "the provided deduplication mode `invalidMode` is not valid",
+		},
+		{
+			name: "top talkers with default dimensions",
+			configYaml: `
+network_devices:
+  netflow:
+    enabled: true
+    top_talkers_count: 5
+`,
+			expectedConfig: NetflowConfig{
+				StopTimeout:                            5,
+				AggregatorBufferSize:                   10000,
+				AggregatorFlushInterval:                300,
+				AggregatorFlowContextTTL:               300,
+				AggregatorPortRollupThreshold:          10,
+				AggregatorRollupTrackerRefreshInterval: 300,
+				AggregatorDeduplicationMode:            "disabled",
+				TopTalkersCount:                        5,
+				TopTalkersDimensions:                   []string{"source_ip", "destination_ip", "destination_port", "ip_protocol"},
+			},
+		},
+		{
+			name: "invalid top talkers dimension",
+			configYaml: `
+network_devices:
+  netflow:
+    enabled: true
+    top_talkers_count: 5
+    top_talkers_dimensions:
+      - source_ip
+      - invalidDimension
+`,
+			expectedError: "the provided top talkers dimension `invalidDimension` is not valid",
+		},
 		{
 			name: "invalid namespace with >100 chars",
 			configYaml: `
@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2022-present Datadog, Inc.
+
+package enrichment
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"github.com/DataDog/datadog-agent/pkg/netflow/common"
+)
+
+// mmdbReader is the subset of maxminddb.Reader used for lookups
+type mmdbReader interface {
+	Lookup(ip net.IP, result interface{}) error
+	Close() error
+}
+
+// geoRecord contains the fields read from a country or city database
+type geoRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// asnRecord contains the fields read from an ASN database
+type asnRecord struct {
+	AutonomousSystemNumber       uint32 `maxminddb:"autonomous_system_number"`
+	AutonomousSystemOrganization string `maxminddb:"autonomous_system_organization"`
+}
+
+// GeoIPEnricher adds geo and AS information to flows from local MaxMind-format (MMDB) databases
+type GeoIPEnricher struct {
+	geoDB mmdbReader
+	asnDB mmdbReader
+}
+
+// NewGeoIPEnricher opens the given country or city database and ASN database.
+// Either path can be empty, the corresponding fields are then not enriched.
+func NewGeoIPEnricher(geoDatabasePath string, asnDatabasePath string) (*GeoIPEnricher, error) {
+	enricher := &GeoIPEnricher{}
+	if geoDatabasePath != "" {
+		geoDB, err := maxminddb.Open(geoDatabasePath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open GeoIP database `%s`: %w", geoDatabasePath, err)
+		}
+		enricher.geoDB = geoDB
+	}
+	if asnDatabasePath != "" {
+		asnDB, err := maxminddb.Open(asnDatabasePath)
+		if err != nil {
+			enricher.Close()
+			return nil, fmt.Errorf("unable to open ASN database `%s`: %w", asnDatabasePath, err)
+		}
+		enricher.asnDB = asnDB
+	}
+	return enricher, nil
+}
+
+// Enrich sets geo and AS fields of the flow source and destination
+func (e *GeoIPEnricher) Enrich(flow *common.Flow) {
+	flow.SrcCountry, flow.SrcCity = e.lookupGeo(flow.SrcAddr)
+	flow.DstCountry, flow.DstCity = e.lookupGeo(flow.DstAddr)
+	flow.SrcASN, flow.SrcASOrg = e.lookupASN(flow.SrcAddr)
+	flow.DstASN, flow.DstASOrg = e.lookupASN(flow.DstAddr)
+}
+
+// Close closes the underlying databases
+func (e *GeoIPEnricher) Close() {
+	for _, db := range []mmdbReader{e.geoDB, e.asnDB} {
+		if db == nil {
+			continue
+		}
+		if err := db.Close(); err != nil {
+			log.Debugf("Error closing GeoIP database: %s", err)
+		}
+	}
+}
+
+func (e *GeoIPEnricher) lookupGeo(ipAddr []byte) (string, string) {
+	if e.geoDB == nil || len(ipAddr) == 0 {
+		return "", ""
+	}
+	var record geoRecord
+	if err := e.geoDB.Lookup(net.IP(ipAddr), &record); err != nil {
+		log.Tracef("GeoIP lookup failed for %s: %s", common.IPBytesToString(ipAddr), err)
+		return "", ""
+	}
+	return record.Country.ISOCode, record.City.Names["en"]
+}
+
+func (e *GeoIPEnricher) lookupASN(ipAddr []byte) (uint32, string) {
+	if e.asnDB == nil || len(ipAddr) == 0 {
+		return 0, ""
+	}
+	var record asnRecord
+	if err := e.asnDB.Lookup(net.IP(ipAddr), &record); err != nil {
+		log.Tracef("ASN lookup failed for %s: %s", common.IPBytesToString(ipAddr), err)
+		return 0, ""
+	}
+	return record.AutonomousSystemNumber, record.AutonomousSystemOrganization
+}
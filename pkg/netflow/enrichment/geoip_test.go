@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2022-present Datadog, Inc.
+
+package enrichment
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/netflow/common"
+)
+
+type fakeMMDBReader struct {
+	records map[string]func(result interface{})
+	closed  bool
+}
+
+func (r *fakeMMDBReader) Lookup(ip net.IP, result interface{}) error {
+	fill, ok := r.records[ip.String()]
+	if !ok {
+		return errors.New("lookup error")
+	}
+	fill(result)
+	return nil
+}
+
+func (r *fakeMMDBReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestGeoIPEnricher_Enrich(t *testing.T) {
+	geoDB := &fakeMMDBReader{records: map[string]func(result interface{}){
+		"1.1.1.1": func(result interface{}) {
+			record := result.(*geoRecord)
+			record.Country.ISOCode = "AU"
+			record.City.Names = map[string]string{"en": "Sydney", "fr": "Sydney"}
+		},
+		"2.2.2.2": func(result interface{}) {
+			record := result.(*geoRecord)
+			record.Country.ISOCode = "FR"
+		},
+	}}
+	asnDB := &fakeMMDBReader{records: map[string]func(result interface{}){
+		"1.1.1.1": func(result interface{}) {
+			record := result.(*asnRecord)
+			record.AutonomousSystemNumber = 13335
+			record.AutonomousSystemOrganization = "CLOUDFLARENET"
+		},
+	}}
+	enricher := &GeoIPEnricher{geoDB: geoDB, asnDB: asnDB}
+
+	flow := &common.Flow{
+		SrcAddr: []byte{1, 1, 1, 1},
+		DstAddr: []byte{2, 2, 2, 2},
+	}
+	enricher.Enrich(flow)
+	assert.Equal(t, "AU", flow.SrcCountry)
+	assert.Equal(t, "Sydney", flow.SrcCity)
+	assert.Equal(t, uint32(13335), flow.SrcASN)
+	assert.Equal(t, "CLOUDFLARENET", flow.SrcASOrg)
+	assert.Equal(t, "FR", flow.DstCountry)
+	assert.Equal(t, "", flow.DstCity)
+	assert.Equal(t, uint32(0), flow.DstASN)
+	assert.Equal(t, "", flow.DstASOrg)
+
+	enricher.Close()
+	assert.True(t, geoDB.closed)
+	assert.True(t, asnDB.closed)
+}
+
+func TestGeoIPEnricher_NoDatabase(t *testing.T) {
+	enricher, err := NewGeoIPEnricher("", "")
+	assert.NoError(t, err)
+
+	flow := &common.Flow{
+		SrcAddr: []byte{1, 1, 1, 1},
+		DstAddr: []byte{2, 2, 2, 2},
+	}
+	enricher.Enrich(flow)
+	assert.Equal(t, &common.Flow{
+		SrcAddr: []byte{1, 1, 1, 1},
+		DstAddr: []byte{2, 2, 2, 2},
+	}, flow)
+	enricher.Close()
+}
+
+func TestNewGeoIPEnricher_InvalidDatabase(t *testing.T) {
+	_, err := NewGeoIPEnricher("/does/not/exist.mmdb", "")
+	assert.ErrorContains(t, err, "unable to open GeoIP database `/does/not/exist.mmdb`")
+
+	_, err = NewGeoIPEnricher("", "/does/not/exist.mmdb")
+	assert.ErrorContains(t, err, "unable to open ASN database `/does/not/exist.mmdb`")
+}
@@ -17,6 +17,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/netflow/common"
 	"github.com/DataDog/datadog-agent/pkg/netflow/config"
+	"github.com/DataDog/datadog-agent/pkg/netflow/enrichment"
 )
 
 const flowAggregatorFlushInterval = 10 * time.Second
@@ -32,6 +33,8 @@ type FlowAggregator struct {
 	receivedFlowCount            *atomic.Uint64
 	flushedFlowCount             *atomic.Uint64
 	hostname                     string
+	geoIPEnricher                *enrichment.GeoIPEnricher
+	topTalkers                   *topTalkersAccumulator
 }
 
 // NewFlowAggregator returns a new FlowAggregator
@@ -39,9 +42,24 @@ func NewFlowAggregator(sender aggregator.Sender, config *config.NetflowConfig, h
 	flushInterval := time.Duration(config.AggregatorFlushInterval) * time.Second
 	flowContextTTL := time.Duration(config.AggregatorFlowContextTTL) * time.Second
 	rollupTrackerRefreshInterval := time.Duration(config.AggregatorRollupTrackerRefreshInterval) * time.Second
+
+	var geoIPEnricher *enrichment.GeoIPEnricher
+	if config.EnrichmentGeoIPDatabasePath != "" || config.EnrichmentASNDatabasePath != "" {
+		var err error
+		geoIPEnricher, err = enrichment.NewGeoIPEnricher(config.EnrichmentGeoIPDatabasePath, config.EnrichmentASNDatabasePath)
+		if err != nil {
+			log.Errorf("GeoIP enrichment disabled: %s", err)
+		}
+	}
+
+	var topTalkers *topTalkersAccumulator
+	if config.TopTalkersCount > 0 {
+		topTalkers = newTopTalkersAccumulator(config.TopTalkersDimensions, config.TopTalkersCount, flushInterval)
+	}
+
 	return &FlowAggregator{
 		flowIn:                       make(chan *common.Flow, config.AggregatorBufferSize),
-		flowAcc:                      newFlowAccumulator(flushInterval, flowContextTTL, config.AggregatorPortRollupThreshold, config.AggregatorPortRollupDisabled),
+		flowAcc:                      newFlowAccumulator(flushInterval, flowContextTTL, config.AggregatorPortRollupThreshold, config.AggregatorPortRollupDisabled, config.AggregatorDeduplicationMode),
 		flushInterval:                flowAggregatorFlushInterval,
 		rollupTrackerRefreshInterval: rollupTrackerRefreshInterval,
 		sender:                       sender,
@@ -49,6 +67,8 @@ func NewFlowAggregator(sender aggregator.Sender, config *config.NetflowConfig, h
 		receivedFlowCount:            atomic.NewUint64(0),
 		flushedFlowCount:             atomic.NewUint64(0),
 		hostname:                     hostname,
+		geoIPEnricher:                geoIPEnricher,
+		topTalkers:                   topTalkers,
 	}
 }
 
@@ -57,6 +77,11 @@ func (agg *FlowAggregator) Start() {
 	log.Info("Flow Aggregator started")
 	go agg.run()
 	agg.flushLoop() // blocking call
+
+	// Databases are closed once the flush loop, which is the only user of the enricher, is stopped.
+	if agg.geoIPEnricher != nil {
+		agg.geoIPEnricher.Close()
+	}
 }
 
 // Stop will stop running FlowAggregator
@@ -84,6 +109,9 @@ func (agg *FlowAggregator) run() {
 
 func (agg *FlowAggregator) sendFlows(flows []*common.Flow) {
 	for _, flow := range flows {
+		if agg.geoIPEnricher != nil {
+			agg.geoIPEnricher.Enrich(flow)
+		}
 		flowPayload := buildPayload(flow, agg.hostname)
 		payloadBytes, err := json.Marshal(flowPayload)
 		if err != nil {
@@ -134,11 +162,16 @@ func (agg *FlowAggregator) flush() int {
 	if len(flowsToFlush) > 0 {
 		agg.sendFlows(flowsToFlush)
 	}
+	if agg.topTalkers != nil {
+		agg.topTalkers.add(flowsToFlush)
+		agg.topTalkers.flush(agg.sender)
+	}
 
 	agg.flushedFlowCount.Add(uint64(len(flowsToFlush)))
 	agg.sender.MonotonicCount("datadog.netflow.aggregator.hash_collisions", float64(agg.flowAcc.hashCollisionFlowCount.Load()), "", nil)
 	agg.sender.MonotonicCount("datadog.netflow.aggregator.flows_received", float64(agg.receivedFlowCount.Load()), "", nil)
 	agg.sender.MonotonicCount("datadog.netflow.aggregator.flows_flushed", float64(agg.flushedFlowCount.Load()), "", nil)
+	agg.sender.MonotonicCount("datadog.netflow.aggregator.flows_deduplicated", float64(agg.flowAcc.deduplicatedFlowCount.Load()), "", nil)
 	agg.sender.Gauge("datadog.netflow.aggregator.flows_contexts", float64(flowsContexts), "", nil)
 	agg.sender.Gauge("datadog.netflow.aggregator.port_rollup.current_store_size", float64(agg.flowAcc.portRollup.GetCurrentStoreSize()), "", nil)
 	agg.sender.Gauge("datadog.netflow.aggregator.port_rollup.new_store_size", float64(agg.flowAcc.portRollup.GetNewStoreSize()), "", nil)
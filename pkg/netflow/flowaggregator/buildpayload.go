@@ -33,12 +33,16 @@ func buildPayload(aggFlow *common.Flow, hostname string) payload.FlowPayload {
 			Port: portrollup.PortToString(aggFlow.SrcPort),
 			Mac:  enrichment.FormatMacAddress(aggFlow.SrcMac),
 			Mask: enrichment.FormatMask(aggFlow.SrcAddr, aggFlow.SrcMask),
+			Geo:  buildGeo(aggFlow.SrcCountry, aggFlow.SrcCity),
+			AS:   buildAS(aggFlow.SrcASN, aggFlow.SrcASOrg),
 		},
 		Destination: payload.Endpoint{
 			IP:   common.IPBytesToString(aggFlow.DstAddr),
 			Port: portrollup.PortToString(aggFlow.DstPort),
 			Mac:  enrichment.FormatMacAddress(aggFlow.DstMac),
 			Mask: enrichment.FormatMask(aggFlow.DstAddr, aggFlow.DstMask),
+			Geo:  buildGeo(aggFlow.DstCountry, aggFlow.DstCity),
+			AS:   buildAS(aggFlow.DstASN, aggFlow.DstASOrg),
 		},
 		Ingress: payload.ObservationPoint{
 			Interface: payload.Interface{
@@ -57,3 +61,23 @@ func buildPayload(aggFlow *common.Flow, hostname string) payload.FlowPayload {
 		},
 	}
 }
+
+func buildGeo(country string, city string) *payload.Geo {
+	if country == "" && city == "" {
+		return nil
+	}
+	return &payload.Geo{
+		CountryISOCode: country,
+		City:           city,
+	}
+}
+
+func buildAS(number uint32, organization string) *payload.AS {
+	if number == 0 {
+		return nil
+	}
+	return &payload.AS{
+		Number:       number,
+		Organization: organization,
+	}
+}
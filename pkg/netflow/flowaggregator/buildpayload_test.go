@@ -82,6 +82,52 @@ func Test_buildPayload(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "geoip enrichment",
+			flow: common.Flow{
+				Namespace:      "my-namespace",
+				FlowType:       common.TypeNetFlow9,
+				DeviceAddr:     []byte{127, 0, 0, 1},
+				SrcAddr:        []byte{1, 1, 1, 1},
+				DstAddr:        []byte{2, 2, 2, 2},
+				SrcPort:        2000,
+				DstPort:        80,
+				SrcCountry:     "AU",
+				SrcCity:        "Sydney",
+				SrcASN:         13335,
+				SrcASOrg:       "CLOUDFLARENET",
+				DstCountry:     "FR",
+				InputInterface: 10,
+			},
+			expectedPayload: payload.FlowPayload{
+				FlowType:   "netflow9",
+				Direction:  "ingress",
+				IPProtocol: "HOPOPT",
+				Device: payload.Device{
+					IP:        "127.0.0.1",
+					Namespace: "my-namespace",
+				},
+				Source: payload.Endpoint{
+					IP:   "1.1.1.1",
+					Port: "2000",
+					Mac:  "00:00:00:00:00:00",
+					Mask: "0.0.0.0/0",
+					Geo:  &payload.Geo{CountryISOCode: "AU", City: "Sydney"},
+					AS:   &payload.AS{Number: 13335, Organization: "CLOUDFLARENET"},
+				},
+				Destination: payload.Endpoint{IP: "2.2.2.2",
+					Port: "80",
+					Mac:  "00:00:00:00:00:00",
+					Mask: "0.0.0.0/0",
+					Geo:  &payload.Geo{CountryISOCode: "FR"},
+				},
+				Ingress: payload.ObservationPoint{Interface: payload.Interface{Index: 10}},
+				Host:    "my-hostname",
+				NextHop: payload.NextHop{
+					IP: "",
+				},
+			},
+		},
 		{
 			name: "ephemeral source port",
 			flow: common.Flow{
@@ -6,6 +6,8 @@
 package flowaggregator
 
 import (
+	"bytes"
+
 	"github.com/DataDog/datadog-agent/pkg/netflow/portrollup"
 	"go.uber.org/atomic"
 	"sync"
@@ -40,6 +42,42 @@ type flowAccumulator struct {
 	portRollupDisabled  bool
 
 	hashCollisionFlowCount *atomic.Uint64
+
+	// deduplication state, only used when deduplicationMode is not DeduplicationModeDisabled
+	deduplicationMode     string
+	deduplicationSources  map[uint64]deduplicationSource
+	deduplicatedFlowCount *atomic.Uint64
+}
+
+// deduplicationSource is the exporter and ingress interface whose copies of a 5-tuple are kept
+type deduplicationSource struct {
+	deviceAddr     []byte
+	inputInterface uint32
+	expiry         time.Time
+	// contributions holds what the copies of the source added to each flow context since its last flush,
+	// so that they can be removed if a preferred source reports the 5-tuple later.
+	contributions map[uint64]deduplicationContribution
+}
+
+// deduplicationContribution is what the copies of a deduplication source added to a flow context
+type deduplicationContribution struct {
+	flowCount           uint64
+	bytes               uint64
+	packets             uint64
+	lastSuccessfulFlush time.Time
+}
+
+// isPreferredTo returns whether the copies of the source are kept rather than the copies of the other source:
+// the lowest ingress interface wins, then the lowest exporter address.
+func (s deduplicationSource) isPreferredTo(other deduplicationSource) bool {
+	if s.inputInterface != other.inputInterface {
+		return s.inputInterface < other.inputInterface
+	}
+	return bytes.Compare(s.deviceAddr, other.deviceAddr) < 0
+}
+
+func (s deduplicationSource) isSameAs(other deduplicationSource) bool {
+	return s.inputInterface == other.inputInterface && bytes.Equal(s.deviceAddr, other.deviceAddr)
 }
 
 func newFlowContext(flow *common.Flow) flowContext {
@@ -50,7 +88,7 @@ func newFlowContext(flow *common.Flow) flowContext {
 	}
 }
 
-func newFlowAccumulator(aggregatorFlushInterval time.Duration, aggregatorFlowContextTTL time.Duration, portRollupThreshold int, portRollupDisabled bool) *flowAccumulator {
+func newFlowAccumulator(aggregatorFlushInterval time.Duration, aggregatorFlowContextTTL time.Duration, portRollupThreshold int, portRollupDisabled bool, deduplicationMode string) *flowAccumulator {
 	return &flowAccumulator{
 		flows:                  make(map[uint64]flowContext),
 		flowFlushInterval:      aggregatorFlushInterval,
@@ -59,6 +97,9 @@ func newFlowAccumulator(aggregatorFlushInterval time.Duration, aggregatorFlowCon
 		portRollupThreshold:    portRollupThreshold,
 		portRollupDisabled:     portRollupDisabled,
 		hashCollisionFlowCount: atomic.NewUint64(0),
+		deduplicationMode:      deduplicationMode,
+		deduplicationSources:   make(map[uint64]deduplicationSource),
+		deduplicatedFlowCount:  atomic.NewUint64(0),
 	}
 }
 
@@ -76,6 +117,8 @@ func (f *flowAccumulator) flush() []*common.Flow {
 	f.flowsMutex.Lock()
 	defer f.flowsMutex.Unlock()
 
+	f.expireDeduplicationSources()
+
 	var flowsToFlush []*common.Flow
 	for key, flowCtx := range f.flows {
 		now := timeNow()
@@ -102,6 +145,23 @@ func (f *flowAccumulator) flush() []*common.Flow {
 func (f *flowAccumulator) add(flowToAdd *common.Flow) {
 	log.Tracef("Add new flow: %+v", flowToAdd)
 
+	// the duplication decision and the contribution of the flow are recorded in the same critical section,
+	// so that a concurrent flush or add cannot run between them.
+	f.flowsMutex.Lock()
+	defer f.flowsMutex.Unlock()
+
+	deduplicationEnabled := f.deduplicationMode == common.DeduplicationModeIngressInterface
+	var deduplicationKey uint64
+	if deduplicationEnabled {
+		// the key is computed before the port rollup rewrites the ports of the flow
+		deduplicationKey = flowToAdd.DeduplicationHash()
+		if f.isDuplicate(deduplicationKey, flowToAdd) {
+			log.Tracef("Drop duplicated flow: %+v", flowToAdd)
+			f.deduplicatedFlowCount.Inc()
+			return
+		}
+	}
+
 	if !f.portRollupDisabled {
 		// Handle port rollup
 		f.portRollup.Add(flowToAdd.SrcAddr, flowToAdd.DstAddr, uint16(flowToAdd.SrcPort), uint16(flowToAdd.DstPort))
@@ -114,10 +174,10 @@ func (f *flowAccumulator) add(flowToAdd *common.Flow) {
 		}
 	}
 
-	f.flowsMutex.Lock()
-	defer f.flowsMutex.Unlock()
-
 	aggHash := flowToAdd.AggregationHash()
+	if deduplicationEnabled {
+		f.recordDeduplicationContribution(deduplicationKey, aggHash, flowToAdd)
+	}
 	aggFlow, ok := f.flows[aggHash]
 	if !ok {
 		f.flows[aggHash] = newFlowContext(flowToAdd)
@@ -139,6 +199,79 @@ func (f *flowAccumulator) add(flowToAdd *common.Flow) {
 	f.flows[aggHash] = aggFlow
 }
 
+// isDuplicate returns true if the 5-tuple of the flow (deduplicationKey) has already been reported during the current
+// flush interval by a preferred source (see deduplicationSource.isPreferredTo). When the flow comes from
+// a source preferred to the current one, the copies of the current source which are not flushed yet are
+// removed, so that the kept copies do not depend on the order in which the exporters report the 5-tuple.
+// flowsMutex must be held.
+func (f *flowAccumulator) isDuplicate(deduplicationKey uint64, flow *common.Flow) bool {
+	candidate := deduplicationSource{
+		deviceAddr:     flow.DeviceAddr,
+		inputInterface: flow.InputInterface,
+		expiry:         timeNow().Add(f.flowFlushInterval),
+		contributions:  make(map[uint64]deduplicationContribution),
+	}
+	source, ok := f.deduplicationSources[deduplicationKey]
+	switch {
+	case !ok || source.expiry.Before(timeNow()):
+		f.deduplicationSources[deduplicationKey] = candidate
+		return false
+	case candidate.isSameAs(source):
+		return false
+	case candidate.isPreferredTo(source):
+		f.removeDeduplicationContributions(source)
+		f.deduplicationSources[deduplicationKey] = candidate
+		return false
+	}
+	return true
+}
+
+// recordDeduplicationContribution records what the flow adds to the flow context of aggHash, flowsMutex must be held
+func (f *flowAccumulator) recordDeduplicationContribution(deduplicationKey uint64, aggHash uint64, flow *common.Flow) {
+	source, ok := f.deduplicationSources[deduplicationKey]
+	if !ok {
+		return
+	}
+	contribution := source.contributions[aggHash]
+	lastSuccessfulFlush := f.flows[aggHash].lastSuccessfulFlush
+	if !contribution.lastSuccessfulFlush.Equal(lastSuccessfulFlush) {
+		// the previous contribution has already been flushed
+		contribution = deduplicationContribution{lastSuccessfulFlush: lastSuccessfulFlush}
+	}
+	contribution.flowCount++
+	contribution.bytes += flow.Bytes
+	contribution.packets += flow.Packets
+	source.contributions[aggHash] = contribution
+}
+
+// removeDeduplicationContributions removes the copies of the source which are not flushed yet, flowsMutex must be held
+func (f *flowAccumulator) removeDeduplicationContributions(source deduplicationSource) {
+	for aggHash, contribution := range source.contributions {
+		flowCtx, ok := f.flows[aggHash]
+		if !ok || flowCtx.flow == nil || !flowCtx.lastSuccessfulFlush.Equal(contribution.lastSuccessfulFlush) {
+			continue
+		}
+		if flowCtx.flow.Bytes <= contribution.bytes && flowCtx.flow.Packets <= contribution.packets {
+			flowCtx.flow = nil
+		} else {
+			flowCtx.flow.Bytes -= common.MinUint64(flowCtx.flow.Bytes, contribution.bytes)
+			flowCtx.flow.Packets -= common.MinUint64(flowCtx.flow.Packets, contribution.packets)
+		}
+		f.flows[aggHash] = flowCtx
+		f.deduplicatedFlowCount.Add(contribution.flowCount)
+	}
+}
+
+// expireDeduplicationSources deletes expired deduplication sources, flowsMutex must be held
+func (f *flowAccumulator) expireDeduplicationSources() {
+	now := timeNow()
+	for key, source := range f.deduplicationSources {
+		if source.expiry.Before(now) {
+			delete(f.deduplicationSources, key)
+		}
+	}
+}
+
 func (f *flowAccumulator) getFlowContextCount() int {
 	f.flowsMutex.Lock()
 	defer f.flowsMutex.Unlock()
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/netflow/common"
 	"github.com/DataDog/datadog-agent/pkg/netflow/portrollup"
@@ -79,7 +80,7 @@ func Test_flowAccumulator_add(t *testing.T) {
 	}
 
 	// When
-	acc := newFlowAccumulator(common.DefaultAggregatorFlushInterval, common.DefaultAggregatorFlushInterval, common.DefaultAggregatorPortRollupThreshold, false)
+	acc := newFlowAccumulator(common.DefaultAggregatorFlushInterval, common.DefaultAggregatorFlushInterval, common.DefaultAggregatorPortRollupThreshold, false, common.DeduplicationModeDisabled)
 	acc.add(flowA1)
 	acc.add(flowA2)
 	acc.add(flowB1)
@@ -150,7 +151,7 @@ func Test_flowAccumulator_portRollUp(t *testing.T) {
 	}
 
 	// When
-	acc := newFlowAccumulator(common.DefaultAggregatorFlushInterval, common.DefaultAggregatorFlushInterval, 3, false)
+	acc := newFlowAccumulator(common.DefaultAggregatorFlushInterval, common.DefaultAggregatorFlushInterval, 3, false, common.DeduplicationModeDisabled)
 	acc.add(flowA1)
 	acc.add(flowA2)
 
@@ -225,7 +226,7 @@ func Test_flowAccumulator_flush(t *testing.T) {
 	}
 
 	// When
-	acc := newFlowAccumulator(flushInterval, flowContextTTL, common.DefaultAggregatorPortRollupThreshold, false)
+	acc := newFlowAccumulator(flushInterval, flowContextTTL, common.DefaultAggregatorPortRollupThreshold, false, common.DeduplicationModeDisabled)
 	acc.add(flow)
 
 	// Then
@@ -288,3 +289,146 @@ func Test_flowAccumulator_flush(t *testing.T) {
 	_, ok = acc.flows[flow.AggregationHash()]
 	assert.False(t, ok)
 }
+
+func Test_flowAccumulator_deduplication(t *testing.T) {
+	setMockTimeNow(MockTimeNow())
+	flushInterval := 60 * time.Second
+
+	newFlow := func(deviceAddr []byte, inputInterface uint32) *common.Flow {
+		return &common.Flow{
+			FlowType:       common.TypeNetFlow9,
+			DeviceAddr:     deviceAddr,
+			Bytes:          10,
+			Packets:        2,
+			SrcAddr:        []byte{10, 10, 10, 10},
+			DstAddr:        []byte{10, 10, 10, 20},
+			IPProtocol:     uint32(6),
+			SrcPort:        2000,
+			DstPort:        80,
+			InputInterface: inputInterface,
+		}
+	}
+	router1 := []byte{127, 0, 0, 1}
+	router2 := []byte{127, 0, 0, 2}
+
+	// When
+	acc := newFlowAccumulator(flushInterval, flushInterval, common.DefaultAggregatorPortRollupThreshold, true, common.DeduplicationModeIngressInterface)
+	acc.add(newFlow(router1, 1))
+	acc.add(newFlow(router2, 5)) // same 5-tuple reported by another exporter
+	acc.add(newFlow(router1, 2)) // same 5-tuple reported on another ingress interface
+	acc.add(newFlow(router1, 1))
+
+	// Then
+	assert.Equal(t, 1, len(acc.flows))
+	assert.Equal(t, uint64(2), acc.deduplicatedFlowCount.Load())
+	wrappedFlow := acc.flows[newFlow(router1, 1).AggregationHash()]
+	assert.Equal(t, uint64(20), wrappedFlow.flow.Bytes)
+	assert.Equal(t, uint64(4), wrappedFlow.flow.Packets)
+
+	// When the deduplication source expires and the copies arrive in the reverse order,
+	// the copies of the lowest ingress interface are still the ones kept
+	setMockTimeNow(MockTimeNow().Add(flushInterval + 1*time.Second))
+	acc.flush()
+	assert.Equal(t, 0, len(acc.deduplicationSources))
+	acc.add(newFlow(router2, 5))
+	acc.add(newFlow(router1, 2))
+	acc.add(newFlow(router1, 1))
+	acc.add(newFlow(router2, 5))
+
+	// Then
+	assert.Equal(t, uint64(5), acc.deduplicatedFlowCount.Load())
+	assert.Nil(t, acc.flows[newFlow(router2, 5).AggregationHash()].flow)
+	assert.Nil(t, acc.flows[newFlow(router1, 2).AggregationHash()].flow)
+	wrappedFlow = acc.flows[newFlow(router1, 1).AggregationHash()]
+	assert.Equal(t, uint64(10), wrappedFlow.flow.Bytes)
+	assert.Equal(t, uint64(2), wrappedFlow.flow.Packets)
+}
+
+func Test_flowAccumulator_deduplicationSameInterface(t *testing.T) {
+	setMockTimeNow(MockTimeNow())
+	flushInterval := 60 * time.Second
+
+	newFlow := func(deviceAddr []byte) *common.Flow {
+		return &common.Flow{
+			FlowType:       common.TypeNetFlow9,
+			DeviceAddr:     deviceAddr,
+			Bytes:          10,
+			Packets:        2,
+			SrcAddr:        []byte{10, 10, 10, 10},
+			DstAddr:        []byte{10, 10, 10, 20},
+			IPProtocol:     uint32(6),
+			SrcPort:        2000,
+			DstPort:        80,
+			InputInterface: 1,
+		}
+	}
+	router1 := []byte{127, 0, 0, 1}
+	router2 := []byte{127, 0, 0, 2}
+
+	for _, order := range [][][]byte{{router1, router2}, {router2, router1}} {
+		acc := newFlowAccumulator(flushInterval, flushInterval, common.DefaultAggregatorPortRollupThreshold, true, common.DeduplicationModeIngressInterface)
+		for _, deviceAddr := range order {
+			acc.add(newFlow(deviceAddr))
+		}
+
+		// the copies of the lowest exporter address are kept when the ingress interfaces are equal
+		flows := acc.flush()
+		require.Len(t, flows, 1)
+		assert.Equal(t, router1, flows[0].DeviceAddr)
+		assert.Equal(t, uint64(10), flows[0].Bytes)
+		assert.Equal(t, uint64(1), acc.deduplicatedFlowCount.Load())
+	}
+}
+
+func Test_flowAccumulator_deduplicationWithPortRollup(t *testing.T) {
+	setMockTimeNow(MockTimeNow())
+	flushInterval := 60 * time.Second
+
+	newFlow := func(deviceAddr []byte, inputInterface uint32, dstPort int32) *common.Flow {
+		return &common.Flow{
+			FlowType:       common.TypeNetFlow9,
+			DeviceAddr:     deviceAddr,
+			Bytes:          10,
+			Packets:        2,
+			SrcAddr:        []byte{10, 10, 10, 10},
+			DstAddr:        []byte{10, 10, 10, 20},
+			IPProtocol:     uint32(6),
+			SrcPort:        80,
+			DstPort:        dstPort,
+			InputInterface: inputInterface,
+		}
+	}
+	router1 := []byte{127, 0, 0, 1}
+	router2 := []byte{127, 0, 0, 2}
+	dstPorts := []int32{2001, 2002, 2003, 2004}
+
+	// When the copies of the preferred source arrive after the ports have been rolled up
+	acc := newFlowAccumulator(flushInterval, flushInterval, 3, false, common.DeduplicationModeIngressInterface)
+	for _, dstPort := range dstPorts {
+		acc.add(newFlow(router2, 5, dstPort))
+	}
+	for _, dstPort := range dstPorts {
+		acc.add(newFlow(router1, 1, dstPort))
+	}
+
+	// Then only the copies of the preferred source are kept, including the rolled up ones
+	flows := acc.flush()
+	var totalBytes uint64
+	for _, flow := range flows {
+		assert.Equal(t, router1, flow.DeviceAddr)
+		totalBytes += flow.Bytes
+	}
+	assert.Equal(t, uint64(40), totalBytes)
+	assert.Equal(t, uint64(4), acc.deduplicatedFlowCount.Load())
+	rolledUpFlow := newFlow(router2, 5, portrollup.EphemeralPort)
+	assert.Nil(t, acc.flows[rolledUpFlow.AggregationHash()].flow)
+}
+
+func Test_flowAccumulator_deduplicationDisabled(t *testing.T) {
+	acc := newFlowAccumulator(common.DefaultAggregatorFlushInterval, common.DefaultAggregatorFlushInterval, common.DefaultAggregatorPortRollupThreshold, true, common.DeduplicationModeDisabled)
+	acc.add(&common.Flow{DeviceAddr: []byte{127, 0, 0, 1}, SrcAddr: []byte{10, 10, 10, 10}, DstAddr: []byte{10, 10, 10, 20}})
+	acc.add(&common.Flow{DeviceAddr: []byte{127, 0, 0, 2}, SrcAddr: []byte{10, 10, 10, 10}, DstAddr: []byte{10, 10, 10, 20}})
+
+	assert.Equal(t, 2, len(acc.flows))
+	assert.Equal(t, uint64(0), acc.deduplicatedFlowCount.Load())
+}
@@ -0,0 +1,151 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2022-present Datadog, Inc.
+
+package flowaggregator
+
+import (
+	"sort"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+
+	"github.com/DataDog/datadog-agent/pkg/netflow/common"
+	"github.com/DataDog/datadog-agent/pkg/netflow/enrichment"
+	"github.com/DataDog/datadog-agent/pkg/netflow/portrollup"
+)
+
+// topTalker contains the bytes and packets of all flows sharing the same dimension value
+type topTalker struct {
+	value   string
+	bytes   uint64
+	packets uint64
+}
+
+// dimensionValue returns the value of a top talkers dimension for a flow
+func dimensionValue(flow *common.Flow, dimension string) string {
+	switch dimension {
+	case common.TopTalkersDimensionSourceIP:
+		return common.IPBytesToString(flow.SrcAddr)
+	case common.TopTalkersDimensionDestinationIP:
+		return common.IPBytesToString(flow.DstAddr)
+	case common.TopTalkersDimensionSourcePort:
+		return portrollup.PortToString(flow.SrcPort)
+	case common.TopTalkersDimensionDestinationPort:
+		return portrollup.PortToString(flow.DstPort)
+	case common.TopTalkersDimensionIPProtocol:
+		return enrichment.MapIPProtocol(flow.IPProtocol)
+	}
+	return ""
+}
+
+// topTalkersGroup identifies the flows whose top talkers are computed together:
+// the flows of a device for a dimension
+type topTalkersGroup struct {
+	namespace string
+	deviceIP  string
+	dimension string
+}
+
+// topTalkersAccumulator accumulates the flushed flows over a fixed interval and submits
+// the top talkers computed from all of them once the interval is over.
+// As each flow context is flushed once per flow flush interval, using this interval as the
+// accumulation interval computes the top talkers from every flow context exactly once.
+type topTalkersAccumulator struct {
+	dimensions []string
+	count      int
+	interval   time.Duration
+	windowEnd  time.Time
+	talkers    map[topTalkersGroup]map[string]*topTalker
+}
+
+func newTopTalkersAccumulator(dimensions []string, count int, interval time.Duration) *topTalkersAccumulator {
+	return &topTalkersAccumulator{
+		dimensions: dimensions,
+		count:      count,
+		interval:   interval,
+		windowEnd:  timeNow().Add(interval),
+		talkers:    make(map[topTalkersGroup]map[string]*topTalker),
+	}
+}
+
+// add accumulates the bytes and packets of the flows in the talkers of each dimension
+func (t *topTalkersAccumulator) add(flows []*common.Flow) {
+	for _, flow := range flows {
+		for _, dimension := range t.dimensions {
+			group := topTalkersGroup{
+				namespace: flow.Namespace,
+				deviceIP:  common.IPBytesToString(flow.DeviceAddr),
+				dimension: dimension,
+			}
+			talkersByValue, ok := t.talkers[group]
+			if !ok {
+				talkersByValue = make(map[string]*topTalker)
+				t.talkers[group] = talkersByValue
+			}
+			value := dimensionValue(flow, dimension)
+			talker, ok := talkersByValue[value]
+			if !ok {
+				talker = &topTalker{value: value}
+				talkersByValue[value] = talker
+			}
+			talker.bytes += flow.Bytes
+			talker.packets += flow.Packets
+		}
+	}
+}
+
+// flush submits the top talkers of each group as gauges and starts a new interval
+// if the current interval is over, it does nothing otherwise.
+func (t *topTalkersAccumulator) flush(sender aggregator.Sender) {
+	now := timeNow()
+	if now.Before(t.windowEnd) {
+		return
+	}
+	for !t.windowEnd.After(now) {
+		t.windowEnd = t.windowEnd.Add(t.interval)
+	}
+	for group, talkersByValue := range t.talkers {
+		tags := []string{"device_namespace:" + group.namespace, "device_ip:" + group.deviceIP, "dimension:" + group.dimension}
+		byBytes, byPackets := computeTopTalkers(talkersByValue, t.count)
+		for _, talker := range byBytes {
+			sender.Gauge("netflow.top_talkers.bytes", float64(talker.bytes), "", append(tags, group.dimension+":"+talker.value))
+		}
+		for _, talker := range byPackets {
+			sender.Gauge("netflow.top_talkers.packets", float64(talker.packets), "", append(tags, group.dimension+":"+talker.value))
+		}
+	}
+	t.talkers = make(map[topTalkersGroup]map[string]*topTalker)
+}
+
+// computeTopTalkers returns the `count` talkers with the most bytes and the most packets
+func computeTopTalkers(talkersByValue map[string]*topTalker, count int) ([]topTalker, []topTalker) {
+	talkers := make([]topTalker, 0, len(talkersByValue))
+	for _, talker := range talkersByValue {
+		talkers = append(talkers, *talker)
+	}
+
+	byBytes := make([]topTalker, len(talkers))
+	copy(byBytes, talkers)
+	sort.Slice(byBytes, func(i, j int) bool {
+		if byBytes[i].bytes == byBytes[j].bytes {
+			return byBytes[i].value < byBytes[j].value
+		}
+		return byBytes[i].bytes > byBytes[j].bytes
+	})
+
+	byPackets := talkers
+	sort.Slice(byPackets, func(i, j int) bool {
+		if byPackets[i].packets == byPackets[j].packets {
+			return byPackets[i].value < byPackets[j].value
+		}
+		return byPackets[i].packets > byPackets[j].packets
+	})
+
+	if len(talkers) > count {
+		byBytes = byBytes[:count]
+		byPackets = byPackets[:count]
+	}
+	return byBytes, byPackets
+}
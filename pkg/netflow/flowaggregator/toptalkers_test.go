@@ -0,0 +1,136 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2022-present Datadog, Inc.
+
+package flowaggregator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/netflow/common"
+)
+
+var topTalkersDevice = []byte{127, 0, 0, 1}
+
+var topTalkersFlows = []*common.Flow{
+	{Namespace: "default", DeviceAddr: topTalkersDevice, SrcAddr: []byte{10, 0, 0, 1}, DstAddr: []byte{10, 0, 0, 10}, SrcPort: 2000, DstPort: 80, IPProtocol: 6, Bytes: 100, Packets: 1},
+	{Namespace: "default", DeviceAddr: topTalkersDevice, SrcAddr: []byte{10, 0, 0, 1}, DstAddr: []byte{10, 0, 0, 11}, SrcPort: 2001, DstPort: 443, IPProtocol: 6, Bytes: 50, Packets: 1},
+	{Namespace: "default", DeviceAddr: topTalkersDevice, SrcAddr: []byte{10, 0, 0, 2}, DstAddr: []byte{10, 0, 0, 10}, SrcPort: -1, DstPort: 53, IPProtocol: 17, Bytes: 120, Packets: 10},
+	{Namespace: "default", DeviceAddr: topTalkersDevice, SrcAddr: []byte{10, 0, 0, 3}, DstAddr: []byte{10, 0, 0, 10}, SrcPort: 2002, DstPort: 80, IPProtocol: 6, Bytes: 10, Packets: 5},
+}
+
+func Test_computeTopTalkers(t *testing.T) {
+	tests := []struct {
+		name              string
+		dimension         string
+		count             int
+		expectedByBytes   []topTalker
+		expectedByPackets []topTalker
+	}{
+		{
+			name:      "source ip",
+			dimension: common.TopTalkersDimensionSourceIP,
+			count:     2,
+			expectedByBytes: []topTalker{
+				{value: "10.0.0.1", bytes: 150, packets: 2},
+				{value: "10.0.0.2", bytes: 120, packets: 10},
+			},
+			expectedByPackets: []topTalker{
+				{value: "10.0.0.2", bytes: 120, packets: 10},
+				{value: "10.0.0.3", bytes: 10, packets: 5},
+			},
+		},
+		{
+			name:      "source port",
+			dimension: common.TopTalkersDimensionSourcePort,
+			count:     1,
+			expectedByBytes: []topTalker{
+				{value: "*", bytes: 120, packets: 10},
+			},
+			expectedByPackets: []topTalker{
+				{value: "*", bytes: 120, packets: 10},
+			},
+		},
+		{
+			name:      "ip protocol with count greater than values",
+			dimension: common.TopTalkersDimensionIPProtocol,
+			count:     10,
+			expectedByBytes: []topTalker{
+				{value: "TCP", bytes: 160, packets: 7},
+				{value: "UDP", bytes: 120, packets: 10},
+			},
+			expectedByPackets: []topTalker{
+				{value: "UDP", bytes: 120, packets: 10},
+				{value: "TCP", bytes: 160, packets: 7},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acc := newTopTalkersAccumulator([]string{tt.dimension}, tt.count, time.Minute)
+			acc.add(topTalkersFlows)
+			group := topTalkersGroup{namespace: "default", deviceIP: "127.0.0.1", dimension: tt.dimension}
+			byBytes, byPackets := computeTopTalkers(acc.talkers[group], tt.count)
+			assert.Equal(t, tt.expectedByBytes, byBytes)
+			assert.Equal(t, tt.expectedByPackets, byPackets)
+		})
+	}
+}
+
+func Test_topTalkersAccumulator_flush(t *testing.T) {
+	setMockTimeNow(MockTimeNow())
+	interval := 60 * time.Second
+	sender := mocksender.NewMockSender("")
+	sender.On("Gauge", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+
+	acc := newTopTalkersAccumulator([]string{common.TopTalkersDimensionDestinationIP, common.TopTalkersDimensionDestinationPort}, 1, interval)
+
+	// flow contexts are flushed at different times during the interval
+	acc.add(topTalkersFlows[:2])
+	acc.flush(sender)
+	setMockTimeNow(MockTimeNow().Add(interval / 2))
+	acc.add(topTalkersFlows[2:])
+	acc.flush(sender)
+	sender.AssertNumberOfCalls(t, "Gauge", 0)
+
+	// the top talkers are computed from all the flows of the interval, even if no flow is flushed at its end
+	setMockTimeNow(MockTimeNow().Add(interval))
+	acc.add(nil)
+	acc.flush(sender)
+
+	deviceTags := []string{"device_namespace:default", "device_ip:127.0.0.1"}
+	sender.AssertNumberOfCalls(t, "Gauge", 4)
+	sender.AssertMetric(t, "Gauge", "netflow.top_talkers.bytes", 230, "", append(deviceTags, "dimension:destination_ip", "destination_ip:10.0.0.10"))
+	sender.AssertMetric(t, "Gauge", "netflow.top_talkers.packets", 16, "", append(deviceTags, "dimension:destination_ip", "destination_ip:10.0.0.10"))
+	sender.AssertMetric(t, "Gauge", "netflow.top_talkers.bytes", 120, "", append(deviceTags, "dimension:destination_port", "destination_port:53"))
+	sender.AssertMetric(t, "Gauge", "netflow.top_talkers.packets", 10, "", append(deviceTags, "dimension:destination_port", "destination_port:53"))
+
+	// a new interval starts empty
+	acc.flush(sender)
+	sender.AssertNumberOfCalls(t, "Gauge", 4)
+	assert.Empty(t, acc.talkers)
+}
+
+func Test_topTalkersAccumulator_devices(t *testing.T) {
+	setMockTimeNow(MockTimeNow())
+	sender := mocksender.NewMockSender("")
+	sender.On("Gauge", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+
+	acc := newTopTalkersAccumulator([]string{common.TopTalkersDimensionIPProtocol}, 1, time.Minute)
+	acc.add([]*common.Flow{
+		{Namespace: "default", DeviceAddr: []byte{127, 0, 0, 1}, IPProtocol: 6, Bytes: 100, Packets: 1},
+		{Namespace: "other", DeviceAddr: []byte{127, 0, 0, 1}, IPProtocol: 17, Bytes: 50, Packets: 1},
+	})
+	setMockTimeNow(MockTimeNow().Add(time.Minute))
+	acc.flush(sender)
+
+	sender.AssertNumberOfCalls(t, "Gauge", 4)
+	sender.AssertMetric(t, "Gauge", "netflow.top_talkers.bytes", 100, "", []string{"device_namespace:default", "device_ip:127.0.0.1", "dimension:ip_protocol", "ip_protocol:TCP"})
+	sender.AssertMetric(t, "Gauge", "netflow.top_talkers.bytes", 50, "", []string{"device_namespace:other", "device_ip:127.0.0.1", "dimension:ip_protocol", "ip_protocol:UDP"})
+}
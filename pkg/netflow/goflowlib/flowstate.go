@@ -36,10 +36,10 @@ type FlowRunnableState interface {
 }
 
 // StartFlowRoutine starts one of the goflow flow routine depending on the flow type
-func StartFlowRoutine(flowType common.FlowType, hostname string, port uint16, workers int, namespace string, flowInChan chan *common.Flow) (*FlowStateWrapper, error) {
+func StartFlowRoutine(flowType common.FlowType, hostname string, port uint16, workers int, namespace string, samplingRate uint64, flowInChan chan *common.Flow) (*FlowStateWrapper, error) {
 	var flowState FlowRunnableState
 
-	formatDriver := NewAggregatorFormatDriver(flowInChan, namespace)
+	formatDriver := NewAggregatorFormatDriver(flowInChan, namespace, samplingRate)
 	logger := GetLogrusLevel()
 
 	switch flowType {
@@ -16,15 +16,18 @@ import (
 
 // AggregatorFormatDriver is used as goflow formatter to forward flow data to aggregator/EP Forwarder
 type AggregatorFormatDriver struct {
-	namespace string
-	flowAggIn chan *common.Flow
+	namespace    string
+	samplingRate uint64
+	flowAggIn    chan *common.Flow
 }
 
 // NewAggregatorFormatDriver returns a new AggregatorFormatDriver
-func NewAggregatorFormatDriver(flowAgg chan *common.Flow, namespace string) *AggregatorFormatDriver {
+// A non-zero samplingRate overrides the sampling rate of every flow.
+func NewAggregatorFormatDriver(flowAgg chan *common.Flow, namespace string, samplingRate uint64) *AggregatorFormatDriver {
 	return &AggregatorFormatDriver{
-		namespace: namespace,
-		flowAggIn: flowAgg,
+		namespace:    namespace,
+		samplingRate: samplingRate,
+		flowAggIn:    flowAgg,
 	}
 }
 
@@ -44,6 +47,10 @@ func (d *AggregatorFormatDriver) Format(data interface{}) ([]byte, []byte, error
 	if !ok {
 		return nil, nil, fmt.Errorf("message is not flowpb.FlowMessage")
 	}
-	d.flowAggIn <- ConvertFlow(flow, d.namespace)
+	convertedFlow := ConvertFlow(flow, d.namespace)
+	if d.samplingRate != 0 {
+		convertedFlow.SamplingRate = d.samplingRate
+	}
+	d.flowAggIn <- convertedFlow
 	return nil, nil, nil
 }
@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2022-present Datadog, Inc.
+
+package goflowlib
+
+import (
+	"testing"
+
+	flowpb "github.com/netsampler/goflow2/pb"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/netflow/common"
+)
+
+func TestAggregatorFormatDriver_Format(t *testing.T) {
+	tests := []struct {
+		name                 string
+		samplingRate         uint64
+		expectedSamplingRate uint64
+	}{
+		{
+			name:                 "sampling rate reported by exporter",
+			samplingRate:         0,
+			expectedSamplingRate: 10,
+		},
+		{
+			name:                 "sampling rate overridden by listener config",
+			samplingRate:         1000,
+			expectedSamplingRate: 1000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flowIn := make(chan *common.Flow, 1)
+			driver := NewAggregatorFormatDriver(flowIn, "my-ns", tt.samplingRate)
+
+			_, _, err := driver.Format(&flowpb.FlowMessage{SamplingRate: 10})
+			assert.NoError(t, err)
+
+			flow := <-flowIn
+			assert.Equal(t, "my-ns", flow.Namespace)
+			assert.Equal(t, tt.expectedSamplingRate, flow.SamplingRate)
+		})
+	}
+}
@@ -17,6 +17,20 @@ type Endpoint struct {
 	Port string `json:"port"` // Port number can be zero/positive or `*` (ephemeral port)
 	Mac  string `json:"mac"`
 	Mask string `json:"mask"`
+	Geo  *Geo   `json:"geo,omitempty"`
+	AS   *AS    `json:"as,omitempty"`
+}
+
+// Geo contains geolocation details of an endpoint
+type Geo struct {
+	CountryISOCode string `json:"country_iso_code,omitempty"`
+	City           string `json:"city,omitempty"`
+}
+
+// AS contains autonomous system details of an endpoint
+type AS struct {
+	Number       uint32 `json:"number"`
+	Organization string `json:"organization,omitempty"`
 }
 
 // NextHop contains next hop details
@@ -116,23 +116,45 @@ var runCommand = execCommand
 // executable to fetch the actual secrets and returns them. Origin should be
 // the name of the configuration where the secret was referenced.
 func fetchSecret(secretsHandle []string, origin string) (map[string]string, error) {
-	payload := map[string]interface{}{
-		"version": PayloadVersion,
-		"secrets": secretsHandle,
-	}
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("could not serialize secrets IDs to fetch password: %s", err)
-	}
-	output, err := runCommand(string(jsonPayload))
+	res, err := fetchSecretValues(secretsHandle)
 	if err != nil {
 		return nil, err
 	}
 
+	now := time.Now()
+	for sec, value := range res {
+		// add it to the cache
+		secretCache[sec] = value
+		// keep track of place where a handle was found
+		secretOrigin[sec] = common.NewStringSet(origin)
+		secretRefreshed[sec] = now
+	}
+	return res, nil
+}
+
+// fetchSecretValues fetches the values of the given secrets, without caching them. Handles
+// with a known provider prefix are resolved by the built-in providers when enabled, the
+// other ones by the secret backend command.
+func fetchSecretValues(secretsHandle []string) (map[string]string, error) {
 	secrets := map[string]Secret{}
-	err = json.Unmarshal(output, &secrets)
-	if err != nil {
-		return nil, fmt.Errorf("could not unmarshal 'secret_backend_command' output: %s", err)
+	remaining := secretsHandle
+	if secretBackendBuiltin {
+		secrets, remaining = readSecretsWithProviders(secretsHandle)
+	}
+
+	if len(remaining) != 0 {
+		if secretBackendBuiltin && secretBackendCommand == "" {
+			return nil, fmt.Errorf("secret handle '%s' has no known provider prefix and no secret_backend_command is set", remaining[0])
+		}
+		output, err := execSecretBackend(remaining)
+		if err != nil {
+			return nil, err
+		}
+		for _, handle := range remaining {
+			if secret, ok := output[handle]; ok {
+				secrets[handle] = secret
+			}
+		}
 	}
 
 	res := map[string]string{}
@@ -148,12 +170,30 @@ func fetchSecret(secretsHandle []string, origin string) (map[string]string, erro
 		if v.Value == "" {
 			return nil, fmt.Errorf("decrypted secret for '%s' is empty", sec)
 		}
-
-		// add it to the cache
-		secretCache[sec] = v.Value
-		// keep track of place where a handle was found
-		secretOrigin[sec] = common.NewStringSet(origin)
 		res[sec] = v.Value
 	}
 	return res, nil
 }
+
+// execSecretBackend execs the secret backend command to fetch the given secrets
+func execSecretBackend(secretsHandle []string) (map[string]Secret, error) {
+	payload := map[string]interface{}{
+		"version": PayloadVersion,
+		"secrets": secretsHandle,
+	}
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("could not serialize secrets IDs to fetch password: %s", err)
+	}
+	output, err := runCommand(string(jsonPayload))
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := map[string]Secret{}
+	err = json.Unmarshal(output, &secrets)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal 'secret_backend_command' output: %s", err)
+	}
+	return secrets, nil
+}
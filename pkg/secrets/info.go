@@ -10,6 +10,7 @@ import (
 	"io"
 	"runtime"
 	"strings"
+	"time"
 )
 
 // SecretInfo export troubleshooting information about the decrypted secrets
@@ -20,6 +21,8 @@ type SecretInfo struct {
 	UnixOwner      string
 	UnixGroup      string
 	SecretsHandles map[string][]string
+	// SecretsRefreshed holds the last time each handle was fetched from the secret backend
+	SecretsRefreshed map[string]time.Time
 }
 
 // Print output a SecretInfo to a io.Writer
@@ -42,5 +45,8 @@ func (si *SecretInfo) Print(w io.Writer) {
 	fmt.Fprintf(w, "Secrets handle decrypted:\n")
 	for handle, origins := range si.SecretsHandles {
 		fmt.Fprintf(w, "- %s: from %s\n", handle, strings.Join(origins, ", "))
+		if refreshed, ok := si.SecretsRefreshed[handle]; ok {
+			fmt.Fprintf(w, "  last refreshed: %s\n", refreshed.Format(time.RFC3339))
+		}
 	}
 }
@@ -11,7 +11,9 @@
 package secrets
 
 import (
+	"context"
 	"fmt"
+	"time"
 )
 
 // SecretBackendOutputMaxSize defines max size of the JSON output from a secrets reader backend
@@ -20,11 +22,20 @@ var SecretBackendOutputMaxSize = 1024 * 1024
 // Init placeholder when compiled without the 'secrets' build tag
 func Init(command string, arguments []string, timeout int, maxSize int, groupExecPerm bool) {}
 
+// InitProviders placeholder when compiled without the 'secrets' build tag
+func InitProviders(backendType string, httpTokenFile string) {}
+
 // Decrypt encrypted secrets are not available on windows
 func Decrypt(data []byte, origin string) ([]byte, error) {
 	return data, nil
 }
 
+// RefreshHandler is notified of the origins where secrets whose value changed were found
+type RefreshHandler func(origins []string, apply func())
+
+// StartRefreshRoutine placeholder when compiled without the 'secrets' build tag
+func StartRefreshRoutine(ctx context.Context, interval time.Duration, handler RefreshHandler) {}
+
 // GetDebugInfo exposes debug informations about secrets to be included in a flare
 func GetDebugInfo() (*SecretInfo, error) {
 	return nil, fmt.Errorf("Secret feature is not available in this version of the agent")
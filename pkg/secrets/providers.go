@@ -0,0 +1,226 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build secrets
+// +build secrets
+
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// BuiltinBackendType is the 'secret_backend_type' resolving the secret handles
+	// inside the agent instead of executing 'secret_backend_command'
+	BuiltinBackendType = "builtin"
+
+	providerPrefixSeparator = "@"
+	filePrefix              = "file"
+	envPrefix               = "env"
+	k8sSecretPrefix         = "k8s_secret"
+	httpPrefix              = "http"
+
+	maxSecretFileSize = 8192
+	// default key of the value in the JSON object returned by an HTTP endpoint
+	httpDefaultValueKey = "value"
+)
+
+// builtinProviders maps the prefix of a secret handle to the provider resolving it
+var builtinProviders = map[string]func(id string) Secret{
+	filePrefix:      ReadSecretFile,
+	envPrefix:       readSecretEnv,
+	k8sSecretPrefix: readKubernetesSecretInCluster,
+	httpPrefix:      readSecretHTTP,
+}
+
+// splitProviderPrefix returns the provider and the ID of a handle of the form
+// "<provider>@<id>", ok is false if the prefix is not a known provider
+func splitProviderPrefix(handle string) (provider string, id string, ok bool) {
+	parts := strings.SplitN(handle, providerPrefixSeparator, 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	if _, ok := builtinProviders[parts[0]]; !ok {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// readSecretsWithProviders resolves the handles using the built-in providers and returns
+// the handles without a known provider prefix.
+func readSecretsWithProviders(secretsHandle []string) (map[string]Secret, []string) {
+	res := map[string]Secret{}
+	var remaining []string
+	for _, handle := range secretsHandle {
+		provider, id, ok := splitProviderPrefix(handle)
+		if !ok {
+			remaining = append(remaining, handle)
+			continue
+		}
+		res[handle] = builtinProviders[provider](id)
+	}
+	return res, remaining
+}
+
+// ReadSecretFile reads the secret stored in the file at path
+func ReadSecretFile(path string) Secret {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Secret{Value: "", ErrorMsg: "secret does not exist"}
+		}
+		return Secret{Value: "", ErrorMsg: err.Error()}
+	}
+
+	// In kubernetes when kubelet mounts the secret|configmap key as a file, it
+	// is always a symlink to allow “atomic update“.
+	if fi.Mode()&os.ModeSymlink != 0 {
+		// Check that the symlink is in the same dir.  This is not a security measure, but just a
+		// sanity check.
+		target, err := os.Readlink(path)
+		if err != nil {
+			return Secret{Value: "", ErrorMsg: fmt.Sprintf("failed to read symlink target: %v", err)}
+		}
+
+		dir := filepath.Dir(path)
+		if !filepath.IsAbs(target) {
+			target, err = filepath.Abs(filepath.Join(dir, target))
+			if err != nil {
+				return Secret{Value: "", ErrorMsg: fmt.Sprintf("failed to resolve symlink absolute path: %v", err)}
+			}
+		}
+
+		targetDir := filepath.Dir(target)
+
+		dirAbs, err := filepath.Abs(dir)
+		if err != nil {
+			return Secret{Value: "", ErrorMsg: fmt.Sprintf("failed to resolve absolute path of directory: %v", err)}
+		}
+
+		if !strings.HasPrefix(targetDir+"/", dirAbs+"/") {
+			return Secret{Value: "", ErrorMsg: fmt.Sprintf("not following symlink %q outside of %q", target, dir)}
+		}
+	}
+	fi, err = os.Stat(path)
+	if err != nil {
+		return Secret{Value: "", ErrorMsg: err.Error()}
+	}
+
+	if fi.Size() > maxSecretFileSize {
+		return Secret{Value: "", ErrorMsg: "secret exceeds max allowed size"}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return Secret{Value: "", ErrorMsg: err.Error()}
+	}
+	defer file.Close()
+
+	bytes, err := ioutil.ReadAll(file)
+	if err != nil {
+		return Secret{Value: "", ErrorMsg: err.Error()}
+	}
+
+	return Secret{Value: string(bytes), ErrorMsg: ""}
+}
+
+// readSecretEnv reads the secret from the environment variable name
+func readSecretEnv(name string) Secret {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return Secret{ErrorMsg: fmt.Sprintf("environment variable %s is not set", name)}
+	}
+	return Secret{Value: value}
+}
+
+// readSecretHTTP fetches a JSON object from rawURL and returns the string found at the
+// dot-separated path given as the URL fragment, "value" by default.
+func readSecretHTTP(rawURL string) Secret {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Secret{ErrorMsg: fmt.Sprintf("invalid URL: %s", err)}
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return Secret{ErrorMsg: fmt.Sprintf("unsupported URL scheme %q", u.Scheme)}
+	}
+	key := u.Fragment
+	if key == "" {
+		key = httpDefaultValueKey
+	}
+	u.Fragment = ""
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return Secret{ErrorMsg: err.Error()}
+	}
+	req.Header.Set("Accept", "application/json")
+	if secretBackendHTTPTokenFile != "" {
+		// the token is only ever sent to an endpoint running on the same host
+		if !isLoopbackHost(u.Hostname()) {
+			return Secret{ErrorMsg: fmt.Sprintf("refusing to send the local token to non-local host %q", u.Hostname())}
+		}
+		token, err := ioutil.ReadFile(secretBackendHTTPTokenFile)
+		if err != nil {
+			return Secret{ErrorMsg: fmt.Sprintf("unable to read the local token: %s", err)}
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	client := &http.Client{Timeout: time.Duration(secretBackendTimeout) * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Secret{ErrorMsg: err.Error()}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Secret{ErrorMsg: fmt.Sprintf("unexpected status code %d", resp.StatusCode)}
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, int64(SecretBackendOutputMaxSize)+1))
+	if err != nil {
+		return Secret{ErrorMsg: err.Error()}
+	}
+	if len(body) > SecretBackendOutputMaxSize {
+		return Secret{ErrorMsg: fmt.Sprintf("response was too long: exceeded %d bytes", SecretBackendOutputMaxSize)}
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return Secret{ErrorMsg: fmt.Sprintf("could not unmarshal response: %s", err)}
+	}
+	for _, part := range strings.Split(key, ".") {
+		obj, ok := data.(map[string]interface{})
+		if !ok {
+			return Secret{ErrorMsg: fmt.Sprintf("key %s not found in response", key)}
+		}
+		if data, ok = obj[part]; !ok {
+			return Secret{ErrorMsg: fmt.Sprintf("key %s not found in response", key)}
+		}
+	}
+	value, ok := data.(string)
+	if !ok {
+		return Secret{ErrorMsg: fmt.Sprintf("value of key %s is not a string", key)}
+	}
+	return Secret{Value: value}
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build secrets && kubeapiserver
+// +build secrets,kubeapiserver
+
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// newKubeClient returns the client used by the 'k8s_secret' provider, for testing purpose
+var newKubeClient = func(timeout time.Duration) (kubernetes.Interface, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	config.Timeout = timeout
+	return kubernetes.NewForConfig(config)
+}
+
+// ReadKubernetesSecret reads the secret at path, of the form "namespace/name/key", with the given client.
+// It is shared with the secret helper, which builds its own client.
+func ReadKubernetesSecret(kubeClient kubernetes.Interface, path string) Secret {
+	splitName := strings.Split(path, "/")
+
+	if len(splitName) != 3 {
+		return Secret{ErrorMsg: fmt.Sprintf("invalid format. Use: \"namespace/name/key\"")}
+	}
+
+	namespace, name, key := splitName[0], splitName[1], splitName[2]
+
+	secret, err := kubeClient.CoreV1().Secrets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return Secret{ErrorMsg: err.Error()}
+	}
+
+	value, ok := secret.Data[key]
+	if !ok {
+		return Secret{ErrorMsg: fmt.Sprintf("key %s not found in secret %s/%s", key, namespace, name)}
+	}
+
+	return Secret{Value: string(value)}
+}
+
+func readKubernetesSecretInCluster(path string) Secret {
+	kubeClient, err := newKubeClient(time.Duration(secretBackendTimeout) * time.Second)
+	if err != nil {
+		return Secret{ErrorMsg: fmt.Sprintf("unable to create the kubernetes client: %s", err)}
+	}
+	return ReadKubernetesSecret(kubeClient, path)
+}
@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build secrets && kubeapiserver
+// +build secrets,kubeapiserver
+
+package secrets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReadKubernetesSecretInCluster(t *testing.T) {
+	defer func(f func(time.Duration) (kubernetes.Interface, error)) { newKubeClient = f }(newKubeClient)
+	newKubeClient = func(time.Duration) (kubernetes.Interface, error) {
+		return fake.NewSimpleClientset(&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "default"},
+			Data:       map[string][]byte{"password": []byte("k8s_password")},
+		}), nil
+	}
+
+	res, remaining := readSecretsWithProviders([]string{
+		"k8s_secret@default/creds/password",
+		"k8s_secret@default/creds/missing",
+		"k8s_secret@default/creds",
+	})
+	assert.Empty(t, remaining)
+	assert.Equal(t, Secret{Value: "k8s_password"}, res["k8s_secret@default/creds/password"])
+	assert.NotEmpty(t, res["k8s_secret@default/creds/missing"].ErrorMsg)
+	assert.NotEmpty(t, res["k8s_secret@default/creds"].ErrorMsg)
+}
@@ -0,0 +1,16 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build secrets && !kubeapiserver
+// +build secrets,!kubeapiserver
+
+package secrets
+
+import "fmt"
+
+// readKubernetesSecretInCluster is not available without the Kubernetes client
+func readKubernetesSecretInCluster(path string) Secret {
+	return Secret{ErrorMsg: fmt.Sprintf("the %s provider is not supported by this build", k8sSecretPrefix)}
+}
@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build secrets
+// +build secrets
+
+package secrets
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/util/common"
+)
+
+func TestReadSecretsWithProviders(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(secretFile, []byte("file_password"), 0600))
+	t.Setenv("DD_TEST_SECRET", "env_password")
+
+	res, remaining := readSecretsWithProviders([]string{
+		"file@" + secretFile,
+		"env@DD_TEST_SECRET",
+		"env@DD_TEST_UNSET_SECRET",
+		"unknown@some_id",
+		"some_handle",
+	})
+	assert.Equal(t, []string{"unknown@some_id", "some_handle"}, remaining)
+	assert.Equal(t, Secret{Value: "file_password"}, res["file@"+secretFile])
+	assert.Equal(t, Secret{Value: "env_password"}, res["env@DD_TEST_SECRET"])
+	assert.NotEmpty(t, res["env@DD_TEST_UNSET_SECRET"].ErrorMsg)
+}
+
+func TestReadSecretHTTP(t *testing.T) {
+	defer func(maxSize int) {
+		secretBackendHTTPTokenFile = ""
+		SecretBackendOutputMaxSize = maxSize
+	}(SecretBackendOutputMaxSize)
+	SecretBackendOutputMaxSize = 1024
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"value": "default_password", "db": {"password": "db_password", "port": 5432}}`)
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("s3cr3t\n"), 0600))
+
+	assert.Equal(t, "unexpected status code 401", readSecretHTTP(server.URL).ErrorMsg)
+
+	secretBackendHTTPTokenFile = tokenFile
+	assert.Equal(t, Secret{Value: "default_password"}, readSecretHTTP(server.URL))
+	assert.Equal(t, Secret{Value: "db_password"}, readSecretHTTP(server.URL+"#db.password"))
+	assert.Equal(t, "value of key db.port is not a string", readSecretHTTP(server.URL+"#db.port").ErrorMsg)
+	assert.Equal(t, "key db.user not found in response", readSecretHTTP(server.URL+"#db.user").ErrorMsg)
+	assert.Equal(t, `refusing to send the local token to non-local host "example.com"`, readSecretHTTP("https://example.com/secret").ErrorMsg)
+	assert.Equal(t, `unsupported URL scheme "ftp"`, readSecretHTTP("ftp://127.0.0.1/secret").ErrorMsg)
+}
+
+func TestDecryptWithProviders(t *testing.T) {
+	defer func() {
+		secretCache = map[string]string{}
+		secretOrigin = map[string]common.StringSet{}
+		secretRefreshed = map[string]time.Time{}
+		secretBackendCommand = ""
+		runCommand = execCommand
+		InitProviders("", "")
+	}()
+	secretCache = map[string]string{}
+	secretOrigin = map[string]common.StringSet{}
+	secretRefreshed = map[string]time.Time{}
+	t.Setenv("DD_TEST_SECRET", "env_password")
+
+	conf := []byte("instances:\n- password: ENC[env@DD_TEST_SECRET]\n  user: ENC[user]\n")
+
+	InitProviders(BuiltinBackendType, "")
+	_, err := Decrypt(conf, "test")
+	assert.EqualError(t, err, "secret handle 'user' has no known provider prefix and no secret_backend_command is set")
+
+	secretBackendCommand = "some_command"
+	runCommand = func(payload string) ([]byte, error) {
+		assert.Equal(t, `{"secrets":["user"],"version":"1.0"}`, payload)
+		return []byte(`{"user": {"value": "some_user"}}`), nil
+	}
+	decrypted, err := Decrypt(conf, "test")
+	require.NoError(t, err)
+	assert.Equal(t, "instances:\n- password: env_password\n  user: some_user\n", string(decrypted))
+
+	secretBackendCommand = ""
+	info, err := GetDebugInfo()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test"}, info.SecretsHandles["env@DD_TEST_SECRET"])
+	assert.Contains(t, info.ExecutablePath, "built-in providers")
+}
@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build secrets
+// +build secrets
+
+package secrets
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/common"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// RefreshHandler is notified of the origins where secrets whose value changed were found.
+// It is called before the new values are stored so that the configurations decrypted with
+// the previous values can be released, then it must call apply to store the new values
+// before decrypting the configurations again.
+type RefreshHandler func(origins []string, apply func())
+
+// StartRefreshRoutine fetches the value of the known secrets again every interval until ctx
+// is done, and calls handler when some of them changed.
+func StartRefreshRoutine(ctx context.Context, interval time.Duration, handler RefreshHandler) {
+	if !isEnabled() || interval <= 0 {
+		return
+	}
+	log.Infof("Refreshing secrets every %s", interval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := refresh(handler); err != nil {
+					log.Errorf("Unable to refresh secrets: %s", err)
+				}
+			}
+		}
+	}()
+}
+
+// refresh fetches the value of all the cached secrets from the secret backend, and calls
+// handler with the origins of the secrets whose value changed.
+func refresh(handler RefreshHandler) error {
+	secretMu.Lock()
+	handles := make([]string, 0, len(secretCache))
+	for handle := range secretCache {
+		handles = append(handles, handle)
+	}
+	secretMu.Unlock()
+	if len(handles) == 0 {
+		return nil
+	}
+	sort.Strings(handles)
+
+	// the secret backend is called without holding the lock, as it can take a while
+	values, err := fetchSecretValues(handles)
+	if err != nil {
+		return err
+	}
+
+	secretMu.Lock()
+	changed := common.NewStringSet()
+	for _, handle := range handles {
+		if secretCache[handle] == values[handle] {
+			continue
+		}
+		log.Infof("Secret '%s' was changed by the secret backend", handle)
+		for _, origin := range secretOrigin[handle].GetAll() {
+			changed.Add(origin)
+		}
+	}
+	secretMu.Unlock()
+
+	var once sync.Once
+	apply := func() {
+		once.Do(func() {
+			secretMu.Lock()
+			defer secretMu.Unlock()
+			now := time.Now()
+			for handle, value := range values {
+				secretCache[handle] = value
+				secretRefreshed[handle] = now
+			}
+		})
+	}
+	if len(changed) > 0 && handler != nil {
+		origins := changed.GetAll()
+		sort.Strings(origins)
+		handler(origins, apply)
+	}
+	// in case the handler did not store the new values
+	apply()
+	return nil
+}
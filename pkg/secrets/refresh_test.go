@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build secrets
+// +build secrets
+
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/util/common"
+)
+
+func TestRefresh(t *testing.T) {
+	defer func() {
+		secretCache = map[string]string{}
+		secretOrigin = map[string]common.StringSet{}
+		secretRefreshed = map[string]time.Time{}
+		secretBackendCommand = ""
+		runCommand = execCommand
+	}()
+	secretCache = map[string]string{}
+	secretOrigin = map[string]common.StringSet{}
+	secretRefreshed = map[string]time.Time{}
+	secretBackendCommand = "some_command"
+
+	values := map[string]string{"pass1": "password1", "pass2": "password2", "pass3": "password3"}
+	backendErr := error(nil)
+	runCommand = func(string) ([]byte, error) {
+		res := []byte("{")
+		for handle, value := range values {
+			if len(res) > 1 {
+				res = append(res, ',')
+			}
+			res = append(res, []byte(fmt.Sprintf(`"%s":{"value":"%s"}`, handle, value))...)
+		}
+		return append(res, '}'), backendErr
+	}
+
+	_, err := Decrypt(testConf, "test")
+	require.NoError(t, err)
+	_, err = Decrypt(testConf2, "test2")
+	require.NoError(t, err)
+	info, err := GetDebugInfo()
+	require.NoError(t, err)
+	firstRefresh := info.SecretsRefreshed["pass1"]
+	assert.False(t, firstRefresh.IsZero())
+	assert.Len(t, info.SecretsRefreshed, 3)
+
+	t.Run("unchanged", func(t *testing.T) {
+		called := false
+		require.NoError(t, refresh(func([]string, func()) { called = true }))
+		assert.False(t, called)
+		assert.Equal(t, "password1", secretCache["pass1"])
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		values["pass3"] = "rotated"
+		var origins []string
+		require.NoError(t, refresh(func(o []string, apply func()) {
+			origins = o
+			// the configurations can still be decrypted with the previous values
+			decrypted, err := Decrypt(testConf2, "test2")
+			require.NoError(t, err)
+			assert.Contains(t, string(decrypted), "password3")
+
+			apply()
+			decrypted, err = Decrypt(testConf2, "test2")
+			require.NoError(t, err)
+			assert.Contains(t, string(decrypted), "rotated")
+		}))
+		assert.Equal(t, []string{"test2"}, origins)
+		assert.Equal(t, "rotated", secretCache["pass3"])
+
+		info, err := GetDebugInfo()
+		require.NoError(t, err)
+		assert.False(t, info.SecretsRefreshed["pass1"].Before(firstRefresh))
+
+		var b bytes.Buffer
+		info.Print(&b)
+		assert.Contains(t, b.String(), "last refreshed: ")
+	})
+
+	t.Run("apply-not-called", func(t *testing.T) {
+		values["pass2"] = "rotated"
+		var origins []string
+		require.NoError(t, refresh(func(o []string, apply func()) { origins = o }))
+		assert.Equal(t, []string{"test", "test2"}, origins)
+		assert.Equal(t, "rotated", secretCache["pass2"])
+	})
+
+	t.Run("backend-error", func(t *testing.T) {
+		values["pass1"] = "rotated"
+		backendErr = fmt.Errorf("some error")
+		assert.Error(t, refresh(func([]string, func()) { t.Fatal("unexpected call") }))
+		assert.Equal(t, "password1", secretCache["pass1"])
+	})
+}
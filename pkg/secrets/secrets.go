@@ -11,6 +11,8 @@ package secrets
 import (
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	yaml "gopkg.in/yaml.v2"
 
@@ -22,11 +24,19 @@ var (
 	secretCache map[string]string
 	// list of handles and where they were found
 	secretOrigin map[string]common.StringSet
+	// last time each handle was fetched from the secret backend
+	secretRefreshed map[string]time.Time
+	// secretMu protects secretCache, secretOrigin and secretRefreshed, which are updated
+	// by the refresh routine
+	secretMu sync.Mutex
 
 	secretBackendCommand               string
 	secretBackendArguments             []string
 	secretBackendTimeout               = 5
 	secretBackendCommandAllowGroupExec bool
+	// secretBackendBuiltin enables the providers resolving prefixed handles inside the agent
+	secretBackendBuiltin       bool
+	secretBackendHTTPTokenFile string
 
 	// SecretBackendOutputMaxSize defines max size of the JSON output from a secrets reader backend
 	SecretBackendOutputMaxSize = 1024 * 1024
@@ -35,6 +45,7 @@ var (
 func init() {
 	secretCache = make(map[string]string)
 	secretOrigin = make(map[string]common.StringSet)
+	secretRefreshed = make(map[string]time.Time)
 }
 
 // Init initializes the command and other options of the secrets package. Since
@@ -51,6 +62,18 @@ func Init(command string, arguments []string, timeout int, maxSize int, groupExe
 	}
 }
 
+// InitProviders configures the built-in providers, used instead of the secret backend
+// command for handles of the form "<provider>@<id>" when backendType is "builtin".
+func InitProviders(backendType string, httpTokenFile string) {
+	secretBackendBuiltin = backendType == BuiltinBackendType
+	secretBackendHTTPTokenFile = httpTokenFile
+}
+
+// isEnabled returns whether secrets can be resolved
+func isEnabled() bool {
+	return secretBackendCommand != "" || secretBackendBuiltin
+}
+
 type walkerCallback func(string) (string, error)
 
 func walkSlice(data []interface{}, callback walkerCallback) error {
@@ -130,10 +153,13 @@ var secretFetcher = fetchSecret
 // Decrypt replaces all encrypted secrets in data by executing
 // "secret_backend_command" once if all secrets aren't present in the cache.
 func Decrypt(data []byte, origin string) ([]byte, error) {
-	if data == nil || secretBackendCommand == "" {
+	if data == nil || !isEnabled() {
 		return data, nil
 	}
 
+	secretMu.Lock()
+	defer secretMu.Unlock()
+
 	var config interface{}
 	err := yaml.Unmarshal(data, &config)
 	if err != nil {
@@ -200,15 +226,26 @@ func Decrypt(data []byte, origin string) ([]byte, error) {
 
 // GetDebugInfo exposes debug informations about secrets to be included in a flare
 func GetDebugInfo() (*SecretInfo, error) {
-	if secretBackendCommand == "" {
+	if !isEnabled() {
 		return nil, fmt.Errorf("No secret_backend_command set: secrets feature is not enabled")
 	}
 	info := &SecretInfo{ExecutablePath: secretBackendCommand}
-	info.populateRights()
+	if secretBackendCommand != "" {
+		info.populateRights()
+	} else {
+		info.ExecutablePath = "none, secrets are resolved by the built-in providers"
+		info.Rights = "OK, no executable is used"
+	}
 
+	secretMu.Lock()
+	defer secretMu.Unlock()
 	info.SecretsHandles = map[string][]string{}
 	for handle, originNames := range secretOrigin {
 		info.SecretsHandles[handle] = originNames.GetAll()
 	}
+	info.SecretsRefreshed = map[string]time.Time{}
+	for handle, refreshed := range secretRefreshed {
+		info.SecretsRefreshed[handle] = refreshed
+	}
 	return info, nil
 }
@@ -26,6 +26,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/serializer/internal/stream"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
 	"github.com/DataDog/datadog-agent/pkg/tagset"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 )
 
 func TestPopulateDeviceField(t *testing.T) {
@@ -308,7 +309,7 @@ func makeSeries(numItems, numPoints int) *IterableSeries {
 func TestMarshalSplitCompress(t *testing.T) {
 	series := makeSeries(10000, 50)
 
-	payloads, err := series.MarshalSplitCompress(marshaler.DefaultBufferContext())
+	payloads, err := series.MarshalSplitCompress(marshaler.DefaultBufferContext(), compression.DefaultCodec)
 	require.NoError(t, err)
 	// check that we got multiple payloads, so splitting occurred
 	require.Greater(t, len(payloads), 1)
@@ -331,7 +332,7 @@ func TestMarshalSplitCompressPointsLimit(t *testing.T) {
 	// ten series, each with 50 points, so two should fit in each payload
 	series := makeSeries(10, 50)
 
-	payloads, err := series.MarshalSplitCompress(marshaler.DefaultBufferContext())
+	payloads, err := series.MarshalSplitCompress(marshaler.DefaultBufferContext(), compression.DefaultCodec)
 	require.NoError(t, err)
 	require.Equal(t, 5, len(payloads))
 }
@@ -343,7 +344,7 @@ func TestMarshalSplitCompressPointsLimitTooBig(t *testing.T) {
 	mockConfig.Set("serializer_max_series_points_per_payload", 1)
 
 	series := makeSeries(1, 2)
-	payloads, err := series.MarshalSplitCompress(marshaler.DefaultBufferContext())
+	payloads, err := series.MarshalSplitCompress(marshaler.DefaultBufferContext(), compression.DefaultCodec)
 	require.NoError(t, err)
 	require.Len(t, payloads, 0)
 }
@@ -378,7 +379,7 @@ func TestPayloadsSeries(t *testing.T) {
 	originalLength := len(testSeries)
 	builder := stream.NewJSONPayloadBuilder(true)
 	iterableSeries := CreateIterableSeries(CreateSerieSource(testSeries))
-	payloads, err := builder.BuildWithOnErrItemTooBigPolicy(iterableSeries, stream.DropItemOnErrItemTooBig)
+	payloads, err := builder.BuildWithOnErrItemTooBigPolicy(iterableSeries, stream.DropItemOnErrItemTooBig, compression.DefaultCodec)
 	require.Nil(t, err)
 	var splitSeries = []Series{}
 	for _, compressedPayload := range payloads {
@@ -426,7 +427,7 @@ func BenchmarkPayloadsSeries(b *testing.B) {
 		// always record the result of Payloads to prevent
 		// the compiler eliminating the function call.
 		iterableSeries := CreateIterableSeries(CreateSerieSource(testSeries))
-		r, _ = builder.BuildWithOnErrItemTooBigPolicy(iterableSeries, stream.DropItemOnErrItemTooBig)
+		r, _ = builder.BuildWithOnErrItemTooBigPolicy(iterableSeries, stream.DropItemOnErrItemTooBig, compression.DefaultCodec)
 	}
 	// ensure we actually had to split
 	if len(r) != 13 {
@@ -20,6 +20,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
 	"github.com/DataDog/datadog-agent/pkg/tagset"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -88,7 +89,7 @@ func TestSketchSeriesMarshalSplitCompressEmpty(t *testing.T) {
 
 	sl := SketchSeriesList{SketchesSource: metrics.NewSketchesSourceTest()}
 	payload, _ := sl.Marshal()
-	payloads, err := sl.MarshalSplitCompress(marshaler.DefaultBufferContext())
+	payloads, err := sl.MarshalSplitCompress(marshaler.DefaultBufferContext(), compression.DefaultCodec)
 
 	assert.Nil(t, err)
 
@@ -122,7 +123,7 @@ func TestSketchSeriesMarshalSplitCompressItemTooBigIsDropped(t *testing.T) {
 	})
 
 	serializer := SketchSeriesList{SketchesSource: sl}
-	payloads, err := serializer.MarshalSplitCompress(marshaler.DefaultBufferContext())
+	payloads, err := serializer.MarshalSplitCompress(marshaler.DefaultBufferContext(), compression.DefaultCodec)
 
 	assert.Nil(t, err)
 
@@ -153,7 +154,7 @@ func TestSketchSeriesMarshalSplitCompress(t *testing.T) {
 	payload, _ := serializer1.Marshal()
 	sl.Reset()
 	serializer2 := SketchSeriesList{SketchesSource: sl}
-	payloads, err := serializer2.MarshalSplitCompress(marshaler.DefaultBufferContext())
+	payloads, err := serializer2.MarshalSplitCompress(marshaler.DefaultBufferContext(), compression.DefaultCodec)
 	require.NoError(t, err)
 
 	firstPayload := payloads[0]
@@ -203,7 +204,7 @@ func TestSketchSeriesMarshalSplitCompressSplit(t *testing.T) {
 	}
 
 	serializer := SketchSeriesList{SketchesSource: sl}
-	payloads, err := serializer.MarshalSplitCompress(marshaler.DefaultBufferContext())
+	payloads, err := serializer.MarshalSplitCompress(marshaler.DefaultBufferContext(), compression.DefaultCodec)
 	assert.Nil(t, err)
 
 	recoveredSketches := []gogen.SketchPayload{}
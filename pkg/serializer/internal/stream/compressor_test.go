@@ -20,6 +20,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 )
 
 var (
@@ -58,7 +59,7 @@ func TestCompressorSimple(t *testing.T) {
 	c, err := NewCompressor(
 		&bytes.Buffer{}, &bytes.Buffer{},
 		maxPayloadSize, maxUncompressedSize,
-		[]byte("{["), []byte("]}"), []byte(","))
+		[]byte("{["), []byte("]}"), []byte(","), compression.DefaultCodec)
 	require.NoError(t, err)
 
 	for i := 0; i < 5; i++ {
@@ -70,6 +71,48 @@ func TestCompressorSimple(t *testing.T) {
 	require.Equal(t, "{[A,A,A,A,A]}", payloadToString(p))
 }
 
+func TestCompressorZstd(t *testing.T) {
+	codec := compression.NewZstdCodec(compression.ZstdDefaultLevel)
+	output := &bytes.Buffer{}
+	// a small payload size forces the compressor to pack the items several times
+	c, err := NewCompressor(
+		&bytes.Buffer{}, output,
+		200, 1000,
+		[]byte("{["), []byte("]}"), []byte(","), codec)
+	require.NoError(t, err)
+
+	expected := "{["
+	for i := 0; i < 20; i++ {
+		item := fmt.Sprintf("item%d", i)
+		require.NoError(t, c.AddItem([]byte(item)))
+		if i > 0 {
+			expected += ","
+		}
+		expected += item
+	}
+	expected += "]}"
+
+	p, err := c.Close()
+	require.NoError(t, err)
+	decompressed, err := codec.Decompress(p)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(decompressed))
+
+	// the stream encoder released by the first compressor is reused
+	output.Reset()
+	c, err = NewCompressor(
+		&bytes.Buffer{}, output,
+		200, 1000,
+		[]byte("{["), []byte("]}"), []byte(","), codec)
+	require.NoError(t, err)
+	require.NoError(t, c.AddItem([]byte("A")))
+	p, err = c.Close()
+	require.NoError(t, err)
+	decompressed, err = codec.Decompress(p)
+	require.NoError(t, err)
+	require.Equal(t, "{[A]}", string(decompressed))
+}
+
 func TestOnePayloadSimple(t *testing.T) {
 	m := &marshaler.DummyMarshaller{
 		Items:  []string{"A", "B", "C"},
@@ -145,7 +188,8 @@ func TestBuildWithOnErrItemTooBigPolicyMetadata(t *testing.T) {
 	builder := NewJSONPayloadBuilder(false)
 	payloads, err := builder.BuildWithOnErrItemTooBigPolicy(
 		marshaler,
-		DropItemOnErrItemTooBig)
+		DropItemOnErrItemTooBig,
+		compression.DefaultCodec)
 	r := require.New(t)
 	r.NoError(err)
 
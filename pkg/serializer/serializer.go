@@ -84,6 +84,33 @@ func initExtraHeaders() {
 	}
 }
 
+// headersWithEncoding returns the extra headers to send along payloads compressed with the given codec.
+// The pre-computed headers are returned as is when the codec is the default one.
+func headersWithEncoding(extraHeadersWithCompression http.Header, codec compression.Codec) http.Header {
+	if codec.ContentEncoding() == extraHeadersWithCompression.Get("Content-Encoding") {
+		return extraHeadersWithCompression
+	}
+	headers := extraHeadersWithCompression.Clone()
+	headers.Set("Content-Encoding", codec.ContentEncoding())
+	return headers
+}
+
+// newCompressionCodec returns the codec configured with `serializer_compressor_kind`.
+// Each transaction falls back to the default (zlib) codec once its intake rejects the configured one.
+func newCompressionCodec() compression.Codec {
+	kind := config.Datadog.GetString("serializer_compressor_kind")
+	codec, err := compression.NewCodec(kind, config.Datadog.GetInt("serializer_zstd_compressor_level"))
+	if err != nil {
+		log.Warnf("Invalid serializer_compressor_kind %q, using %q instead", kind, compression.ZlibKind)
+	}
+	return codec
+}
+
+// payloadCodecProvider is implemented by the forwarders resolving the codec of each domain
+type payloadCodecProvider interface {
+	PayloadCodec() compression.Codec
+}
+
 // MetricSerializer represents the interface of method needed by the aggregator to serialize its data
 type MetricSerializer interface {
 	SendEvents(e metrics.Events) error
@@ -110,6 +137,9 @@ type Serializer struct {
 
 	seriesJSONPayloadBuilder *stream.JSONPayloadBuilder
 
+	// compressionCodec is the codec compressing the payloads when the forwarder does not select it
+	compressionCodec compression.Codec
+
 	// Those variables allow users to blacklist any kind of payload
 	// from being sent by the agent. This was introduced for
 	// environment where, for example, events or serviceChecks
@@ -134,6 +164,7 @@ func NewSerializer(forwarder forwarder.Forwarder, orchestratorForwarder, contlcy
 		orchestratorForwarder:         orchestratorForwarder,
 		contlcycleForwarder:           contlcycleForwarder,
 		seriesJSONPayloadBuilder:      stream.NewJSONPayloadBuilder(config.Datadog.GetBool("enable_json_stream_shared_compressor_buffers")),
+		compressionCodec:              newCompressionCodec(),
 		enableEvents:                  config.Datadog.GetBool("enable_payloads.events"),
 		enableSeries:                  config.Datadog.GetBool("enable_payloads.series"),
 		enableServiceChecks:           config.Datadog.GetBool("enable_payloads.service_checks"),
@@ -164,6 +195,14 @@ func NewSerializer(forwarder forwarder.Forwarder, orchestratorForwarder, contlcy
 	return s
 }
 
+// codec returns the codec compressing the payloads, the forwarder selects it from the codecs of its domains.
+func (s Serializer) codec() compression.Codec {
+	if provider, ok := s.Forwarder.(payloadCodecProvider); ok {
+		return provider.PayloadCodec()
+	}
+	return s.compressionCodec
+}
+
 func (s Serializer) serializePayload(
 	jsonMarshaler marshaler.JSONMarshaler,
 	protoMarshaler marshaler.ProtoMarshaler,
@@ -177,28 +216,30 @@ func (s Serializer) serializePayload(
 
 func (s Serializer) serializePayloadJSON(payload marshaler.JSONMarshaler, compress bool) (transaction.BytesPayloads, http.Header, error) {
 	var extraHeaders http.Header
+	codec := s.codec()
 
 	if compress {
-		extraHeaders = jsonExtraHeadersWithCompression
+		extraHeaders = headersWithEncoding(jsonExtraHeadersWithCompression, codec)
 	} else {
 		extraHeaders = jsonExtraHeaders
 	}
 
-	return s.serializePayloadInternal(payload, compress, extraHeaders, split.JSONMarshalFct)
+	return s.serializePayloadInternal(payload, compress, extraHeaders, split.JSONMarshalFct, codec)
 }
 
 func (s Serializer) serializePayloadProto(payload marshaler.ProtoMarshaler, compress bool) (transaction.BytesPayloads, http.Header, error) {
 	var extraHeaders http.Header
+	codec := s.codec()
 	if compress {
-		extraHeaders = protobufExtraHeadersWithCompression
+		extraHeaders = headersWithEncoding(protobufExtraHeadersWithCompression, codec)
 	} else {
 		extraHeaders = protobufExtraHeaders
 	}
-	return s.serializePayloadInternal(payload, compress, extraHeaders, split.ProtoMarshalFct)
+	return s.serializePayloadInternal(payload, compress, extraHeaders, split.ProtoMarshalFct, codec)
 }
 
-func (s Serializer) serializePayloadInternal(payload marshaler.AbstractMarshaler, compress bool, extraHeaders http.Header, marshalFct split.MarshalFct) (transaction.BytesPayloads, http.Header, error) {
-	payloads, err := split.Payloads(payload, compress, marshalFct)
+func (s Serializer) serializePayloadInternal(payload marshaler.AbstractMarshaler, compress bool, extraHeaders http.Header, marshalFct split.MarshalFct, codec compression.Codec) (transaction.BytesPayloads, http.Header, error) {
+	payloads, err := split.Payloads(payload, compress, marshalFct, codec)
 
 	if err != nil {
 		return nil, nil, fmt.Errorf("could not split payload into small enough chunks: %s", err)
@@ -209,13 +250,13 @@ func (s Serializer) serializePayloadInternal(payload marshaler.AbstractMarshaler
 
 func (s Serializer) serializeStreamablePayload(payload marshaler.StreamJSONMarshaler, policy stream.OnErrItemTooBigPolicy) (transaction.BytesPayloads, http.Header, error) {
 	adapter := marshaler.NewIterableStreamJSONMarshalerAdapter(payload)
-	payloads, err := s.seriesJSONPayloadBuilder.BuildWithOnErrItemTooBigPolicy(adapter, policy)
-	return payloads, jsonExtraHeadersWithCompression, err
+	return s.serializeIterableStreamablePayload(adapter, policy)
 }
 
 func (s Serializer) serializeIterableStreamablePayload(payload marshaler.IterableStreamJSONMarshaler, policy stream.OnErrItemTooBigPolicy) (transaction.BytesPayloads, http.Header, error) {
-	payloads, err := s.seriesJSONPayloadBuilder.BuildWithOnErrItemTooBigPolicy(payload, policy)
-	return payloads, jsonExtraHeadersWithCompression, err
+	codec := s.codec()
+	payloads, err := s.seriesJSONPayloadBuilder.BuildWithOnErrItemTooBigPolicy(payload, policy, codec)
+	return payloads, headersWithEncoding(jsonExtraHeadersWithCompression, codec), err
 }
 
 // As events are gathered by SourceType, the serialization logic is more complex than for the other serializations.
@@ -326,8 +367,9 @@ func (s *Serializer) SendIterableSeries(serieSource metrics.SerieSource) error {
 	} else if useV1API && !s.enableJSONStream {
 		seriesBytesPayloads, extraHeaders, err = s.serializePayloadJSON(seriesSerializer, true)
 	} else {
-		seriesBytesPayloads, err = seriesSerializer.MarshalSplitCompress(marshaler.DefaultBufferContext())
-		extraHeaders = protobufExtraHeadersWithCompression
+		codec := s.codec()
+		seriesBytesPayloads, err = seriesSerializer.MarshalSplitCompress(marshaler.DefaultBufferContext(), codec)
+		extraHeaders = headersWithEncoding(protobufExtraHeadersWithCompression, codec)
 	}
 
 	if err != nil {
@@ -353,9 +395,10 @@ func (s *Serializer) SendSketch(sketches metrics.SketchesSource) error {
 	}
 	sketchesSerializer := metricsserializer.SketchSeriesList{SketchesSource: sketches}
 	if s.enableSketchProtobufStream {
-		payloads, err := sketchesSerializer.MarshalSplitCompress(marshaler.DefaultBufferContext())
+		codec := s.codec()
+		payloads, err := sketchesSerializer.MarshalSplitCompress(marshaler.DefaultBufferContext(), codec)
 		if err == nil {
-			return s.Forwarder.SubmitSketchSeries(payloads, protobufExtraHeadersWithCompression)
+			return s.Forwarder.SubmitSketchSeries(payloads, headersWithEncoding(protobufExtraHeadersWithCompression, codec))
 		}
 		log.Warnf("Error: %v trying to stream compress SketchSeriesList - falling back to split/compress method", err)
 	}
@@ -385,7 +428,8 @@ func (s *Serializer) SendAgentchecksMetadata(m marshaler.JSONMarshaler) error {
 }
 
 func (s *Serializer) sendMetadata(m marshaler.JSONMarshaler, submit func(payload transaction.BytesPayloads, extra http.Header) error) error {
-	mustSplit, compressedPayload, payload, err := split.CheckSizeAndSerialize(m, true, split.JSONMarshalFct)
+	codec := s.codec()
+	mustSplit, compressedPayload, payload, err := split.CheckSizeAndSerialize(m, true, split.JSONMarshalFct, codec)
 	if err != nil {
 		return fmt.Errorf("could not determine size of metadata payload: %s", err)
 	}
@@ -396,7 +440,7 @@ func (s *Serializer) sendMetadata(m marshaler.JSONMarshaler, submit func(payload
 		return fmt.Errorf("metadata payload was too big to send (%d bytes compressed, %d bytes uncompressed), metadata payloads cannot be split", len(compressedPayload), len(payload))
 	}
 
-	if err := submit(transaction.NewBytesPayloadsWithoutMetaData([]*[]byte{&compressedPayload}), jsonExtraHeadersWithCompression); err != nil {
+	if err := submit(transaction.NewBytesPayloadsWithoutMetaData([]*[]byte{&compressedPayload}), headersWithEncoding(jsonExtraHeadersWithCompression, codec)); err != nil {
 		return err
 	}
 
@@ -416,11 +460,12 @@ func (s *Serializer) SendProcessesMetadata(data interface{}) error {
 	if err != nil {
 		return fmt.Errorf("could not serialize processes metadata payload: %s", err)
 	}
-	compressedPayload, err := compression.Compress(payload)
+	codec := s.codec()
+	compressedPayload, err := codec.Compress(payload)
 	if err != nil {
 		return fmt.Errorf("could not compress processes metadata payload: %s", err)
 	}
-	if err := s.Forwarder.SubmitV1Intake(transaction.NewBytesPayloadsWithoutMetaData([]*[]byte{&compressedPayload}), jsonExtraHeadersWithCompression); err != nil {
+	if err := s.Forwarder.SubmitV1Intake(transaction.NewBytesPayloadsWithoutMetaData([]*[]byte{&compressedPayload}), headersWithEncoding(jsonExtraHeadersWithCompression, codec)); err != nil {
 		return err
 	}
 
@@ -16,7 +16,9 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	metricsserializer "github.com/DataDog/datadog-agent/pkg/serializer/internal/metrics"
 	"github.com/DataDog/datadog-agent/pkg/serializer/internal/stream"
+	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
 	"github.com/DataDog/datadog-agent/pkg/serializer/split"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 )
 
 func buildEvents(numberOfEvents int) metricsserializer.Events {
@@ -38,6 +40,11 @@ func buildEvents(numberOfEvents int) metricsserializer.Events {
 
 var results transaction.BytesPayloads
 
+var (
+	zlibCodec = compression.DefaultCodec
+	zstdCodec = compression.NewZstdCodec(compression.ZstdDefaultLevel)
+)
+
 func benchmarkJSONStream(b *testing.B, passes int, sharedBuffers bool, numberOfEvents int) {
 	events := buildEvents(numberOfEvents)
 	marshaler := events.CreateSingleMarshaler()
@@ -52,11 +59,40 @@ func benchmarkJSONStream(b *testing.B, passes int, sharedBuffers bool, numberOfE
 }
 
 func benchmarkSplit(b *testing.B, numberOfEvents int) {
+	benchmarkSplitCodec(b, numberOfEvents, zlibCodec)
+}
+
+func benchmarkSplitCodec(b *testing.B, numberOfEvents int, codec compression.Codec) {
 	events := buildEvents(numberOfEvents)
 	b.ResetTimer()
 
 	for n := 0; n < b.N; n++ {
-		results, _ = split.Payloads(events, true, split.JSONMarshalFct)
+		results, _ = split.Payloads(events, true, split.JSONMarshalFct, codec)
+	}
+}
+
+// benchmarkStreamCodec reports the compression ratio along the usual metrics
+// to compare the zlib and zstd codecs.
+func benchmarkStreamCodec(b *testing.B, numberOfEvents int, codec compression.Codec) {
+	events := buildEvents(numberOfEvents)
+	eventsMarshaler := events.CreateSingleMarshaler()
+	payloadBuilder := stream.NewJSONPayloadBuilder(true)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		adapter := marshaler.NewIterableStreamJSONMarshalerAdapter(eventsMarshaler)
+		results, _ = payloadBuilder.BuildWithOnErrItemTooBigPolicy(adapter, stream.DropItemOnErrItemTooBig, codec)
+	}
+	b.StopTimer()
+
+	compressedSize := 0
+	for _, payload := range results {
+		compressedSize += payload.Len()
+	}
+	uncompressed, _ := split.JSONMarshalFct(events)
+	if compressedSize > 0 {
+		b.ReportMetric(float64(len(uncompressed))/float64(compressedSize), "ratio")
 	}
 }
 
@@ -122,3 +158,15 @@ func BenchmarkSplit10000(b *testing.B)    { benchmarkSplit(b, 10000) }
 func BenchmarkSplit100000(b *testing.B)   { benchmarkSplit(b, 100000) }
 func BenchmarkSplit1000000(b *testing.B)  { benchmarkSplit(b, 1000000) }
 func BenchmarkSplit10000000(b *testing.B) { benchmarkSplit(b, 10000000) }
+
+// Compression codecs
+func BenchmarkJSONStreamZlib100(b *testing.B)     { benchmarkStreamCodec(b, 100, zlibCodec) }
+func BenchmarkJSONStreamZlib10000(b *testing.B)   { benchmarkStreamCodec(b, 10000, zlibCodec) }
+func BenchmarkJSONStreamZlib1000000(b *testing.B) { benchmarkStreamCodec(b, 1000000, zlibCodec) }
+func BenchmarkJSONStreamZstd100(b *testing.B)     { benchmarkStreamCodec(b, 100, zstdCodec) }
+func BenchmarkJSONStreamZstd10000(b *testing.B)   { benchmarkStreamCodec(b, 10000, zstdCodec) }
+func BenchmarkJSONStreamZstd1000000(b *testing.B) { benchmarkStreamCodec(b, 1000000, zstdCodec) }
+
+func BenchmarkSplitZstd100(b *testing.B)     { benchmarkSplitCodec(b, 100, zstdCodec) }
+func BenchmarkSplitZstd10000(b *testing.B)   { benchmarkSplitCodec(b, 10000, zstdCodec) }
+func BenchmarkSplitZstd1000000(b *testing.B) { benchmarkSplitCodec(b, 1000000, zstdCodec) }
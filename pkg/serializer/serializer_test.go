@@ -308,6 +308,63 @@ func TestSendSeries(t *testing.T) {
 	f.AssertExpectations(t)
 }
 
+func TestSendSeriesZstd(t *testing.T) {
+	config.Datadog.Set("serializer_compressor_kind", compression.ZstdKind)
+	defer config.Datadog.Set("serializer_compressor_kind", nil)
+
+	content := []byte{0xa, 0xa, 0xa, 0x6, 0xa, 0x4, 0x68, 0x6f, 0x73, 0x74, 0x28, 0x3}
+	zstdCodec := compression.NewZstdCodec(compression.ZstdDefaultLevel)
+	zstdMatcher := mock.MatchedBy(func(payloads transaction.BytesPayloads) bool {
+		for _, compressedPayload := range payloads {
+			if payload, err := zstdCodec.Decompress(compressedPayload.GetContent()); err == nil && reflect.DeepEqual(content, payload) {
+				return true
+			}
+		}
+		return false
+	})
+	zstdHeaders := protobufExtraHeadersWithCompression.Clone()
+	zstdHeaders.Set("Content-Encoding", compression.ZstdEncoding)
+
+	f := &forwarder.MockedForwarder{}
+	f.On("SubmitSeries", zstdMatcher, zstdHeaders).Return(nil).Times(1)
+	s := NewSerializer(f, nil, nil)
+	err := s.SendIterableSeries(metricsserializer.CreateSerieSource(metrics.Series{&metrics.Serie{}}))
+	require.Nil(t, err)
+	f.AssertExpectations(t)
+}
+
+type codecForwarder struct {
+	*forwarder.MockedForwarder
+	codec compression.Codec
+}
+
+func (f codecForwarder) PayloadCodec() compression.Codec {
+	return f.codec
+}
+
+func TestSendSeriesForwarderCodec(t *testing.T) {
+	content := []byte{0xa, 0xa, 0xa, 0x6, 0xa, 0x4, 0x68, 0x6f, 0x73, 0x74, 0x28, 0x3}
+	zstdCodec := compression.NewZstdCodec(compression.ZstdDefaultLevel)
+	zstdMatcher := mock.MatchedBy(func(payloads transaction.BytesPayloads) bool {
+		for _, compressedPayload := range payloads {
+			if payload, err := zstdCodec.Decompress(compressedPayload.GetContent()); err == nil && reflect.DeepEqual(content, payload) {
+				return true
+			}
+		}
+		return false
+	})
+	zstdHeaders := protobufExtraHeadersWithCompression.Clone()
+	zstdHeaders.Set("Content-Encoding", compression.ZstdEncoding)
+
+	// the forwarder selects the codec of the payloads over serializer_compressor_kind
+	f := codecForwarder{MockedForwarder: &forwarder.MockedForwarder{}, codec: zstdCodec}
+	f.On("SubmitSeries", zstdMatcher, zstdHeaders).Return(nil).Times(1)
+	s := NewSerializer(f, nil, nil)
+	err := s.SendIterableSeries(metricsserializer.CreateSerieSource(metrics.Series{&metrics.Serie{}}))
+	require.Nil(t, err)
+	f.AssertExpectations(t)
+}
+
 func TestSendSketch(t *testing.T) {
 	f := &forwarder.MockedForwarder{}
 
@@ -58,10 +58,10 @@ func init() {
 
 }
 
-// CheckSizeAndSerialize Check the size of a payload and marshall it (optionally compress it)
+// CheckSizeAndSerialize Check the size of a payload and marshall it (optionally compress it with the given codec)
 // The dual role makes sense as you will never serialize without checking the size of the payload
-func CheckSizeAndSerialize(m marshaler.AbstractMarshaler, compress bool, marshalFct MarshalFct) (bool, []byte, []byte, error) {
-	compressedPayload, payload, err := serializeMarshaller(m, compress, marshalFct)
+func CheckSizeAndSerialize(m marshaler.AbstractMarshaler, compress bool, marshalFct MarshalFct, codec compression.Codec) (bool, []byte, []byte, error) {
+	compressedPayload, payload, err := serializeMarshaller(m, compress, marshalFct, codec)
 	if err != nil {
 		return false, nil, nil, err
 	}
@@ -71,11 +71,11 @@ func CheckSizeAndSerialize(m marshaler.AbstractMarshaler, compress bool, marshal
 	return mustBeSplit, compressedPayload, payload, nil
 }
 
-// Payloads serializes a metadata payload and sends it to the forwarder
-func Payloads(m marshaler.AbstractMarshaler, compress bool, marshalFct MarshalFct) (transaction.BytesPayloads, error) {
+// Payloads serializes a metadata payload, optionally compressed with the given codec, and sends it to the forwarder
+func Payloads(m marshaler.AbstractMarshaler, compress bool, marshalFct MarshalFct, codec compression.Codec) (transaction.BytesPayloads, error) {
 	marshallers := []marshaler.AbstractMarshaler{m}
 	smallEnoughPayloads := transaction.BytesPayloads{}
-	tooBig, compressedPayload, _, err := CheckSizeAndSerialize(m, compress, marshalFct)
+	tooBig, compressedPayload, _, err := CheckSizeAndSerialize(m, compress, marshalFct, codec)
 	if err != nil {
 		return smallEnoughPayloads, err
 	}
@@ -102,7 +102,7 @@ func Payloads(m marshaler.AbstractMarshaler, compress bool, marshalFct MarshalFc
 		for _, toSplit := range tempSlice {
 			var e error
 			// we have to do this every time to get the proper payload
-			compressedPayload, payload, e := serializeMarshaller(toSplit, compress, marshalFct)
+			compressedPayload, payload, e := serializeMarshaller(toSplit, compress, marshalFct, codec)
 			if e != nil {
 				return smallEnoughPayloads, e
 			}
@@ -124,7 +124,7 @@ func Payloads(m marshaler.AbstractMarshaler, compress bool, marshalFct MarshalFc
 			// after the payload has been split, loop through the chunks
 			for _, chunk := range chunks {
 				// serialize the payload
-				tooBigChunk, compressedPayload, _, err := CheckSizeAndSerialize(chunk, compress, marshalFct)
+				tooBigChunk, compressedPayload, _, err := CheckSizeAndSerialize(chunk, compress, marshalFct, codec)
 				if err != nil {
 					log.Debugf("Error serializing a chunk: %s", err)
 					continue
@@ -158,7 +158,7 @@ func Payloads(m marshaler.AbstractMarshaler, compress bool, marshalFct MarshalFc
 }
 
 // serializeMarshaller serializes the marshaller and returns both the compressed and uncompressed payloads
-func serializeMarshaller(m marshaler.AbstractMarshaler, compress bool, marshalFct MarshalFct) ([]byte, []byte, error) {
+func serializeMarshaller(m marshaler.AbstractMarshaler, compress bool, marshalFct MarshalFct, codec compression.Codec) ([]byte, []byte, error) {
 	var payload []byte
 	var compressedPayload []byte
 	var err error
@@ -168,7 +168,7 @@ func serializeMarshaller(m marshaler.AbstractMarshaler, compress bool, marshalFc
 		return nil, nil, err
 	}
 	if compress {
-		compressedPayload, err = compression.Compress(payload)
+		compressedPayload, err = codec.Compress(payload)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -72,7 +72,7 @@ func testSplitPayloadsSeries(t *testing.T, numPoints int, compress bool) {
 		testSeries = append(testSeries, &point)
 	}
 
-	payloads, err := Payloads(testSeries, compress, JSONMarshalFct)
+	payloads, err := Payloads(testSeries, compress, JSONMarshalFct, compression.DefaultCodec)
 	require.Nil(t, err)
 
 	originalLength := len(testSeries)
@@ -123,7 +123,7 @@ func BenchmarkSplitPayloadsSeries(b *testing.B) {
 	for n := 0; n < b.N; n++ {
 		// always record the result of Payloads to prevent
 		// the compiler eliminating the function call.
-		r, _ = Payloads(testSeries, true, JSONMarshalFct)
+		r, _ = Payloads(testSeries, true, JSONMarshalFct, compression.DefaultCodec)
 
 	}
 	// ensure we actually had to split
@@ -185,7 +185,7 @@ func testSplitPayloadsEvents(t *testing.T, numPoints int, compress bool) {
 		testEvent = append(testEvent, &event)
 	}
 
-	payloads, err := Payloads(testEvent, compress, JSONMarshalFct)
+	payloads, err := Payloads(testEvent, compress, JSONMarshalFct, compression.DefaultCodec)
 	require.Nil(t, err)
 
 	originalLength := len(testEvent)
@@ -250,7 +250,7 @@ func testSplitPayloadsServiceChecks(t *testing.T, numPoints int, compress bool)
 		testServiceChecks = append(testServiceChecks, &sc)
 	}
 
-	payloads, err := Payloads(testServiceChecks, compress, JSONMarshalFct)
+	payloads, err := Payloads(testServiceChecks, compress, JSONMarshalFct, compression.DefaultCodec)
 	require.Nil(t, err)
 
 	originalLength := len(testServiceChecks)
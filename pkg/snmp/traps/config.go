@@ -57,11 +57,6 @@ func ReadConfig(agentHostname string) (*Config, error) {
 		return nil, errors.New("traps listener is disabled")
 	}
 
-	// gosnmp only supports one v3 user at the moment.
-	if len(c.Users) > 1 {
-		return nil, errors.New("only one user is currently supported in SNMP Traps Listener configuration")
-	}
-
 	// Set defaults.
 	if c.Port == 0 {
 		c.Port = defaultPort
@@ -104,6 +99,8 @@ func (c *Config) Addr() string {
 }
 
 // BuildSNMPParams returns a valid GoSNMP params structure from configuration.
+// When several v3 users are configured, the returned params are the ones of the
+// first user; use BuildUsersSNMPParams to get the params of every user.
 func (c *Config) BuildSNMPParams() (*gosnmp.GoSNMP, error) {
 	if len(c.Users) == 0 {
 		return &gosnmp.GoSNMP{
@@ -113,7 +110,24 @@ func (c *Config) BuildSNMPParams() (*gosnmp.GoSNMP, error) {
 			Logger:    gosnmp.NewLogger(&trapLogger{}),
 		}, nil
 	}
-	user := c.Users[0]
+	return c.buildUserSNMPParams(c.Users[0])
+}
+
+// BuildUsersSNMPParams returns one valid GoSNMP params structure per configured v3 user,
+// in the order in which users are defined in the configuration.
+func (c *Config) BuildUsersSNMPParams() ([]*gosnmp.GoSNMP, error) {
+	usersParams := make([]*gosnmp.GoSNMP, 0, len(c.Users))
+	for _, user := range c.Users {
+		params, err := c.buildUserSNMPParams(user)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration for user %q: %w", user.Username, err)
+		}
+		usersParams = append(usersParams, params)
+	}
+	return usersParams, nil
+}
+
+func (c *Config) buildUserSNMPParams(user UserV3) (*gosnmp.GoSNMP, error) {
 	authProtocol, err := gosnmplib.GetAuthProtocol(user.AuthProtocol)
 	if err != nil {
 		return nil, err
@@ -73,6 +73,55 @@ func TestFullConfig(t *testing.T) {
 	}, params.SecurityParameters)
 }
 
+func TestMultipleUsersConfig(t *testing.T) {
+	Configure(t, Config{
+		Users: []UserV3{
+			{
+				Username:     "user",
+				AuthKey:      "password",
+				AuthProtocol: "MD5",
+				PrivKey:      "password",
+				PrivProtocol: "AES",
+			},
+			{
+				Username:     "user2",
+				AuthKey:      "password2",
+				AuthProtocol: "SHA",
+			},
+		},
+	})
+	config, err := ReadConfig(mockedHostname)
+	assert.NoError(t, err)
+	assert.Len(t, config.Users, 2)
+
+	usersParams, err := config.BuildUsersSNMPParams()
+	assert.NoError(t, err)
+	assert.Len(t, usersParams, 2)
+	assert.Equal(t, gosnmp.AuthPriv, usersParams[0].MsgFlags)
+	assert.Equal(t, gosnmp.AuthNoPriv, usersParams[1].MsgFlags)
+	assert.Equal(t, &gosnmp.UsmSecurityParameters{
+		UserName:                 "user2",
+		AuthoritativeEngineID:    expectedEngineID,
+		AuthenticationProtocol:   gosnmp.SHA,
+		AuthenticationPassphrase: "password2",
+		PrivacyProtocol:          gosnmp.NoPriv,
+	}, usersParams[1].SecurityParameters)
+}
+
+func TestInvalidUserConfig(t *testing.T) {
+	Configure(t, Config{
+		Users: []UserV3{
+			{Username: "user", AuthKey: "password", AuthProtocol: "MD5"},
+			{Username: "user2", AuthKey: "password", AuthProtocol: "foo"},
+		},
+	})
+	config, err := ReadConfig(mockedHostname)
+	assert.NoError(t, err)
+
+	_, err = config.BuildUsersSNMPParams()
+	assert.EqualError(t, err, `invalid configuration for user "user2": unsupported authentication protocol: foo`)
+}
+
 func TestMinimalConfig(t *testing.T) {
 	Configure(t, Config{})
 	config, err := ReadConfig("")
@@ -6,8 +6,10 @@
 package traps
 
 import (
+	"errors"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/gosnmp/gosnmp"
@@ -15,65 +17,182 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
+// usmStatsUnknownEngineIDs is the OID of the counter reported when a v3 message uses an invalid engine ID (RFC3414 section 5).
+const usmStatsUnknownEngineIDs = ".1.3.6.1.6.3.15.1.1.4.0"
+
 // TrapListener opens an UDP socket and put all received traps in a channel
 type TrapListener struct {
-	config        Config
-	packets       PacketsChannel
-	listener      *gosnmp.TrapListener
-	errorsChannel chan error
+	config  Config
+	packets PacketsChannel
+	// params decodes v1 and v2c packets, and v3 packets when no user is configured.
+	params *gosnmp.GoSNMP
+	// users decodes v3 packets using the credentials of the user that sent them.
+	users *usersTable
+
+	conn                          *net.UDPConn
+	usmStatsUnknownEngineIDsCount int
+	stopOnce                      sync.Once
+	stopped                       chan struct{}
+	done                          chan struct{}
 }
 
 // NewTrapListener creates a simple TrapListener instance but does not start it
 func NewTrapListener(config Config, packets PacketsChannel) (*TrapListener, error) {
-	var err error
-	gosnmpListener := gosnmp.NewTrapListener()
-	gosnmpListener.Params, err = config.BuildSNMPParams()
+	params, err := config.BuildSNMPParams()
+	if err != nil {
+		return nil, err
+	}
+	usersParams, err := config.BuildUsersSNMPParams()
 	if err != nil {
 		return nil, err
 	}
-	errorsChan := make(chan error, 1)
 	trapListener := &TrapListener{
-		config:        config,
-		packets:       packets,
-		listener:      gosnmpListener,
-		errorsChannel: errorsChan,
+		config:  config,
+		packets: packets,
+		params:  params,
+		users:   newUsersTable(usersParams),
+		stopped: make(chan struct{}),
+		done:    make(chan struct{}),
 	}
-
-	gosnmpListener.OnNewTrap = trapListener.receiveTrap
 	return trapListener, nil
 }
 
 // Start the TrapListener instance. Need to be manually Stopped
 func (t *TrapListener) Start() error {
 	log.Infof("Start listening for traps on %s", t.config.Addr())
+	addr, err := net.ResolveUDPAddr("udp", t.config.Addr())
+	if err != nil {
+		return fmt.Errorf("error happened when listening for SNMP Traps: %s", err)
+	}
+	t.conn, err = net.ListenUDP("udp", addr)
+	if err != nil {
+		return fmt.Errorf("error happened when listening for SNMP Traps: %s", err)
+	}
 	go t.run()
-	return t.blockUntilReady()
+	return nil
 }
 
 func (t *TrapListener) run() {
-	err := t.listener.Listen(t.config.Addr()) // blocking call
+	defer close(t.done)
+	for {
+		// The decoded packets reference the buffer they were read into and are queued
+		// until forwarded: every datagram needs its own buffer.
+		var buf [4096]byte
+		n, remote, err := t.conn.ReadFromUDP(buf[:])
+		if err != nil {
+			select {
+			case <-t.stopped:
+				return
+			default:
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Debugf("Error reading from %s: %s", t.config.Addr(), err)
+			continue
+		}
+		t.handlePacket(buf[:n], remote)
+	}
+}
+
+// Stop the current TrapListener instance
+func (t *TrapListener) Stop() {
+	t.stopOnce.Do(func() {
+		close(t.stopped)
+		t.conn.Close()
+		<-t.done
+	})
+}
+
+func (t *TrapListener) handlePacket(msg []byte, remote *net.UDPAddr) {
+	packet, err := t.unmarshalTrap(msg)
 	if err != nil {
-		t.errorsChannel <- err
+		log.Debugf("Unable to decode packet from %s on listener %s: %s", remote.String(), t.config.Addr(), err)
+		trapsPacketsAuthErrors.Add(1)
+		return
+	}
+
+	if packet.Version == gosnmp.Version3 && packet.SecurityModel == gosnmp.UserSecurityModel {
+		engineID := packet.SecurityParameters.(*gosnmp.UsmSecurityParameters).AuthoritativeEngineID
+		if engineID != t.config.authoritativeEngineID && (len(engineID) < 5 || len(engineID) > 32) {
+			// RFC3411 section 5. – SnmpEngineID definition.
+			// SnmpEngineID is an OCTET STRING which size should be between 5 and 32
+			// According to RFC3414 3.2.3b: stop processing and report
+			// the listener authoritative engine ID
+			t.usmStatsUnknownEngineIDsCount++
+			if err := t.reportAuthoritativeEngineID(packet, remote); err != nil {
+				log.Debugf("Unable to report authoritative engine ID to %s: %s", remote.String(), err)
+			}
+			return
+		}
+		// RFC3414 3.2.3a: Continue processing
 	}
 
+	t.receiveTrap(packet, remote)
+
+	// If it was an Inform request, we need to send a response with the exact same variables.
+	if packet.PDUType == gosnmp.InformRequest {
+		response := *packet
+		response.PDUType = gosnmp.GetResponse
+		response.Error = gosnmp.NoError
+		response.ErrorIndex = 0
+		if err := t.sendUDP(&response, remote); err != nil {
+			log.Debugf("Unable to respond to inform from %s: %s", remote.String(), err)
+		}
+	}
 }
 
-func (t *TrapListener) blockUntilReady() error {
-	select {
-	// Wait for listener to be started and listening to traps.
-	// See: https://godoc.org/github.com/gosnmp/gosnmp#TrapListener.Listening
-	case <-t.listener.Listening():
-		return nil
-	// If the listener failed to start (eg because it couldn't bind to a socket),
-	// we'll get an error here.
-	case err := <-t.errorsChannel:
-		return fmt.Errorf("error happened when listening for SNMP Traps: %s", err)
+// unmarshalTrap decodes a packet, using the credentials of its sender if it is a v3 packet.
+func (t *TrapListener) unmarshalTrap(msg []byte) (*gosnmp.SnmpPacket, error) {
+	if len(t.config.Users) == 0 {
+		return t.params.UnmarshalTrap(msg, false)
+	}
+	username, engineID, err := parseV3UserIdentity(msg)
+	if err != nil {
+		// Not a v3 packet, the params of any user can decode it.
+		return t.params.UnmarshalTrap(msg, false)
+	}
+	packet, err := t.users.unmarshalTrap(msg, username, engineID)
+	if err != nil {
+		if t.users.isConfigured(username) {
+			trapsPacketsUserDecodeErrors.Add(username, 1)
+		} else {
+			trapsPacketsUnknownUserDecodeErrors.Add(1)
+		}
+		return nil, fmt.Errorf("user %q: %w", username, err)
 	}
+	return packet, nil
 }
 
-// Stop the current TrapListener instance
-func (t *TrapListener) Stop() {
-	t.listener.Close()
+func (t *TrapListener) reportAuthoritativeEngineID(trap *gosnmp.SnmpPacket, addr *net.UDPAddr) error {
+	securityParams, ok := trap.SecurityParameters.Copy().(*gosnmp.UsmSecurityParameters)
+	if !ok {
+		return errors.New("unable to cast SecurityParams to UsmSecurityParameters")
+	}
+	securityParams.AuthoritativeEngineID = t.config.authoritativeEngineID
+	report := *trap
+	report.PDUType = gosnmp.Report
+	report.MsgFlags &= gosnmp.AuthPriv
+	report.SecurityParameters = securityParams
+	report.Variables = []gosnmp.SnmpPDU{
+		{
+			Name:  usmStatsUnknownEngineIDs,
+			Value: t.usmStatsUnknownEngineIDsCount,
+			Type:  gosnmp.Integer,
+		},
+	}
+	return t.sendUDP(&report, addr)
+}
+
+func (t *TrapListener) sendUDP(packet *gosnmp.SnmpPacket, addr *net.UDPAddr) error {
+	msg, err := packet.MarshalMsg()
+	if err != nil {
+		return fmt.Errorf("error marshaling SnmpPacket: %w", err)
+	}
+	if _, err := t.conn.WriteToUDP(msg, addr); err != nil {
+		return fmt.Errorf("error sending SnmpPacket: %w", err)
+	}
+	return nil
 }
 
 func (t *TrapListener) receiveTrap(p *gosnmp.SnmpPacket, u *net.UDPAddr) {
@@ -64,6 +64,36 @@ func TestServerV2(t *testing.T) {
 	assertVariables(t, packet)
 }
 
+func TestServerV2QueuedPackets(t *testing.T) {
+	config := Config{Port: serverPort, CommunityStrings: []string{"public"}}
+	Configure(t, config)
+
+	packetOutChan := make(PacketsChannel, 2)
+	trapListener, err := startSNMPTrapListener(config, packetOutChan)
+	require.NoError(t, err)
+	defer trapListener.Stop()
+
+	sendTestV2Trap(t, config, "public")
+	// Same layout as the first trap, with different string values
+	sendV2Trap(t, config, "public", gosnmp.SnmpTrap{
+		Variables: []gosnmp.SnmpPDU{
+			{Name: "1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(1000)},
+			{Name: "1.3.6.1.6.3.1.1.4.1.0", Type: gosnmp.OctetString, Value: "1.3.6.1.4.1.8072.2.3.0.2"},
+			{Name: "1.3.6.1.4.1.8072.2.3.2.1", Type: gosnmp.Integer, Value: 1024},
+			{Name: "1.3.6.1.4.1.8072.2.3.2.2", Type: gosnmp.OctetString, Value: "TEST"},
+		},
+	})
+	firstPacket := receivePacket(t, trapListener)
+	require.NotNil(t, firstPacket)
+	secondPacket := receivePacket(t, trapListener)
+	require.NotNil(t, secondPacket)
+
+	// Reading the second trap must not overwrite the values of the first one
+	assertVariables(t, firstPacket)
+	assert.Equal(t, "1.3.6.1.4.1.8072.2.3.0.2", string(secondPacket.Content.Variables[1].Value.([]byte)))
+	assert.Equal(t, "TEST", string(secondPacket.Content.Variables[3].Value.([]byte)))
+}
+
 func TestServerV2BadCredentials(t *testing.T) {
 	config := Config{Port: serverPort, CommunityStrings: []string{"public"}}
 	Configure(t, config)
@@ -121,6 +151,91 @@ func TestServerV3BadCredentials(t *testing.T) {
 	assertNoPacketReceived(t, trapListener)
 }
 
+func TestServerV3MultipleUsers(t *testing.T) {
+	users := []UserV3{
+		{Username: "user", AuthKey: "password", AuthProtocol: "sha", PrivKey: "password", PrivProtocol: "aes"},
+		{Username: "user", AuthKey: "password2", AuthProtocol: "md5", PrivKey: "password2", PrivProtocol: "des"},
+		{Username: "other", AuthKey: "password3", AuthProtocol: "sha256", PrivKey: "password3", PrivProtocol: "aes256"},
+	}
+	config := Config{Port: serverPort, Users: users}
+	Configure(t, config)
+
+	packetOutChan := make(PacketsChannel)
+	trapListener, err := startSNMPTrapListener(config, packetOutChan)
+	require.NoError(t, err)
+	defer trapListener.Stop()
+
+	for _, securityParams := range []*gosnmp.UsmSecurityParameters{
+		{
+			UserName:                 "user",
+			AuthoritativeEngineID:    "foobarbaz",
+			AuthenticationPassphrase: "password2",
+			AuthenticationProtocol:   gosnmp.MD5,
+			PrivacyPassphrase:        "password2",
+			PrivacyProtocol:          gosnmp.DES,
+		},
+		{
+			UserName:                 "other",
+			AuthoritativeEngineID:    "foobarbaz",
+			AuthenticationPassphrase: "password3",
+			AuthenticationProtocol:   gosnmp.SHA256,
+			PrivacyPassphrase:        "password3",
+			PrivacyProtocol:          gosnmp.AES256,
+		},
+		{
+			UserName:                 "user",
+			AuthoritativeEngineID:    "foobarbaz",
+			AuthenticationPassphrase: "password",
+			AuthenticationProtocol:   gosnmp.SHA,
+			PrivacyPassphrase:        "password",
+			PrivacyProtocol:          gosnmp.AES,
+		},
+	} {
+		sendTestV3Trap(t, config, securityParams)
+		packet := receivePacket(t, trapListener)
+		require.NotNil(t, packet)
+		assertVariables(t, packet)
+	}
+}
+
+func TestServerV3UnknownUser(t *testing.T) {
+	userV3 := UserV3{Username: "user", AuthKey: "password", AuthProtocol: "sha", PrivKey: "password", PrivProtocol: "aes"}
+	config := Config{Port: serverPort, Users: []UserV3{userV3}}
+	Configure(t, config)
+
+	packetOutChan := make(PacketsChannel)
+	trapListener, err := startSNMPTrapListener(config, packetOutChan)
+	require.NoError(t, err)
+	defer trapListener.Stop()
+	trapsPacketsUserDecodeErrors.Init()
+	trapsPacketsUnknownUserDecodeErrors.Set(0)
+
+	sendTestV3Trap(t, config, &gosnmp.UsmSecurityParameters{
+		UserName:                 "attacker",
+		AuthoritativeEngineID:    "foobarbaz",
+		AuthenticationPassphrase: "password",
+		AuthenticationProtocol:   gosnmp.SHA,
+		PrivacyPassphrase:        "password",
+		PrivacyProtocol:          gosnmp.AES,
+	})
+	assertNoPacketReceived(t, trapListener)
+	// usernames missing from the configuration are not used as keys
+	assert.Nil(t, trapsPacketsUserDecodeErrors.Get("attacker"))
+	assert.Equal(t, int64(1), trapsPacketsUnknownUserDecodeErrors.Value())
+
+	sendTestV3Trap(t, config, &gosnmp.UsmSecurityParameters{
+		UserName:                 "user",
+		AuthoritativeEngineID:    "foobarbaz",
+		AuthenticationPassphrase: "wrongpassword",
+		AuthenticationProtocol:   gosnmp.SHA,
+		PrivacyPassphrase:        "wrongpassword",
+		PrivacyProtocol:          gosnmp.AES,
+	})
+	assertNoPacketReceived(t, trapListener)
+	assert.Equal(t, "1", trapsPacketsUserDecodeErrors.Get("user").String())
+	assert.Equal(t, int64(1), trapsPacketsUnknownUserDecodeErrors.Value())
+}
+
 // receivePacket waits for a received trap packet and returns it.
 func receivePacket(t *testing.T, listener *TrapListener) *SnmpPacket {
 	select {
@@ -16,11 +16,19 @@ var (
 	trapsExpvars           = expvar.NewMap("snmp_traps")
 	trapsPackets           = expvar.Int{}
 	trapsPacketsAuthErrors = expvar.Int{}
+	// trapsPacketsUserDecodeErrors counts the v3 packets that could not be decoded, per configured username.
+	trapsPacketsUserDecodeErrors = expvar.Map{}
+	// trapsPacketsUnknownUserDecodeErrors counts the v3 packets that could not be decoded and were sent by
+	// users missing from the configuration. The username of a packet is not authenticated, using it as a
+	// key would let any sender add keys.
+	trapsPacketsUnknownUserDecodeErrors = expvar.Int{}
 )
 
 func init() {
 	trapsExpvars.Set("Packets", &trapsPackets)
 	trapsExpvars.Set("PacketsAuthErrors", &trapsPacketsAuthErrors)
+	trapsExpvars.Set("PacketsUserDecodeErrors", &trapsPacketsUserDecodeErrors)
+	trapsExpvars.Set("PacketsUnknownUserDecodeErrors", &trapsPacketsUnknownUserDecodeErrors)
 }
 
 func getDroppedPackets() int64 {
@@ -49,6 +57,13 @@ func GetStatus() map[string]interface{} {
 	metricsJSON := []byte(expvar.Get("snmp_traps").String())
 	metrics := make(map[string]interface{})
 	json.Unmarshal(metricsJSON, &metrics) //nolint:errcheck
+	// Per-user errors are reported separately from the other metrics, which are all counters.
+	if userDecodeErrors, ok := metrics["PacketsUserDecodeErrors"].(map[string]interface{}); ok {
+		delete(metrics, "PacketsUserDecodeErrors")
+		if len(userDecodeErrors) > 0 {
+			status["userDecodeErrors"] = userDecodeErrors
+		}
+	}
 	if dropped := getDroppedPackets(); dropped > 0 {
 		metrics["PacketsDropped"] = dropped
 	}
@@ -169,6 +169,10 @@ func sendTestV1SpecificTrap(t *testing.T, trapConfig Config, community string) *
 }
 
 func sendTestV2Trap(t *testing.T, trapConfig Config, community string) *gosnmp.GoSNMP {
+	return sendV2Trap(t, trapConfig, community, NetSNMPExampleHeartbeatNotification)
+}
+
+func sendV2Trap(t *testing.T, trapConfig Config, community string, trap gosnmp.SnmpTrap) *gosnmp.GoSNMP {
 	params, err := trapConfig.BuildSNMPParams()
 	require.NoError(t, err)
 	params.Community = community
@@ -179,7 +183,6 @@ func sendTestV2Trap(t *testing.T, trapConfig Config, community string) *gosnmp.G
 	require.NoError(t, err)
 	defer params.Conn.Close()
 
-	trap := NetSNMPExampleHeartbeatNotification
 	_, err = params.SendTrap(trap)
 	require.NoError(t, err)
 
@@ -0,0 +1,128 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2022-present Datadog, Inc.
+
+package traps
+
+import (
+	"encoding/asn1"
+	"errors"
+	"fmt"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// v3MessageHeader is the beginning of an SNMPv3 message (RFC3412 section 6).
+// Only the fields needed to find the user that sent the message are decoded,
+// the scoped PDU (which may be encrypted) is kept as is.
+type v3MessageHeader struct {
+	Version    int
+	GlobalData struct {
+		ID            int
+		MaxSize       int
+		Flags         []byte
+		SecurityModel int
+	}
+	SecurityParameters []byte
+	ScopedPDU          asn1.RawValue
+}
+
+// usmSecurityParameters is the USM security parameters field of an SNMPv3 message (RFC3414 section 2.4).
+type usmSecurityParameters struct {
+	AuthoritativeEngineID    []byte
+	AuthoritativeEngineBoots int
+	AuthoritativeEngineTime  int
+	UserName                 []byte
+	AuthenticationParameters []byte
+	PrivacyParameters        []byte
+}
+
+// parseV3UserIdentity returns the username and the authoritative engine ID of an SNMPv3 USM message.
+// It returns an error if the message is not an SNMPv3 message using the User Security Model.
+func parseV3UserIdentity(msg []byte) (string, string, error) {
+	var header v3MessageHeader
+	if _, err := asn1.Unmarshal(msg, &header); err != nil {
+		return "", "", err
+	}
+	if header.Version != int(gosnmp.Version3) {
+		return "", "", fmt.Errorf("unexpected SNMP version %d", header.Version)
+	}
+	if header.GlobalData.SecurityModel != int(gosnmp.UserSecurityModel) {
+		return "", "", fmt.Errorf("unsupported security model %d", header.GlobalData.SecurityModel)
+	}
+	var usm usmSecurityParameters
+	if _, err := asn1.Unmarshal(header.SecurityParameters, &usm); err != nil {
+		return "", "", err
+	}
+	return string(usm.UserName), string(usm.AuthoritativeEngineID), nil
+}
+
+type userEngineKey struct {
+	username string
+	engineID string
+}
+
+// usersTable holds the GoSNMP params of every configured v3 user and picks the
+// params to use to decode a message from its username and engine ID.
+// It is not safe for concurrent use.
+type usersTable struct {
+	byUsername map[string][]*gosnmp.GoSNMP
+	// byEngine remembers which params successfully decoded the last message of a
+	// username/engine ID pair, so that it is tried first for the next messages.
+	byEngine map[userEngineKey]*gosnmp.GoSNMP
+}
+
+func newUsersTable(usersParams []*gosnmp.GoSNMP) *usersTable {
+	table := &usersTable{
+		byUsername: make(map[string][]*gosnmp.GoSNMP),
+		byEngine:   make(map[userEngineKey]*gosnmp.GoSNMP),
+	}
+	for _, params := range usersParams {
+		username := params.SecurityParameters.(*gosnmp.UsmSecurityParameters).UserName
+		table.byUsername[username] = append(table.byUsername[username], params)
+	}
+	return table
+}
+
+// isConfigured returns whether the username is the username of a configured user
+func (u *usersTable) isConfigured(username string) bool {
+	return len(u.byUsername[username]) > 0
+}
+
+// unmarshalTrap decodes a v3 message with the params of the users matching its username,
+// starting with the params which last succeeded for its engine ID.
+func (u *usersTable) unmarshalTrap(msg []byte, username string, engineID string) (*gosnmp.SnmpPacket, error) {
+	candidates := u.byUsername[username]
+	if len(candidates) == 0 {
+		return nil, errors.New("unknown user")
+	}
+
+	var err error
+	key := userEngineKey{username: username, engineID: engineID}
+	if params, ok := u.byEngine[key]; ok {
+		var packet *gosnmp.SnmpPacket
+		if packet, err = params.UnmarshalTrap(copyMessage(msg), false); err == nil {
+			return packet, nil
+		}
+	}
+
+	for _, params := range candidates {
+		if params == u.byEngine[key] {
+			continue
+		}
+		var packet *gosnmp.SnmpPacket
+		packet, err = params.UnmarshalTrap(copyMessage(msg), false)
+		if err == nil {
+			u.byEngine[key] = params
+			return packet, nil
+		}
+	}
+	return nil, err
+}
+
+// copyMessage returns a copy of a raw message, gosnmp zeroes the authentication
+// parameters of the buffer it decodes, so each decoding attempt needs its own copy.
+func copyMessage(msg []byte) []byte {
+	return append([]byte(nil), msg...)
+}
@@ -110,7 +110,17 @@ func (c *WorkloadMetaCollector) stream(ctx context.Context) {
 		}
 	}()
 
-	ch := c.store.Subscribe(name, workloadmeta.TaggerPriority, nil)
+	// host processes and systemd units are not tagged yet
+	filter := workloadmeta.NewFilter(
+		[]workloadmeta.Kind{
+			workloadmeta.KindContainer,
+			workloadmeta.KindKubernetesPod,
+			workloadmeta.KindECSTask,
+		},
+		workloadmeta.SourceAll,
+		workloadmeta.EventTypeAll,
+	)
+	ch := c.store.Subscribe(name, workloadmeta.TaggerPriority, filter)
 
 	log.Infof("workloadmeta tagger collector started")
 
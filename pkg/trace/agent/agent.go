@@ -50,6 +50,7 @@ type Agent struct {
 	PrioritySampler       *sampler.PrioritySampler
 	ErrorsSampler         *sampler.ErrorsSampler
 	RareSampler           *sampler.RareSampler
+	LatencySampler        *sampler.LatencySampler
 	NoPrioritySampler     *sampler.NoPrioritySampler
 	EventProcessor        *event.Processor
 	TraceWriter           *writer.TraceWriter
@@ -95,6 +96,7 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig) *Agent {
 		PrioritySampler:       sampler.NewPrioritySampler(conf, dynConf),
 		ErrorsSampler:         sampler.NewErrorsSampler(conf),
 		RareSampler:           sampler.NewRareSampler(conf),
+		LatencySampler:        sampler.NewLatencySampler(conf),
 		NoPrioritySampler:     sampler.NewNoPrioritySampler(conf),
 		EventProcessor:        newEventProcessor(conf),
 		TraceWriter:           writer.NewTraceWriter(conf),
@@ -183,6 +185,7 @@ func (a *Agent) loop() {
 				a.ErrorsSampler,
 				a.NoPrioritySampler,
 				a.RareSampler,
+				a.LatencySampler,
 				a.EventProcessor,
 				a.OTLPReceiver,
 				a.obfuscator,
@@ -261,6 +264,14 @@ func (a *Agent) Process(p *api.Payload) {
 			continue
 		}
 
+		if filteredByTagRules(chunk, root, p.TracerPayload.Env, a.conf.TagFilterRules) {
+			log.Debugf("Trace rejected as it fails to meet tag filter rules. root: %v", root)
+			ts.TracesFiltered.Inc()
+			ts.SpansFiltered.Add(tracen)
+			p.RemoveChunk(i)
+			continue
+		}
+
 		// Extra sanitization steps of the trace.
 		for _, span := range chunk.Spans {
 			for k, v := range a.conf.GlobalTags {
@@ -473,7 +484,7 @@ func (a *Agent) sample(now time.Time, ts *info.TagStats, pt traceutil.ProcessedT
 		}
 	}
 
-	sampled := a.runSamplers(now, pt, hasPriority)
+	sampled := a.runSamplers(now, ts, pt, hasPriority)
 
 	filteredChunk = pt.TraceChunk
 	if !sampled {
@@ -490,18 +501,19 @@ func (a *Agent) sample(now time.Time, ts *info.TagStats, pt traceutil.ProcessedT
 }
 
 // runSamplers runs all the agent's samplers on pt and returns the sampling decision
-// along with the sampling rate.
-func (a *Agent) runSamplers(now time.Time, pt traceutil.ProcessedTrace, hasPriority bool) bool {
+// along with the sampling rate. The reason of the decision is counted in ts.
+func (a *Agent) runSamplers(now time.Time, ts *info.TagStats, pt traceutil.ProcessedTrace, hasPriority bool) bool {
 	if hasPriority {
-		return a.samplePriorityTrace(now, pt)
+		return a.samplePriorityTrace(now, ts, pt)
 	}
-	return a.sampleNoPriorityTrace(now, pt)
+	return a.sampleNoPriorityTrace(now, ts, pt)
 }
 
 // samplePriorityTrace samples traces with priority set on them. PrioritySampler and
 // ErrorSampler are run in parallel. The RareSampler catches traces with rare top-level
 // or measured spans that are not caught by PrioritySampler and ErrorSampler.
-func (a *Agent) samplePriorityTrace(now time.Time, pt traceutil.ProcessedTrace) bool {
+// The LatencySampler catches the slow traces that are not caught by the other samplers.
+func (a *Agent) samplePriorityTrace(now time.Time, ts *info.TagStats, pt traceutil.ProcessedTrace) bool {
 	var rare bool
 	if !a.conf.RareSamplerEnabled {
 		rare = false
@@ -509,22 +521,58 @@ func (a *Agent) samplePriorityTrace(now time.Time, pt traceutil.ProcessedTrace)
 		// run this early to make sure the signature gets counted by the RareSampler.
 		rare = a.RareSampler.Sample(now, pt.TraceChunk, pt.TracerEnv)
 	}
+	// run this early to make sure the duration gets counted by the LatencySampler.
+	slow := a.isSlow(now, pt)
 	if a.PrioritySampler.Sample(now, pt.TraceChunk, pt.Root, pt.TracerEnv, pt.ClientDroppedP0sWeight) {
+		ts.TracesSampled.Priority.Inc()
 		return true
 	}
 	if traceContainsError(pt.TraceChunk.Spans) {
-		return a.ErrorsSampler.Sample(now, pt.TraceChunk.Spans, pt.Root, pt.TracerEnv)
+		if a.ErrorsSampler.Sample(now, pt.TraceChunk.Spans, pt.Root, pt.TracerEnv) {
+			ts.TracesSampled.Error.Inc()
+			return true
+		}
+	} else if rare {
+		ts.TracesSampled.Rare.Inc()
+		return true
 	}
-	return rare
+	return slow && a.sampleLatency(ts, pt)
 }
 
 // sampleNoPriorityTrace samples traces with no priority set on them. The traces
 // get sampled by either the score sampler or the error sampler if they have an error.
-func (a *Agent) sampleNoPriorityTrace(now time.Time, pt traceutil.ProcessedTrace) bool {
+// The LatencySampler catches the slow traces that are not caught by these samplers.
+func (a *Agent) sampleNoPriorityTrace(now time.Time, ts *info.TagStats, pt traceutil.ProcessedTrace) bool {
+	// run this early to make sure the duration gets counted by the LatencySampler.
+	slow := a.isSlow(now, pt)
 	if traceContainsError(pt.TraceChunk.Spans) {
-		return a.ErrorsSampler.Sample(now, pt.TraceChunk.Spans, pt.Root, pt.TracerEnv)
+		if a.ErrorsSampler.Sample(now, pt.TraceChunk.Spans, pt.Root, pt.TracerEnv) {
+			ts.TracesSampled.Error.Inc()
+			return true
+		}
+	} else if a.NoPrioritySampler.Sample(now, pt.TraceChunk.Spans, pt.Root, pt.TracerEnv) {
+		ts.TracesSampled.NoPriority.Inc()
+		return true
+	}
+	return slow && a.sampleLatency(ts, pt)
+}
+
+// isSlow counts the duration of pt with the LatencySampler if it is enabled,
+// and returns whether pt is slow.
+func (a *Agent) isSlow(now time.Time, pt traceutil.ProcessedTrace) bool {
+	if !a.conf.LatencySamplerEnabled {
+		return false
+	}
+	return a.LatencySampler.IsSlow(now, pt.Root, pt.TracerEnv)
+}
+
+// sampleLatency runs the LatencySampler on a slow trace that the other samplers did not keep.
+func (a *Agent) sampleLatency(ts *info.TagStats, pt traceutil.ProcessedTrace) bool {
+	if !a.LatencySampler.Sample(pt.Root) {
+		return false
 	}
-	return a.NoPrioritySampler.Sample(now, pt.TraceChunk.Spans, pt.Root, pt.TracerEnv)
+	ts.TracesSampled.Latency.Inc()
+	return true
 }
 
 func traceContainsError(trace pb.Trace) bool {
@@ -551,6 +599,44 @@ func filteredByTags(root *pb.Span, require, reject []*config.Tag) bool {
 	return false
 }
 
+// filteredByTagRules returns whether the chunk is filtered by the tag filter rules applying
+// to its env and root span service: it is filtered when none of its spans matches a "require"
+// rule, or when one of its spans matches a "reject" rule. The env of the tracer payload is
+// used when set.
+func filteredByTagRules(chunk *pb.TraceChunk, root *pb.Span, env string, rules []*config.TagFilterRule) bool {
+	if len(rules) == 0 {
+		return false
+	}
+	if env == "" {
+		env = traceutil.GetEnv(root, chunk)
+	}
+	for _, rule := range rules {
+		if rule.Env != "" && rule.Env != env {
+			continue
+		}
+		if rule.Service != "" && rule.Service != root.Service {
+			continue
+		}
+		matched := chunkMatchesTagFilterRule(chunk, rule)
+		if matched == (rule.Type == config.TagFilterReject) {
+			return true
+		}
+	}
+	return false
+}
+
+// chunkMatchesTagFilterRule returns whether any span of the chunk has the tag of the rule,
+// with a value matching its pattern when it is set.
+func chunkMatchesTagFilterRule(chunk *pb.TraceChunk, rule *config.TagFilterRule) bool {
+	for _, span := range chunk.Spans {
+		v, ok := span.Meta[rule.Key]
+		if ok && (rule.Re == nil || rule.Re.MatchString(v)) {
+			return true
+		}
+	}
+	return false
+}
+
 func newEventProcessor(conf *config.AgentConfig) *event.Processor {
 	extractors := []event.Extractor{event.NewMetricBasedExtractor()}
 	if len(conf.AnalyzedSpansByService) > 0 {
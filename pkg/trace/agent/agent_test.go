@@ -153,6 +153,42 @@ func TestProcess(t *testing.T) {
 		assert.EqualValues(2, want.SpansFiltered.Load())
 	})
 
+	t.Run("TagFilterRules", func(t *testing.T) {
+		cfg := config.New()
+		cfg.Endpoints[0].APIKey = "test"
+		cfg.TagFilterRules = []*config.TagFilterRule{
+			{Type: config.TagFilterReject, Key: "http.url", Pattern: "/health$", Re: regexp.MustCompile("/health$")},
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		agnt := NewAgent(ctx, cfg)
+		defer cancel()
+
+		now := time.Now()
+		newChunk := func(url string) *pb.TraceChunk {
+			return testutil.TraceChunkWithSpans([]*pb.Span{
+				{TraceID: 1, SpanID: 1, Service: "web", Resource: "GET", Start: now.Add(-time.Second).UnixNano(), Duration: (500 * time.Millisecond).Nanoseconds()},
+				{TraceID: 1, SpanID: 2, ParentID: 1, Service: "web", Resource: "GET", Start: now.Add(-time.Second).UnixNano(), Duration: (100 * time.Millisecond).Nanoseconds(), Meta: map[string]string{"http.url": url}},
+			})
+		}
+
+		want := agnt.Receiver.Stats.GetTagStats(info.Tags{})
+		assert := assert.New(t)
+
+		agnt.Process(&api.Payload{
+			TracerPayload: testutil.TracerPayloadWithChunk(newChunk("http://localhost/users")),
+			Source:        want,
+		})
+		assert.EqualValues(0, want.TracesFiltered.Load())
+		assert.EqualValues(0, want.SpansFiltered.Load())
+
+		agnt.Process(&api.Payload{
+			TracerPayload: testutil.TracerPayloadWithChunk(newChunk("http://localhost/health")),
+			Source:        want,
+		})
+		assert.EqualValues(1, want.TracesFiltered.Load())
+		assert.EqualValues(2, want.SpansFiltered.Load())
+	})
+
 	t.Run("BlacklistPayload", func(t *testing.T) {
 		// Regression test for DataDog/datadog-agent#6500
 		cfg := config.New()
@@ -770,6 +806,44 @@ func TestFilteredByTags(t *testing.T) {
 	}
 }
 
+func TestFilteredByTagRules(t *testing.T) {
+	rule := func(typ, key, pattern, env, service string) *config.TagFilterRule {
+		r := &config.TagFilterRule{Type: typ, Key: key, Pattern: pattern, Env: env, Service: service}
+		if pattern != "" {
+			r.Re = regexp.MustCompile(pattern)
+		}
+		return r
+	}
+	root := &pb.Span{SpanID: 1, Service: "web", Meta: map[string]string{"env": "prod"}}
+	child := &pb.Span{SpanID: 2, ParentID: 1, Service: "db", Meta: map[string]string{"http.url": "http://localhost/health", "synthetics": "true"}}
+	chunk := testutil.TraceChunkWithSpans([]*pb.Span{root, child})
+
+	for name, tt := range map[string]struct {
+		rules []*config.TagFilterRule
+		env   string
+		drop  bool
+	}{
+		"no-rules":                 {drop: false},
+		"reject-child-regex":       {rules: []*config.TagFilterRule{rule("reject", "http.url", "/health$", "", "")}, drop: true},
+		"reject-child-no-match":    {rules: []*config.TagFilterRule{rule("reject", "http.url", "^/users", "", "")}, drop: false},
+		"reject-key-exists":        {rules: []*config.TagFilterRule{rule("reject", "synthetics", "", "", "")}, drop: true},
+		"reject-key-missing":       {rules: []*config.TagFilterRule{rule("reject", "missing", "", "", "")}, drop: false},
+		"require-child-regex":      {rules: []*config.TagFilterRule{rule("require", "http.url", "health", "", "")}, drop: false},
+		"require-missing":          {rules: []*config.TagFilterRule{rule("require", "missing", "", "", "")}, drop: true},
+		"reject-other-env":         {rules: []*config.TagFilterRule{rule("reject", "synthetics", "", "staging", "")}, drop: false},
+		"reject-span-env":          {rules: []*config.TagFilterRule{rule("reject", "synthetics", "", "prod", "")}, drop: true},
+		"reject-payload-env":       {rules: []*config.TagFilterRule{rule("reject", "synthetics", "", "staging", "")}, env: "staging", drop: true},
+		"reject-root-service":      {rules: []*config.TagFilterRule{rule("reject", "synthetics", "", "", "web")}, drop: true},
+		"reject-other-service":     {rules: []*config.TagFilterRule{rule("reject", "synthetics", "", "", "db")}, drop: false},
+		"require-other-service":    {rules: []*config.TagFilterRule{rule("require", "missing", "", "", "api")}, drop: false},
+		"require-and-reject-match": {rules: []*config.TagFilterRule{rule("require", "http.url", "", "", ""), rule("reject", "synthetics", "true", "", "")}, drop: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.drop, filteredByTagRules(chunk, root, tt.env, tt.rules))
+		})
+	}
+}
+
 func TestClientComputedStats(t *testing.T) {
 	cfg := config.New()
 	cfg.Endpoints[0].APIKey = "test"
@@ -938,13 +1012,97 @@ func TestSampling(t *testing.T) {
 			a := configureAgent(tt.agentConfig)
 			for _, tc := range tt.testCases {
 				_, hasPriority := sampler.GetSamplingPriority(tc.trace.TraceChunk)
-				sampled := a.runSamplers(time.Now(), tc.trace, hasPriority)
+				sampled := a.runSamplers(time.Now(), info.NewReceiverStats().GetTagStats(info.Tags{}), tc.trace, hasPriority)
 				assert.EqualValues(t, tc.wantSampled, sampled)
 			}
 		})
 	}
 }
 
+func TestLatencySampling(t *testing.T) {
+	cfg := &config.AgentConfig{LatencySamplerEnabled: true, LatencySamplerPercentile: 90, LatencySamplerTPS: 5}
+	a := &Agent{
+		NoPrioritySampler: sampler.NewNoPrioritySampler(cfg),
+		ErrorsSampler:     sampler.NewErrorsSampler(cfg),
+		PrioritySampler:   sampler.NewPrioritySampler(cfg, &sampler.DynamicConfig{}),
+		RareSampler:       sampler.NewRareSampler(config.New()),
+		LatencySampler:    sampler.NewLatencySampler(cfg),
+		conf:              cfg,
+	}
+	defer a.LatencySampler.Stop()
+	generateProcessedTrace := func(p sampler.SamplingPriority, duration time.Duration) traceutil.ProcessedTrace {
+		root := &pb.Span{
+			Service:  "serv1",
+			Resource: "GET /users",
+			Duration: duration.Nanoseconds(),
+			Metrics:  map[string]float64{"_top_level": 1},
+		}
+		pt := traceutil.ProcessedTrace{TraceChunk: testutil.TraceChunkWithSpan(root), Root: root}
+		pt.TraceChunk.Priority = int32(p)
+		return pt
+	}
+	ts := info.NewReceiverStats().GetTagStats(info.Tags{})
+	now := time.Now()
+	for i := 1; i <= 100; i++ {
+		assert.True(t, a.runSamplers(now, ts, generateProcessedTrace(sampler.PriorityAutoKeep, time.Duration(i)*time.Millisecond), true))
+	}
+	assert.EqualValues(t, 100, ts.TracesSampled.Priority.Load())
+
+	now = now.Add(time.Minute)
+	assert.False(t, a.runSamplers(now, ts, generateProcessedTrace(sampler.PriorityAutoDrop, 10*time.Millisecond), true))
+	assert.True(t, a.runSamplers(now, ts, generateProcessedTrace(sampler.PriorityAutoDrop, time.Second), true))
+	assert.True(t, a.runSamplers(now, ts, generateProcessedTrace(sampler.PriorityNone, time.Second), false))
+	assert.EqualValues(t, 2, ts.TracesSampled.Latency.Load())
+}
+
+func TestLatencySamplingErrorTraces(t *testing.T) {
+	cfg := &config.AgentConfig{LatencySamplerEnabled: true, LatencySamplerPercentile: 90, LatencySamplerTPS: 0.001, ErrorTPS: 1000}
+	a := &Agent{
+		NoPrioritySampler: sampler.NewNoPrioritySampler(cfg),
+		ErrorsSampler:     sampler.NewErrorsSampler(cfg),
+		PrioritySampler:   sampler.NewPrioritySampler(cfg, &sampler.DynamicConfig{}),
+		RareSampler:       sampler.NewRareSampler(config.New()),
+		LatencySampler:    sampler.NewLatencySampler(cfg),
+		conf:              cfg,
+	}
+	defer a.LatencySampler.Stop()
+	generateProcessedTrace := func(p sampler.SamplingPriority, duration time.Duration, isError bool) traceutil.ProcessedTrace {
+		root := &pb.Span{
+			Service:  "serv1",
+			Resource: "GET /users",
+			Duration: duration.Nanoseconds(),
+			Metrics:  map[string]float64{"_top_level": 1},
+		}
+		if isError {
+			root.Error = 1
+		}
+		pt := traceutil.ProcessedTrace{TraceChunk: testutil.TraceChunkWithSpan(root), Root: root}
+		pt.TraceChunk.Priority = int32(p)
+		return pt
+	}
+	ts := info.NewReceiverStats().GetTagStats(info.Tags{})
+	now := time.Now()
+	for i := 1; i <= 100; i++ {
+		a.runSamplers(now, ts, generateProcessedTrace(sampler.PriorityAutoKeep, time.Duration(i)*time.Millisecond, false), true)
+	}
+
+	// the slow error traces are kept by the ErrorsSampler, without using the latency budget
+	now = now.Add(time.Minute)
+	for i := 0; i < 100; i++ {
+		pt := generateProcessedTrace(sampler.PriorityAutoDrop, time.Second, true)
+		assert.True(t, a.runSamplers(now, ts, pt, true))
+		_, ok := pt.Root.Metrics["_dd.latency"]
+		assert.False(t, ok)
+	}
+	assert.EqualValues(t, 100, ts.TracesSampled.Error.Load())
+	assert.EqualValues(t, 0, ts.TracesSampled.Latency.Load())
+
+	pt := generateProcessedTrace(sampler.PriorityAutoDrop, time.Second, false)
+	assert.True(t, a.runSamplers(now, ts, pt, true))
+	assert.Equal(t, 1.0, pt.Root.Metrics["_dd.latency"])
+	assert.EqualValues(t, 1, ts.TracesSampled.Latency.Load())
+}
+
 func TestSample(t *testing.T) {
 	cfg := &config.AgentConfig{TargetTPS: 5, ErrorTPS: 10}
 	a := &Agent{
@@ -437,6 +437,29 @@ func decodeTracerPayload(v Version, req *http.Request, ts *info.TagStats, cIDPro
 			Chunks:          traceChunksFromTraces(traces),
 			TracerVersion:   ts.TracerVersion,
 		}, true, err
+	case zipkinV2, jaegerThrift:
+		buf := getBuffer()
+		defer putBuffer(buf)
+		if _, err = io.Copy(buf, req.Body); err != nil {
+			return nil, false, err
+		}
+		var chunks []*pb.TraceChunk
+		if v == zipkinV2 {
+			chunks, err = decodeZipkinSpans(getMediaType(req), buf.Bytes())
+		} else {
+			chunks, err = decodeJaegerBatch(buf.Bytes())
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		runMetaHook(chunks)
+		return &pb.TracerPayload{
+			LanguageName:    ts.Lang,
+			LanguageVersion: ts.LangVersion,
+			ContainerID:     cIDProvider.GetContainerID(req.Context(), req.Header),
+			Chunks:          chunks,
+			TracerVersion:   ts.TracerVersion,
+		}, true, nil
 	case V07:
 		buf := getBuffer()
 		defer putBuffer(buf)
@@ -466,7 +489,7 @@ func decodeTracerPayload(v Version, req *http.Request, ts *info.TagStats, cIDPro
 // was successful.
 func (r *HTTPReceiver) replyOK(req *http.Request, v Version, w http.ResponseWriter) (n uint64, ok bool) {
 	switch v {
-	case v01, v02, v03:
+	case v01, v02, v03, zipkinV2, jaegerThrift:
 		return httpOK(w)
 	default:
 		ratesVersion := req.Header.Get(headerRatesPayloadVersion)
@@ -833,6 +856,27 @@ func traceChunksFromTraces(traces pb.Traces) []*pb.TraceChunk {
 	return traceChunks
 }
 
+// traceChunksWithPriority groups the spans by trace ID, keeping the order in which the traces
+// are first seen. The traces are kept by default as they were already sampled by the tracer,
+// unless one of their spans sets a sampling priority.
+func traceChunksWithPriority(spans []*pb.Span) []*pb.TraceChunk {
+	var traceChunks []*pb.TraceChunk
+	byID := make(map[uint64]*pb.TraceChunk)
+	for _, s := range spans {
+		chunk, ok := byID[s.TraceID]
+		if !ok {
+			chunk = &pb.TraceChunk{Priority: int32(sampler.PriorityAutoKeep)}
+			byID[s.TraceID] = chunk
+			traceChunks = append(traceChunks, chunk)
+		}
+		if p, ok := s.Metrics["_sampling_priority_v1"]; ok {
+			chunk.Priority = int32(p)
+		}
+		chunk.Spans = append(chunk.Spans, s)
+	}
+	return traceChunks
+}
+
 // getContainerTag returns container and orchestrator tags belonging to containerID. If containerID
 // is empty or no tags are found, an empty string is returned.
 func getContainerTags(fn func(string) ([]string, error), containerID string) string {
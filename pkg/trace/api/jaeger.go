@@ -0,0 +1,491 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+)
+
+// Jaeger span flags, see https://www.jaegertracing.io/docs/latest/client-libraries/#tracespan-identity
+const (
+	jaegerFlagDebug = 2
+)
+
+// Jaeger tag value types (jaeger.thrift TagType).
+const (
+	jaegerTagString = iota
+	jaegerTagDouble
+	jaegerTagBool
+	jaegerTagLong
+	jaegerTagBinary
+)
+
+// jaegerTag is a key/value pair of the jaeger.thrift model, holding a value of type vType.
+type jaegerTag struct {
+	key     string
+	vType   int32
+	vStr    string
+	vDouble float64
+	vBool   bool
+	vLong   int64
+	vBinary []byte
+}
+
+// decodeJaegerBatch decodes a jaeger.thrift Batch encoded with the Thrift binary protocol,
+// and converts its spans to trace chunks.
+func decodeJaegerBatch(b []byte) ([]*pb.TraceChunk, error) {
+	r := &thriftReader{b: b}
+	var (
+		service     string
+		processTags []jaegerTag
+		spans       []*pb.Span
+	)
+	// the spans are converted once the process is known, the fields of a struct can be in any order
+	var rawSpans [][]byte
+	err := r.readStruct(func(id int16, typ byte) error {
+		switch {
+		case id == 1 && typ == thriftStruct:
+			return r.readStruct(func(id int16, typ byte) error {
+				var err error
+				switch {
+				case id == 1 && typ == thriftString:
+					service, err = r.readString()
+				case id == 2 && typ == thriftList:
+					processTags, err = r.readTags()
+				default:
+					err = r.skip(typ)
+				}
+				return err
+			})
+		case id == 2 && typ == thriftList:
+			return r.readList(thriftStruct, func() error {
+				start := r.off
+				if err := r.skip(thriftStruct); err != nil {
+					return err
+				}
+				rawSpans = append(rawSpans, r.b[start:r.off])
+				return nil
+			})
+		default:
+			return r.skip(typ)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, raw := range rawSpans {
+		span, err := decodeJaegerSpan(raw, service, processTags)
+		if err != nil {
+			return nil, err
+		}
+		spans = append(spans, span)
+	}
+	return traceChunksWithPriority(spans), nil
+}
+
+// decodeJaegerSpan decodes a jaeger.thrift Span and converts it to a Datadog span, adding
+// the service and the tags of the process that reported it.
+func decodeJaegerSpan(b []byte, service string, processTags []jaegerTag) (*pb.Span, error) {
+	r := &thriftReader{b: b}
+	var (
+		traceIDHigh int64
+		flags       int32
+		tags        []jaegerTag
+		logs        [][]jaegerTag
+	)
+	span := &pb.Span{
+		Service: service,
+		Meta:    make(map[string]string, len(processTags)),
+		Metrics: map[string]float64{},
+	}
+	err := r.readStruct(func(id int16, typ byte) error {
+		var (
+			v   int64
+			err error
+		)
+		switch {
+		case id == 1 && typ == thriftI64:
+			v, err = r.readI64()
+			span.TraceID = uint64(v)
+		case id == 2 && typ == thriftI64:
+			traceIDHigh, err = r.readI64()
+		case id == 3 && typ == thriftI64:
+			v, err = r.readI64()
+			span.SpanID = uint64(v)
+		case id == 4 && typ == thriftI64:
+			v, err = r.readI64()
+			span.ParentID = uint64(v)
+		case id == 5 && typ == thriftString:
+			span.Name, err = r.readString()
+		case id == 6 && typ == thriftList:
+			err = r.readList(thriftStruct, func() error {
+				return r.readJaegerSpanRef(span)
+			})
+		case id == 7 && typ == thriftI32:
+			flags, err = r.readI32()
+		case id == 8 && typ == thriftI64:
+			v, err = r.readI64()
+			span.Start = v * 1000
+		case id == 9 && typ == thriftI64:
+			v, err = r.readI64()
+			span.Duration = v * 1000
+		case id == 10 && typ == thriftList:
+			tags, err = r.readTags()
+		case id == 11 && typ == thriftList:
+			err = r.readList(thriftStruct, func() error {
+				return r.readStruct(func(id int16, typ byte) error {
+					if id != 2 || typ != thriftList {
+						return r.skip(typ)
+					}
+					fields, err := r.readTags()
+					logs = append(logs, fields)
+					return err
+				})
+			})
+		default:
+			err = r.skip(typ)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if traceIDHigh != 0 {
+		var traceID [16]byte
+		binary.BigEndian.PutUint64(traceID[:8], uint64(traceIDHigh))
+		binary.BigEndian.PutUint64(traceID[8:], span.TraceID)
+		span.Meta["jaeger.trace_id"] = hex.EncodeToString(traceID[:])
+	}
+	for _, t := range processTags {
+		t.setOn(span)
+	}
+	for _, t := range tags {
+		t.setOn(span)
+	}
+	if v, ok := span.Meta["error"]; ok && v == "true" {
+		span.Error = 1
+		setJaegerErrorFromLogs(span, logs)
+	}
+	if flags&jaegerFlagDebug != 0 {
+		span.Metrics["_sampling_priority_v1"] = float64(sampler.PriorityUserKeep)
+	}
+	finishForeignSpan(span, strings.ToLower(span.Meta["span.kind"]))
+	return span, nil
+}
+
+// readJaegerSpanRef reads a jaeger.thrift SpanRef, and sets the parent of span from it
+// when it is a CHILD_OF reference and the parent is not set yet.
+func (r *thriftReader) readJaegerSpanRef(span *pb.Span) error {
+	var (
+		refType int32
+		spanID  int64
+	)
+	err := r.readStruct(func(id int16, typ byte) error {
+		var err error
+		switch {
+		case id == 1 && typ == thriftI32:
+			refType, err = r.readI32()
+		case id == 4 && typ == thriftI64:
+			spanID, err = r.readI64()
+		default:
+			err = r.skip(typ)
+		}
+		return err
+	})
+	if err == nil && refType == 0 && span.ParentID == 0 {
+		span.ParentID = uint64(spanID)
+	}
+	return err
+}
+
+// setJaegerErrorFromLogs sets the error message, type and stack of span from the fields of its
+// error logs, following the OpenTracing semantic conventions.
+func setJaegerErrorFromLogs(span *pb.Span, logs [][]jaegerTag) {
+	for _, fields := range logs {
+		values := make(map[string]string, len(fields))
+		for _, f := range fields {
+			values[f.key] = f.String()
+		}
+		if values["event"] != "error" {
+			continue
+		}
+		if msg, ok := values["message"]; ok {
+			span.Meta["error.msg"] = msg
+		} else if obj, ok := values["error.object"]; ok {
+			span.Meta["error.msg"] = obj
+		}
+		setMetaIfNotEmpty(span, "error.type", values["error.kind"])
+		setMetaIfNotEmpty(span, "error.stack", values["stack"])
+	}
+}
+
+// setOn sets the tag on span, the numeric values being set as metrics.
+func (t jaegerTag) setOn(span *pb.Span) {
+	switch t.vType {
+	case jaegerTagDouble:
+		span.Metrics[t.key] = t.vDouble
+	case jaegerTagLong:
+		span.Metrics[t.key] = float64(t.vLong)
+	default:
+		span.Meta[t.key] = t.String()
+	}
+}
+
+// String returns the value of the tag as a string.
+func (t jaegerTag) String() string {
+	switch t.vType {
+	case jaegerTagDouble:
+		return strconv.FormatFloat(t.vDouble, 'f', -1, 64)
+	case jaegerTagBool:
+		return strconv.FormatBool(t.vBool)
+	case jaegerTagLong:
+		return strconv.FormatInt(t.vLong, 10)
+	case jaegerTagBinary:
+		return hex.EncodeToString(t.vBinary)
+	default:
+		return t.vStr
+	}
+}
+
+// readTags reads a list of jaeger.thrift Tag.
+func (r *thriftReader) readTags() ([]jaegerTag, error) {
+	var tags []jaegerTag
+	err := r.readList(thriftStruct, func() error {
+		var t jaegerTag
+		err := r.readStruct(func(id int16, typ byte) error {
+			var err error
+			switch {
+			case id == 1 && typ == thriftString:
+				t.key, err = r.readString()
+			case id == 2 && typ == thriftI32:
+				t.vType, err = r.readI32()
+			case id == 3 && typ == thriftString:
+				t.vStr, err = r.readString()
+			case id == 4 && typ == thriftDouble:
+				t.vDouble, err = r.readDouble()
+			case id == 5 && typ == thriftBool:
+				t.vBool, err = r.readBool()
+			case id == 6 && typ == thriftI64:
+				t.vLong, err = r.readI64()
+			case id == 7 && typ == thriftString:
+				var s string
+				s, err = r.readString()
+				t.vBinary = []byte(s)
+			default:
+				err = r.skip(typ)
+			}
+			return err
+		})
+		tags = append(tags, t)
+		return err
+	})
+	return tags, err
+}
+
+// Thrift binary protocol types.
+const (
+	thriftStop   byte = 0
+	thriftBool   byte = 2
+	thriftByte   byte = 3
+	thriftDouble byte = 4
+	thriftI16    byte = 6
+	thriftI32    byte = 8
+	thriftI64    byte = 10
+	thriftString byte = 11
+	thriftStruct byte = 12
+	thriftMap    byte = 13
+	thriftSet    byte = 14
+	thriftList   byte = 15
+)
+
+// thriftMaxDepth limits the nesting of the structures skipped by a thriftReader.
+const thriftMaxDepth = 64
+
+var errThriftShortBytes = errors.New("thrift: unexpected end of payload")
+
+// thriftReader reads the values encoded with the Thrift binary protocol in b.
+type thriftReader struct {
+	b     []byte
+	off   int
+	depth int
+}
+
+func (r *thriftReader) next(n int) ([]byte, error) {
+	if n < 0 || len(r.b)-r.off < n {
+		return nil, errThriftShortBytes
+	}
+	b := r.b[r.off : r.off+n]
+	r.off += n
+	return b, nil
+}
+
+func (r *thriftReader) readByte() (byte, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (r *thriftReader) readBool() (bool, error) {
+	b, err := r.readByte()
+	return b != 0, err
+}
+
+func (r *thriftReader) readI16() (int16, error) {
+	b, err := r.next(2)
+	if err != nil {
+		return 0, err
+	}
+	return int16(binary.BigEndian.Uint16(b)), nil
+}
+
+func (r *thriftReader) readI32() (int32, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.BigEndian.Uint32(b)), nil
+}
+
+func (r *thriftReader) readI64() (int64, error) {
+	b, err := r.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(b)), nil
+}
+
+func (r *thriftReader) readDouble() (float64, error) {
+	v, err := r.readI64()
+	return math.Float64frombits(uint64(v)), err
+}
+
+func (r *thriftReader) readString() (string, error) {
+	n, err := r.readI32()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.next(int(n))
+	return string(b), err
+}
+
+// readStruct calls fn with the id and the type of each field of a struct, fn must read or
+// skip the value of the field.
+func (r *thriftReader) readStruct(fn func(id int16, typ byte) error) error {
+	for {
+		typ, err := r.readByte()
+		if err != nil {
+			return err
+		}
+		if typ == thriftStop {
+			return nil
+		}
+		id, err := r.readI16()
+		if err != nil {
+			return err
+		}
+		if err := fn(id, typ); err != nil {
+			return err
+		}
+	}
+}
+
+// readList calls fn to read each element of a list of elements of type typ.
+func (r *thriftReader) readList(typ byte, fn func() error) error {
+	etyp, err := r.readByte()
+	if err != nil {
+		return err
+	}
+	n, err := r.readI32()
+	if err != nil {
+		return err
+	}
+	if etyp != typ {
+		return fmt.Errorf("thrift: unexpected list of type %d", etyp)
+	}
+	if n < 0 || int(n) > len(r.b)-r.off {
+		// each element takes at least a byte
+		return errThriftShortBytes
+	}
+	for i := int32(0); i < n; i++ {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skip skips a value of type typ.
+func (r *thriftReader) skip(typ byte) error {
+	r.depth++
+	defer func() { r.depth-- }()
+	if r.depth > thriftMaxDepth {
+		return errors.New("thrift: maximum depth exceeded")
+	}
+	var err error
+	switch typ {
+	case thriftBool, thriftByte:
+		_, err = r.next(1)
+	case thriftI16:
+		_, err = r.next(2)
+	case thriftI32:
+		_, err = r.next(4)
+	case thriftDouble, thriftI64:
+		_, err = r.next(8)
+	case thriftString:
+		_, err = r.readString()
+	case thriftStruct:
+		err = r.readStruct(func(_ int16, typ byte) error { return r.skip(typ) })
+	case thriftMap:
+		var ktyp, vtyp byte
+		var n int32
+		if ktyp, err = r.readByte(); err != nil {
+			return err
+		}
+		if vtyp, err = r.readByte(); err != nil {
+			return err
+		}
+		if n, err = r.readI32(); err != nil {
+			return err
+		}
+		if n < 0 || int(n) > len(r.b)-r.off {
+			return errThriftShortBytes
+		}
+		for i := int32(0); i < n && err == nil; i++ {
+			if err = r.skip(ktyp); err == nil {
+				err = r.skip(vtyp)
+			}
+		}
+	case thriftSet, thriftList:
+		var etyp byte
+		var n int32
+		if etyp, err = r.readByte(); err != nil {
+			return err
+		}
+		if n, err = r.readI32(); err != nil {
+			return err
+		}
+		if n < 0 || int(n) > len(r.b)-r.off {
+			return errThriftShortBytes
+		}
+		for i := int32(0); i < n && err == nil; i++ {
+			err = r.skip(etyp)
+		}
+	default:
+		err = fmt.Errorf("thrift: unknown type %d", typ)
+	}
+	return err
+}
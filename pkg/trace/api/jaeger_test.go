@@ -0,0 +1,216 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+)
+
+// thriftWriter encodes values with the Thrift binary protocol.
+type thriftWriter struct{ bytes.Buffer }
+
+func (w *thriftWriter) field(typ byte, id int16) {
+	w.WriteByte(typ)
+	binary.Write(w, binary.BigEndian, id) //nolint:errcheck
+}
+
+func (w *thriftWriter) stop() { w.WriteByte(thriftStop) }
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(thriftI32, id)
+	binary.Write(w, binary.BigEndian, v) //nolint:errcheck
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(thriftI64, id)
+	binary.Write(w, binary.BigEndian, v) //nolint:errcheck
+}
+
+func (w *thriftWriter) str(id int16, v string) {
+	w.field(thriftString, id)
+	binary.Write(w, binary.BigEndian, int32(len(v))) //nolint:errcheck
+	w.WriteString(v)
+}
+
+func (w *thriftWriter) list(id int16, typ byte, n int) {
+	w.field(thriftList, id)
+	w.WriteByte(typ)
+	binary.Write(w, binary.BigEndian, int32(n)) //nolint:errcheck
+}
+
+func (w *thriftWriter) tag(key string, v interface{}) {
+	w.str(1, key)
+	switch v := v.(type) {
+	case string:
+		w.i32(2, jaegerTagString)
+		w.str(3, v)
+	case float64:
+		w.i32(2, jaegerTagDouble)
+		w.field(thriftDouble, 4)
+		binary.Write(w, binary.BigEndian, math.Float64bits(v)) //nolint:errcheck
+	case bool:
+		w.i32(2, jaegerTagBool)
+		w.field(thriftBool, 5)
+		if v {
+			w.WriteByte(1)
+		} else {
+			w.WriteByte(0)
+		}
+	case int64:
+		w.i32(2, jaegerTagLong)
+		w.i64(6, v)
+	}
+	w.stop()
+}
+
+func (w *thriftWriter) tags(id int16, tags ...interface{}) {
+	w.list(id, thriftStruct, len(tags)/2)
+	for i := 0; i < len(tags); i += 2 {
+		w.tag(tags[i].(string), tags[i+1])
+	}
+}
+
+// jaegerTestBatch returns a Batch of a process "frontend" holding a server span
+// and a failed client span of the same trace, and a debug span of another trace.
+func jaegerTestBatch() []byte {
+	var w thriftWriter
+	// process
+	w.field(thriftStruct, 1)
+	w.str(1, "frontend")
+	w.tags(2, "hostname", "host-a", "deployment.environment", "prod")
+	w.stop()
+	// spans
+	w.list(2, thriftStruct, 3)
+	{
+		w.i64(1, 42)
+		w.i64(2, 1)
+		w.i64(3, 100)
+		w.i64(4, 0)
+		w.str(5, "get /users")
+		w.i32(7, 1)
+		w.i64(8, 1556604172355737)
+		w.i64(9, 1431)
+		w.tags(10, "span.kind", "server", "http.method", "GET", "http.route", "/users", "http.status_code", int64(200))
+		w.field(thriftMap, 12) // unknown field
+		w.WriteByte(thriftString)
+		w.WriteByte(thriftI32)
+		binary.Write(&w, binary.BigEndian, int32(0)) //nolint:errcheck
+		w.stop()
+	}
+	{
+		w.i64(1, 42)
+		w.i64(2, 1)
+		w.i64(3, 101)
+		w.str(5, "query")
+		w.list(6, thriftStruct, 1)
+		w.i32(1, 0)
+		w.i64(2, 42)
+		w.i64(3, 1)
+		w.i64(4, 100)
+		w.stop()
+		w.i64(8, 1556604172355800)
+		w.i64(9, 1000)
+		w.tags(10, "span.kind", "client", "db.system", "redis", "error", true, "ratio", 0.5)
+		w.list(11, thriftStruct, 1)
+		w.i64(1, 1556604172356000)
+		w.tags(2, "event", "error", "message", "connection refused", "error.kind", "net.OpError")
+		w.stop()
+		w.stop()
+	}
+	{
+		w.i64(1, 7)
+		w.i64(3, 1)
+		w.str(5, "job")
+		w.i32(7, 3)
+		w.stop()
+	}
+	w.i64(3, 1) // seqNo
+	w.stop()
+	return w.Bytes()
+}
+
+func TestDecodeJaegerBatch(t *testing.T) {
+	chunks, err := decodeJaegerBatch(jaegerTestBatch())
+	require.NoError(t, err)
+	require.Len(t, chunks, 2)
+
+	assert.Equal(t, int32(sampler.PriorityAutoKeep), chunks[0].Priority)
+	require.Len(t, chunks[0].Spans, 2)
+	server, client := chunks[0].Spans[0], chunks[0].Spans[1]
+	assert.Equal(t, uint64(42), server.TraceID)
+	assert.Equal(t, uint64(100), server.SpanID)
+	assert.Equal(t, uint64(0), server.ParentID)
+	assert.Equal(t, "frontend", server.Service)
+	assert.Equal(t, "get /users", server.Name)
+	assert.Equal(t, "GET /users", server.Resource)
+	assert.Equal(t, "web", server.Type)
+	assert.Equal(t, int64(1556604172355737000), server.Start)
+	assert.Equal(t, int64(1431000), server.Duration)
+	assert.Equal(t, int32(0), server.Error)
+	assert.Equal(t, "0000000000000001000000000000002a", server.Meta["jaeger.trace_id"])
+	assert.Equal(t, "host-a", server.Meta["hostname"])
+	assert.Equal(t, "prod", server.Meta["env"])
+	assert.Equal(t, 200.0, server.Metrics["http.status_code"])
+
+	assert.Equal(t, uint64(101), client.SpanID)
+	assert.Equal(t, uint64(100), client.ParentID)
+	assert.Equal(t, "query", client.Resource)
+	assert.Equal(t, "cache", client.Type)
+	assert.Equal(t, int32(1), client.Error)
+	assert.Equal(t, "connection refused", client.Meta["error.msg"])
+	assert.Equal(t, "net.OpError", client.Meta["error.type"])
+	assert.Equal(t, 0.5, client.Metrics["ratio"])
+
+	assert.Equal(t, int32(sampler.PriorityUserKeep), chunks[1].Priority)
+	require.Len(t, chunks[1].Spans, 1)
+	assert.Equal(t, uint64(7), chunks[1].Spans[0].TraceID)
+	assert.NotContains(t, chunks[1].Spans[0].Meta, "jaeger.trace_id")
+
+	t.Run("truncated", func(t *testing.T) {
+		b := jaegerTestBatch()
+		for _, n := range []int{0, 1, 10, len(b) / 2, len(b) - 1} {
+			_, err := decodeJaegerBatch(b[:n])
+			assert.Error(t, err, n)
+		}
+	})
+
+	t.Run("huge-list", func(t *testing.T) {
+		var w thriftWriter
+		w.list(2, thriftStruct, math.MaxInt32)
+		_, err := decodeJaegerBatch(w.Bytes())
+		assert.Equal(t, errThriftShortBytes, err)
+	})
+}
+
+func TestJaegerEndpoint(t *testing.T) {
+	r := newTestReceiverFromConfig(newTestReceiverConfig())
+	server := httptest.NewServer(r.handleWithVersion(jaegerThrift, r.handleTraces))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL, "application/x-thrift", bytes.NewReader(jaegerTestBatch()))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	select {
+	case p := <-r.out:
+		assert.Len(t, p.Chunks(), 2)
+		assert.Equal(t, string(jaegerThrift), p.Source.EndpointVersion)
+	case <-time.After(time.Second):
+		t.Fatal("no data received")
+	}
+}
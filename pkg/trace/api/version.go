@@ -81,4 +81,20 @@ const (
 	// Response: Service sampling rates.
 	//
 	V07 Version = "v0.7"
+
+	// zipkinV2
+	//
+	// Content-Type: application/json or application/x-protobuf
+	// Payload: Zipkin v2 list of spans, as JSON or as proto3 (zipkin.proto3.ListOfSpans).
+	// Response: OK.
+	//
+	zipkinV2 Version = "zipkin_v2"
+
+	// jaegerThrift
+	//
+	// Content-Type: application/x-thrift or application/vnd.apache.thrift.binary
+	// Payload: Jaeger Batch (jaeger.thrift) encoded with the Thrift binary protocol.
+	// Response: OK.
+	//
+	jaegerThrift Version = "jaeger_thrift"
 )
@@ -0,0 +1,314 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+// zipkinSpan is a span of the Zipkin v2 model, see https://zipkin.io/zipkin-api/#/default/post_spans.
+type zipkinSpan struct {
+	TraceID        string            `json:"traceId"`
+	ParentID       string            `json:"parentId"`
+	ID             string            `json:"id"`
+	Kind           string            `json:"kind"`
+	Name           string            `json:"name"`
+	Timestamp      uint64            `json:"timestamp"` // microseconds
+	Duration       uint64            `json:"duration"`  // microseconds
+	Debug          bool              `json:"debug"`
+	LocalEndpoint  *zipkinEndpoint   `json:"localEndpoint"`
+	RemoteEndpoint *zipkinEndpoint   `json:"remoteEndpoint"`
+	Tags           map[string]string `json:"tags"`
+}
+
+// zipkinEndpoint is the network context of a node in the service graph.
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+	IPv4        string `json:"ipv4"`
+	IPv6        string `json:"ipv6"`
+	Port        int    `json:"port"`
+}
+
+// decodeZipkinSpans decodes the Zipkin v2 spans in b, a JSON list or a proto3 ListOfSpans
+// depending on the media type, and converts them to trace chunks.
+func decodeZipkinSpans(mediaType string, b []byte) ([]*pb.TraceChunk, error) {
+	var (
+		spans []*zipkinSpan
+		err   error
+	)
+	switch mediaType {
+	case "application/x-protobuf":
+		spans, err = decodeZipkinProto(b)
+	default:
+		err = json.Unmarshal(b, &spans)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ddspans := make([]*pb.Span, 0, len(spans))
+	for _, s := range spans {
+		if s == nil {
+			continue
+		}
+		span, err := s.convert()
+		if err != nil {
+			return nil, err
+		}
+		ddspans = append(ddspans, span)
+	}
+	return traceChunksWithPriority(ddspans), nil
+}
+
+// convert converts the Zipkin span to a Datadog span.
+func (s *zipkinSpan) convert() (*pb.Span, error) {
+	traceIDHigh, traceID, err := parseHexTraceID(s.TraceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid traceId %q: %v", s.TraceID, err)
+	}
+	spanID, err := strconv.ParseUint(s.ID, 16, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id %q: %v", s.ID, err)
+	}
+	var parentID uint64
+	if s.ParentID != "" {
+		if parentID, err = strconv.ParseUint(s.ParentID, 16, 64); err != nil {
+			return nil, fmt.Errorf("invalid parentId %q: %v", s.ParentID, err)
+		}
+	}
+	span := &pb.Span{
+		TraceID:  traceID,
+		SpanID:   spanID,
+		ParentID: parentID,
+		Name:     s.Name,
+		Start:    int64(s.Timestamp) * 1000,
+		Duration: int64(s.Duration) * 1000,
+		Meta:     make(map[string]string, len(s.Tags)+2),
+		Metrics:  map[string]float64{},
+	}
+	if traceIDHigh != 0 {
+		span.Meta["zipkin.trace_id"] = s.TraceID
+	}
+	if s.LocalEndpoint != nil {
+		span.Service = s.LocalEndpoint.ServiceName
+	}
+	if e := s.RemoteEndpoint; e != nil {
+		setMetaIfNotEmpty(span, "peer.service", e.ServiceName)
+		setMetaIfNotEmpty(span, "peer.ipv4", e.IPv4)
+		setMetaIfNotEmpty(span, "peer.ipv6", e.IPv6)
+		if e.Port != 0 {
+			span.Meta["peer.port"] = strconv.Itoa(e.Port)
+		}
+	}
+	for k, v := range s.Tags {
+		span.Meta[k] = v
+	}
+	if msg, ok := s.Tags["error"]; ok {
+		// Zipkin sets the error tag on failed spans, holding the error message when known
+		span.Error = 1
+		if msg != "" && msg != "true" {
+			span.Meta["error.msg"] = msg
+		}
+	}
+	if s.Debug {
+		span.Metrics["_sampling_priority_v1"] = float64(sampler.PriorityUserKeep)
+	}
+	kind := strings.ToLower(s.Kind)
+	setMetaIfNotEmpty(span, "span.kind", kind)
+	finishForeignSpan(span, kind)
+	return span, nil
+}
+
+// decodeZipkinProto decodes a zipkin.proto3.ListOfSpans message.
+func decodeZipkinProto(b []byte) ([]*zipkinSpan, error) {
+	var spans []*zipkinSpan
+	err := decodeProtoFields(b, func(num protowire.Number, _ uint64, data []byte) error {
+		if num != 1 {
+			return nil
+		}
+		s, err := decodeZipkinProtoSpan(data)
+		if err != nil {
+			return err
+		}
+		spans = append(spans, s)
+		return nil
+	})
+	return spans, err
+}
+
+// decodeZipkinProtoSpan decodes a zipkin.proto3.Span message, the IDs are hex encoded
+// to share the conversion of the JSON spans.
+func decodeZipkinProtoSpan(b []byte) (*zipkinSpan, error) {
+	s := &zipkinSpan{}
+	err := decodeProtoFields(b, func(num protowire.Number, v uint64, data []byte) error {
+		var err error
+		switch num {
+		case 1:
+			s.TraceID = hex.EncodeToString(data)
+		case 2:
+			s.ParentID = hex.EncodeToString(data)
+		case 3:
+			s.ID = hex.EncodeToString(data)
+		case 4:
+			s.Kind = zipkinProtoKinds[v]
+		case 5:
+			s.Name = string(data)
+		case 6:
+			s.Timestamp = v
+		case 7:
+			s.Duration = v
+		case 8:
+			s.LocalEndpoint, err = decodeZipkinProtoEndpoint(data)
+		case 9:
+			s.RemoteEndpoint, err = decodeZipkinProtoEndpoint(data)
+		case 11:
+			var k, val string
+			err = decodeProtoFields(data, func(num protowire.Number, _ uint64, data []byte) error {
+				switch num {
+				case 1:
+					k = string(data)
+				case 2:
+					val = string(data)
+				}
+				return nil
+			})
+			if s.Tags == nil {
+				s.Tags = make(map[string]string)
+			}
+			s.Tags[k] = val
+		case 12:
+			s.Debug = v != 0
+		}
+		return err
+	})
+	return s, err
+}
+
+// decodeZipkinProtoEndpoint decodes a zipkin.proto3.Endpoint message.
+func decodeZipkinProtoEndpoint(b []byte) (*zipkinEndpoint, error) {
+	e := &zipkinEndpoint{}
+	err := decodeProtoFields(b, func(num protowire.Number, v uint64, data []byte) error {
+		switch num {
+		case 1:
+			e.ServiceName = string(data)
+		case 2:
+			e.IPv4 = net.IP(data).String()
+		case 3:
+			e.IPv6 = net.IP(data).String()
+		case 4:
+			e.Port = int(int32(v))
+		}
+		return nil
+	})
+	return e, err
+}
+
+// zipkinProtoKinds maps the values of the zipkin.proto3.Span.Kind enum to their JSON names.
+var zipkinProtoKinds = map[uint64]string{
+	1: "CLIENT",
+	2: "SERVER",
+	3: "PRODUCER",
+	4: "CONSUMER",
+}
+
+// decodeProtoFields calls fn with each field of the protobuf message b, with the value of
+// the varint and fixed size fields in v, and the content of the length-delimited fields in data.
+func decodeProtoFields(b []byte, fn func(num protowire.Number, v uint64, data []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		var (
+			v    uint64
+			v32  uint32
+			data []byte
+		)
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			v, n = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			v32, n = protowire.ConsumeFixed32(b)
+			v = uint64(v32)
+		case protowire.BytesType:
+			data, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if err := fn(num, v, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseHexTraceID parses a 64 or 128 bits hex encoded trace ID, and returns its higher
+// and lower 64 bits.
+func parseHexTraceID(s string) (high, low uint64, err error) {
+	if len(s) > 16 {
+		if high, err = strconv.ParseUint(s[:len(s)-16], 16, 64); err != nil {
+			return 0, 0, err
+		}
+		s = s[len(s)-16:]
+	}
+	low, err = strconv.ParseUint(s, 16, 64)
+	return high, low, err
+}
+
+// setMetaIfNotEmpty sets the meta k of span to v when v is not empty.
+func setMetaIfNotEmpty(span *pb.Span, k, v string) {
+	if v != "" {
+		span.Meta[k] = v
+	}
+}
+
+// foreignSpanKinds maps the span kinds used by Zipkin and Jaeger to the OpenTelemetry ones.
+var foreignSpanKinds = map[string]ptrace.SpanKind{
+	"client":   ptrace.SpanKindClient,
+	"server":   ptrace.SpanKindServer,
+	"producer": ptrace.SpanKindProducer,
+	"consumer": ptrace.SpanKindConsumer,
+}
+
+// finishForeignSpan sets the fields of a span converted from Zipkin or Jaeger that are
+// deduced from its tags: the env, the sampling priority, the resource and the type.
+func finishForeignSpan(span *pb.Span, kind string) {
+	if _, ok := span.Meta["env"]; !ok {
+		setMetaIfNotEmpty(span, "env", traceutil.NormalizeTag(span.Meta["deployment.environment"]))
+	}
+	if v, ok := span.Meta["sampling.priority"]; ok {
+		if p, err := strconv.ParseFloat(v, 64); err == nil {
+			span.Metrics["_sampling_priority_v1"] = p
+		}
+	}
+	if p, ok := span.Metrics["sampling.priority"]; ok {
+		span.Metrics["_sampling_priority_v1"] = p
+	}
+	if r := resourceFromTags(span.Meta); r != "" {
+		span.Resource = r
+	} else {
+		span.Resource = span.Name
+	}
+	span.Type = spanKind2Type(foreignSpanKinds[kind], span)
+}
@@ -0,0 +1,207 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+)
+
+const zipkinTestJSON = `[
+	{
+		"traceId": "5af7183fb1d4cf5f8c7b1e6e2fa2a3b4",
+		"id": "352bff9a74ca9ad2",
+		"kind": "SERVER",
+		"name": "get /users",
+		"timestamp": 1556604172355737,
+		"duration": 1431,
+		"localEndpoint": {"serviceName": "frontend", "ipv4": "192.168.99.1"},
+		"tags": {"http.method": "GET", "http.route": "/users", "deployment.environment": "Staging"}
+	},
+	{
+		"traceId": "5af7183fb1d4cf5f8c7b1e6e2fa2a3b4",
+		"parentId": "352bff9a74ca9ad2",
+		"id": "6b221d5bc9e6496c",
+		"kind": "CLIENT",
+		"name": "query",
+		"timestamp": 1556604172355800,
+		"duration": 1000,
+		"localEndpoint": {"serviceName": "frontend"},
+		"remoteEndpoint": {"serviceName": "users-db", "ipv4": "10.0.0.2", "port": 5432},
+		"tags": {"db.system": "postgresql", "error": "connection refused"}
+	},
+	{
+		"traceId": "00000000000000ff",
+		"id": "0000000000000001",
+		"name": "job",
+		"debug": true,
+		"localEndpoint": {"serviceName": "worker"}
+	}
+]`
+
+func TestDecodeZipkinSpans(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		chunks, err := decodeZipkinSpans("application/json", []byte(zipkinTestJSON))
+		require.NoError(t, err)
+		require.Len(t, chunks, 2)
+
+		assert.Equal(t, int32(sampler.PriorityAutoKeep), chunks[0].Priority)
+		require.Len(t, chunks[0].Spans, 2)
+		server, client := chunks[0].Spans[0], chunks[0].Spans[1]
+		assert.Equal(t, uint64(0x8c7b1e6e2fa2a3b4), server.TraceID)
+		assert.Equal(t, uint64(0x352bff9a74ca9ad2), server.SpanID)
+		assert.Equal(t, uint64(0), server.ParentID)
+		assert.Equal(t, "frontend", server.Service)
+		assert.Equal(t, "get /users", server.Name)
+		assert.Equal(t, "GET /users", server.Resource)
+		assert.Equal(t, "web", server.Type)
+		assert.Equal(t, int64(1556604172355737000), server.Start)
+		assert.Equal(t, int64(1431000), server.Duration)
+		assert.Equal(t, int32(0), server.Error)
+		assert.Equal(t, "5af7183fb1d4cf5f8c7b1e6e2fa2a3b4", server.Meta["zipkin.trace_id"])
+		assert.Equal(t, "server", server.Meta["span.kind"])
+		assert.Equal(t, "staging", server.Meta["env"])
+
+		assert.Equal(t, server.TraceID, client.TraceID)
+		assert.Equal(t, server.SpanID, client.ParentID)
+		assert.Equal(t, "query", client.Resource)
+		assert.Equal(t, "db", client.Type)
+		assert.Equal(t, int32(1), client.Error)
+		assert.Equal(t, "connection refused", client.Meta["error.msg"])
+		assert.Equal(t, "users-db", client.Meta["peer.service"])
+		assert.Equal(t, "10.0.0.2", client.Meta["peer.ipv4"])
+		assert.Equal(t, "5432", client.Meta["peer.port"])
+
+		assert.Equal(t, int32(sampler.PriorityUserKeep), chunks[1].Priority)
+		require.Len(t, chunks[1].Spans, 1)
+		job := chunks[1].Spans[0]
+		assert.Equal(t, uint64(0xff), job.TraceID)
+		assert.Equal(t, "custom", job.Type)
+		assert.NotContains(t, job.Meta, "zipkin.trace_id")
+	})
+
+	t.Run("proto", func(t *testing.T) {
+		var endpoint []byte
+		endpoint = protowire.AppendTag(endpoint, 1, protowire.BytesType)
+		endpoint = protowire.AppendString(endpoint, "frontend")
+		endpoint = protowire.AppendTag(endpoint, 2, protowire.BytesType)
+		endpoint = protowire.AppendBytes(endpoint, []byte{192, 168, 99, 1})
+
+		var tag []byte
+		tag = protowire.AppendTag(tag, 1, protowire.BytesType)
+		tag = protowire.AppendString(tag, "error")
+		tag = protowire.AppendTag(tag, 2, protowire.BytesType)
+		tag = protowire.AppendString(tag, "true")
+
+		var span []byte
+		span = protowire.AppendTag(span, 1, protowire.BytesType)
+		span = protowire.AppendBytes(span, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 42})
+		span = protowire.AppendTag(span, 2, protowire.BytesType)
+		span = protowire.AppendBytes(span, []byte{0, 0, 0, 0, 0, 0, 0, 7})
+		span = protowire.AppendTag(span, 3, protowire.BytesType)
+		span = protowire.AppendBytes(span, []byte{0, 0, 0, 0, 0, 0, 0, 8})
+		span = protowire.AppendTag(span, 4, protowire.VarintType)
+		span = protowire.AppendVarint(span, 1)
+		span = protowire.AppendTag(span, 5, protowire.BytesType)
+		span = protowire.AppendString(span, "call")
+		span = protowire.AppendTag(span, 6, protowire.Fixed64Type)
+		span = protowire.AppendFixed64(span, 1000)
+		span = protowire.AppendTag(span, 7, protowire.VarintType)
+		span = protowire.AppendVarint(span, 20)
+		span = protowire.AppendTag(span, 8, protowire.BytesType)
+		span = protowire.AppendBytes(span, endpoint)
+		span = protowire.AppendTag(span, 11, protowire.BytesType)
+		span = protowire.AppendBytes(span, tag)
+		span = protowire.AppendTag(span, 14, protowire.VarintType) // unknown field
+		span = protowire.AppendVarint(span, 1)
+
+		var list []byte
+		list = protowire.AppendTag(list, 1, protowire.BytesType)
+		list = protowire.AppendBytes(list, span)
+
+		chunks, err := decodeZipkinSpans("application/x-protobuf", list)
+		require.NoError(t, err)
+		require.Len(t, chunks, 1)
+		require.Len(t, chunks[0].Spans, 1)
+		s := chunks[0].Spans[0]
+		assert.Equal(t, uint64(42), s.TraceID)
+		assert.Equal(t, uint64(8), s.SpanID)
+		assert.Equal(t, uint64(7), s.ParentID)
+		assert.Equal(t, "frontend", s.Service)
+		assert.Equal(t, "call", s.Name)
+		assert.Equal(t, "http", s.Type)
+		assert.Equal(t, int64(1000000), s.Start)
+		assert.Equal(t, int64(20000), s.Duration)
+		assert.Equal(t, int32(1), s.Error)
+		assert.NotContains(t, s.Meta, "error.msg")
+		assert.NotContains(t, s.Meta, "zipkin.trace_id")
+		assert.Equal(t, "client", s.Meta["span.kind"])
+
+		_, err = decodeZipkinSpans("application/x-protobuf", list[:len(list)-3])
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, payload := range []string{
+			`{"traceId": "1", "id": "2"}`,
+			`[{"traceId": "xyz", "id": "2"}]`,
+			`[{"traceId": "1", "id": ""}]`,
+			`[{"traceId": "1", "id": "2", "parentId": "not hex"}]`,
+		} {
+			_, err := decodeZipkinSpans("application/json", []byte(payload))
+			assert.Error(t, err, payload)
+		}
+	})
+}
+
+func TestZipkinEndpoint(t *testing.T) {
+	r := newTestReceiverFromConfig(newTestReceiverConfig())
+	server := httptest.NewServer(r.handleWithVersion(zipkinV2, r.handleTraces))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL, "application/json", bytes.NewBufferString(zipkinTestJSON))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	select {
+	case p := <-r.out:
+		assert.Len(t, p.Chunks(), 2)
+		assert.Equal(t, int64(2), p.Source.TracesReceived.Load())
+		assert.Equal(t, string(zipkinV2), p.Source.EndpointVersion)
+	case <-time.After(time.Second):
+		t.Fatal("no data received")
+	}
+
+	resp, err = http.Post(server.URL, "application/json", bytes.NewBufferString(`[{"traceId": "xyz"}]`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestTraceChunksWithPriority(t *testing.T) {
+	spans := []*pb.Span{
+		{TraceID: 1, SpanID: 1},
+		{TraceID: 2, SpanID: 2, Metrics: map[string]float64{"_sampling_priority_v1": -1}},
+		{TraceID: 1, SpanID: 3},
+	}
+	chunks := traceChunksWithPriority(spans)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, []*pb.Span{spans[0], spans[2]}, chunks[0].Spans)
+	assert.Equal(t, int32(sampler.PriorityAutoKeep), chunks[0].Priority)
+	assert.Equal(t, []*pb.Span{spans[1]}, chunks[1].Spans)
+	assert.Equal(t, int32(sampler.PriorityUserDrop), chunks[1].Priority)
+}
@@ -233,6 +233,39 @@ type WriterConfig struct {
 	FlushPeriodSeconds float64 `mapstructure:"flush_period_seconds"`
 }
 
+// File export formats.
+const (
+	// FileExportJSON writes each payload as a line of JSON.
+	FileExportJSON = "json"
+	// FileExportProtobuf writes each payload as a protobuf message prefixed by its varint encoded size.
+	FileExportProtobuf = "protobuf"
+)
+
+// FileExportConfig specifies the export of the payloads flushed by the writers to local files.
+type FileExportConfig struct {
+	// Enabled reports whether the payloads are written to files.
+	Enabled bool
+
+	// Path specifies the directory in which the files are written.
+	Path string
+
+	// Format specifies the encoding of the payloads, FileExportJSON or FileExportProtobuf.
+	Format string
+
+	// MaxFileSize specifies the size in bytes above which a file is rotated.
+	MaxFileSize int64
+
+	// MaxFileAge specifies the duration after which a file is rotated.
+	MaxFileAge time.Duration
+
+	// MaxFiles specifies the number of files kept for each kind of payload, the oldest
+	// ones being removed.
+	MaxFiles int
+
+	// SendToIntake reports whether the payloads are still sent to the intake.
+	SendToIntake bool
+}
+
 // FargateOrchestratorName is a Fargate orchestrator name.
 type FargateOrchestratorName string
 
@@ -305,6 +338,7 @@ type AgentConfig struct {
 	// Concentrator
 	BucketInterval   time.Duration // the size of our pre-aggregation per bucket
 	ExtraAggregators []string
+	PeerTags         []string // span tags used as additional stats dimensions of client and producer spans
 
 	// Sampler configuration
 	ExtraSampleRate float64
@@ -319,6 +353,11 @@ type AgentConfig struct {
 	RareSamplerCooldownPeriod time.Duration
 	RareSamplerCardinality    int
 
+	// Latency Sampler configuration
+	LatencySamplerEnabled    bool
+	LatencySamplerPercentile float64
+	LatencySamplerTPS        float64
+
 	// Receiver
 	ReceiverHost    string
 	ReceiverPort    int
@@ -338,6 +377,8 @@ type AgentConfig struct {
 	StatsWriter             *WriterConfig
 	TraceWriter             *WriterConfig
 	ConnectionResetInterval time.Duration // frequency at which outgoing connections are reset. 0 means no reset is performed
+	// FileExport specifies the export of the payloads flushed by the writers to local files.
+	FileExport *FileExportConfig
 
 	// internal telemetry
 	StatsdEnabled  bool
@@ -385,6 +426,9 @@ type AgentConfig struct {
 	// RejectTags specifies a list of tags which must be absent on the root span in order for a trace to be accepted.
 	RejectTags []*Tag
 
+	// TagFilterRules specifies rules filtering traces based on the tags of any of their spans.
+	TagFilterRules []*TagFilterRule
+
 	// OTLPReceiver holds the configuration for OpenTelemetry receiver.
 	OTLPReceiver *OTLP
 
@@ -431,6 +475,36 @@ type Tag struct {
 	K, V string
 }
 
+// Tag filter rule types
+const (
+	TagFilterRequire = "require"
+	TagFilterReject  = "reject"
+)
+
+// TagFilterRule specifies a rule filtering traces based on the tags of any of their spans.
+// Traces without any span matching a "require" rule, or with a span matching a "reject"
+// rule, are dropped.
+type TagFilterRule struct {
+	// Type specifies whether matching spans are required ("require") or rejected ("reject").
+	Type string `mapstructure:"type"`
+
+	// Key specifies the tag key the rule addresses.
+	Key string `mapstructure:"key"`
+
+	// Pattern specifies the regexp pattern the tag value must match. When empty,
+	// the rule only requires the tag key to exist.
+	Pattern string `mapstructure:"pattern"`
+
+	// Re holds the compiled Pattern and is only used internally.
+	Re *regexp.Regexp `mapstructure:"-"`
+
+	// Env restricts the rule to the traces of this env, when set.
+	Env string `mapstructure:"env"`
+
+	// Service restricts the rule to the traces whose root span has this service, when set.
+	Service string `mapstructure:"service"`
+}
+
 // New returns a configuration with the default values.
 func New() *AgentConfig {
 	return &AgentConfig{
@@ -454,6 +528,10 @@ func New() *AgentConfig {
 		RareSamplerCooldownPeriod: 5 * time.Minute,
 		RareSamplerCardinality:    200,
 
+		LatencySamplerEnabled:    false,
+		LatencySamplerPercentile: 99,
+		LatencySamplerTPS:        5,
+
 		ReceiverHost:           "localhost",
 		ReceiverPort:           8126,
 		MaxRequestBytes:        50 * 1024 * 1024, // 50MB
@@ -464,6 +542,12 @@ func New() *AgentConfig {
 		StatsWriter:             new(WriterConfig),
 		TraceWriter:             new(WriterConfig),
 		ConnectionResetInterval: 0, // disabled
+		FileExport: &FileExportConfig{
+			Format:      FileExportJSON,
+			MaxFileSize: 100 * 1024 * 1024,
+			MaxFileAge:  time.Hour,
+			MaxFiles:    10,
+		},
 
 		StatsdHost:    "localhost",
 		StatsdPort:    8125,
@@ -430,6 +430,13 @@ func TestPublishReceiverStats(t *testing.T) {
 					maxAbsPriority + 4: atom(5),
 				},
 			},
+			TracesSampled: TracesSampled{
+				atom(6),
+				atom(7),
+				atom(8),
+				atom(9),
+				atom(10),
+			},
 			ClientDroppedP0Traces: atom(7),
 			ClientDroppedP0Spans:  atom(8),
 			TracesBytes:           atom(9),
@@ -489,6 +496,13 @@ func TestPublishReceiverStats(t *testing.T) {
 			"TracesPerSamplingPriority": map[string]interface{}{},
 			"TracesPriorityNone":        5.0,
 			"TracesReceived":            1.0,
+			"TracesSampled": map[string]interface{}{
+				"Priority":   6.0,
+				"NoPriority": 7.0,
+				"Error":      8.0,
+				"Rare":       9.0,
+				"Latency":    10.0,
+			},
 		}})
 }
 
@@ -171,6 +171,14 @@ func (ts *TagStats) publishAndReset() {
 				count, append(tags, "priority:"+priority), 1)
 		}
 	}
+
+	for reason, counter := range ts.TracesSampled.tagCounters() {
+		count := counter.Swap(0)
+		if count > 0 {
+			metrics.Count("datadog.trace_agent.receiver.traces_sampled",
+				count, append(tags, "reason:"+reason), 1)
+		}
+	}
 }
 
 // mapToString serializes the entries in this map into format "key1: value1, key2: value2, ...", sorted by
@@ -304,6 +312,55 @@ func (s *SpansMalformed) String() string {
 	return mapToString(s.tagValues())
 }
 
+// TracesSampled contains counts for the reasons traces have been sampled
+type TracesSampled struct {
+	// all atomic values are included as values in this struct, to simplify umarshaling and
+	// initialization of the type.
+
+	// Priority is when the trace is sampled by the priority sampler
+	Priority atomic.Int64
+	// NoPriority is when the trace without priority is sampled by the no priority sampler
+	NoPriority atomic.Int64
+	// Error is when the trace is sampled by the errors sampler
+	Error atomic.Int64
+	// Rare is when the trace is sampled by the rare sampler
+	Rare atomic.Int64
+	// Latency is when the trace is sampled by the latency sampler
+	Latency atomic.Int64
+}
+
+func (s *TracesSampled) tagCounters() map[string]*atomic.Int64 {
+	return map[string]*atomic.Int64{
+		"priority":    &s.Priority,
+		"no_priority": &s.NoPriority,
+		"error":       &s.Error,
+		"rare":        &s.Rare,
+		"latency":     &s.Latency,
+	}
+}
+
+// tagValues converts TracesSampled into a map representation with keys matching standardized names for all reasons
+func (s *TracesSampled) tagValues() map[string]int64 {
+	v := make(map[string]int64)
+	for tag, counter := range s.tagCounters() {
+		v[tag] = counter.Load()
+	}
+	return v
+}
+
+func (s *TracesSampled) String() string {
+	return mapToString(s.tagValues())
+}
+
+// update absorbs recent stats on top of existing ones.
+func (s *TracesSampled) update(recent *TracesSampled) {
+	s.Priority.Add(recent.Priority.Load())
+	s.NoPriority.Add(recent.NoPriority.Load())
+	s.Error.Add(recent.Error.Load())
+	s.Rare.Add(recent.Rare.Load())
+	s.Latency.Add(recent.Latency.Load())
+}
+
 // maxAbsPriority specifies the absolute maximum priority for stats purposes. For example, with a value
 // of 10, the range of priorities reported will be [-10, 10].
 const maxAbsPriority = 10
@@ -372,6 +429,8 @@ type Stats struct {
 	TracesPriorityNone atomic.Int64
 	// TracesPerPriority holds counters for each priority in position MaxAbsPriorityValue + priority.
 	TracesPerSamplingPriority samplingPriorityStats
+	// TracesSampled contains stats about the count of sampled traces by reason
+	TracesSampled TracesSampled
 	// ClientDroppedP0Traces number of P0 traces dropped by client.
 	ClientDroppedP0Traces atomic.Int64
 	// ClientDroppedP0Spans number of P0 spans dropped by client.
@@ -441,6 +500,7 @@ func (s *Stats) update(recent *Stats) {
 	s.PayloadAccepted.Add(recent.PayloadAccepted.Load())
 	s.PayloadRefused.Add(recent.PayloadRefused.Load())
 	s.TracesPerSamplingPriority.update(&recent.TracesPerSamplingPriority)
+	s.TracesSampled.update(&recent.TracesSampled)
 }
 
 func (s *Stats) isEmpty() bool {
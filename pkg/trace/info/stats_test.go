@@ -77,6 +77,26 @@ func TestSpansMalformed(t *testing.T) {
 	})
 }
 
+func TestTracesSampled(t *testing.T) {
+	s := TracesSampled{}
+	s.Priority.Store(3)
+	s.Latency.Store(1)
+
+	t.Run("tagValues", func(t *testing.T) {
+		assert.Equal(t, map[string]int64{
+			"priority":    3,
+			"no_priority": 0,
+			"error":       0,
+			"rare":        0,
+			"latency":     1,
+		}, s.tagValues())
+	})
+
+	t.Run("String", func(t *testing.T) {
+		assert.Equal(t, "latency:1, priority:3", s.String())
+	})
+}
+
 func TestStatsTags(t *testing.T) {
 	assert.Equal(t, (&Tags{
 		Lang:            "go",
@@ -215,6 +235,11 @@ func TestReceiverStats(t *testing.T) {
 		stats.TracesPerSamplingPriority.counts[maxAbsPriority+2].Store(3)
 		stats.TracesPerSamplingPriority.counts[maxAbsPriority+3].Store(4)
 		stats.TracesPerSamplingPriority.counts[maxAbsPriority+4].Store(5)
+		stats.TracesSampled.Priority.Store(1)
+		stats.TracesSampled.NoPriority.Store(2)
+		stats.TracesSampled.Error.Store(3)
+		stats.TracesSampled.Rare.Store(4)
+		stats.TracesSampled.Latency.Store(5)
 		stats.ClientDroppedP0Traces.Store(7)
 		stats.ClientDroppedP0Spans.Store(8)
 		stats.TracesBytes.Store(9)
@@ -260,7 +285,7 @@ func TestReceiverStats(t *testing.T) {
 	t.Run("PublishAndReset", func(t *testing.T) {
 		rs := testStats()
 		rs.PublishAndReset()
-		assert.EqualValues(t, 39, statsclient.counts.Load())
+		assert.EqualValues(t, 44, statsclient.counts.Load())
 		assertStatsAreReset(t, rs)
 	})
 
@@ -0,0 +1,217 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sampler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/sketches-go/ddsketch"
+	"go.uber.org/atomic"
+	"golang.org/x/time/rate"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+const (
+	// latencySamplerBurst sizes the token store used by the rate limiter.
+	latencySamplerBurst = 50
+	// latencyWindow is the minimum period over which the durations of a signature
+	// are accumulated before its threshold is updated.
+	latencyWindow = time.Minute
+	// latencyMinCount is the minimum number of durations accumulated for a signature
+	// before its threshold is updated, low traffic signatures have longer windows.
+	latencyMinCount = 100
+	// latencyMaxSignatures limits the number of signatures tracked, and the memory usage.
+	// Once it is reached, the signatures that got no trace during a latencyWindow are evicted.
+	latencyMaxSignatures = 5000
+	// latencyRelativeAccuracy and latencyMaxBins size the sketches of the durations.
+	latencyRelativeAccuracy = 0.01
+	latencyMaxBins          = 1024
+	latencyKey              = "_dd.latency"
+)
+
+// LatencySampler samples the slowest traces, whatever their priority.
+// It keeps a rolling distribution of the root span durations for each combination
+// of (env, service, resource), and samples the traces above a percentile of this
+// distribution that were not sampled by the other samplers, within a TPS budget.
+// The resulting sampled traces are flagged with a latencyKey metric set at 1.
+type LatencySampler struct {
+	hits   *atomic.Int64
+	misses *atomic.Int64
+	// untracked counts the traces whose signature could not be tracked
+	// because latencyMaxSignatures was reached.
+	untracked *atomic.Int64
+	mu        sync.RWMutex
+
+	tickStats     *time.Ticker
+	limiter       *rate.Limiter
+	quantile      float64
+	distributions map[Signature]*latencyDistribution
+	lastEviction  time.Time
+}
+
+// NewLatencySampler returns a LatencySampler sampling the traces above the
+// configured percentile of their signature durations.
+func NewLatencySampler(conf *config.AgentConfig) *LatencySampler {
+	s := &LatencySampler{
+		hits:          atomic.NewInt64(0),
+		misses:        atomic.NewInt64(0),
+		untracked:     atomic.NewInt64(0),
+		limiter:       rate.NewLimiter(rate.Limit(conf.LatencySamplerTPS), latencySamplerBurst),
+		quantile:      conf.LatencySamplerPercentile / 100,
+		distributions: make(map[Signature]*latencyDistribution),
+		tickStats:     time.NewTicker(10 * time.Second),
+	}
+	go func() {
+		for range s.tickStats.C {
+			s.report()
+		}
+	}()
+	return s
+}
+
+// IsSlow adds the duration of the root span to the distribution of its signature,
+// and returns whether the trace is above the threshold of the signature.
+// It must be called for every trace, so that the distributions include the traces
+// kept by the other samplers.
+func (s *LatencySampler) IsSlow(now time.Time, root *pb.Span, env string) bool {
+	d := s.loadDistribution(now, latencySignature(root, env))
+	if d == nil {
+		return false
+	}
+	return d.add(now, float64(root.Duration), s.quantile)
+}
+
+// Sample samples a slow trace that was not sampled by the other samplers, and returns
+// true if the trace was sampled (should be kept) within the TPS budget.
+func (s *LatencySampler) Sample(root *pb.Span) bool {
+	if !s.limiter.Allow() {
+		s.misses.Inc()
+		return false
+	}
+	s.hits.Inc()
+	traceutil.SetMetric(root, latencyKey, 1)
+	return true
+}
+
+// Stop stops reporting stats
+func (s *LatencySampler) Stop() {
+	s.tickStats.Stop()
+}
+
+func (s *LatencySampler) loadDistribution(now time.Time, sig Signature) *latencyDistribution {
+	s.mu.RLock()
+	d, ok := s.distributions[sig]
+	s.mu.RUnlock()
+	if ok {
+		return d
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d, ok := s.distributions[sig]; ok {
+		return d
+	}
+	if len(s.distributions) >= latencyMaxSignatures && now.Sub(s.lastEviction) >= latencyWindow {
+		s.evictIdleDistributions(now)
+	}
+	if len(s.distributions) >= latencyMaxSignatures {
+		s.untracked.Inc()
+		return nil
+	}
+	sketch, err := ddsketch.LogCollapsingLowestDenseDDSketch(latencyRelativeAccuracy, latencyMaxBins)
+	if err != nil {
+		log.Errorf("Error when creating ddsketch: %v", err)
+		return nil
+	}
+	d = &latencyDistribution{sketch: sketch, windowStart: now, lastSeen: now}
+	s.distributions[sig] = d
+	return d
+}
+
+// evictIdleDistributions removes the distributions of the signatures that got no trace
+// during the last latencyWindow. It must be called with s.mu locked.
+func (s *LatencySampler) evictIdleDistributions(now time.Time) {
+	s.lastEviction = now
+	for sig, d := range s.distributions {
+		if d.idle(now) {
+			delete(s.distributions, sig)
+		}
+	}
+}
+
+func (s *LatencySampler) report() {
+	s.mu.RLock()
+	signatures := len(s.distributions)
+	s.mu.RUnlock()
+	untracked := s.untracked.Swap(0)
+	if untracked > 0 {
+		log.Warnf("Latency sampler tracks the maximum of %d signatures, %d traces of other signatures were not considered for latency sampling", latencyMaxSignatures, untracked)
+	}
+	metrics.Count("datadog.trace_agent.sampler.latency.hits", s.hits.Swap(0), nil, 1)
+	metrics.Count("datadog.trace_agent.sampler.latency.misses", s.misses.Swap(0), nil, 1)
+	metrics.Count("datadog.trace_agent.sampler.latency.untracked", untracked, nil, 1)
+	metrics.Gauge("datadog.trace_agent.sampler.latency.signatures", float64(signatures), nil, 1)
+}
+
+// latencyDistribution keeps the durations of a signature seen during the current window,
+// and the threshold computed from the previous one.
+type latencyDistribution struct {
+	mu          sync.Mutex
+	sketch      *ddsketch.DDSketch
+	windowStart time.Time
+	// threshold is the duration above which a trace is slow, it is only
+	// valid once a window is complete.
+	threshold float64
+	ready     bool
+	// lastSeen is the time of the last duration added.
+	lastSeen time.Time
+}
+
+// add adds a duration to the distribution and returns whether it is above the threshold.
+// The threshold is updated when the current window holds enough durations.
+func (d *latencyDistribution) add(now time.Time, duration float64, quantile float64) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lastSeen = now
+	if now.Sub(d.windowStart) >= latencyWindow && d.sketch.GetCount() >= latencyMinCount {
+		if threshold, err := d.sketch.GetValueAtQuantile(quantile); err == nil {
+			d.threshold = threshold
+			d.ready = true
+		}
+		d.sketch.Clear()
+		d.windowStart = now
+	}
+	if duration > 0 {
+		// ddsketch does not track durations lower or equal to 0
+		if err := d.sketch.Add(duration); err != nil {
+			log.Debugf("Error when adding a duration to ddsketch: %v", err)
+		}
+	}
+	return d.ready && duration >= d.threshold
+}
+
+// idle returns whether no duration was added during the last latencyWindow.
+func (d *latencyDistribution) idle(now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return now.Sub(d.lastSeen) >= latencyWindow
+}
+
+// latencySignature returns the signature of the (env, service, resource) of the root span.
+func latencySignature(root *pb.Span, env string) Signature {
+	h := new32a()
+	h.Write([]byte(env))
+	h.WriteChar(',')
+	h.Write([]byte(root.Service))
+	h.WriteChar(',')
+	h.Write([]byte(root.Resource))
+	return Signature(h.Sum32())
+}
@@ -0,0 +1,130 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sampler
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+// fillLatencySampler adds a complete window of durations from 1ms to 100ms to the sampler,
+// and returns the time at which the window ends.
+func fillLatencySampler(s *LatencySampler, start time.Time, service, resource string) time.Time {
+	for i := 1; i <= 100; i++ {
+		span := &pb.Span{Service: service, Resource: resource, Duration: (time.Duration(i) * time.Millisecond).Nanoseconds()}
+		s.IsSlow(start, span, "prod")
+	}
+	return start.Add(latencyWindow)
+}
+
+// sampleLatency samples the span like the agent does for the traces not kept by the other samplers.
+func sampleLatency(s *LatencySampler, now time.Time, span *pb.Span, env string) bool {
+	return s.IsSlow(now, span, env) && s.Sample(span)
+}
+
+func TestLatencySampler(t *testing.T) {
+	c := config.New()
+	c.LatencySamplerPercentile = 90
+	s := NewLatencySampler(c)
+	s.Stop()
+	testTime := time.Unix(13829192398, 0)
+
+	slowSpan := func() *pb.Span {
+		return &pb.Span{Service: "s1", Resource: "r1", Duration: (95 * time.Millisecond).Nanoseconds()}
+	}
+
+	// no threshold until a window is complete
+	span := slowSpan()
+	assert.False(t, sampleLatency(s, testTime, span, "prod"))
+
+	now := fillLatencySampler(s, testTime, "s1", "r1")
+	fastSpan := &pb.Span{Service: "s1", Resource: "r1", Duration: (10 * time.Millisecond).Nanoseconds()}
+	assert.False(t, sampleLatency(s, now, fastSpan, "prod"))
+	_, ok := fastSpan.Metrics[latencyKey]
+	assert.False(t, ok)
+
+	span = slowSpan()
+	assert.True(t, sampleLatency(s, now, span, "prod"))
+	assert.Equal(t, 1.0, span.Metrics[latencyKey])
+
+	// thresholds are computed for each signature
+	span = &pb.Span{Service: "s1", Resource: "r2", Duration: (95 * time.Millisecond).Nanoseconds()}
+	assert.False(t, sampleLatency(s, now, span, "prod"))
+	span = slowSpan()
+	assert.False(t, sampleLatency(s, now, span, "staging"))
+}
+
+func TestLatencySamplerTPS(t *testing.T) {
+	c := config.New()
+	c.LatencySamplerTPS = 0.001
+	s := NewLatencySampler(c)
+	s.Stop()
+
+	now := fillLatencySampler(s, time.Unix(13829192398, 0), "s1", "r1")
+	kept := 0
+	for i := 0; i < latencySamplerBurst+10; i++ {
+		span := &pb.Span{Service: "s1", Resource: "r1", Duration: time.Second.Nanoseconds()}
+		if sampleLatency(s, now, span, "prod") {
+			kept++
+		}
+	}
+	assert.Equal(t, latencySamplerBurst, kept)
+	assert.EqualValues(t, 10, s.misses.Load())
+}
+
+func TestLatencySamplerWindow(t *testing.T) {
+	c := config.New()
+	s := NewLatencySampler(c)
+	s.Stop()
+	testTime := time.Unix(13829192398, 0)
+
+	// the threshold is not updated until the window holds enough durations
+	for i := 0; i < latencyMinCount-1; i++ {
+		span := &pb.Span{Service: "s1", Resource: "r1", Duration: time.Millisecond.Nanoseconds()}
+		s.IsSlow(testTime, span, "prod")
+	}
+	span := &pb.Span{Service: "s1", Resource: "r1", Duration: time.Second.Nanoseconds()}
+	assert.False(t, sampleLatency(s, testTime.Add(2*latencyWindow), span, "prod"))
+	span = &pb.Span{Service: "s1", Resource: "r1", Duration: time.Second.Nanoseconds()}
+	assert.True(t, sampleLatency(s, testTime.Add(2*latencyWindow), span, "prod"))
+}
+
+func TestLatencySamplerMaxSignatures(t *testing.T) {
+	c := config.New()
+	s := NewLatencySampler(c)
+	s.Stop()
+	testTime := time.Unix(13829192398, 0)
+
+	for i := 0; i < latencyMaxSignatures; i++ {
+		span := &pb.Span{Service: "s1", Resource: strconv.Itoa(i), Duration: time.Millisecond.Nanoseconds()}
+		s.IsSlow(testTime, span, "prod")
+	}
+	assert.Len(t, s.distributions, latencyMaxSignatures)
+
+	// the signatures that got traces during the last window are not evicted
+	now := testTime.Add(latencyWindow / 2)
+	s.IsSlow(now, &pb.Span{Service: "s1", Resource: "0", Duration: time.Millisecond.Nanoseconds()}, "prod")
+	s.IsSlow(now, &pb.Span{Service: "s1", Resource: "new", Duration: time.Millisecond.Nanoseconds()}, "prod")
+	assert.Len(t, s.distributions, latencyMaxSignatures)
+	assert.NotContains(t, s.distributions, latencySignature(&pb.Span{Service: "s1", Resource: "new"}, "prod"))
+	assert.EqualValues(t, 1, s.untracked.Load())
+
+	// the idle signatures are evicted to track the new ones, at most once per window
+	now = testTime.Add(latencyWindow)
+	s.IsSlow(now, &pb.Span{Service: "s1", Resource: "0", Duration: time.Millisecond.Nanoseconds()}, "prod")
+	now = testTime.Add(latencyWindow/2 + latencyWindow)
+	s.IsSlow(now, &pb.Span{Service: "s1", Resource: "new", Duration: time.Millisecond.Nanoseconds()}, "prod")
+	assert.Len(t, s.distributions, 2)
+	assert.Contains(t, s.distributions, latencySignature(&pb.Span{Service: "s1", Resource: "0"}, "prod"))
+	assert.Contains(t, s.distributions, latencySignature(&pb.Span{Service: "s1", Resource: "new"}, "prod"))
+	assert.EqualValues(t, 1, s.untracked.Load())
+}
@@ -6,6 +6,8 @@
 package stats
 
 import (
+	"hash/fnv"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -17,6 +19,7 @@ import (
 const (
 	tagStatusCode = "http.status_code"
 	tagSynthetics = "synthetics"
+	tagSpanKind   = "span.kind"
 )
 
 // Aggregation contains all the dimension on which we aggregate statistics.
@@ -33,6 +36,10 @@ type BucketsAggregationKey struct {
 	Type       string
 	StatusCode uint32
 	Synthetics bool
+	SpanKind   string
+	// PeerTagsHash is the hash of the peer tags of the span, which can not be part of a
+	// comparable key as a slice.
+	PeerTagsHash uint64
 }
 
 // PayloadAggregationKey specifies the key by which a payload is aggregated.
@@ -61,31 +68,89 @@ func getStatusCode(s *pb.Span) uint32 {
 	return uint32(c)
 }
 
-// NewAggregationFromSpan creates a new aggregation from the provided span and env
-func NewAggregationFromSpan(s *pb.Span, origin string, aggKey PayloadAggregationKey) Aggregation {
+// preparePeerTagKeys returns the sorted and deduplicated non-empty keys of peerTagKeys.
+func preparePeerTagKeys(peerTagKeys []string) []string {
+	keys := make([]string, 0, len(peerTagKeys))
+	seen := make(map[string]struct{}, len(peerTagKeys))
+	for _, k := range peerTagKeys {
+		k = strings.TrimSpace(k)
+		if _, ok := seen[k]; ok || k == "" {
+			continue
+		}
+		seen[k] = struct{}{}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// matchingPeerTags returns the peer tags of s as "key:value", for the given keys which are
+// set on the span. Peer tags are only extracted from client and producer spans, as they
+// describe the downstream dependency called by the span.
+func matchingPeerTags(s *pb.Span, spanKind string, peerTagKeys []string) []string {
+	if len(peerTagKeys) == 0 || (spanKind != "client" && spanKind != "producer") {
+		return nil
+	}
+	var tags []string
+	for _, k := range peerTagKeys {
+		if v, ok := s.Meta[k]; ok && v != "" {
+			tags = append(tags, k+":"+v)
+		}
+	}
+	return tags
+}
+
+// peerTagsHash returns a hash of tags which does not depend on their order.
+func peerTagsHash(tags []string) uint64 {
+	if len(tags) == 0 {
+		return 0
+	}
+	if !sort.StringsAreSorted(tags) {
+		tags = append([]string(nil), tags...)
+		sort.Strings(tags)
+	}
+	h := fnv.New64a()
+	for i, t := range tags {
+		if i > 0 {
+			h.Write([]byte{0})
+		}
+		h.Write([]byte(t))
+	}
+	return h.Sum64()
+}
+
+// NewAggregationFromSpan creates a new aggregation from the provided span and env. It also returns
+// the peer tags of the span matching peerTagKeys, which must be sorted.
+func NewAggregationFromSpan(s *pb.Span, origin string, aggKey PayloadAggregationKey, peerTagKeys []string) (Aggregation, []string) {
 	synthetics := strings.HasPrefix(origin, tagSynthetics)
+	spanKind := strings.ToLower(s.Meta[tagSpanKind])
+	peerTags := matchingPeerTags(s, spanKind, peerTagKeys)
 	return Aggregation{
 		PayloadAggregationKey: aggKey,
 		BucketsAggregationKey: BucketsAggregationKey{
-			Resource:   s.Resource,
-			Service:    s.Service,
-			Name:       s.Name,
-			Type:       s.Type,
-			StatusCode: getStatusCode(s),
-			Synthetics: synthetics,
+			Resource:     s.Resource,
+			Service:      s.Service,
+			Name:         s.Name,
+			Type:         s.Type,
+			StatusCode:   getStatusCode(s),
+			Synthetics:   synthetics,
+			SpanKind:     spanKind,
+			PeerTagsHash: peerTagsHash(peerTags),
 		},
-	}
+	}, peerTags
 }
 
 // NewAggregationFromGroup gets the Aggregation key of grouped stats.
 func NewAggregationFromGroup(g pb.ClientGroupedStats) Aggregation {
 	return Aggregation{
 		BucketsAggregationKey: BucketsAggregationKey{
-			Resource:   g.Resource,
-			Service:    g.Service,
-			Name:       g.Name,
-			StatusCode: g.HTTPStatusCode,
-			Synthetics: g.Synthetics,
+			Resource:     g.Resource,
+			Service:      g.Service,
+			Name:         g.Name,
+			StatusCode:   g.HTTPStatusCode,
+			Synthetics:   g.Synthetics,
+			SpanKind:     g.SpanKind,
+			PeerTagsHash: peerTagsHash(g.PeerTags),
 		},
 	}
 }
@@ -208,7 +208,7 @@ func (b *bucket) aggregateCounts(p pb.ClientStatsPayload) {
 			aggKey := newBucketAggregationKey(sb)
 			agg, ok := payloadAgg[aggKey]
 			if !ok {
-				agg = &aggregatedCounts{}
+				agg = &aggregatedCounts{peerTags: sb.PeerTags}
 				payloadAgg[aggKey] = agg
 			}
 			agg.hits += sb.Hits
@@ -237,6 +237,8 @@ func (b *bucket) aggregationToPayloads() []pb.ClientStatsPayload {
 				HTTPStatusCode: aggrKey.StatusCode,
 				Type:           aggrKey.Type,
 				Synthetics:     aggrKey.Synthetics,
+				SpanKind:       aggrKey.SpanKind,
+				PeerTags:       counts.peerTags,
 				Hits:           counts.hits,
 				Errors:         counts.errors,
 				Duration:       counts.duration,
@@ -265,12 +267,14 @@ func newPayloadAggregationKey(env, hostname, version, cid string) PayloadAggrega
 
 func newBucketAggregationKey(b pb.ClientGroupedStats) BucketsAggregationKey {
 	return BucketsAggregationKey{
-		Service:    b.Service,
-		Name:       b.Name,
-		Resource:   b.Resource,
-		Type:       b.Type,
-		Synthetics: b.Synthetics,
-		StatusCode: b.HTTPStatusCode,
+		Service:      b.Service,
+		Name:         b.Name,
+		Resource:     b.Resource,
+		Type:         b.Type,
+		Synthetics:   b.Synthetics,
+		StatusCode:   b.HTTPStatusCode,
+		SpanKind:     b.SpanKind,
+		PeerTagsHash: peerTagsHash(b.PeerTags),
 	}
 }
 
@@ -291,4 +295,5 @@ func trimCounts(p pb.ClientStatsPayload) pb.ClientStatsPayload {
 // Distributions and TopLevelCount will stay on the initial payload
 type aggregatedCounts struct {
 	hits, errors, duration uint64
+	peerTags               []string
 }
@@ -57,6 +57,7 @@ func payloadWithCounts(ts time.Time, k BucketsAggregationKey, hits, errors, dura
 						HTTPStatusCode: k.StatusCode,
 						Type:           k.Type,
 						Synthetics:     k.Synthetics,
+						SpanKind:       k.SpanKind,
 						Hits:           hits,
 						Errors:         errors,
 						Duration:       duration,
@@ -290,6 +291,11 @@ func TestCountAggregation(t *testing.T) {
 			pb.ClientGroupedStats{HTTPStatusCode: 10},
 			"status",
 		},
+		{
+			BucketsAggregationKey{SpanKind: "client"},
+			pb.ClientGroupedStats{SpanKind: "client"},
+			"span-kind",
+		},
 	}
 	for _, tc := range tts {
 		t.Run(tc.name, func(t *testing.T) {
@@ -333,6 +339,37 @@ func TestCountAggregation(t *testing.T) {
 	}
 }
 
+func TestCountAggregationPeerTags(t *testing.T) {
+	assert := assert.New(t)
+	a := newTestAggregator()
+	testTime := time.Unix(time.Now().Unix(), 0)
+	k := BucketsAggregationKey{Service: "s", SpanKind: "client"}
+	withPeerTags := func(p pb.ClientStatsPayload, tags ...string) pb.ClientStatsPayload {
+		p.Stats[0].Stats[0].PeerTags = tags
+		return p
+	}
+
+	a.add(testTime, withPeerTags(payloadWithCounts(testTime, k, 1, 0, 10), "peer.service:users-db", "db.instance:users"))
+	a.add(testTime, withPeerTags(payloadWithCounts(testTime, k, 2, 1, 20), "db.instance:users", "peer.service:users-db"))
+	a.add(testTime, withPeerTags(payloadWithCounts(testTime, k, 4, 0, 40), "peer.service:orders-db"))
+	a.flushOnTime(testTime.Add(oldestBucketStart + time.Nanosecond))
+	assert.Len(a.out, 3)
+	<-a.out
+	<-a.out
+	aggCounts := <-a.out
+	assertAggCountsPayload(t, aggCounts)
+
+	hits := make(map[uint64]uint64)
+	for _, g := range aggCounts.Stats[0].Stats[0].Stats {
+		assert.Equal("client", g.SpanKind)
+		hits[peerTagsHash(g.PeerTags)] = g.Hits
+	}
+	assert.Equal(map[uint64]uint64{
+		peerTagsHash([]string{"db.instance:users", "peer.service:users-db"}): 3,
+		peerTagsHash([]string{"peer.service:orders-db"}):                     4,
+	}, hits)
+}
+
 func deepCopy(p pb.ClientStatsPayload) pb.ClientStatsPayload {
 	new := p
 	new.Stats = deepCopyStatsBucket(p.Stats)
@@ -46,6 +46,7 @@ type Concentrator struct {
 	agentEnv      string
 	agentHostname string
 	agentVersion  string
+	peerTagKeys   []string // sorted keys of the span tags aggregated for client and producer spans
 }
 
 // NewConcentrator initializes a new concentrator ready to be started
@@ -65,6 +66,7 @@ func NewConcentrator(conf *config.AgentConfig, out chan pb.StatsPayload, now tim
 		agentEnv:      conf.DefaultEnv,
 		agentHostname: conf.Hostname,
 		agentVersion:  conf.AgentVersion,
+		peerTagKeys:   preparePeerTagKeys(conf.PeerTags),
 	}
 	return &c
 }
@@ -180,7 +182,7 @@ func (c *Concentrator) addNow(pt *traceutil.ProcessedTrace, containerID string)
 			b = NewRawBucket(uint64(btime), uint64(c.bsize))
 			c.buckets[btime] = b
 		}
-		b.HandleSpan(s, weight, isTop, pt.TraceChunk.Origin, aggKey)
+		b.HandleSpan(s, weight, isTop, pt.TraceChunk.Origin, aggKey, c.peerTagKeys)
 	}
 }
 
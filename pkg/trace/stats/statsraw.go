@@ -36,6 +36,7 @@ type groupedStats struct {
 	duration        float64
 	okDistribution  *ddsketch.DDSketch
 	errDistribution *ddsketch.DDSketch
+	peerTags        []string
 }
 
 // round a float to an int, uniformly choosing
@@ -72,6 +73,8 @@ func (s *groupedStats) export(a Aggregation) (pb.ClientGroupedStats, error) {
 		OkSummary:      okSummary,
 		ErrorSummary:   errSummary,
 		Synthetics:     a.Synthetics,
+		SpanKind:       a.SpanKind,
+		PeerTags:       s.peerTags,
 	}, nil
 }
 
@@ -143,21 +146,23 @@ func (sb *RawBucket) Export() map[PayloadAggregationKey]pb.ClientStatsBucket {
 	return m
 }
 
-// HandleSpan adds the span to this bucket stats, aggregated with the finest grain matching given aggregators
-func (sb *RawBucket) HandleSpan(s *pb.Span, weight float64, isTop bool, origin string, aggKey PayloadAggregationKey) {
+// HandleSpan adds the span to this bucket stats, aggregated with the finest grain matching given aggregators.
+// peerTagKeys are the sorted keys of the span tags used as additional dimensions for client and producer spans.
+func (sb *RawBucket) HandleSpan(s *pb.Span, weight float64, isTop bool, origin string, aggKey PayloadAggregationKey, peerTagKeys []string) {
 	if aggKey.Env == "" {
 		panic("env should never be empty")
 	}
-	aggr := NewAggregationFromSpan(s, origin, aggKey)
-	sb.add(s, weight, isTop, aggr)
+	aggr, peerTags := NewAggregationFromSpan(s, origin, aggKey, peerTagKeys)
+	sb.add(s, weight, isTop, aggr, peerTags)
 }
 
-func (sb *RawBucket) add(s *pb.Span, weight float64, isTop bool, aggr Aggregation) {
+func (sb *RawBucket) add(s *pb.Span, weight float64, isTop bool, aggr Aggregation, peerTags []string) {
 	var gs *groupedStats
 	var ok bool
 
 	if gs, ok = sb.data[aggr]; !ok {
 		gs = newGroupedStats()
+		gs.peerTags = peerTags
 		sb.data[aggr] = gs
 	}
 	if isTop {
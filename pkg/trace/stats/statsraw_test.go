@@ -11,16 +11,17 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGrain(t *testing.T) {
 	assert := assert.New(t)
 	s := pb.Span{Service: "thing", Name: "other", Resource: "yo"}
-	aggr := NewAggregationFromSpan(&s, "", PayloadAggregationKey{
+	aggr, _ := NewAggregationFromSpan(&s, "", PayloadAggregationKey{
 		Env:         "default",
 		Hostname:    "default",
 		ContainerID: "cid",
-	})
+	}, nil)
 	assert.Equal(Aggregation{
 		PayloadAggregationKey: PayloadAggregationKey{
 			Env:         "default",
@@ -38,12 +39,12 @@ func TestGrain(t *testing.T) {
 func TestGrainWithExtraTags(t *testing.T) {
 	assert := assert.New(t)
 	s := pb.Span{Service: "thing", Name: "other", Resource: "yo", Meta: map[string]string{tagStatusCode: "418"}}
-	aggr := NewAggregationFromSpan(&s, "synthetics-browser", PayloadAggregationKey{
+	aggr, _ := NewAggregationFromSpan(&s, "synthetics-browser", PayloadAggregationKey{
 		Hostname:    "host-id",
 		Version:     "v0",
 		Env:         "default",
 		ContainerID: "cid",
-	})
+	}, nil)
 	assert.Equal(Aggregation{
 		PayloadAggregationKey: PayloadAggregationKey{
 			Hostname:    "host-id",
@@ -61,13 +62,68 @@ func TestGrainWithExtraTags(t *testing.T) {
 	}, aggr)
 }
 
+func TestGrainWithPeerTags(t *testing.T) {
+	peerTagKeys := preparePeerTagKeys([]string{"peer.service", "db.instance", "peer.service", ""})
+	assert.Equal(t, []string{"db.instance", "peer.service"}, peerTagKeys)
+	aggKey := PayloadAggregationKey{Env: "default"}
+
+	t.Run("client", func(t *testing.T) {
+		s := pb.Span{Service: "thing", Name: "query", Meta: map[string]string{
+			"span.kind":    "CLIENT",
+			"peer.service": "users-db",
+			"db.instance":  "users",
+			"db.system":    "postgres",
+		}}
+		aggr, peerTags := NewAggregationFromSpan(&s, "", aggKey, peerTagKeys)
+		assert.Equal(t, []string{"db.instance:users", "peer.service:users-db"}, peerTags)
+		assert.Equal(t, "client", aggr.SpanKind)
+		assert.NotZero(t, aggr.PeerTagsHash)
+		assert.Equal(t, peerTagsHash([]string{"peer.service:users-db", "db.instance:users"}), aggr.PeerTagsHash)
+
+		s.Meta["peer.service"] = "orders-db"
+		other, _ := NewAggregationFromSpan(&s, "", aggKey, peerTagKeys)
+		assert.NotEqual(t, aggr, other)
+	})
+
+	t.Run("server", func(t *testing.T) {
+		s := pb.Span{Service: "thing", Name: "request", Meta: map[string]string{
+			"span.kind":    "server",
+			"peer.service": "users-db",
+		}}
+		aggr, peerTags := NewAggregationFromSpan(&s, "", aggKey, peerTagKeys)
+		assert.Nil(t, peerTags)
+		assert.Equal(t, "server", aggr.SpanKind)
+		assert.Zero(t, aggr.PeerTagsHash)
+	})
+
+	t.Run("export", func(t *testing.T) {
+		sb := NewRawBucket(0, 1e9)
+		for _, service := range []string{"users-db", "users-db", "orders-db"} {
+			s := &pb.Span{Service: "thing", Name: "query", Duration: 100, Meta: map[string]string{
+				"span.kind":    "producer",
+				"peer.service": service,
+			}}
+			sb.HandleSpan(s, 1, true, "", aggKey, peerTagKeys)
+		}
+		stats := sb.Export()[aggKey].Stats
+		require.Len(t, stats, 2)
+		hits := make(map[string]uint64)
+		for _, g := range stats {
+			assert.Equal(t, "producer", g.SpanKind)
+			require.Len(t, g.PeerTags, 1)
+			hits[g.PeerTags[0]] = g.Hits
+		}
+		assert.Equal(t, map[string]uint64{"peer.service:users-db": 2, "peer.service:orders-db": 1}, hits)
+	})
+}
+
 func BenchmarkHandleSpanRandom(b *testing.B) {
 	sb := NewRawBucket(0, 1e9)
 	b.ResetTimer()
 	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
 		for _, span := range benchSpans {
-			sb.HandleSpan(span, 1, true, "", PayloadAggregationKey{"a", "b", "c", "d"})
+			sb.HandleSpan(span, 1, true, "", PayloadAggregationKey{"a", "b", "c", "d"}, nil)
 		}
 	}
 }
@@ -0,0 +1,203 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package writer
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
+)
+
+// fileExportTimeFormat is the format of the creation time in the names of the exported files,
+// it sorts the files by creation time.
+const fileExportTimeFormat = "20060102T150405.000000000"
+
+// exportedPayload is a payload that can be written by a fileExporter.
+type exportedPayload interface {
+	Marshal() ([]byte, error)
+}
+
+// marshaledPayload is a payload exported with the protobuf message it was already marshaled to by
+// its writer, so that it is not marshaled twice.
+type marshaledPayload struct {
+	payload interface{}
+	msg     []byte
+}
+
+// Marshal implements exportedPayload.
+func (p marshaledPayload) Marshal() ([]byte, error) {
+	return p.msg, nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p marshaledPayload) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.payload)
+}
+
+// fileExporter writes the payloads flushed by a writer to files in a directory. The files are
+// rotated when they reach their maximum size or age, and only the most recent ones are kept.
+type fileExporter struct {
+	conf   *config.FileExportConfig
+	prefix string // prefix of the file names, it identifies the kind of payloads
+	ext    string // extension of the file names, it identifies the format
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64     // size of file
+	created time.Time // creation time of file
+
+	now     func() time.Time // replaced in tests
+	easylog *log.ThrottledLogger
+}
+
+// newFileExporter returns a fileExporter writing the payloads in files named after prefix,
+// or nil if the file export is disabled.
+func newFileExporter(conf *config.FileExportConfig, prefix string) *fileExporter {
+	if conf == nil || !conf.Enabled {
+		return nil
+	}
+	ext := "jsonl"
+	if conf.Format == config.FileExportProtobuf {
+		ext = "pb"
+	}
+	log.Infof("Exporting %s payloads to %s (format=%s)", prefix, conf.Path, conf.Format)
+	return &fileExporter{
+		conf:    conf,
+		prefix:  prefix,
+		ext:     ext,
+		now:     time.Now,
+		easylog: log.NewThrottled(5, 10*time.Second), // no more than 5 messages every 10 seconds
+	}
+}
+
+// export writes p to the current file, a nil exporter does nothing.
+func (e *fileExporter) export(p exportedPayload) {
+	if e == nil {
+		return
+	}
+	b, err := e.encode(p)
+	if err == nil {
+		err = e.write(b)
+	}
+	if err != nil {
+		e.easylog.Error("Error exporting %s payload to file: %v", e.prefix, err)
+		metrics.Count("datadog.trace_agent.file_export.errors", 1, []string{"payload:" + e.prefix}, 1)
+		return
+	}
+	metrics.Count("datadog.trace_agent.file_export.bytes", int64(len(b)), []string{"payload:" + e.prefix}, 1)
+}
+
+// encode encodes p as a line of JSON, or as a protobuf message prefixed by its varint encoded size.
+func (e *fileExporter) encode(p exportedPayload) ([]byte, error) {
+	if e.conf.Format == config.FileExportProtobuf {
+		msg, err := p.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(msg))
+		n := binary.PutUvarint(b, uint64(len(msg)))
+		return append(b[:n], msg...), nil
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+func (e *fileExporter) write(b []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now()
+	if e.file != nil && e.shouldRotate(now, len(b)) {
+		e.closeFile()
+	}
+	if e.file == nil {
+		if err := e.openFile(now); err != nil {
+			return err
+		}
+	}
+	n, err := e.file.Write(b)
+	e.size += int64(n)
+	return err
+}
+
+// shouldRotate reports whether the current file must be rotated before writing n bytes at now.
+// A file holds at least a payload, and a limit lower or equal to 0 is disabled.
+func (e *fileExporter) shouldRotate(now time.Time, n int) bool {
+	if e.conf.MaxFileSize > 0 && e.size > 0 && e.size+int64(n) > e.conf.MaxFileSize {
+		return true
+	}
+	return e.conf.MaxFileAge > 0 && now.Sub(e.created) >= e.conf.MaxFileAge
+}
+
+// openFile creates a new file and removes the oldest ones above the maximum number of files.
+func (e *fileExporter) openFile(now time.Time) error {
+	if err := os.MkdirAll(e.conf.Path, 0755); err != nil {
+		return err
+	}
+	name := filepath.Join(e.conf.Path, fmt.Sprintf("%s-%s.%s", e.prefix, now.UTC().Format(fileExportTimeFormat), e.ext))
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	e.file = f
+	e.size = 0
+	e.created = now
+	e.removeOldFiles()
+	return nil
+}
+
+// removeOldFiles removes the oldest files above the maximum number of files.
+func (e *fileExporter) removeOldFiles() {
+	if e.conf.MaxFiles <= 0 {
+		return
+	}
+	files, err := filepath.Glob(filepath.Join(e.conf.Path, e.prefix+"-*."+e.ext))
+	if err != nil || len(files) <= e.conf.MaxFiles {
+		return
+	}
+	sort.Strings(files)
+	for _, f := range files[:len(files)-e.conf.MaxFiles] {
+		if err := os.Remove(f); err != nil {
+			e.easylog.Warn("Error removing exported file %s: %v", f, err)
+		}
+	}
+}
+
+func (e *fileExporter) closeFile() {
+	if err := e.file.Close(); err != nil {
+		e.easylog.Error("Error closing exported file %s: %v", e.file.Name(), err)
+	}
+	e.file = nil
+}
+
+// stop closes the current file, a nil exporter does nothing.
+func (e *fileExporter) stop() {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.file != nil {
+		e.closeFile()
+	}
+}
+
+// sendsToIntake reports whether the payloads are sent to the intake, they are not when they are
+// only exported to files.
+func sendsToIntake(conf *config.FileExportConfig) bool {
+	return conf == nil || !conf.Enabled || conf.SendToIntake
+}
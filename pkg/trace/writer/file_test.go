@@ -0,0 +1,209 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package writer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/testutil"
+)
+
+func testFileExportConfig(dir, format string) *config.FileExportConfig {
+	return &config.FileExportConfig{
+		Enabled:     true,
+		Path:        dir,
+		Format:      format,
+		MaxFileSize: 1024 * 1024,
+		MaxFileAge:  time.Hour,
+		MaxFiles:    3,
+	}
+}
+
+func exportedFiles(t *testing.T, dir, pattern string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, pattern))
+	require.NoError(t, err)
+	return files
+}
+
+func TestFileExporter(t *testing.T) {
+	payload := &pb.AgentPayload{
+		HostName:       testHostname,
+		Env:            testEnv,
+		TracerPayloads: []*pb.TracerPayload{{Chunks: []*pb.TraceChunk{testutil.RandomTraceChunk(3, 0)}}},
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.Nil(t, newFileExporter(nil, "traces"))
+		assert.Nil(t, newFileExporter(&config.FileExportConfig{Path: t.TempDir()}, "traces"))
+		var e *fileExporter
+		e.export(payload)
+		e.stop()
+	})
+
+	t.Run("json", func(t *testing.T) {
+		dir := t.TempDir()
+		e := newFileExporter(testFileExportConfig(dir, config.FileExportJSON), "traces")
+		e.export(payload)
+		e.export(payload)
+		e.stop()
+
+		files := exportedFiles(t, dir, "traces-*.jsonl")
+		require.Len(t, files, 1)
+		f, err := os.Open(files[0])
+		require.NoError(t, err)
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(nil, 1024*1024)
+		var lines int
+		for scanner.Scan() {
+			var got pb.AgentPayload
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &got))
+			assert.Equal(t, payload, &got)
+			lines++
+		}
+		assert.Equal(t, 2, lines)
+	})
+
+	t.Run("protobuf", func(t *testing.T) {
+		dir := t.TempDir()
+		e := newFileExporter(testFileExportConfig(dir, config.FileExportProtobuf), "traces")
+		e.export(payload)
+		e.export(payload)
+		e.stop()
+
+		files := exportedFiles(t, dir, "traces-*.pb")
+		require.Len(t, files, 1)
+		b, err := os.ReadFile(files[0])
+		require.NoError(t, err)
+		r := bytes.NewReader(b)
+		var messages int
+		for r.Len() > 0 {
+			n, err := binary.ReadUvarint(r)
+			require.NoError(t, err)
+			msg := make([]byte, n)
+			_, err = r.Read(msg)
+			require.NoError(t, err)
+			var got pb.AgentPayload
+			require.NoError(t, got.Unmarshal(msg))
+			assert.Equal(t, payload.HostName, got.HostName)
+			assert.Len(t, got.TracerPayloads, 1)
+			messages++
+		}
+		assert.Equal(t, 2, messages)
+	})
+
+	t.Run("marshaled", func(t *testing.T) {
+		msg, err := payload.Marshal()
+		require.NoError(t, err)
+		dir := t.TempDir()
+		e := newFileExporter(testFileExportConfig(dir, config.FileExportProtobuf), "traces")
+		e.export(marshaledPayload{payload: payload, msg: msg})
+		e.stop()
+		files := exportedFiles(t, dir, "traces-*.pb")
+		require.Len(t, files, 1)
+		b, err := os.ReadFile(files[0])
+		require.NoError(t, err)
+		n, size := binary.Uvarint(b)
+		assert.Equal(t, msg, b[size:size+int(n)])
+
+		dir = t.TempDir()
+		e = newFileExporter(testFileExportConfig(dir, config.FileExportJSON), "traces")
+		e.export(marshaledPayload{payload: payload, msg: msg})
+		e.stop()
+		files = exportedFiles(t, dir, "traces-*.jsonl")
+		require.Len(t, files, 1)
+		b, err = os.ReadFile(files[0])
+		require.NoError(t, err)
+		var got pb.AgentPayload
+		require.NoError(t, json.Unmarshal(b, &got))
+		assert.Equal(t, payload, &got)
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		dir := t.TempDir()
+		cfg := testFileExportConfig(dir, config.FileExportJSON)
+		now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+		e := newFileExporter(cfg, "stats")
+		e.now = func() time.Time { return now }
+		p := &pb.StatsPayload{AgentHostname: testHostname}
+
+		// a payload larger than the maximum size still gets its own file
+		cfg.MaxFileSize = 10
+		e.export(p)
+		now = now.Add(time.Second)
+		e.export(p)
+		assert.Len(t, exportedFiles(t, dir, "stats-*.jsonl"), 2)
+
+		// appended to the current file
+		cfg.MaxFileSize = 1024
+		now = now.Add(time.Second)
+		e.export(p)
+		assert.Len(t, exportedFiles(t, dir, "stats-*.jsonl"), 2)
+
+		// rotated by age, the oldest file is removed above the maximum number of files
+		now = now.Add(time.Hour)
+		e.export(p)
+		now = now.Add(time.Hour)
+		e.export(p)
+		e.stop()
+		files := exportedFiles(t, dir, "stats-*.jsonl")
+		require.Len(t, files, 3)
+		assert.Equal(t, filepath.Join(dir, "stats-20221001T120001.000000000.jsonl"), files[0])
+		assert.Equal(t, filepath.Join(dir, "stats-20221001T140002.000000000.jsonl"), files[2])
+	})
+}
+
+func TestTraceWriterFileExport(t *testing.T) {
+	for _, sendToIntake := range []bool{false, true} {
+		srv := newTestServer()
+		dir := t.TempDir()
+		fe := testFileExportConfig(dir, config.FileExportJSON)
+		fe.SendToIntake = sendToIntake
+		cfg := &config.AgentConfig{
+			Hostname:    testHostname,
+			DefaultEnv:  testEnv,
+			Endpoints:   []*config.Endpoint{{APIKey: "123", Host: srv.URL}},
+			TraceWriter: &config.WriterConfig{ConnectionLimit: 200, QueueSize: 40},
+			FileExport:  fe,
+		}
+		tw := NewTraceWriter(cfg)
+		tw.In = make(chan *SampledChunks)
+		go tw.Run()
+		tw.In <- randomSampledSpans(20, 8)
+		tw.Stop()
+
+		assert.Len(t, exportedFiles(t, dir, "traces-*.jsonl"), 1)
+		if sendToIntake {
+			assert.Equal(t, 1, srv.Accepted())
+		} else {
+			assert.Equal(t, 0, srv.Accepted())
+		}
+	}
+}
+
+func TestStatsWriterFileExport(t *testing.T) {
+	sw, _, srv := testStatsWriter()
+	dir := t.TempDir()
+	sw.exporter = newFileExporter(testFileExportConfig(dir, config.FileExportProtobuf), "stats")
+	sw.intake = false
+	sw.SendPayload(pb.StatsPayload{AgentHostname: testHostname})
+	sw.exporter.stop()
+
+	assert.Len(t, exportedFiles(t, dir, "stats-*.pb"), 1)
+	assert.Equal(t, 0, srv.Accepted())
+}
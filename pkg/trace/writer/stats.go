@@ -51,6 +51,9 @@ type StatsWriter struct {
 	flushChan chan chan struct{}
 
 	easylog *log.ThrottledLogger
+
+	exporter *fileExporter // exports the payloads to files, if enabled
+	intake   bool          // reports whether the payloads are sent to the intake
 }
 
 // NewStatsWriter returns a new StatsWriter. It must be started using Run.
@@ -63,6 +66,8 @@ func NewStatsWriter(cfg *config.AgentConfig, in <-chan pb.StatsPayload) *StatsWr
 		syncMode:  cfg.SynchronousFlushing,
 		easylog:   log.NewThrottled(5, 10*time.Second), // no more than 5 messages every 10 seconds
 		conf:      cfg,
+		exporter:  newFileExporter(cfg.FileExport, "stats"),
+		intake:    sendsToIntake(cfg.FileExport),
 	}
 	climit := cfg.StatsWriter.ConnectionLimit
 	if climit == 0 {
@@ -127,6 +132,7 @@ func (w *StatsWriter) Stop() {
 	w.stop <- struct{}{}
 	<-w.stop
 	stopSenders(w.senders)
+	w.exporter.stop()
 }
 
 func (w *StatsWriter) addStats(sp pb.StatsPayload) {
@@ -135,8 +141,12 @@ func (w *StatsWriter) addStats(sp pb.StatsPayload) {
 	w.payloads = append(w.payloads, payloads...)
 }
 
-// SendPayload sends a stats payload to the Datadog backend.
+// SendPayload sends a stats payload to the Datadog backend, and exports it to files when enabled.
 func (w *StatsWriter) SendPayload(p pb.StatsPayload) {
+	w.exporter.export(&p)
+	if !w.intake {
+		return
+	}
 	req := newPayload(map[string]string{
 		headerLanguages:    strings.Join(info.Languages(), "|"),
 		"Content-Type":     "application/msgpack",
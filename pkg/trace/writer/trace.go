@@ -67,6 +67,9 @@ type TraceWriter struct {
 	flushChan chan chan struct{}
 
 	easylog *log.ThrottledLogger
+
+	exporter *fileExporter // exports the payloads to files, if enabled
+	intake   bool          // reports whether the payloads are sent to the intake
 }
 
 // NewTraceWriter returns a new TraceWriter. It is created for the given agent configuration and
@@ -85,6 +88,8 @@ func NewTraceWriter(cfg *config.AgentConfig) *TraceWriter {
 		tick:         5 * time.Second,
 		agentVersion: cfg.AgentVersion,
 		easylog:      log.NewThrottled(5, 10*time.Second), // no more than 5 messages every 10 seconds
+		exporter:     newFileExporter(cfg.FileExport, "traces"),
+		intake:       sendsToIntake(cfg.FileExport),
 	}
 	climit := cfg.TraceWriter.ConnectionLimit
 	if climit == 0 {
@@ -117,6 +122,7 @@ func (w *TraceWriter) Stop() {
 	w.stop <- struct{}{}
 	<-w.stop
 	stopSenders(w.senders)
+	w.exporter.stop()
 }
 
 // Run starts the TraceWriter.
@@ -242,6 +248,11 @@ func (w *TraceWriter) flush() {
 
 	w.stats.BytesUncompressed.Add(int64(len(b)))
 
+	w.exporter.export(marshaledPayload{payload: &p, msg: b})
+	if !w.intake {
+		return
+	}
+
 	w.wg.Add(1)
 	go func() {
 		defer timing.Since("datadog.trace_agent.trace_writer.compress_ms", time.Now())
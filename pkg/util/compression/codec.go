@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package compression
+
+import (
+	"fmt"
+	"io"
+)
+
+const (
+	// ZlibKind is the configuration value selecting the zlib codec
+	ZlibKind = "zlib"
+	// ZstdKind is the configuration value selecting the zstd codec
+	ZstdKind = "zstd"
+)
+
+// Codec compresses payloads and describes the HTTP header value associated with its compression method
+type Codec interface {
+	// ContentEncoding returns the HTTP header value associated with the compression method
+	ContentEncoding() string
+	// Compress compresses the data
+	Compress(src []byte) ([]byte, error)
+	// Decompress decompresses the data
+	Decompress(src []byte) ([]byte, error)
+	// CompressBound returns the worst case size needed for a destination buffer
+	CompressBound(sourceLen int) int
+	// NewStreamWriter returns a writer compressing the data written to it into output
+	NewStreamWriter(output io.Writer) (StreamWriter, error)
+}
+
+// StreamWriter compresses the data written to it
+type StreamWriter interface {
+	io.WriteCloser
+	// Flush writes any pending data to the underlying writer
+	Flush() error
+}
+
+// NewCodec returns the codec selected by the given configuration value,
+// the zstd codec compresses with the given zstd level.
+// It returns the default codec and an error for an unknown value.
+func NewCodec(kind string, zstdLevel int) (Codec, error) {
+	switch kind {
+	case ZstdKind:
+		return NewZstdCodec(zstdLevel), nil
+	case ZlibKind:
+		return DefaultCodec, nil
+	}
+	return DefaultCodec, fmt.Errorf("invalid compression kind %q", kind)
+}
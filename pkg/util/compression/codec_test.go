@@ -0,0 +1,135 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+// +build test
+
+package compression
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPayload = []byte(strings.Repeat(`{"metric":"system.cpu.user","points":[[1668000000,12.5]],"tags":["env:prod"]},`, 100))
+
+func TestZstdCodec(t *testing.T) {
+	codec := NewZstdCodec(ZstdDefaultLevel)
+	assert.Equal(t, "zstd", codec.ContentEncoding())
+
+	compressed, err := codec.Compress(testPayload)
+	require.NoError(t, err)
+	assert.Less(t, len(compressed), len(testPayload))
+	assert.LessOrEqual(t, len(compressed), codec.CompressBound(len(testPayload)))
+
+	decompressed, err := codec.Decompress(compressed)
+	require.NoError(t, err)
+	assert.Equal(t, testPayload, decompressed)
+}
+
+func TestZstdCodecStreamWriter(t *testing.T) {
+	codec := NewZstdCodec(3)
+
+	for i := 0; i < 2; i++ {
+		var output bytes.Buffer
+		writer, err := codec.NewStreamWriter(&output)
+		require.NoError(t, err)
+
+		_, err = writer.Write(testPayload[:100])
+		require.NoError(t, err)
+		require.NoError(t, writer.Flush())
+		assert.NotZero(t, output.Len())
+		_, err = writer.Write(testPayload[100:])
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+		// closing twice must not release the encoder twice
+		require.NoError(t, writer.Close())
+
+		decompressed, err := codec.Decompress(output.Bytes())
+		require.NoError(t, err)
+		assert.Equal(t, testPayload, decompressed)
+	}
+}
+
+func TestGzipCodec(t *testing.T) {
+	codec := NewGzipCodec(6)
+	assert.Equal(t, "gzip", codec.ContentEncoding())
+
+	compressed, err := codec.Compress(testPayload)
+	require.NoError(t, err)
+	assert.Less(t, len(compressed), len(testPayload))
+	assert.LessOrEqual(t, len(compressed), codec.CompressBound(len(testPayload)))
+
+	decompressed, err := codec.Decompress(compressed)
+	require.NoError(t, err)
+	assert.Equal(t, testPayload, decompressed)
+
+	var output bytes.Buffer
+	writer, err := NewGzipCodec(42).NewStreamWriter(&output)
+	require.NoError(t, err)
+	_, err = writer.Write(testPayload)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	decompressed, err = codec.Decompress(output.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, testPayload, decompressed)
+}
+
+func TestNewCodec(t *testing.T) {
+	codec, err := NewCodec(ZstdKind, 3)
+	require.NoError(t, err)
+	assert.Equal(t, ZstdEncoding, codec.ContentEncoding())
+
+	codec, err = NewCodec(ZlibKind, 3)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultCodec, codec)
+
+	codec, err = NewCodec("br", 3)
+	assert.Error(t, err)
+	assert.Equal(t, DefaultCodec, codec)
+}
+
+func TestRejectedEncodings(t *testing.T) {
+	rejected := NewRejectedEncodings("test")
+	assert.False(t, rejected.IsRejected(ZstdEncoding))
+
+	rejected.Report(ZstdEncoding)
+	assert.True(t, rejected.IsRejected(ZstdEncoding))
+	assert.False(t, rejected.IsRejected(DefaultCodec.ContentEncoding()))
+
+	// the state is kept per endpoint
+	assert.False(t, NewRejectedEncodings("other").IsRejected(ZstdEncoding))
+
+	var none *RejectedEncodings
+	none.Report(ZstdEncoding)
+	assert.False(t, none.IsRejected(ZstdEncoding))
+}
+
+func TestDecompressEncoding(t *testing.T) {
+	compressed, err := NewZstdCodec(ZstdDefaultLevel).Compress(testPayload)
+	require.NoError(t, err)
+	decompressed, err := DecompressEncoding(compressed, ZstdEncoding)
+	require.NoError(t, err)
+	assert.Equal(t, testPayload, decompressed)
+
+	compressed, err = DefaultCodec.Compress(testPayload)
+	require.NoError(t, err)
+	decompressed, err = DecompressEncoding(compressed, DefaultCodec.ContentEncoding())
+	require.NoError(t, err)
+	assert.Equal(t, testPayload, decompressed)
+
+	compressed, err = NewGzipCodec(6).Compress(testPayload)
+	require.NoError(t, err)
+	decompressed, err = DecompressEncoding(compressed, GzipEncoding)
+	require.NoError(t, err)
+	assert.Equal(t, testPayload, decompressed)
+
+	_, err = DecompressEncoding(compressed, "br")
+	assert.Error(t, err)
+}
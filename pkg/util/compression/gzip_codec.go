@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+)
+
+// GzipEncoding is the HTTP header value associated with the gzip compression method
+const GzipEncoding = "gzip"
+
+// GzipCodec compresses payloads with gzip
+type GzipCodec struct {
+	level int
+}
+
+// NewGzipCodec creates a gzip codec compressing with the given level,
+// levels out of the range supported by gzip are mapped to the closest one.
+func NewGzipCodec(level int) *GzipCodec {
+	if level < gzip.NoCompression {
+		level = gzip.NoCompression
+	} else if level > gzip.BestCompression {
+		level = gzip.BestCompression
+	}
+	return &GzipCodec{level: level}
+}
+
+// ContentEncoding returns the HTTP header value associated with the gzip compression method
+func (c *GzipCodec) ContentEncoding() string {
+	return GzipEncoding
+}
+
+// Compress will compress the data with gzip
+func (c *GzipCodec) Compress(src []byte) ([]byte, error) {
+	var dst bytes.Buffer
+	dst.Grow(c.CompressBound(len(src)))
+	writer, err := gzip.NewWriterLevel(&dst, c.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = writer.Write(src); err != nil {
+		return nil, err
+	}
+	if err = writer.Close(); err != nil {
+		return nil, err
+	}
+	return dst.Bytes(), nil
+}
+
+// Decompress will decompress the data with gzip
+func (c *GzipCodec) Decompress(src []byte) ([]byte, error) {
+	return gzipDecompress(src)
+}
+
+// CompressBound returns the worst case size needed for a destination buffer
+func (c *GzipCodec) CompressBound(sourceLen int) int {
+	// the deflate bound from compressBound in https://github.com/madler/zlib/blob/master/compress.c
+	// and the 18 bytes of the gzip header and trailer
+	return sourceLen + (sourceLen >> 12) + (sourceLen >> 14) + (sourceLen >> 25) + 13 + 18
+}
+
+// NewStreamWriter returns a writer compressing the data written to it into output with gzip
+func (c *GzipCodec) NewStreamWriter(output io.Writer) (StreamWriter, error) {
+	return gzip.NewWriterLevel(output, c.level)
+}
+
+func gzipDecompress(src []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package compression
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// RejectedEncodings records the content encodings an endpoint rejected.
+// Each endpoint keeps its own instance so that an intake rejecting an encoding
+// does not change the encoding of the payloads sent to the other intakes.
+// A nil RejectedEncodings never rejects any encoding.
+type RejectedEncodings struct {
+	name      string
+	mu        sync.RWMutex
+	encodings map[string]struct{}
+}
+
+// NewRejectedEncodings creates a new RejectedEncodings for the endpoint with the given name
+func NewRejectedEncodings(name string) *RejectedEncodings {
+	return &RejectedEncodings{
+		name:      name,
+		encodings: map[string]struct{}{},
+	}
+}
+
+// Report records that the endpoint rejected a payload because of its content encoding
+func (r *RejectedEncodings) Report(encoding string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, found := r.encodings[encoding]; !found {
+		log.Warnf("%s rejected payloads with the %q content encoding, falling back to the default one", r.name, encoding)
+		r.encodings[encoding] = struct{}{}
+	}
+}
+
+// IsRejected returns whether the endpoint rejected a payload because of the given content encoding
+func (r *RejectedEncodings) IsRejected(encoding string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, found := r.encodings[encoding]
+	return found
+}
+
+// DecompressEncoding decompresses a payload compressed with the given content encoding
+func DecompressEncoding(src []byte, encoding string) ([]byte, error) {
+	switch encoding {
+	case ZstdEncoding:
+		return zstdDecompress(src)
+	case GzipEncoding:
+		return gzipDecompress(src)
+	case DefaultCodec.ContentEncoding():
+		return DefaultCodec.Decompress(src)
+	}
+	return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+}
@@ -8,6 +8,13 @@
 
 package compression
 
+import (
+	"io"
+)
+
+// DefaultCodec does not compress anything
+var DefaultCodec Codec = noopCodec{}
+
 // ContentEncoding describes the HTTP header value associated with the compression method
 // empty here since there's no compression
 // var instead of const to ease testing
@@ -27,3 +34,20 @@ func Decompress(src []byte) ([]byte, error) {
 func CompressBound(sourceLen int) int {
 	return sourceLen
 }
+
+type noopCodec struct{}
+
+func (noopCodec) ContentEncoding() string               { return ContentEncoding }
+func (noopCodec) Compress(src []byte) ([]byte, error)   { return Compress(src) }
+func (noopCodec) Decompress(src []byte) ([]byte, error) { return Decompress(src) }
+func (noopCodec) CompressBound(sourceLen int) int       { return CompressBound(sourceLen) }
+func (noopCodec) NewStreamWriter(output io.Writer) (StreamWriter, error) {
+	return noopStreamWriter{output}, nil
+}
+
+type noopStreamWriter struct {
+	io.Writer
+}
+
+func (noopStreamWriter) Flush() error { return nil }
+func (noopStreamWriter) Close() error { return nil }
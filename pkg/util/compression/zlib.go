@@ -11,9 +11,13 @@ package compression
 import (
 	"bytes"
 	"compress/zlib"
+	"io"
 	"io/ioutil"
 )
 
+// DefaultCodec compresses payloads with zlib
+var DefaultCodec Codec = zlibCodec{}
+
 // ContentEncoding describes the HTTP header value associated with the compression method
 // var instead of const to ease testing
 var ContentEncoding = "deflate"
@@ -49,8 +53,18 @@ func Decompress(src []byte) ([]byte, error) {
 	return dst, nil
 }
 
-//  CompressBound returns the worst case size needed for a destination buffer
+// CompressBound returns the worst case size needed for a destination buffer
 func CompressBound(sourceLen int) int {
 	// From https://code.woboq.org/gcc/zlib/compress.c.html#compressBound
 	return sourceLen + (sourceLen >> 12) + (sourceLen >> 14) + (sourceLen >> 25) + 13
 }
+
+type zlibCodec struct{}
+
+func (zlibCodec) ContentEncoding() string               { return ContentEncoding }
+func (zlibCodec) Compress(src []byte) ([]byte, error)   { return Compress(src) }
+func (zlibCodec) Decompress(src []byte) ([]byte, error) { return Decompress(src) }
+func (zlibCodec) CompressBound(sourceLen int) int       { return CompressBound(sourceLen) }
+func (zlibCodec) NewStreamWriter(output io.Writer) (StreamWriter, error) {
+	return zlib.NewWriter(output), nil
+}
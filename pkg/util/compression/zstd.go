@@ -8,28 +8,24 @@
 
 package compression
 
-import (
-	zstd_0 "github.com/DataDog/zstd_0"
-)
-
-// TODO: the intake still uses a pre-v1 (unstable) version of the zstd compression format.
-// The agent shouldn't use zstd compression until the intake supports a stable v1 format.
+// DefaultCodec compresses payloads with zstd
+var DefaultCodec Codec = NewZstdCodec(ZstdDefaultLevel)
 
 // ContentEncoding describes the HTTP header value associated with the compression method
 // var instead of const to ease testing
-var ContentEncoding = "zstd"
+var ContentEncoding = ZstdEncoding
 
 // Compress will compress the data with zstd
 func Compress(src []byte) ([]byte, error) {
-	return zstd_0.Compress(nil, src)
+	return DefaultCodec.Compress(src)
 }
 
 // Decompress will decompress the data with zstd
 func Decompress(src []byte) ([]byte, error) {
-	return zstd_0.Decompress(nil, src)
+	return DefaultCodec.Decompress(src)
 }
 
 // CompressBound returns the worst case size needed for a destination buffer
 func CompressBound(sourceLen int) int {
-	return zstd_0.CompressBound(sourceLen)
+	return DefaultCodec.CompressBound(sourceLen)
 }
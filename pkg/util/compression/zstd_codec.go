@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package compression
+
+import (
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// ZstdEncoding is the HTTP header value associated with the zstd compression method
+	ZstdEncoding = "zstd"
+
+	// ZstdDefaultLevel is the zstd compression level used when none is configured
+	ZstdDefaultLevel = 1
+)
+
+// zstdDecoder is safe for concurrent use when only calling DecodeAll
+var zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+
+// ZstdCodec compresses payloads with the stable (v1) zstd format
+type ZstdCodec struct {
+	level   zstd.EncoderLevel
+	encoder *zstd.Encoder
+	// streamEncoders holds the encoders released by the stream writers,
+	// allocating an encoder is expensive.
+	streamEncoders sync.Pool
+}
+
+// NewZstdCodec creates a zstd codec compressing with the given zstd level (1 to 22).
+// Levels are mapped to the closest level supported by the encoder.
+func NewZstdCodec(level int) *ZstdCodec {
+	if level < 1 {
+		level = ZstdDefaultLevel
+	}
+	encoderLevel := zstd.EncoderLevelFromZstd(level)
+	// the options are valid, NewWriter cannot fail
+	encoder, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(encoderLevel), zstd.WithEncoderConcurrency(1))
+	return &ZstdCodec{
+		level:   encoderLevel,
+		encoder: encoder,
+	}
+}
+
+// ContentEncoding returns the HTTP header value associated with the zstd compression method
+func (c *ZstdCodec) ContentEncoding() string {
+	return ZstdEncoding
+}
+
+// Compress will compress the data with zstd
+func (c *ZstdCodec) Compress(src []byte) ([]byte, error) {
+	return c.encoder.EncodeAll(src, make([]byte, 0, c.CompressBound(len(src)))), nil
+}
+
+// Decompress will decompress the data with zstd
+func (c *ZstdCodec) Decompress(src []byte) ([]byte, error) {
+	return zstdDecompress(src)
+}
+
+// CompressBound returns the worst case size needed for a destination buffer
+func (c *ZstdCodec) CompressBound(sourceLen int) int {
+	// From ZSTD_COMPRESSBOUND in https://github.com/facebook/zstd/blob/dev/lib/zstd.h
+	bound := sourceLen + (sourceLen >> 8)
+	if sourceLen < 128<<10 {
+		bound += ((128 << 10) - sourceLen) >> 11
+	}
+	return bound
+}
+
+// NewStreamWriter returns a writer compressing the data written to it into output with zstd
+func (c *ZstdCodec) NewStreamWriter(output io.Writer) (StreamWriter, error) {
+	if encoder, ok := c.streamEncoders.Get().(*zstd.Encoder); ok {
+		encoder.Reset(output)
+		return &zstdStreamWriter{Encoder: encoder, codec: c}, nil
+	}
+	encoder, err := zstd.NewWriter(output, zstd.WithEncoderLevel(c.level), zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &zstdStreamWriter{Encoder: encoder, codec: c}, nil
+}
+
+type zstdStreamWriter struct {
+	*zstd.Encoder
+	codec *ZstdCodec
+}
+
+// Close writes the zstd footer and releases the encoder, the writer must not be used afterwards
+func (w *zstdStreamWriter) Close() error {
+	if w.Encoder == nil {
+		return nil
+	}
+	err := w.Encoder.Close()
+	// drop the reference to the output buffer before pooling the encoder
+	w.Encoder.Reset(nil)
+	w.codec.streamEncoders.Put(w.Encoder)
+	w.Encoder = nil
+	return err
+}
+
+func zstdDecompress(src []byte) ([]byte, error) {
+	return zstdDecoder.DecodeAll(src, nil)
+}
@@ -0,0 +1,34 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build systemd
+// +build systemd
+
+package systemd
+
+import (
+	"github.com/coreos/go-systemd/dbus"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// DefaultPrivateSocket is the path of the private socket of the systemd manager
+const DefaultPrivateSocket = "/run/systemd/private"
+
+// NewDefaultConnection connects to the systemd manager of the host. When the
+// agent is containerized, the private socket of the host is expected to be
+// mounted under /host, otherwise the system bus is used, falling back to the
+// private socket.
+func NewDefaultConnection() (*dbus.Conn, error) {
+	if config.IsContainerized() {
+		return NewSystemdConnection("/host" + DefaultPrivateSocket)
+	}
+
+	conn, err := dbus.NewSystemConnection()
+	if err != nil {
+		return NewSystemdConnection(DefaultPrivateSocket)
+	}
+	return conn, nil
+}
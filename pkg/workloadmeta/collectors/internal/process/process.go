@@ -0,0 +1,196 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+package process
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	dderrors "github.com/DataDog/datadog-agent/pkg/errors"
+	"github.com/DataDog/datadog-agent/pkg/process/procutil"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+const (
+	collectorID   = "process"
+	componentName = "workloadmeta-process"
+
+	// the store pulls every few seconds, listing all the processes of the
+	// host is throttled to collectionInterval
+	collectionInterval = 30 * time.Second
+)
+
+type collector struct {
+	store workloadmeta.Store
+	// seen maps the processes reported by the previous pull to their creation time,
+	// a PID reused by a new process is reported as a new entity
+	seen     map[workloadmeta.EntityID]time.Time
+	lastPull time.Time
+
+	listProcesses  func(now time.Time) (map[int32]*procutil.Process, error)
+	getSystemdUnit func(pid int32) string
+}
+
+func init() {
+	workloadmeta.RegisterCollector(collectorID, func() workloadmeta.Collector {
+		return &collector{
+			seen: make(map[workloadmeta.EntityID]time.Time),
+		}
+	})
+}
+
+func (c *collector) Start(_ context.Context, store workloadmeta.Store) error {
+	if !config.Datadog.GetBool("workloadmeta.host_processes.enabled") {
+		return dderrors.NewDisabled(componentName, "host process collection is disabled")
+	}
+
+	probe := procutil.NewProcessProbe()
+	c.listProcesses = func(now time.Time) (map[int32]*procutil.Process, error) {
+		return probe.ProcessesByPID(now, false)
+	}
+	c.getSystemdUnit = readSystemdUnit
+	c.store = store
+
+	return nil
+}
+
+func (c *collector) Pull(_ context.Context) error {
+	now := time.Now()
+	if now.Sub(c.lastPull) < collectionInterval {
+		return nil
+	}
+
+	processes, err := c.listProcesses(now)
+	if err != nil {
+		return err
+	}
+	c.lastPull = now
+
+	seen := make(map[workloadmeta.EntityID]time.Time)
+	events := make([]workloadmeta.CollectorEvent, 0, len(processes))
+
+	for _, proc := range processes {
+		// processes in their own PID namespace run in containers, which
+		// are reported by the container runtime collectors
+		if proc.NsPid != 0 && proc.NsPid != proc.Pid {
+			continue
+		}
+
+		entity := convertProcess(proc, c.getSystemdUnit(proc.Pid))
+		seen[entity.EntityID] = entity.CreatedAt
+		if isPIDReused(c.seen, entity) {
+			// unset the previous process first, so that it is not merged
+			// with the new one using the same PID
+			events = append(events, workloadmeta.CollectorEvent{
+				Type:   workloadmeta.EventTypeUnset,
+				Source: workloadmeta.SourceHost,
+				Entity: &workloadmeta.Process{
+					EntityID: entity.EntityID,
+				},
+			})
+		}
+		events = append(events, workloadmeta.CollectorEvent{
+			Type:   workloadmeta.EventTypeSet,
+			Source: workloadmeta.SourceHost,
+			Entity: entity,
+		})
+	}
+
+	for seenID := range c.seen {
+		if _, ok := seen[seenID]; ok {
+			continue
+		}
+
+		events = append(events, workloadmeta.CollectorEvent{
+			Type:   workloadmeta.EventTypeUnset,
+			Source: workloadmeta.SourceHost,
+			Entity: &workloadmeta.Process{
+				EntityID: seenID,
+			},
+		})
+	}
+
+	c.seen = seen
+
+	c.store.Notify(events)
+
+	return nil
+}
+
+// isPIDReused returns whether the PID of the process belonged to another process
+// when it was last seen, the creation time of a process being unknown if its
+// stats could not be read.
+func isPIDReused(seen map[workloadmeta.EntityID]time.Time, entity *workloadmeta.Process) bool {
+	createdAt, ok := seen[entity.EntityID]
+	if !ok || createdAt.IsZero() || entity.CreatedAt.IsZero() {
+		return false
+	}
+	return !createdAt.Equal(entity.CreatedAt)
+}
+
+func convertProcess(proc *procutil.Process, systemdUnit string) *workloadmeta.Process {
+	var createdAt time.Time
+	if proc.Stats != nil && proc.Stats.CreateTime > 0 {
+		createdAt = time.UnixMilli(proc.Stats.CreateTime)
+	}
+
+	return &workloadmeta.Process{
+		EntityID: workloadmeta.EntityID{
+			Kind: workloadmeta.KindProcess,
+			ID:   strconv.Itoa(int(proc.Pid)),
+		},
+		EntityMeta: workloadmeta.EntityMeta{
+			Name: proc.Name,
+		},
+		PID:         int(proc.Pid),
+		PPID:        int(proc.Ppid),
+		Executable:  proc.Exe,
+		Cmdline:     proc.Cmdline,
+		CreatedAt:   createdAt,
+		SystemdUnit: systemdUnit,
+	}
+}
+
+// readSystemdUnit returns the systemd service of the process from its cgroup
+func readSystemdUnit(pid int32) string {
+	content, err := ioutil.ReadFile(util.HostProc(strconv.Itoa(int(pid)), "cgroup"))
+	if err != nil {
+		return ""
+	}
+	return parseSystemdUnit(content)
+}
+
+// parseSystemdUnit parses the content of /proc/<pid>/cgroup and returns the last
+// service found in the path of the systemd hierarchy, or the unified hierarchy
+// with cgroup v2.
+func parseSystemdUnit(content []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 || (fields[1] != "name=systemd" && fields[0] != "0") {
+			continue
+		}
+
+		segments := strings.Split(fields[2], "/")
+		for i := len(segments) - 1; i >= 0; i-- {
+			if strings.HasSuffix(segments[i], ".service") {
+				return segments[i]
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,193 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+package process
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/process/procutil"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+type fakeWorkloadmetaStore struct {
+	workloadmeta.Store
+	notifiedEvents []workloadmeta.CollectorEvent
+}
+
+func (store *fakeWorkloadmetaStore) Notify(events []workloadmeta.CollectorEvent) {
+	store.notifiedEvents = append(store.notifiedEvents, events...)
+}
+
+func TestPull(t *testing.T) {
+	processes := map[int32]*procutil.Process{
+		1: {
+			Pid:     1,
+			NsPid:   1,
+			Name:    "systemd",
+			Exe:     "/usr/lib/systemd/systemd",
+			Cmdline: []string{"/sbin/init"},
+			Stats:   &procutil.Stats{CreateTime: 1600000000000},
+		},
+		42: {
+			Pid:     42,
+			Ppid:    1,
+			NsPid:   42,
+			Name:    "nginx",
+			Exe:     "/usr/sbin/nginx",
+			Cmdline: []string{"nginx", "-g", "daemon off;"},
+		},
+		// running in a container
+		43: {
+			Pid:     43,
+			Ppid:    42,
+			NsPid:   1,
+			Name:    "redis-server",
+			Cmdline: []string{"redis-server"},
+		},
+	}
+
+	store := &fakeWorkloadmetaStore{}
+	c := &collector{
+		store: store,
+		seen:  make(map[workloadmeta.EntityID]time.Time),
+		listProcesses: func(time.Time) (map[int32]*procutil.Process, error) {
+			return processes, nil
+		},
+		getSystemdUnit: func(pid int32) string {
+			if pid == 42 {
+				return "nginx.service"
+			}
+			return ""
+		},
+	}
+
+	require.NoError(t, c.Pull(context.TODO()))
+	assert.ElementsMatch(t, []workloadmeta.CollectorEvent{
+		{
+			Type:   workloadmeta.EventTypeSet,
+			Source: workloadmeta.SourceHost,
+			Entity: &workloadmeta.Process{
+				EntityID:   workloadmeta.EntityID{Kind: workloadmeta.KindProcess, ID: "1"},
+				EntityMeta: workloadmeta.EntityMeta{Name: "systemd"},
+				PID:        1,
+				Executable: "/usr/lib/systemd/systemd",
+				Cmdline:    []string{"/sbin/init"},
+				CreatedAt:  time.UnixMilli(1600000000000),
+			},
+		},
+		{
+			Type:   workloadmeta.EventTypeSet,
+			Source: workloadmeta.SourceHost,
+			Entity: &workloadmeta.Process{
+				EntityID:    workloadmeta.EntityID{Kind: workloadmeta.KindProcess, ID: "42"},
+				EntityMeta:  workloadmeta.EntityMeta{Name: "nginx"},
+				PID:         42,
+				PPID:        1,
+				Executable:  "/usr/sbin/nginx",
+				Cmdline:     []string{"nginx", "-g", "daemon off;"},
+				SystemdUnit: "nginx.service",
+			},
+		},
+	}, store.notifiedEvents)
+
+	// pulls are throttled
+	delete(processes, 42)
+	store.notifiedEvents = nil
+	require.NoError(t, c.Pull(context.TODO()))
+	assert.Empty(t, store.notifiedEvents)
+
+	c.lastPull = time.Time{}
+	require.NoError(t, c.Pull(context.TODO()))
+	require.Len(t, store.notifiedEvents, 2)
+	assert.Equal(t, workloadmeta.CollectorEvent{
+		Type:   workloadmeta.EventTypeUnset,
+		Source: workloadmeta.SourceHost,
+		Entity: &workloadmeta.Process{
+			EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindProcess, ID: "42"},
+		},
+	}, store.notifiedEvents[1])
+
+	// a new process reusing a PID replaces the previous one
+	processes[1] = &procutil.Process{
+		Pid:     1,
+		NsPid:   1,
+		Name:    "bash",
+		Exe:     "/usr/bin/bash",
+		Cmdline: []string{"bash"},
+		Stats:   &procutil.Stats{CreateTime: 1600000060000},
+	}
+	store.notifiedEvents = nil
+	c.lastPull = time.Time{}
+	require.NoError(t, c.Pull(context.TODO()))
+	require.Len(t, store.notifiedEvents, 2)
+	assert.Equal(t, workloadmeta.CollectorEvent{
+		Type:   workloadmeta.EventTypeUnset,
+		Source: workloadmeta.SourceHost,
+		Entity: &workloadmeta.Process{
+			EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindProcess, ID: "1"},
+		},
+	}, store.notifiedEvents[0])
+	assert.Equal(t, workloadmeta.EventTypeSet, store.notifiedEvents[1].Type)
+	assert.Equal(t, "bash", store.notifiedEvents[1].Entity.(*workloadmeta.Process).Name)
+
+	// the same process is only set again
+	store.notifiedEvents = nil
+	c.lastPull = time.Time{}
+	require.NoError(t, c.Pull(context.TODO()))
+	require.Len(t, store.notifiedEvents, 1)
+	assert.Equal(t, workloadmeta.EventTypeSet, store.notifiedEvents[0].Type)
+}
+
+func TestParseSystemdUnit(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name: "cgroup v1",
+			content: `12:memory:/system.slice/nginx.service
+1:name=systemd:/system.slice/nginx.service
+0::/system.slice/nginx.service
+`,
+			expected: "nginx.service",
+		},
+		{
+			name:     "cgroup v2",
+			content:  "0::/system.slice/docker.service\n",
+			expected: "docker.service",
+		},
+		{
+			name:     "user service",
+			content:  "0::/user.slice/user-1000.slice/user@1000.service/app.slice/pipewire.service\n",
+			expected: "pipewire.service",
+		},
+		{
+			name:     "session",
+			content:  "0::/user.slice/user-1000.slice/session-3.scope\n",
+			expected: "",
+		},
+		{
+			name:     "empty",
+			content:  "",
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, parseSystemdUnit([]byte(test.content)))
+		})
+	}
+}
@@ -0,0 +1,153 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build systemd
+// +build systemd
+
+package systemd
+
+import (
+	"context"
+	"time"
+
+	"github.com/coreos/go-systemd/dbus"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	dderrors "github.com/DataDog/datadog-agent/pkg/errors"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	systemdutil "github.com/DataDog/datadog-agent/pkg/util/systemd"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+const (
+	collectorID   = "systemd"
+	componentName = "workloadmeta-systemd"
+
+	unitLoadedState = "loaded"
+
+	// the store pulls every few seconds, listing the units is throttled to
+	// collectionInterval
+	collectionInterval = 15 * time.Second
+)
+
+type systemdClient interface {
+	ListUnits() ([]dbus.UnitStatus, error)
+	Close()
+}
+
+type collector struct {
+	client   systemdClient
+	connect  func() (systemdClient, error)
+	store    workloadmeta.Store
+	seen     map[workloadmeta.EntityID]struct{}
+	lastPull time.Time
+}
+
+func init() {
+	workloadmeta.RegisterCollector(collectorID, func() workloadmeta.Collector {
+		return &collector{
+			seen: make(map[workloadmeta.EntityID]struct{}),
+		}
+	})
+}
+
+func (c *collector) Start(_ context.Context, store workloadmeta.Store) error {
+	if !config.Datadog.GetBool("workloadmeta.systemd_units.enabled") {
+		return dderrors.NewDisabled(componentName, "systemd unit collection is disabled")
+	}
+
+	c.connect = func() (systemdClient, error) {
+		return systemdutil.NewDefaultConnection()
+	}
+
+	client, err := c.connect()
+	if err != nil {
+		return err
+	}
+
+	c.client = client
+	c.store = store
+
+	return nil
+}
+
+func (c *collector) Pull(_ context.Context) error {
+	now := time.Now()
+	if now.Sub(c.lastPull) < collectionInterval {
+		return nil
+	}
+
+	if c.client == nil {
+		client, err := c.connect()
+		if err != nil {
+			return err
+		}
+		c.client = client
+	}
+
+	units, err := c.client.ListUnits()
+	if err != nil {
+		// the connection is re-established on the next pull, systemd
+		// may have been re-executed
+		c.client.Close()
+		c.client = nil
+		return err
+	}
+	c.lastPull = now
+
+	seen := make(map[workloadmeta.EntityID]struct{})
+	events := make([]workloadmeta.CollectorEvent, 0, len(units))
+
+	for _, unit := range units {
+		if unit.LoadState != unitLoadedState {
+			log.Tracef("Skipping systemd unit %s in load state %s", unit.Name, unit.LoadState)
+			continue
+		}
+
+		entity := convertUnit(unit)
+		seen[entity.EntityID] = struct{}{}
+		events = append(events, workloadmeta.CollectorEvent{
+			Type:   workloadmeta.EventTypeSet,
+			Source: workloadmeta.SourceHost,
+			Entity: entity,
+		})
+	}
+
+	for seenID := range c.seen {
+		if _, ok := seen[seenID]; ok {
+			continue
+		}
+
+		events = append(events, workloadmeta.CollectorEvent{
+			Type:   workloadmeta.EventTypeUnset,
+			Source: workloadmeta.SourceHost,
+			Entity: &workloadmeta.SystemdUnit{
+				EntityID: seenID,
+			},
+		})
+	}
+
+	c.seen = seen
+
+	c.store.Notify(events)
+
+	return nil
+}
+
+func convertUnit(unit dbus.UnitStatus) *workloadmeta.SystemdUnit {
+	return &workloadmeta.SystemdUnit{
+		EntityID: workloadmeta.EntityID{
+			Kind: workloadmeta.KindSystemdUnit,
+			ID:   unit.Name,
+		},
+		EntityMeta: workloadmeta.EntityMeta{
+			Name: unit.Name,
+		},
+		Description: unit.Description,
+		LoadState:   unit.LoadState,
+		ActiveState: unit.ActiveState,
+		SubState:    unit.SubState,
+	}
+}
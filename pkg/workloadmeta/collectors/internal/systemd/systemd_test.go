@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build systemd
+// +build systemd
+
+package systemd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-systemd/dbus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+type fakeWorkloadmetaStore struct {
+	workloadmeta.Store
+	notifiedEvents []workloadmeta.CollectorEvent
+}
+
+func (store *fakeWorkloadmetaStore) Notify(events []workloadmeta.CollectorEvent) {
+	store.notifiedEvents = append(store.notifiedEvents, events...)
+}
+
+type fakeSystemdClient struct {
+	units  []dbus.UnitStatus
+	err    error
+	closed bool
+}
+
+func (client *fakeSystemdClient) ListUnits() ([]dbus.UnitStatus, error) {
+	return client.units, client.err
+}
+
+func (client *fakeSystemdClient) Close() {
+	client.closed = true
+}
+
+func TestPull(t *testing.T) {
+	client := &fakeSystemdClient{
+		units: []dbus.UnitStatus{
+			{Name: "nginx.service", Description: "A high performance web server", LoadState: "loaded", ActiveState: "active", SubState: "running"},
+			{Name: "cron.service", Description: "Regular background program processing daemon", LoadState: "loaded", ActiveState: "failed", SubState: "failed"},
+			{Name: "missing.service", LoadState: "not-found", ActiveState: "inactive", SubState: "dead"},
+		},
+	}
+	connections := 0
+
+	store := &fakeWorkloadmetaStore{}
+	c := &collector{
+		client: client,
+		connect: func() (systemdClient, error) {
+			connections++
+			return client, nil
+		},
+		store: store,
+		seen:  make(map[workloadmeta.EntityID]struct{}),
+	}
+
+	require.NoError(t, c.Pull(context.TODO()))
+	assert.ElementsMatch(t, []workloadmeta.CollectorEvent{
+		{
+			Type:   workloadmeta.EventTypeSet,
+			Source: workloadmeta.SourceHost,
+			Entity: &workloadmeta.SystemdUnit{
+				EntityID:    workloadmeta.EntityID{Kind: workloadmeta.KindSystemdUnit, ID: "nginx.service"},
+				EntityMeta:  workloadmeta.EntityMeta{Name: "nginx.service"},
+				Description: "A high performance web server",
+				LoadState:   "loaded",
+				ActiveState: "active",
+				SubState:    "running",
+			},
+		},
+		{
+			Type:   workloadmeta.EventTypeSet,
+			Source: workloadmeta.SourceHost,
+			Entity: &workloadmeta.SystemdUnit{
+				EntityID:    workloadmeta.EntityID{Kind: workloadmeta.KindSystemdUnit, ID: "cron.service"},
+				EntityMeta:  workloadmeta.EntityMeta{Name: "cron.service"},
+				Description: "Regular background program processing daemon",
+				LoadState:   "loaded",
+				ActiveState: "failed",
+				SubState:    "failed",
+			},
+		},
+	}, store.notifiedEvents)
+
+	// the connection is closed on errors, and re-established on the next pull
+	client.err = errors.New("connection reset")
+	c.lastPull = time.Time{}
+	assert.Error(t, c.Pull(context.TODO()))
+	assert.True(t, client.closed)
+	assert.Nil(t, c.client)
+
+	client.err = nil
+	client.units = client.units[:1]
+	store.notifiedEvents = nil
+	require.NoError(t, c.Pull(context.TODO()))
+	assert.Equal(t, 1, connections)
+	require.Len(t, store.notifiedEvents, 2)
+	assert.Equal(t, workloadmeta.CollectorEvent{
+		Type:   workloadmeta.EventTypeUnset,
+		Source: workloadmeta.SourceHost,
+		Entity: &workloadmeta.SystemdUnit{
+			EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindSystemdUnit, ID: "cron.service"},
+		},
+	}, store.notifiedEvents[1])
+}
@@ -311,6 +311,50 @@ func (s *store) GetECSTask(id string) (*ECSTask, error) {
 	return entity.(*ECSTask), nil
 }
 
+// GetProcess implements Store#GetProcess
+func (s *store) GetProcess(id string) (*Process, error) {
+	entity, err := s.getEntityByKind(KindProcess, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.(*Process), nil
+}
+
+// ListProcesses implements Store#ListProcesses
+func (s *store) ListProcesses() []*Process {
+	entities := s.listEntitiesByKind(KindProcess)
+
+	processes := make([]*Process, 0, len(entities))
+	for _, entity := range entities {
+		processes = append(processes, entity.(*Process))
+	}
+
+	return processes
+}
+
+// GetSystemdUnit implements Store#GetSystemdUnit
+func (s *store) GetSystemdUnit(id string) (*SystemdUnit, error) {
+	entity, err := s.getEntityByKind(KindSystemdUnit, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.(*SystemdUnit), nil
+}
+
+// ListSystemdUnits implements Store#ListSystemdUnits
+func (s *store) ListSystemdUnits() []*SystemdUnit {
+	entities := s.listEntitiesByKind(KindSystemdUnit)
+
+	units := make([]*SystemdUnit, 0, len(entities))
+	for _, entity := range entities {
+		units = append(units, entity.(*SystemdUnit))
+	}
+
+	return units
+}
+
 // Notify implements Store#Notify
 func (s *store) Notify(events []CollectorEvent) {
 	if len(events) > 0 {
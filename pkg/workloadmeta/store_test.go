@@ -683,6 +683,57 @@ func TestListContainersWithFilter(t *testing.T) {
 	assert.DeepEqual(t, []*Container{runningContainer}, runningContainers)
 }
 
+func TestListProcessesAndSystemdUnits(t *testing.T) {
+	process := &Process{
+		EntityID: EntityID{
+			Kind: KindProcess,
+			ID:   "42",
+		},
+		PID:         42,
+		SystemdUnit: "nginx.service",
+	}
+
+	unit := &SystemdUnit{
+		EntityID: EntityID{
+			Kind: KindSystemdUnit,
+			ID:   "nginx.service",
+		},
+		ActiveState: "active",
+	}
+
+	testStore := newTestStore()
+
+	assert.DeepEqual(t, []*Process{}, testStore.ListProcesses())
+	assert.DeepEqual(t, []*SystemdUnit{}, testStore.ListSystemdUnits())
+
+	testStore.handleEvents([]CollectorEvent{
+		{
+			Type:   EventTypeSet,
+			Source: fooSource,
+			Entity: process,
+		},
+		{
+			Type:   EventTypeSet,
+			Source: fooSource,
+			Entity: unit,
+		},
+	})
+
+	assert.DeepEqual(t, []*Process{process}, testStore.ListProcesses())
+	assert.DeepEqual(t, []*SystemdUnit{unit}, testStore.ListSystemdUnits())
+
+	storedProcess, err := testStore.GetProcess("42")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, process, storedProcess)
+
+	storedUnit, err := testStore.GetSystemdUnit(storedProcess.SystemdUnit)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, unit, storedUnit)
+
+	_, err = testStore.GetProcess("43")
+	assert.Assert(t, errors.IsNotFound(err))
+}
+
 func newTestStore() *store {
 	return &store{
 		store: make(map[Kind]map[string]*cachedEntity),
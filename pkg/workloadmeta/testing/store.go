@@ -104,6 +104,50 @@ func (s *Store) GetECSTask(id string) (*workloadmeta.ECSTask, error) {
 	return entity.(*workloadmeta.ECSTask), nil
 }
 
+// GetProcess returns metadata about a host process.
+func (s *Store) GetProcess(id string) (*workloadmeta.Process, error) {
+	entity, err := s.getEntityByKind(workloadmeta.KindProcess, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.(*workloadmeta.Process), nil
+}
+
+// ListProcesses returns metadata about all known host processes.
+func (s *Store) ListProcesses() []*workloadmeta.Process {
+	entities := s.listEntitiesByKind(workloadmeta.KindProcess)
+
+	processes := make([]*workloadmeta.Process, 0, len(entities))
+	for _, entity := range entities {
+		processes = append(processes, entity.(*workloadmeta.Process))
+	}
+
+	return processes
+}
+
+// GetSystemdUnit returns metadata about a systemd unit.
+func (s *Store) GetSystemdUnit(id string) (*workloadmeta.SystemdUnit, error) {
+	entity, err := s.getEntityByKind(workloadmeta.KindSystemdUnit, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.(*workloadmeta.SystemdUnit), nil
+}
+
+// ListSystemdUnits returns metadata about all known systemd units.
+func (s *Store) ListSystemdUnits() []*workloadmeta.SystemdUnit {
+	entities := s.listEntitiesByKind(workloadmeta.KindSystemdUnit)
+
+	units := make([]*workloadmeta.SystemdUnit, 0, len(entities))
+	for _, entity := range entities {
+		units = append(units, entity.(*workloadmeta.SystemdUnit))
+	}
+
+	return units
+}
+
 // Set sets an entity in the store.
 func (s *Store) Set(entity workloadmeta.Entity) {
 	s.mu.Lock()
@@ -73,6 +73,22 @@ type Store interface {
 	// kind KindECSTask and the given ID.
 	GetECSTask(id string) (*ECSTask, error)
 
+	// GetProcess returns metadata about a host process.  It fetches the
+	// entity with kind KindProcess and the given ID, the PID of the process.
+	GetProcess(id string) (*Process, error)
+
+	// ListProcesses returns metadata about all known host processes,
+	// equivalent to all entities with kind KindProcess.
+	ListProcesses() []*Process
+
+	// GetSystemdUnit returns metadata about a systemd unit.  It fetches the
+	// entity with kind KindSystemdUnit and the given ID, the unit name.
+	GetSystemdUnit(id string) (*SystemdUnit, error)
+
+	// ListSystemdUnits returns metadata about all known systemd units,
+	// equivalent to all entities with kind KindSystemdUnit.
+	ListSystemdUnits() []*SystemdUnit
+
 	// Notify notifies the store with a slice of events.  It should only be
 	// used by workloadmeta collectors.
 	Notify(events []CollectorEvent)
@@ -89,6 +105,8 @@ const (
 	KindContainer     Kind = "container"
 	KindKubernetesPod Kind = "kubernetes_pod"
 	KindECSTask       Kind = "ecs_task"
+	KindProcess       Kind = "process"
+	KindSystemdUnit   Kind = "systemd_unit"
 )
 
 // Source is the source name of an entity.
@@ -114,6 +132,10 @@ const (
 	// the central component of an orchestrator, or the Datadog Cluster
 	// Agent.  `kube_metadata` and `cloudfoundry` use this.
 	SourceClusterOrchestrator Source = "cluster_orchestrator"
+
+	// SourceHost represents entities detected on the host operating
+	// system, outside of any container.  `process` and `systemd` use this.
+	SourceHost Source = "host"
 )
 
 // ContainerRuntime is the container runtime used by a container.
@@ -596,6 +618,122 @@ func (t ECSTask) String(verbose bool) string {
 
 var _ Entity = &ECSTask{}
 
+// Process is an Entity representing a process running on the host, outside
+// of any container.
+type Process struct {
+	EntityID
+	EntityMeta
+	PID        int
+	PPID       int
+	Executable string
+	Cmdline    []string
+	CreatedAt  time.Time
+	// SystemdUnit is the name of the systemd service the process belongs
+	// to, if any.
+	SystemdUnit string
+}
+
+// GetID implements Entity#GetID.
+func (p Process) GetID() EntityID {
+	return p.EntityID
+}
+
+// Merge implements Entity#Merge.
+func (p *Process) Merge(e Entity) error {
+	pp, ok := e.(*Process)
+	if !ok {
+		return fmt.Errorf("cannot merge Process with different kind %T", e)
+	}
+
+	return merge(p, pp)
+}
+
+// DeepCopy implements Entity#DeepCopy.
+func (p Process) DeepCopy() Entity {
+	cp := deepcopy.Copy(p).(Process)
+	return &cp
+}
+
+// String implements Entity#String.
+func (p Process) String(verbose bool) string {
+	var sb strings.Builder
+	_, _ = fmt.Fprintln(&sb, "----------- Entity ID -----------")
+	_, _ = fmt.Fprint(&sb, p.EntityID.String(verbose))
+
+	_, _ = fmt.Fprintln(&sb, "----------- Entity Meta -----------")
+	_, _ = fmt.Fprint(&sb, p.EntityMeta.String(verbose))
+
+	_, _ = fmt.Fprintln(&sb, "----------- Process Info -----------")
+	_, _ = fmt.Fprintln(&sb, "PID:", p.PID)
+	_, _ = fmt.Fprintln(&sb, "Executable:", p.Executable)
+	_, _ = fmt.Fprintln(&sb, "Systemd Unit:", p.SystemdUnit)
+
+	if verbose {
+		_, _ = fmt.Fprintln(&sb, "PPID:", p.PPID)
+		_, _ = fmt.Fprintln(&sb, "Command Line:", strings.Join(p.Cmdline, " "))
+		_, _ = fmt.Fprintln(&sb, "Created At:", p.CreatedAt)
+	}
+
+	return sb.String()
+}
+
+var _ Entity = &Process{}
+
+// SystemdUnit is an Entity representing a unit loaded by the systemd
+// manager of the host.
+type SystemdUnit struct {
+	EntityID
+	EntityMeta
+	Description string
+	LoadState   string
+	ActiveState string
+	SubState    string
+}
+
+// GetID implements Entity#GetID.
+func (u SystemdUnit) GetID() EntityID {
+	return u.EntityID
+}
+
+// Merge implements Entity#Merge.
+func (u *SystemdUnit) Merge(e Entity) error {
+	uu, ok := e.(*SystemdUnit)
+	if !ok {
+		return fmt.Errorf("cannot merge SystemdUnit with different kind %T", e)
+	}
+
+	return merge(u, uu)
+}
+
+// DeepCopy implements Entity#DeepCopy.
+func (u SystemdUnit) DeepCopy() Entity {
+	cp := deepcopy.Copy(u).(SystemdUnit)
+	return &cp
+}
+
+// String implements Entity#String.
+func (u SystemdUnit) String(verbose bool) string {
+	var sb strings.Builder
+	_, _ = fmt.Fprintln(&sb, "----------- Entity ID -----------")
+	_, _ = fmt.Fprint(&sb, u.EntityID.String(verbose))
+
+	_, _ = fmt.Fprintln(&sb, "----------- Entity Meta -----------")
+	_, _ = fmt.Fprint(&sb, u.EntityMeta.String(verbose))
+
+	_, _ = fmt.Fprintln(&sb, "----------- Unit State -----------")
+	_, _ = fmt.Fprintln(&sb, "Active State:", u.ActiveState)
+	_, _ = fmt.Fprintln(&sb, "Sub State:", u.SubState)
+
+	if verbose {
+		_, _ = fmt.Fprintln(&sb, "Description:", u.Description)
+		_, _ = fmt.Fprintln(&sb, "Load State:", u.LoadState)
+	}
+
+	return sb.String()
+}
+
+var _ Entity = &SystemdUnit{}
+
 // CollectorEvent is an event generated by a metadata collector, to be handled
 // by the metadata store.
 type CollectorEvent struct {